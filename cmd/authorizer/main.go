@@ -2,7 +2,6 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
@@ -13,12 +12,13 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
-	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
 	"github.com/google/uuid"
 )
 
@@ -265,11 +265,12 @@ func (s *AuthSession) GetInitialCSRFToken() error {
 	}
 
 	// Manual token entry if automatic methods fail
-	reader := bufio.NewReader(os.Stdin)
 	s.log.Info("❌ CSRF token not found automatically")
-	s.log.Info("Please enter the CSRF token manually (or press Enter to search for potential tokens): ")
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
+	input, err := utils.Prompt("Please enter the CSRF token manually (or press Enter to search for potential tokens): ",
+		"run the authorizer in a terminal", s.log.Info)
+	if err != nil {
+		return err
+	}
 
 	if input != "" {
 		s.csrfToken = input
@@ -284,9 +285,12 @@ func (s *AuthSession) GetInitialCSRFToken() error {
 			s.log.Infof("  %d. %s\n", i+1, token)
 		}
 
-		s.log.Info("Select a token number (or 0 to skip): ")
-		var choice int
-		if _, err := fmt.Scanf("%d", &choice); err != nil {
+		choiceStr, err := utils.Prompt("Select a token number (or 0 to skip): ", "run the authorizer in a terminal", s.log.Info)
+		if err != nil {
+			return err
+		}
+		choice, err := strconv.Atoi(choiceStr)
+		if err != nil {
 			s.log.Errorf("Error reading choice: %v", err)
 			return fmt.Errorf("failed to read choice")
 		}
@@ -586,27 +590,18 @@ func generateOAuthState() string {
 }
 
 // promptForLogin asks user to input their Yandex login
-func promptForLogin(log *logger.Logger) string {
-	reader := bufio.NewReader(os.Stdin)
-	log.Info("Enter Yandex login: ")
-	login, _ := reader.ReadString('\n')
-	return strings.TrimSpace(login)
+func promptForLogin(log *logger.Logger) (string, error) {
+	return utils.Prompt("Enter Yandex login: ", "run the authorizer in a terminal", log.Info)
 }
 
 // promptForPassword asks user to input their password
-func promptForPassword(log *logger.Logger) string {
-	reader := bufio.NewReader(os.Stdin)
-	log.Info("Enter password: ")
-	password, _ := reader.ReadString('\n')
-	return strings.TrimSpace(password)
+func promptForPassword(log *logger.Logger) (string, error) {
+	return utils.Prompt("Enter password: ", "run the authorizer in a terminal", log.Info)
 }
 
 // promptForCode asks user to input 2FA code
-func promptForCode(log *logger.Logger) string {
-	reader := bufio.NewReader(os.Stdin)
-	log.Info("Enter code from push notification: ")
-	code, _ := reader.ReadString('\n')
-	return strings.TrimSpace(code)
+func promptForCode(log *logger.Logger) (string, error) {
+	return utils.Prompt("Enter code from push notification: ", "run the authorizer in a terminal", log.Info)
 }
 
 // minInt returns the smaller of two integers
@@ -619,8 +614,11 @@ func minInt(a, b int) int {
 
 func main() {
 	verbose := flag.Bool("verbose", false, "Output debug messages")
+	asciiUI := flag.Bool("ascii-ui", false, "Replace decorative glyphs in output with plain text")
+	nonInteractive := flag.Bool("non-interactive", false, "Fail instead of prompting for input")
 	flag.Parse()
-	log := logger.New(*verbose)
+	utils.SetNonInteractive(*nonInteractive)
+	log := logger.NewWithOptions(logger.Options{Verbose: *verbose, ASCII: *asciiUI})
 
 	log.Info("Yandex Music Authorization Tool")
 	log.Info("==============================")
@@ -639,7 +637,10 @@ func main() {
 	}
 
 	// Get login from user
-	login := promptForLogin(log)
+	login, err := promptForLogin(log)
+	if err != nil {
+		log.Fatal("Error reading login: %v", err)
+	}
 
 	// Start authentication
 	log.Info("Starting authentication...")
@@ -653,7 +654,10 @@ func main() {
 	}
 
 	// Get password from user
-	password := promptForPassword(log)
+	password, err := promptForPassword(log)
+	if err != nil {
+		log.Fatal("Error reading password: %v", err)
+	}
 
 	// Submit password
 	log.Info("Submitting password...")
@@ -696,7 +700,10 @@ func main() {
 			log.Info("Push notification sent to your device.")
 
 			// Get confirmation code
-			code := promptForCode(log)
+			code, err := promptForCode(log)
+			if err != nil {
+				log.Fatal("Error reading code: %v", err)
+			}
 
 			// Submit code
 			log.Info("Submitting confirmation code...")
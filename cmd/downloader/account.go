@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// runCheckToken prints the account the token belongs to and returns the exit code:
+// 0 for a valid token, 1 otherwise
+func runCheckToken(client *yamusic.Client, log *logger.Logger) int {
+	status, err := client.GetAccountStatus()
+	if err != nil {
+		log.Error("Token check failed: %v", err)
+		return 1
+	}
+
+	account := status.Account
+	log.Info("Token is valid: %s (uid %s, region %d)", account.Login, account.UID, account.Region)
+	if status.Plus.HasPlus {
+		log.Info("Subscription: active")
+	} else {
+		log.Warn("Subscription: none, lossless and full tracks are unavailable")
+	}
+	return 0
+}
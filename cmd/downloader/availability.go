@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// confirmAvailability checks the availability of the batch items, prints a summary and,
+// in interactive mode, asks whether to start the downloads. It reports whether to go on;
+// failures of the check itself don't stop the batch.
+func confirmAvailability(client *yamusic.Client, items []batchItem, log *logger.Logger) bool {
+	if len(items) == 0 {
+		return true
+	}
+
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.trackID
+	}
+	statuses, err := client.CheckAvailability(ids)
+	if err != nil {
+		log.Warn("Availability not checked: %v", err)
+		return true
+	}
+
+	counts := make(map[yamusic.AvailabilityStatus]int)
+	for _, id := range ids {
+		counts[statuses[id]]++
+	}
+	log.Info("Available: %d, subscription only: %d, region blocked: %d, not found: %d",
+		counts[yamusic.TrackAvailable], counts[yamusic.TrackPremiumOnly],
+		counts[yamusic.TrackRegionBlocked], counts[yamusic.TrackNotFound])
+
+	if !utils.IsInteractive() {
+		return true
+	}
+
+	answer, err := utils.Prompt("Start the downloads? [y/N]: ", "pass -non-interactive to skip the question", log.Info)
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// batchSummary counts the outcomes of a batch run
+type batchSummary struct {
+	Downloaded   int
+	Skipped      int
+	Failed       int
+	Unavailable  int
+	NotAttempted int
+
+	// Explicit counts the explicit tracks skipped with -skip-explicit
+	Explicit int
+
+	// Filtered counts the album tracks excluded with -exclude and -only-volumes
+	Filtered int
+
+	// ExitCode is the exit code matching the failures
+	ExitCode int
+}
+
+// Batch item sources
+const (
+	sourceTrack    = "track"
+	sourceAlbum    = "album"
+	sourceLikes    = "likes"
+	sourceChart    = "chart"
+	sourcePlaylist = "playlist"
+	sourceSimilar  = "similar"
+	sourceStation  = "station"
+)
+
+// batchItem is a single track to download
+type batchItem struct {
+	input   string
+	trackID string
+	albumID string
+	source  string
+}
+
+// expandInputs turns the inputs into tracks: short links are resolved, album URLs are
+// expanded into their available tracks, and track URLs and IDs are taken as is; playlist
+// and artist links are rejected. With saveCover the cover of every album is saved into
+// outputDir once. Albums are registered for the playlist export.
+func expandInputs(client *yamusic.Client, inputs []string, outputDir string, saveCover bool,
+	summary *batchSummary, playlists *m3uExport, log *logger.Logger) []batchItem {
+	var items []batchItem
+	for _, input := range inputs {
+		ref, err := client.ResolveInput(input)
+		if err != nil {
+			log.Error("Error resolving %s: %v", input, err)
+			summary.fail(err, log)
+			continue
+		}
+		switch ref.Kind {
+		case yamusic.EntityTrack:
+			items = append(items, batchItem{input: input, trackID: ref.ID, albumID: ref.AlbumID, source: sourceTrack})
+			continue
+		case yamusic.EntityPlaylist:
+			err = fmt.Errorf("%s is a playlist; download it with -playlist", input)
+		case yamusic.EntityArtist:
+			err = fmt.Errorf("%s is an artist, not a track or album", input)
+		}
+		if err != nil {
+			log.Error("%v", err)
+			summary.fail(err, log)
+			continue
+		}
+
+		album, albumItems, err := expandAlbum(client, input, ref.ID, outputDir, saveCover, summary, log)
+		if err != nil {
+			summary.fail(err, log)
+			continue
+		}
+		playlists.addList(album.Title, albumItems)
+		items = append(items, albumItems...)
+	}
+	return items
+}
+
+// expandAlbum turns an album into its available tracks not excluded by the track filter,
+// counting the others in summary. Errors are logged. With saveCover the cover is saved
+// into the album directory; so is the metadata file when enabled.
+func expandAlbum(client *yamusic.Client, input, albumID, outputDir string, saveCover bool,
+	summary *batchSummary, log *logger.Logger) (*yamusic.Album, []batchItem, error) {
+	album, err := client.GetAlbum(albumID)
+	if err != nil {
+		log.Error("Error getting album %s: %v", albumID, err)
+		return nil, nil, err
+	}
+
+	// Excluded tracks don't count towards the availability of the album
+	available, unavailable := yamusic.AlbumTracks(album)
+	available, excluded := client.FilterAlbumTracks(album, available)
+	unavailable, _ = client.FilterAlbumTracks(album, unavailable)
+	for _, track := range excluded {
+		log.Info("Excluded from album %q: %d-%02d %s", album.Title, track.Volume, track.Position, track.Track.Title)
+	}
+	if err := client.CheckAlbumAvailability(album, log); err != nil {
+		log.Error("Skipping album %q: %v", album.Title, err)
+		return nil, nil, err
+	}
+	summary.Unavailable += len(unavailable)
+	summary.Filtered += len(excluded)
+
+	log.Info("Album %q: %d tracks", album.Title, len(available))
+	if saveCover {
+		if _, err := client.DownloadAlbumCover(album, "", client.AlbumDir(album, outputDir)); err != nil {
+			log.Warn("Cover of album %q not saved: %v", album.Title, err)
+		}
+	}
+	if _, err := client.WriteAlbumSidecar(album, client.AlbumDir(album, outputDir)); err != nil {
+		log.Warn("Metadata file of album %q not saved: %v", album.Title, err)
+	}
+	items := make([]batchItem, 0, len(available))
+	for _, track := range available {
+		items = append(items, batchItem{input: input, trackID: track.Track.ID, albumID: albumID, source: sourceAlbum})
+	}
+	return album, items, nil
+}
+
+// expandPlaylist turns a playlist into its tracks
+func expandPlaylist(client *yamusic.Client, owner, kind string, log *logger.Logger) (*yamusic.Playlist, []batchItem, error) {
+	playlist, err := client.GetPlaylist(owner, kind)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Info("%s: %d tracks", playlist.Title, len(playlist.Tracks))
+
+	var items []batchItem
+	for _, track := range yamusic.PlaylistTrackRefs(playlist) {
+		items = append(items, batchItem{input: track.ID, trackID: track.ID, albumID: track.AlbumID, source: sourcePlaylist})
+	}
+	return playlist, items, nil
+}
+
+// similarItems returns up to count available tracks similar to each track input
+func similarItems(client *yamusic.Client, items []batchItem, count int, log *logger.Logger) []batchItem {
+	var similar []batchItem
+	for _, item := range items {
+		if item.source != sourceTrack {
+			continue
+		}
+
+		tracks, err := client.GetSimilarTracks(item.trackID)
+		if err != nil {
+			log.Warn("Error getting tracks similar to %s: %v", item.trackID, err)
+			continue
+		}
+
+		added := 0
+		for _, track := range tracks {
+			if added == count {
+				break
+			}
+			if !track.Available {
+				continue
+			}
+			albumID := ""
+			if len(track.Albums) > 0 {
+				albumID = track.Albums[0].ID.String()
+			}
+			similar = append(similar, batchItem{input: item.input, trackID: track.ID, albumID: albumID, source: sourceSimilar})
+			added++
+		}
+		log.Info("Similar to %s: %d tracks", item.trackID, added)
+	}
+	return similar
+}
+
+// runBatch downloads the items with concurrency parallel workers and returns the failures.
+// With a positive limit it stops cleanly after that many successful downloads; failures
+// and skipped tracks don't consume the limit. When ctx is cancelled the downloads in progress are aborted
+// and the rest is not attempted. Every item is recorded in the manifest and the playlist
+// export unless they are nil.
+func runBatch(ctx context.Context, client *yamusic.Client, items []batchItem, quality yamusic.AudioQuality,
+	outputDir string, limit, concurrency int, summary *batchSummary, manifest *downloadManifest,
+	playlists *m3uExport, log *logger.Logger) []failedTrack {
+	var failures []failedTrack
+
+	// Without a limit everything is downloaded in one round; with a limit every round
+	// is no larger than the number of downloads still allowed
+	for start := 0; start < len(items); {
+		if limit > 0 && summary.Downloaded >= limit {
+			summary.NotAttempted = len(items) - start
+			log.Info("Limit of %d downloads reached, %d tracks not attempted", limit, summary.NotAttempted)
+			for _, rest := range items[start:] {
+				log.Debug("Not attempted: %s", rest.trackID)
+			}
+			manifest.addNotAttempted(items[start:])
+			break
+		}
+
+		end := len(items)
+		if limit > 0 {
+			end = min(start+limit-summary.Downloaded, len(items))
+		}
+
+		round := items[start:end]
+		tracks := make([]yamusic.TrackRef, len(round))
+		for i, item := range round {
+			tracks[i] = yamusic.TrackRef{ID: item.trackID, AlbumID: item.albumID}
+		}
+
+		for i, result := range client.DownloadTracksContext(ctx, tracks, quality, outputDir, concurrency) {
+			playlists.add(round[i], result)
+			switch {
+			case result.SkipReason == yamusic.SkipExplicit:
+				summary.Explicit++
+				manifest.add(round[i], result, manifestSkipped)
+			case result.Skipped:
+				summary.Skipped++
+				manifest.add(round[i], result, manifestSkipped)
+			case result.Err == nil:
+				summary.Downloaded++
+				manifest.add(round[i], result, manifestDownloaded)
+			case ctx.Err() != nil:
+				// Aborted by the interruption, not a failure of the track
+				summary.NotAttempted++
+				manifest.add(round[i], result, manifestNotAttempted)
+			case errors.Is(result.Err, yamusic.ErrNotAvailable):
+				// Unavailable tracks are reported apart from errors, but kept for -retry-all
+				summary.Unavailable++
+				failures = append(failures, newFailedTrack(round[i], result.Started, result.Err))
+				manifest.add(round[i], result, manifestUnavailable)
+			default:
+				log.Error("Error downloading %s: %v", result.ID, result.Err)
+				summary.fail(result.Err, log)
+				failures = append(failures, newFailedTrack(round[i], result.Started, result.Err))
+				manifest.add(round[i], result, manifestFailed)
+			}
+		}
+
+		if ctx.Err() != nil {
+			summary.NotAttempted += len(items) - end
+			manifest.addNotAttempted(items[end:])
+			log.Warn("Interrupted, %d tracks not downloaded", summary.NotAttempted)
+			break
+		}
+		start = end
+	}
+
+	return failures
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// openBatchState resumes the batch of an existing state file, replacing items with its
+// remaining tracks, or starts a new state file for items. It returns nil after logging
+// the error when the state can't be used.
+func openBatchState(path string, items []batchItem, quality yamusic.AudioQuality, outputDir string,
+	concurrency int, log *logger.Logger) (*yamusic.BatchState, []batchItem) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		tracks := make([]yamusic.TrackRef, len(items))
+		for i, item := range items {
+			tracks[i] = yamusic.TrackRef{ID: item.trackID, AlbumID: item.albumID}
+		}
+		state, err := yamusic.NewBatchState(path, tracks, quality, outputDir, concurrency)
+		if err != nil {
+			log.Error("Error: %v", err)
+			return nil, nil
+		}
+		log.Info("Batch progress of %d tracks saved to %s", len(items), path)
+		return state, items
+	}
+
+	state, err := yamusic.OpenBatchState(path)
+	if err != nil {
+		log.Error("Error: %v", err)
+		return nil, nil
+	}
+	if len(items) > 0 {
+		log.Warn("Resuming the batch of %s, the tracks given on the command line are ignored", path)
+	}
+
+	pending := state.Pending()
+	log.Info("Resuming batch %s: %d of %d tracks left (quality %s, output directory %s)",
+		path, len(pending), len(state.Tracks), state.Quality, state.OutputDir)
+	items = make([]batchItem, len(pending))
+	for i, track := range pending {
+		items[i] = batchItem{input: track.ID, trackID: track.ID, albumID: track.AlbumID, source: sourceTrack}
+	}
+	return state, items
+}
+
+// finishBatchState removes the state file once every track of the batch is completed
+func finishBatchState(state *yamusic.BatchState, path string, log *logger.Logger) {
+	if pending := len(state.Pending()); pending > 0 {
+		log.Info("%d tracks left, continue with -batch-state %s", pending, path)
+		return
+	}
+	if err := os.Remove(path); err != nil {
+		log.Warn("Error removing batch state: %v", err)
+		return
+	}
+	log.Debug("Batch complete, removed %s", path)
+}
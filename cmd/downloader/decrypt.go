@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// runDecrypt decrypts a file kept with -keep-encrypted next to it, dropping the ".enc"
+// suffix (or adding ".dec" to other names), and returns the exit code
+func runDecrypt(client *yamusic.Client, path, key string, log *logger.Logger) int {
+	if key == "" {
+		log.Error("-decrypt requires -key")
+		return 1
+	}
+
+	output, found := strings.CutSuffix(path, ".enc")
+	if !found {
+		output = path + ".dec"
+	}
+
+	if err := client.DecryptFile(path, key, output); err != nil {
+		log.Error("Error: %v", err)
+		return 1
+	}
+	log.Info("Decrypted to %s", output)
+	return 0
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// runDoctor runs the self-test checks, prints the report and returns the exit code
+func runDoctor(client *yamusic.Client, outputDir string, log *logger.Logger) int {
+	log.Info("Running self-test...")
+
+	exitCode := 0
+	for _, check := range client.Doctor(outputDir) {
+		if check.OK {
+			log.Info("✓ %s: %s", check.Name, check.Detail)
+			continue
+		}
+
+		log.Error("✗ %s: %s", check.Name, check.Detail)
+		log.Info("  Hint: %s", check.Hint)
+		if check.Critical {
+			exitCode = 1
+		}
+	}
+
+	if exitCode == 0 {
+		log.Info("All checks passed")
+	} else {
+		log.Error("Some critical checks failed")
+	}
+
+	return exitCode
+}
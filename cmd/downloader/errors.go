@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// Exit codes; 2 is left to the flag package for usage errors
+const (
+	exitOK           = 0
+	exitFailure      = 1
+	exitUnauthorized = 3
+	exitNotFound     = 4
+	exitNotAvailable = 5
+	exitRateLimited  = 6
+)
+
+// exitCode maps an error to the exit code of the program
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, yamusic.ErrUnauthorized), errors.Is(err, yamusic.ErrMissingScope):
+		return exitUnauthorized
+	case errors.Is(err, yamusic.ErrTrackNotFound):
+		return exitNotFound
+	case errors.Is(err, yamusic.ErrNotAvailable):
+		return exitNotAvailable
+	case errors.Is(err, yamusic.ErrRateLimited):
+		return exitRateLimited
+	default:
+		return exitFailure
+	}
+}
+
+// errorHint returns advice on resolving an error, or an empty string
+func errorHint(err error) string {
+	switch exitCode(err) {
+	case exitUnauthorized:
+		return "The access token is invalid, expired or lacks permissions; obtain a new token with yamusic-auth"
+	case exitNotFound:
+		return "The track doesn't exist; check the ID or URL"
+	case exitNotAvailable:
+		return "The track isn't available for this account, e.g. in its region"
+	case exitRateLimited:
+		return "The API rate limit was hit; try again later or lower -concurrency"
+	default:
+		return ""
+	}
+}
+
+// fail records a failure and logs advice on resolving it. The exit code stays specific
+// while all failures agree on it.
+func (s *batchSummary) fail(err error, log *logger.Logger) {
+	code := exitCode(err)
+	if s.Failed > 0 && s.ExitCode != code {
+		code = exitFailure
+	}
+	s.Failed++
+	s.ExitCode = code
+
+	if hint := errorHint(err); hint != "" {
+		log.Warn("%s", hint)
+	}
+}
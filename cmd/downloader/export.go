@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// runExportLikes writes the liked tracks inventory to path (CSV or JSON by extension)
+// and returns the exit code
+func runExportLikes(client *yamusic.Client, path string, log *logger.Logger) int {
+	write := yamusic.WriteLikesCSV
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+	case ".json":
+		write = yamusic.WriteLikesJSON
+	default:
+		log.Error("Error: unsupported export format %q (use .csv or .json)", filepath.Ext(path))
+		return 1
+	}
+
+	log.Info("Getting liked tracks...")
+	likes, err := client.GetLikedTracks("")
+	if err != nil {
+		log.Error("Error getting liked tracks: %v", err)
+		return 1
+	}
+
+	log.Info("Resolving metadata for %d tracks...", len(likes))
+	tracks, err := client.ResolveLikedTracks(likes)
+	if err != nil {
+		log.Error("Error: %v", err)
+		return 1
+	}
+
+	if err := writeExport(path, tracks, write); err != nil {
+		log.Error("Error writing %s: %v", path, err)
+		return 1
+	}
+
+	log.Info("Exported %d liked tracks to %s", len(tracks), path)
+	return 0
+}
+
+// writeExport creates the export file and writes the tracks with the given writer
+func writeExport(path string, tracks []yamusic.LikedTrackInfo, write func(w io.Writer, likes []yamusic.LikedTrackInfo) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file, tracks); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error closing file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// failedReportName is the file name of the failure report in the output directory
+const failedReportName = "failed.json"
+
+// failedReport lists the tracks that failed in a batch run
+type failedReport struct {
+	Version   int           `json:"version"`
+	Generated time.Time     `json:"generated"`
+	Quality   string        `json:"quality"`
+	Failures  []failedTrack `json:"failures"`
+}
+
+// failedTrack describes a single failure
+type failedTrack struct {
+	ID       string    `json:"id"`
+	AlbumID  string    `json:"albumId,omitempty"`
+	Input    string    `json:"input"`
+	Source   string    `json:"source"`
+	Category string    `json:"category"`
+	Error    string    `json:"error"`
+	Started  time.Time `json:"started"`
+	Failed   time.Time `json:"failed"`
+}
+
+// newFailedTrack records a failed batch item
+func newFailedTrack(item batchItem, started time.Time, err error) failedTrack {
+	return failedTrack{
+		ID:       item.trackID,
+		AlbumID:  item.albumID,
+		Input:    item.input,
+		Source:   item.source,
+		Category: yamusic.ErrorCategory(err),
+		Error:    err.Error(),
+		Started:  started,
+		Failed:   time.Now(),
+	}
+}
+
+// writeFailedReport atomically writes failed.json to the output directory.
+// Without failures no report is written and a stale one is removed.
+func writeFailedReport(outputDir string, quality yamusic.AudioQuality, failures []failedTrack, log *logger.Logger) {
+	if outputDir == "" {
+		outputDir = "."
+	}
+	path := filepath.Join(outputDir, failedReportName)
+
+	if len(failures) == 0 {
+		if err := os.Remove(path); err == nil {
+			log.Debug("Removed stale %s", path)
+		}
+		return
+	}
+
+	report := failedReport{Version: 1, Generated: time.Now(), Quality: string(quality), Failures: failures}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Error("Error encoding failure report: %v", err)
+		return
+	}
+
+	if err := utils.WriteFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		log.Error("Error writing failure report: %v", err)
+		return
+	}
+
+	log.Info("%d failed tracks listed in %s (retry with -retry %s)", len(failures), path, path)
+}
+
+// readFailedReport loads the batch items to retry from a failure report.
+// Known-permanent failures are skipped unless retryAll is set.
+func readFailedReport(path string, retryAll bool, log *logger.Logger) ([]batchItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report failedReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid failure report: %w", err)
+	}
+
+	var items []batchItem
+	for _, failure := range report.Failures {
+		if !retryAll && yamusic.IsPermanent(failure.Category) {
+			log.Info("Skipping %s (%s), use -retry-all to retry it", failure.ID, failure.Category)
+			continue
+		}
+		items = append(items, batchItem{
+			input:   failure.Input,
+			trackID: failure.ID,
+			albumID: failure.AlbumID,
+			source:  failure.Source,
+		})
+	}
+
+	return items, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+// trackList collects track inputs from repeated or comma-separated -track flags
+type trackList []string
+
+// String implements flag.Value
+func (l *trackList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value
+func (l *trackList) Set(value string) error {
+	for _, input := range strings.Split(value, ",") {
+		if input = strings.TrimSpace(input); input != "" {
+			*l = append(*l, input)
+		}
+	}
+	return nil
+}
+
+// headerList collects "Name: value" pairs from repeated -header flags
+type headerList [][2]string
+
+// String implements flag.Value
+func (l *headerList) String() string {
+	parts := make([]string, len(*l))
+	for i, h := range *l {
+		parts[i] = h[0] + ": " + h[1]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Set implements flag.Value
+func (l *headerList) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("header must be in \"Name: value\" form")
+	}
+	*l = append(*l, [2]string{name, strings.TrimSpace(val)})
+	return nil
+}
+
+// byteRate is a download speed in bytes per second given as a number with an optional
+// k, m or g suffix (powers of 1024), e.g. "500k"
+type byteRate int64
+
+// String implements flag.Value
+func (r *byteRate) String() string {
+	return strconv.FormatInt(int64(*r), 10)
+}
+
+// Set implements flag.Value
+func (r *byteRate) Set(value string) error {
+	size, err := utils.ParseByteSize(value)
+	if err != nil {
+		return fmt.Errorf("rate must be a number of bytes per second with an optional k, m or g suffix, e.g. 500k")
+	}
+	*r = byteRate(size)
+	return nil
+}
+
+// flagPassed reports whether a flag was given on the command line rather than left at its default
+func flagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// runInspect prints what downloading the track inputs would produce without downloading
+// and returns the exit code. With asJSON the descriptions are written to stdout as a
+// JSON array.
+func runInspect(client *yamusic.Client, inputs []string, quality yamusic.AudioQuality, asJSON bool,
+	log *logger.Logger) int {
+	code := exitOK
+	inspections := []*yamusic.TrackInspection{}
+	for _, input := range inputs {
+		trackID, albumID := utils.ExtractTrackRef(input)
+		track := yamusic.TrackRef{ID: trackID, AlbumID: albumID}
+		inspection, err := client.InspectTrackContext(context.Background(), track, quality)
+		if err != nil {
+			log.Error("Error inspecting track %s: %v", trackID, err)
+			if hint := errorHint(err); hint != "" {
+				log.Warn("%s", hint)
+			}
+			code = exitCode(err)
+			continue
+		}
+		if asJSON {
+			inspections = append(inspections, inspection)
+			continue
+		}
+
+		log.Info("%s - %s (%s) [%s], %s", inspection.Title, strings.Join(inspection.Artists, " & "),
+			strings.Join(inspection.Albums, ", "), inspection.TrackID, formatDuration(inspection.DurationMs))
+		if inspection.Explicit {
+			log.Info("  Explicit content")
+		}
+		if len(inspection.Disclaimers) > 0 {
+			log.Info("  Disclaimers: %s", strings.Join(inspection.Disclaimers, ", "))
+		}
+		log.Info("  Download: %s", formatStream(inspection.Stream))
+		for _, stream := range inspection.Available {
+			log.Info("  Available: %s", formatStream(stream))
+		}
+		for _, variant := range inspection.Variants {
+			log.Info("  Variant: %s", formatVariant(variant))
+		}
+		log.Info("  File: %s", inspection.FileName)
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(inspections, "", "  ")
+		if err != nil {
+			log.Error("Error encoding JSON: %v", err)
+			return exitFailure
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+	}
+	return code
+}
+
+// formatVariant describes a download variant: codec, bitrate and flags
+func formatVariant(variant yamusic.DownloadVariant) string {
+	description := fmt.Sprintf("%s, %d kbps", variant.Codec, variant.Bitrate)
+	if variant.Gain {
+		description += ", normalized"
+	}
+	if variant.Preview {
+		description += ", preview"
+	}
+	return description
+}
+
+// formatStream describes a stream: quality, codec, bitrate and size
+func formatStream(stream yamusic.StreamInfo) string {
+	return fmt.Sprintf("%s, %s, %d kbps, %.1f MB", stream.Quality, stream.Codec, stream.Bitrate,
+		float64(stream.Size)/(1<<20))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// runList prints the track listing of the album inputs without downloading and returns
+// the exit code
+func runList(client *yamusic.Client, inputs []string, log *logger.Logger) int {
+	code := 0
+	for _, input := range inputs {
+		albumID, ok := utils.ExtractAlbumID(input)
+		if !ok {
+			log.Error("Not an album URL: %s", input)
+			code = 1
+			continue
+		}
+
+		album, err := client.GetAlbum(albumID)
+		if err != nil {
+			log.Error("Error getting album %s: %v", albumID, err)
+			code = 1
+			continue
+		}
+
+		log.Info("%s (%d) [%s]", album.Title, album.Year, album.ID)
+		available, unavailable := yamusic.AlbumTracks(album)
+		tracks := append(available, unavailable...)
+		sortAlbumTracks(tracks)
+		for _, track := range tracks {
+			line := fmt.Sprintf("%d-%02d %s - %s (%s) [%s]", track.Volume, track.Position, track.Track.Title,
+				artistNames(track.Track.Artists), formatDuration(track.Track.DurationMs), track.Track.ID)
+			if !track.Track.Available {
+				line += " (unavailable)"
+			}
+			log.Info("%s", line)
+		}
+	}
+	return code
+}
+
+// sortAlbumTracks orders tracks by volume and position
+func sortAlbumTracks(tracks []yamusic.AlbumTrack) {
+	slices.SortFunc(tracks, func(a, b yamusic.AlbumTrack) int {
+		if a.Volume != b.Volume {
+			return a.Volume - b.Volume
+		}
+		return a.Position - b.Position
+	})
+}
+
+// artistNames joins artist names with " & "
+func artistNames(artists []yamusic.Artist) string {
+	names := make([]string, 0, len(artists))
+	for _, artist := range artists {
+		names = append(names, artist.Name)
+	}
+	return strings.Join(names, " & ")
+}
+
+// formatDuration formats milliseconds as m:ss
+func formatDuration(ms int) string {
+	seconds := ms / 1000
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// m3uList is a playlist or album to export as an .m3u8 file
+type m3uList struct {
+	title    string
+	trackIDs []string
+}
+
+// m3uExport collects the playlists and albums of a batch and the results of their tracks.
+// A nil export ignores all calls.
+type m3uExport struct {
+	lists   []m3uList
+	results map[string]yamusic.TrackResult
+}
+
+// newM3UExport returns an export, or nil when exporting is disabled
+func newM3UExport(enabled bool) *m3uExport {
+	if !enabled {
+		return nil
+	}
+	return &m3uExport{results: make(map[string]yamusic.TrackResult)}
+}
+
+// addList registers a playlist or album with its tracks in original order
+func (e *m3uExport) addList(title string, items []batchItem) {
+	if e == nil || len(items) == 0 {
+		return
+	}
+
+	list := m3uList{title: title, trackIDs: make([]string, len(items))}
+	for i, item := range items {
+		list.trackIDs[i] = item.trackID
+	}
+	e.lists = append(e.lists, list)
+}
+
+// add records the result of a track
+func (e *m3uExport) add(item batchItem, result yamusic.TrackResult) {
+	if e == nil {
+		return
+	}
+	e.results[item.trackID] = result
+}
+
+// write saves an .m3u8 file named after every playlist and album into outputDir
+func (e *m3uExport) write(outputDir string, log *logger.Logger) {
+	if e == nil {
+		return
+	}
+
+	for _, list := range e.lists {
+		results := make([]yamusic.TrackResult, 0, len(list.trackIDs))
+		for _, id := range list.trackIDs {
+			if result, ok := e.results[id]; ok {
+				results = append(results, result)
+			}
+		}
+
+		path := filepath.Join(outputDir, yamusic.M3U8Name(list.title))
+		if err := yamusic.WriteM3U8(path, list.title, results); err != nil {
+			log.Error("Error writing playlist %q: %v", list.title, err)
+			continue
+		}
+		log.Info("Playlist written to %s", path)
+	}
+}
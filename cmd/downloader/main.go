@@ -2,11 +2,20 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/archive"
+	"github.com/Kud1nov/yamusic-dl/internal/cache"
 	"github.com/Kud1nov/yamusic-dl/internal/logger"
 	"github.com/Kud1nov/yamusic-dl/internal/utils"
 	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
@@ -14,24 +23,184 @@ import (
 
 func main() {
 	// Define command line parameters
-	trackInput := flag.String("track", "", "Track ID or Yandex Music URL")
+	var trackInputs trackList
+	flag.Var(&trackInputs, "track", "Track ID, track or album URL (repeat or separate with commas for several)")
 	accessToken := flag.String("token", "", "Access token for Yandex Music API")
 	qualityStr := flag.String("quality", string(api.QualityHigh),
 		"Track quality (min, normal, max)")
-	outputDir := flag.String("output", "", "Directory for saving files")
+	existing := flag.String("existing", "overwrite", "What to do with already downloaded tracks: overwrite, skip, rename")
+	preferAlbum := flag.String("prefer-album", "",
+		"Album used for naming when a track is on several albums and the URL has none (original, latest, first)")
+	nameTemplate := flag.String("name-template", "", "Template of file names without the extension, e.g. \"{artist} - {album} ({year}) - {title}\"; fields: {title}, {artist}, {album}, {id}, {year}, {genre}, {label}, {track}, {disc}")
+	layoutName := flag.String("layout", "flat", "Directory layout of saved files: flat, artist-album (Artist/Album (Year)/, CD1, CD2 for multi-disc albums)")
+	outputDir := flag.String("output", "", "Directory for saving files (\"-\" streams the audio to stdout)")
 	verbose := flag.Bool("verbose", false, "Output debug messages")
+	logTimestamp := flag.String("log-timestamp", string(logger.TimestampTime),
+		"Log timestamp format (time, datetime, rfc3339, off; datetime by default with -watch)")
+	var speedLimit byteRate
+	chunks := flag.Int("chunks", 1, "Download each file over this many connections in byte ranges, when the server supports them (1 - a single connection)")
+	flag.Var(&speedLimit, "limit-rate", "Maximum download speed of all downloads together in bytes per second, e.g. 500k or 2m (0 - no limit)")
+	rateLimit := flag.Float64("rate-limit", yamusic.DefaultRequestsPerSecond, "Maximum number of API requests per second (0 disables the limit)")
+	maxMediaDownloads := flag.Int("max-media-downloads", yamusic.DefaultMaxMediaDownloads,
+		"Maximum number of media files transferred at once by all workers (0 - no limit)")
+	proxyURL := flag.String("proxy", "", "Proxy for API and media requests (http://, https:// or socks5://host:port)")
+	maxConnsPerHost := flag.Int("max-conns-per-host", yamusic.DefaultMaxConnsPerHost,
+		"Maximum simultaneous connections and transfers per host")
+	showStats := flag.Bool("stats", false, "Print API call timing statistics at the end")
+	slowThreshold := flag.Duration("slow-threshold", yamusic.DefaultSlowCallThreshold,
+		"Warn when an API call takes longer than this")
+	clientPreset := flag.String("client-preset", api.DefaultPreset, "Client identity headers preset (desktop, android, ios)")
+	var headers headerList
+	flag.Var(&headers, "header", "Override a request header, \"Name: value\" (can be repeated)")
+	logFormat := flag.String("log-format", string(logger.FormatConsole), "Log output format (console, json)")
+	asciiUI := flag.Bool("ascii-ui", false, "Replace decorative glyphs in output with plain text")
+	checkAvailability := flag.Bool("check-availability", false,
+		"Count available, subscription-only, region-blocked and missing tracks before downloading; asks to confirm in interactive mode")
+	nonInteractive := flag.Bool("non-interactive", false,
+		"Never prompt for input (enabled automatically when stdin is not a terminal)")
+	verifyLibrary := flag.String("verify-library", "", "Verify previously downloaded files in the directory")
+	strictQuality := flag.Bool("strict-quality", false,
+		"Fail tracks the API delivers in a lower quality than requested instead of downloading them with a warning")
+	keepEncrypted := flag.Bool("keep-encrypted", false, "Keep the encrypted file from the CDN next to every track as <file>.enc")
+	decryptFile := flag.String("decrypt", "", "Decrypt a file kept with -keep-encrypted using -key instead of downloading")
+	decryptKey := flag.String("key", "", "Hex-encoded decryption key for -decrypt")
+	verifyMetadata := flag.Bool("verify-metadata", false, "Also compare files against the API metadata (with -verify-library)")
+	exportLikes := flag.String("export-likes", "", "Export the liked tracks list to a .csv or .json file without downloading")
+	coverFile := flag.String("cover", "", "Save album covers under this name into the output directory, e.g. cover.jpg or folder.jpg")
+	coverSize := flag.String("cover-size", yamusic.DefaultCoverSize, "Size of saved covers: 200x200, 400x400, 1000x1000 or orig")
+	sidecarFormat := flag.String("metadata-file", "none", "Write a metadata file next to every track and album: nfo (Kodi, Jellyfin), json or none")
+	signKeys := flag.String("sign-keys", "", "Comma-separated sign keys tried after the built-in one when a signature is rejected")
+	codecs := flag.String("codecs", "", "Comma-separated codecs to prefer, e.g. aac,aac-mp4 (default: all supported codecs)")
+	noLegacy := flag.Bool("no-legacy-fallback", false, "Don't fall back to the legacy download API (unencrypted MP3) when get-file-info fails")
+	noFallback := flag.Bool("no-quality-fallback", false, "Fail tracks not available in the requested quality instead of downloading a lower one")
+	scrobble := flag.Bool("scrobble", false, "Report downloaded tracks as played, adding them to the listening history")
+	noDedup := flag.Bool("no-dedup", false, "Download every release of a recording instead of skipping tracks with the realId of a downloaded track")
+	releaseMtime := flag.Bool("release-mtime", false, "Set the modification time of downloaded files to the album release date")
+	preview := flag.Bool("preview", false, "Download only the preview of tracks (about 30 seconds in low quality) as Name.preview.ext")
+	saveLyrics := flag.Bool("lyrics", false, "Save lyrics next to the tracks (.lrc when synced lyrics exist, .txt otherwise)")
+	embedLyrics := flag.Bool("embed-lyrics", false, "Embed lyrics into the tags of the tracks (synced when available, plain otherwise)")
+	noTags := flag.Bool("no-tags", false, "Don't write standard tags (title, artist, album, etc.) into files")
+	skipExplicit := flag.Bool("skip-explicit", false, "Skip tracks marked explicit")
+	noExtraTags := flag.Bool("no-extra-tags", false, "Don't write Yandex-specific tags (fade points) and gapless info into files")
+	noPreflight := flag.Bool("no-preflight", false, "Skip checking the token scopes before the first download")
+	requireComplete := flag.Bool("require-complete", false, "Skip partially available albums instead of downloading the available tracks")
+	listAlbums := flag.Bool("list", false, "Print the track listing of the albums given with -track and exit")
+	dryRun := flag.Bool("dry-run", false, "Print the metadata, available qualities and file name of the tracks given with -track and exit without downloading")
+	flag.BoolVar(dryRun, "info", false, "Same as -dry-run")
+	inspectJSON := flag.Bool("json", false, "With -dry-run, print the track information as JSON")
+	searchQuery := flag.String("search", "", "Search for tracks by name and pick the ones to download")
+	downloadLikes := flag.Bool("likes", false, "Download all tracks liked by the account")
+	similarCount := flag.Int("similar", 0, "Also download up to N available tracks similar to each track given with -track")
+	excludePattern := flag.String("exclude", "",
+		"Skip album tracks whose title or version, or whose album version or type, matches this regular expression, e.g. \"(?i)(live|remix|karaoke)\"")
+	onlyVolumes := flag.String("only-volumes", "", "Download only these discs of albums, e.g. 1 or 1,2")
+	stationID := flag.String("station", "", "Download tracks of a rotor station, e.g. "+yamusic.MyWaveStation+" for My Wave or genre:rock")
+	stationCount := flag.Int("station-count", 50, "Number of tracks to download with -station")
+	chartType := flag.String("chart", "", "Download the tracks of a chart (russia, world)")
+	chartTop := flag.Int("top", 0, "With -chart, download only the top N tracks (0 - the whole chart)")
+	playlistInput := flag.String("playlist", "", "Download the tracks of a playlist given by URL or owner:kind")
+	watchInterval := flag.Duration("watch", 0, "With -playlist, keep polling the playlist at this interval and download newly added tracks")
+	watchState := flag.String("watch-state", "", "File with the last seen playlist state of -watch (default: "+watchStateName+" in the output directory)")
+	retryReport := flag.String("retry", "", "Retry the tracks listed in a failed.json report")
+	retryAll := flag.Bool("retry-all", false, "With -retry, also retry known-permanent failures (region restrictions)")
+	retryAttempts := flag.Int("retry-attempts", yamusic.DefaultRetryAttempts,
+		"Attempts per request on transient errors (5xx, 429, network); 1 disables retries")
+	apiTimeout := flag.Duration("api-timeout", yamusic.DefaultAPITimeout, "Time limit of an API call (0 - no limit)")
+	stallTimeout := flag.Duration("stall-timeout", yamusic.DefaultStallTimeout,
+		"Abort a file transfer that receives no data for this long (0 - never); transfers have no overall time limit")
+	lockStale := flag.Duration("lock-stale", yamusic.DefaultLockStaleness,
+		"Remove the lock of a track file older than this, left by a crashed run")
+	retryDelay := flag.Duration("retry-delay", yamusic.DefaultRetryDelay,
+		"Delay before the first retry, doubled with every attempt")
+	batchStatePath := flag.String("batch-state", "", "Save the progress of the batch to this file and resume the batch from it when it exists")
+	limit := flag.Int("limit", 0, "Stop after this many successful downloads (0 - no limit)")
+	concurrency := flag.Int("concurrency", 1, "Number of tracks downloaded in parallel")
+	showProgress := flag.Bool("progress", false, "Show a download progress bar on stderr")
+	cachePath := flag.String("cache", "", "Metadata cache file (disabled when empty)")
+	writeM3U := flag.Bool("m3u", false, "Write an .m3u8 playlist of the downloaded tracks in original order for every playlist and album")
+	writeManifestFile := flag.Bool("manifest", false, "Write a JSON manifest of the batch run (downloads.json in the output directory)")
+	manifestPath := flag.String("manifest-file", "", "Path of the download manifest; implies -manifest")
+	archivePath := flag.String("archive", "", "Download archive file: skip tracks listed in it and add downloaded track IDs (disabled when empty)")
+	cacheTTL := flag.Duration("cache-ttl", cache.DefaultTTL, "Time after which cached metadata is refreshed")
+	offline := flag.Bool("offline", false, "Use cached metadata only and never access the network (requires -cache)")
+	cacheStats := flag.Bool("cache-stats", false, "Print metadata cache statistics and exit (requires -cache)")
+	serveAddr := flag.String("serve", "", "Run as a daemon with an HTTP API on the address, e.g. :8080")
+	serveToken := flag.String("serve-token", "", "Bearer token required by the -serve HTTP API")
+	checkToken := flag.Bool("check-token", false, "Check the access token and the account subscription and exit")
+	doctor := flag.Bool("doctor", false, "Run self-test checks and print a report")
 
 	// Parse parameters
 	flag.Parse()
 
+	// Tracks come from a source other than -track
+	fromSources := *retryReport != "" || *batchStatePath != "" || *downloadLikes || *chartType != "" ||
+		*stationID != "" || *playlistInput != ""
+
+	// isBatch reports whether the run downloads a batch rather than a single track, given
+	// the number of tracks known so far
+	isBatch := func(tracks int) bool {
+		return tracks > 1 || fromSources || *similarCount > 0
+	}
+
 	// Check required parameters
-	if *trackInput == "" || *accessToken == "" {
+	// Library verification works offline unless metadata checks are requested
+	needsTrack := !*doctor && !*checkToken && *verifyLibrary == "" && *exportLikes == "" && !*cacheStats && *serveAddr == "" && *decryptFile == ""
+	needsToken := (*verifyLibrary == "" || *verifyMetadata) && !*cacheStats && !*offline && *decryptFile == ""
+	if (needsTrack && len(trackInputs) == 0 && !fromSources && *searchQuery == "") || (needsToken && *accessToken == "") {
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	// Extract track ID from input (URL or ID)
-	trackID := utils.ExtractTrackID(*trackInput)
+	// Unattended runs must never block waiting for input
+	utils.SetNonInteractive(*nonInteractive || !utils.StdinIsTerminal())
+
+	albumPolicy, err := yamusic.ParseAlbumPolicy(*preferAlbum)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sidecar, err := yamusic.ParseSidecarFormat(*sidecarFormat)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var trackFilter yamusic.TrackFilter
+	if *excludePattern != "" {
+		trackFilter.Exclude, err = regexp.Compile(*excludePattern)
+		if err != nil {
+			fmt.Printf("Error: invalid -exclude pattern: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	trackFilter.Volumes, err = yamusic.ParseVolumes(*onlyVolumes)
+	if err != nil {
+		fmt.Printf("Error: invalid -only-volumes: %v\n", err)
+		os.Exit(1)
+	}
+
+	overwritePolicy, err := yamusic.ParseOverwritePolicy(*existing)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dirLayout, err := yamusic.ParseDirLayout(*layoutName)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := yamusic.ValidateCoverSize(*coverSize); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := yamusic.ValidateFileNameTemplate(*nameTemplate); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Check quality
 	quality := yamusic.AudioQuality(*qualityStr)
@@ -42,24 +211,399 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Check logging options
+	// A watch runs for days, so the time of day alone is ambiguous
+	if *watchInterval > 0 && !flagPassed("log-timestamp") {
+		*logTimestamp = string(logger.TimestampDateTime)
+	}
+	timestampFormat, err := logger.ParseTimestampFormat(*logTimestamp)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	format, err := logger.ParseFormat(*logFormat)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Streaming to stdout makes sense for a single track only
+	toStdout := *outputDir == stdoutOutput
+	if toStdout && len(trackInputs) > 1 {
+		fmt.Fprintln(os.Stderr, "Error: -output - can't be used with several tracks")
+		os.Exit(1)
+	}
+
+	// Keep stdout clean for the audio stream and JSON output
+	logOutput := io.Writer(os.Stdout)
+	if toStdout || (*dryRun && *inspectJSON) {
+		logOutput = os.Stderr
+	}
+
 	// Configure logger
-	log := logger.New(*verbose)
+	log := logger.NewWithOptions(logger.Options{
+		Output:    logOutput,
+		Verbose:   *verbose,
+		Timestamp: timestampFormat,
+		Format:    format,
+		ASCII:     *asciiUI,
+		// Batches tend to repeat the same warnings for every track
+		Dedup: isBatch(len(trackInputs)),
+	})
+
+	// Repeat summaries still pending in the logger are written before exiting
+	exit := func(code int) {
+		log.Flush()
+		os.Exit(code)
+	}
+
+	if (*offline || *cacheStats) && *cachePath == "" {
+		log.Error("Error: -offline and -cache-stats require -cache")
+		exit(1)
+	}
+
+	// Open the metadata cache
+	var metadataCache *cache.Store
+	if *cachePath != "" {
+		var warning string
+		metadataCache, warning, err = cache.Open(*cachePath, *cacheTTL)
+		if err != nil {
+			log.Error("Error opening cache: %v", err)
+			exit(1)
+		}
+		defer metadataCache.Close()
+		if warning != "" {
+			log.Warn("%s", warning)
+		}
+	}
+
+	if *cacheStats {
+		exit(runCacheStats(metadataCache, *cachePath, log))
+	}
+
+	// Open the download archive; every ID is written as soon as the track is done
+	var downloadArchive *archive.Archive
+	if *archivePath != "" {
+		downloadArchive, err = archive.Open(*archivePath)
+		if err != nil {
+			log.Error("Error opening download archive: %v", err)
+			exit(1)
+		}
+		defer downloadArchive.Close()
+		log.Debug("Download archive: %d tracks", downloadArchive.Len())
+	}
 
 	// Create directory for saving if needed
-	if *outputDir != "" {
+	if *outputDir != "" && !toStdout {
 		if err := os.MkdirAll(*outputDir, 0755); err != nil {
 			log.Error("Error creating directory: %v", err)
-			os.Exit(1)
+			exit(1)
 		}
 	}
 
 	// Create Yandex Music client
 	client := yamusic.NewClient(*accessToken, api.DefaultSignKey, log)
-
-	// Download track
-	_, err := client.DownloadTrack(trackID, quality, *outputDir)
-	if err != nil {
+	if *signKeys != "" {
+		if err := client.SetSignKeys(append([]string{api.DefaultSignKey}, strings.Split(*signKeys, ",")...)...); err != nil {
+			log.Error("Error: %v", err)
+			exit(1)
+		}
+	}
+	client.SetSlowCallThreshold(*slowThreshold)
+	client.SetRetry(*retryAttempts, *retryDelay)
+	client.SetTimeouts(*apiTimeout, *stallTimeout)
+	client.SetLockStaleness(*lockStale)
+	if *showProgress {
+		client.SetProgressFunc(newProgressBar(os.Stderr).update)
+	}
+	client.SetMaxConnsPerHost(*maxConnsPerHost)
+	client.SetRateLimit(*rateLimit, yamusic.DefaultRequestBurst)
+	client.SetMaxMediaDownloads(*maxMediaDownloads)
+	client.SetSpeedLimit(int64(speedLimit))
+	if *chunks > 1 {
+		client.SetChunkedDownload(*chunks)
+	}
+	if *proxyURL != "" {
+		if err := client.SetProxy(*proxyURL); err != nil {
+			log.Error("Error: %v", err)
+			exit(1)
+		}
+	}
+	client.SetAlbumPolicy(albumPolicy)
+	client.SetOverwritePolicy(overwritePolicy)
+	client.SetDirLayout(dirLayout)
+	client.SetFileNameTemplate(*nameTemplate)
+	if metadataCache != nil {
+		client.SetCache(metadataCache)
+	}
+	if downloadArchive != nil {
+		client.SetArchive(downloadArchive)
+	}
+	client.SetOffline(*offline)
+	client.SetSaveCover(*coverFile, *coverSize)
+	client.SetSidecarFormat(sidecar)
+	client.SetQualityFallback(!*noFallback)
+	if *codecs != "" {
+		if err := client.SetCodecs(strings.Split(*codecs, ",")...); err != nil {
+			log.Error("Error: %v", err)
+			exit(1)
+		}
+	}
+	client.SetLyrics(*saveLyrics)
+	client.SetEmbedLyrics(*embedLyrics)
+	client.SetScrobble(*scrobble)
+	client.SetPreview(*preview)
+	client.SetReleaseDateTimestamps(*releaseMtime)
+	client.SetDeduplication(!*noDedup)
+	client.SetLegacyFallback(!*noLegacy)
+	client.SetStandardTags(!*noTags)
+	client.SetExtraTags(!*noExtraTags)
+	client.SetSkipExplicit(*skipExplicit)
+	client.SetKeepEncrypted(*keepEncrypted)
+	client.SetStrictQuality(*strictQuality)
+	client.SetTrackFilter(trackFilter)
+	client.SetRequireComplete(*requireComplete)
+	if err := client.SetClientPreset(*clientPreset); err != nil {
 		log.Error("Error: %v", err)
-		os.Exit(1)
+		exit(1)
+	}
+	for _, h := range headers {
+		client.SetHeader(h[0], h[1])
+	}
+
+	// Decrypt a kept encrypted file instead of downloading
+	if *decryptFile != "" {
+		exit(runDecrypt(client, *decryptFile, *decryptKey, log))
+	}
+
+	// Verify library instead of downloading
+	if *verifyLibrary != "" {
+		exit(runVerify(client, *verifyLibrary, *verifyMetadata, log))
+	}
+
+	// Export liked tracks instead of downloading
+	if *exportLikes != "" {
+		exit(runExportLikes(client, *exportLikes, log))
+	}
+
+	// Check the token instead of downloading
+	if *checkToken {
+		exit(runCheckToken(client, log))
+	}
+
+	// Run self-test instead of downloading
+	if *doctor {
+		exit(runDoctor(client, *outputDir, log))
+	}
+
+	// Check the token scopes once before downloading anything
+	if !*noPreflight && !*offline {
+		if err := client.Preflight(); err != nil {
+			log.Error("Error: %v", err)
+			exit(1)
+		}
+	}
+
+	// Serve the HTTP API instead of downloading
+	if *serveAddr != "" {
+		exit(runServe(client, *serveAddr, *serveToken, quality, *outputDir, *concurrency, log))
+	}
+
+	// Keep downloading the tracks added to the playlist
+	if *watchInterval > 0 {
+		if *playlistInput == "" {
+			log.Error("Error: -watch requires -playlist")
+			exit(1)
+		}
+		exit(runWatch(client, *playlistInput, *watchState, *watchInterval, quality, *outputDir, log))
+	}
+
+	// Print album listings instead of downloading
+	if *listAlbums {
+		exit(runList(client, trackInputs, log))
+	}
+
+	// Describe the tracks instead of downloading
+	if *dryRun {
+		exit(runInspect(client, trackInputs, quality, *inspectJSON, log))
+	}
+
+	// Let the user pick tracks from search results
+	if *searchQuery != "" {
+		selected, err := searchTracks(client, *searchQuery, log)
+		if err != nil {
+			log.Error("Search error: %v", err)
+			exit(1)
+		}
+		trackInputs = append(trackInputs, selected...)
+		if len(trackInputs) == 0 && !fromSources {
+			exit(0)
+		}
+	}
+
+	// Stream the track to stdout
+	if toStdout {
+		exit(runStdout(client, trackInputs[0], quality, log))
+	}
+
+	// Download tracks
+	var summary batchSummary
+	playlists := newM3UExport(*writeM3U)
+	items := expandInputs(client, trackInputs, *outputDir, *coverFile != "", &summary, playlists, log)
+	if *similarCount > 0 {
+		items = append(items, similarItems(client, items, *similarCount, log)...)
+	}
+	if *downloadLikes {
+		likes, err := client.GetLikedTracks("")
+		if err != nil {
+			log.Error("Error getting liked tracks: %v", err)
+			exit(1)
+		}
+		log.Info("Liked tracks: %d", len(likes))
+		for _, like := range likes {
+			items = append(items, batchItem{input: like.ID, trackID: like.ID, albumID: like.AlbumID, source: sourceLikes})
+		}
+	}
+	if *chartType != "" {
+		chart, err := client.GetChart(*chartType, *chartTop)
+		if err != nil {
+			log.Error("Error getting chart: %v", err)
+			exit(1)
+		}
+		log.Info("%s: %d tracks", chart.Title, len(chart.Chart.Tracks))
+		for _, entry := range chart.Chart.Tracks {
+			albumID := ""
+			if len(entry.Track.Albums) > 0 {
+				albumID = entry.Track.Albums[0].ID.String()
+			}
+			items = append(items, batchItem{input: entry.Track.ID, trackID: entry.Track.ID, albumID: albumID, source: sourceChart})
+		}
+	}
+	if *stationID != "" {
+		tracks, err := client.GetStationTracks(*stationID, *stationCount)
+		if err != nil {
+			log.Error("Error getting station tracks: %v", err)
+			exit(1)
+		}
+		log.Info("Station %s: %d tracks", *stationID, len(tracks))
+		var stationItems []batchItem
+		for _, track := range tracks {
+			if !track.Available {
+				continue
+			}
+			albumID := ""
+			if len(track.Albums) > 0 {
+				albumID = track.Albums[0].ID.String()
+			}
+			stationItems = append(stationItems, batchItem{input: track.ID, trackID: track.ID, albumID: albumID, source: sourceStation})
+		}
+		playlists.addList(*stationID, stationItems)
+		items = append(items, stationItems...)
+	}
+	if *playlistInput != "" {
+		owner, kind, ok := utils.ExtractPlaylistRef(*playlistInput)
+		if !ok {
+			log.Error("Invalid playlist %q: expected a playlist URL or owner:kind", *playlistInput)
+			exit(1)
+		}
+		playlist, playlistItems, err := expandPlaylist(client, owner, kind, log)
+		if err != nil {
+			log.Error("Error getting playlist: %v", err)
+			exit(1)
+		}
+		playlists.addList(playlist.Title, playlistItems)
+		items = append(items, playlistItems...)
+	}
+	if *retryReport != "" {
+		retryItems, err := readFailedReport(*retryReport, *retryAll, log)
+		if err != nil {
+			log.Error("Error reading %s: %v", *retryReport, err)
+			exit(1)
+		}
+		items = append(items, retryItems...)
+	}
+	var batchState *yamusic.BatchState
+	if *batchStatePath != "" {
+		batchState, items = openBatchState(*batchStatePath, items, quality, *outputDir, *concurrency, log)
+		if batchState == nil {
+			exit(1)
+		}
+		quality, *outputDir = batchState.Quality, batchState.OutputDir
+		client.SetBatchState(batchState)
+	}
+	if *checkAvailability && !confirmAvailability(client, items, log) {
+		exit(0)
+	}
+
+	// Abort the current download on SIGINT/SIGTERM so its temporary file is cleaned up
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	var manifest *downloadManifest
+	if *writeManifestFile || *manifestPath != "" {
+		manifest = &downloadManifest{Tracks: []manifestTrack{}}
+	}
+	failures := runBatch(ctx, client, items, quality, *outputDir, *limit, *concurrency, &summary, manifest, playlists, log)
+	stop()
+	if batchState != nil {
+		finishBatchState(batchState, *batchStatePath, log)
+	}
+	playlists.write(*outputDir, log)
+	if manifest != nil {
+		writeManifest(manifest, *manifestPath, *outputDir, quality, log)
+	}
+	if isBatch(len(items)) {
+		writeFailedReport(*outputDir, quality, failures, log)
+	}
+	if summary.Downloaded+summary.Skipped+summary.Failed+summary.Unavailable+summary.NotAttempted+summary.Explicit+summary.Filtered > 1 {
+		log.Info("Downloaded: %d, already existing: %d, failed: %d, unavailable: %d, not attempted: %d",
+			summary.Downloaded, summary.Skipped, summary.Failed, summary.Unavailable, summary.NotAttempted)
+		if summary.Explicit > 0 {
+			log.Info("Explicit tracks skipped: %d", summary.Explicit)
+		}
+		if summary.Filtered > 0 {
+			log.Info("Album tracks excluded by -exclude and -only-volumes: %d", summary.Filtered)
+		}
+	}
+
+	if *showStats {
+		printStats(log, client.Stats())
+	}
+
+	if summary.Failed > 0 {
+		exit(summary.ExitCode)
+	}
+	if summary.Unavailable > 0 && summary.Downloaded+summary.Skipped == 0 {
+		exit(exitNotAvailable)
+	}
+	log.Flush()
+}
+
+// runCacheStats prints the metadata cache statistics and returns the exit code
+func runCacheStats(store *cache.Store, path string, log *logger.Logger) int {
+	stats := store.Stats()
+
+	total := 0
+	for _, count := range stats.Entries {
+		total += count
+	}
+
+	log.Info("Cache %s: %d entries (%d stale), %.1f KiB", path, total, stats.Stale, float64(stats.Size)/1024)
+	for _, kind := range []string{cache.KindTrack, cache.KindAlbum, cache.KindPlaylist} {
+		log.Info("  %s: %d", kind, stats.Entries[kind])
+	}
+
+	return 0
+}
+
+// printStats outputs the aggregated API call timings
+func printStats(log *logger.Logger, stats []yamusic.EndpointStats) {
+	log.Info("API call statistics:")
+	for _, s := range stats {
+		avgWall := s.TotalWall / time.Duration(s.Calls)
+		avgServer := s.TotalServer / time.Duration(s.Calls)
+		log.Info("  %s: %d calls (%d slow), avg %s (server %s, network %s), max %s (server %s)",
+			s.Endpoint, s.Calls, s.Slow,
+			avgWall.Round(time.Millisecond), avgServer, s.Network().Round(time.Millisecond),
+			s.MaxWall.Round(time.Millisecond), s.MaxServer)
 	}
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// manifestName is the default file name of the download manifest in the output directory
+const manifestName = "downloads.json"
+
+// Track statuses in the download manifest
+const (
+	manifestDownloaded   = "downloaded"
+	manifestSkipped      = "skipped"
+	manifestFailed       = "failed"
+	manifestUnavailable  = "unavailable"
+	manifestNotAttempted = "not-attempted"
+)
+
+// downloadManifest records every track of a batch run
+type downloadManifest struct {
+	Version   int             `json:"version"`
+	Generated time.Time       `json:"generated"`
+	Quality   string          `json:"quality"`
+	Tracks    []manifestTrack `json:"tracks"`
+}
+
+// manifestTrack describes the outcome of a single track
+type manifestTrack struct {
+	ID      string `json:"id"`
+	AlbumID string `json:"albumId,omitempty"`
+	Input   string `json:"input"`
+	Source  string `json:"source"`
+	Status  string `json:"status"`
+
+	Title      string   `json:"title,omitempty"`
+	Artists    []string `json:"artists,omitempty"`
+	Albums     []string `json:"albums,omitempty"`
+	DurationMs int      `json:"durationMs,omitempty"`
+
+	// Fade holds the fade points of the track in seconds, for crossfading
+	Fade *yamusic.Fade `json:"fade,omitempty"`
+
+	Path    string `json:"path,omitempty"`
+	Quality string `json:"quality,omitempty"`
+	Codec   string `json:"codec,omitempty"`
+	Bitrate int    `json:"bitrate,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+
+	// RequestedQuality is the quality requested from the API; Downgraded is set when
+	// Quality, Codec and Bitrate describe a lower quality
+	RequestedQuality string `json:"requestedQuality,omitempty"`
+	Downgraded       bool   `json:"downgraded,omitempty"`
+
+	// ElapsedMs is the time the download took
+	ElapsedMs int64 `json:"elapsedMs,omitempty"`
+
+	Category string `json:"category,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// add records the outcome of a batch item; a nil manifest records nothing
+func (m *downloadManifest) add(item batchItem, result yamusic.TrackResult, status string) {
+	if m == nil {
+		return
+	}
+
+	track := manifestTrack{
+		ID:      item.trackID,
+		AlbumID: item.albumID,
+		Input:   item.input,
+		Source:  item.source,
+		Status:  status,
+		Path:    result.Path,
+	}
+	if download := result.Download; download != nil {
+		track.Title, track.Artists, track.Albums = download.Title, download.Artists, download.Albums
+		track.DurationMs = download.DurationMs
+		if download.Fade != (yamusic.Fade{}) {
+			fade := download.Fade
+			track.Fade = &fade
+		}
+		track.Quality, track.Codec, track.Bitrate = string(download.Quality), download.Codec, download.Bitrate
+		track.Size = download.Size
+		track.RequestedQuality, track.Downgraded = string(download.RequestedQuality), download.Downgraded
+	}
+	if !result.Started.IsZero() && !result.Finished.IsZero() {
+		track.ElapsedMs = result.Finished.Sub(result.Started).Milliseconds()
+	}
+	if result.Err != nil && (status == manifestFailed || status == manifestUnavailable) {
+		track.Category = yamusic.ErrorCategory(result.Err)
+		track.Error = result.Err.Error()
+	}
+	m.Tracks = append(m.Tracks, track)
+}
+
+// addNotAttempted records batch items that were not attempted
+func (m *downloadManifest) addNotAttempted(items []batchItem) {
+	for _, item := range items {
+		m.add(item, yamusic.TrackResult{}, manifestNotAttempted)
+	}
+}
+
+// writeManifest atomically writes the manifest to path, downloads.json in the output
+// directory when empty
+func writeManifest(manifest *downloadManifest, path, outputDir string, quality yamusic.AudioQuality, log *logger.Logger) {
+	if path == "" {
+		if outputDir == "" {
+			outputDir = "."
+		}
+		path = filepath.Join(outputDir, manifestName)
+	}
+
+	manifest.Version, manifest.Generated, manifest.Quality = 1, time.Now(), string(quality)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		log.Error("Error encoding download manifest: %v", err)
+		return
+	}
+
+	if err := utils.WriteFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		log.Error("Error writing download manifest: %v", err)
+		return
+	}
+
+	log.Info("Download manifest of %d tracks written to %s", len(manifest.Tracks), path)
+}
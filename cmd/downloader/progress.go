@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// progressBarWidth is the number of cells of the progress bar
+const progressBarWidth = 30
+
+// progressBar renders download progress on a single terminal line. With concurrent
+// downloads the line shows the track that reported last.
+type progressBar struct {
+	mu      sync.Mutex
+	out     io.Writer
+	lastLen int
+}
+
+// newProgressBar creates a progress bar writing to out
+func newProgressBar(out io.Writer) *progressBar {
+	return &progressBar{out: out}
+}
+
+// update implements yamusic.ProgressFunc
+func (b *progressBar) update(p yamusic.Progress) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Finished downloads are reported by the log; just clear the line
+	if p.Done {
+		b.render("")
+		return
+	}
+
+	line := fmt.Sprintf("%s %.1f MiB", p.TrackID, float64(p.Downloaded)/(1<<20))
+	if p.Total > 0 {
+		filled := int(min(p.Downloaded, p.Total) * progressBarWidth / p.Total)
+		line = fmt.Sprintf("%s [%s%s] %3d%% %.1f/%.1f MiB", p.TrackID,
+			strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled),
+			min(p.Downloaded, p.Total)*100/p.Total, float64(p.Downloaded)/(1<<20), float64(p.Total)/(1<<20))
+	}
+	b.render(line)
+}
+
+// render overwrites the current line, padding with spaces to erase the previous content
+func (b *progressBar) render(line string) {
+	fmt.Fprintf(b.out, "\r%s%s", line, strings.Repeat(" ", max(b.lastLen-len(line), 0)))
+	if line == "" {
+		fmt.Fprint(b.out, "\r")
+	}
+	b.lastLen = len(line)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// searchTracks searches for tracks, lists the first page of results and lets the user pick
+// the tracks to download. It returns the selected track IDs; in non-interactive runs the
+// results are only listed.
+func searchTracks(client *yamusic.Client, query string, log *logger.Logger) ([]string, error) {
+	result, err := client.Search(query, yamusic.SearchTrack, 0)
+	if err != nil {
+		return nil, err
+	}
+	if result.Tracks == nil || len(result.Tracks.Results) == 0 {
+		log.Info("Nothing found for %q", query)
+		return nil, nil
+	}
+
+	if result.MisspellCorrected {
+		log.Info("Showing results for %q", result.MisspellResult)
+	}
+	tracks := result.Tracks.Results
+	log.Info("Found %d tracks, showing %d:", result.Tracks.Total, len(tracks))
+	for i, track := range tracks {
+		log.Info("%3d. %s", i+1, describeTrack(&track))
+	}
+
+	if !utils.IsInteractive() {
+		return nil, nil
+	}
+
+	input, err := utils.Prompt("Track numbers to download, separated by commas (empty to quit): ",
+		"pass the track IDs with -track", log.Info)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []string
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > len(tracks) {
+			return nil, fmt.Errorf("invalid track number %q", field)
+		}
+		selected = append(selected, tracks[n-1].ID)
+	}
+	return selected, nil
+}
+
+// describeTrack formats a search result as "Title - Artist1 & Artist2 (Album) [ID]"
+func describeTrack(track *yamusic.TrackInfo) string {
+	line := fmt.Sprintf("%s - %s", track.Title, artistNames(track.Artists))
+	if len(track.Albums) > 0 {
+		line += fmt.Sprintf(" (%s)", track.Albums[0].Title)
+	}
+	return line + fmt.Sprintf(" [%s]", track.ID)
+}
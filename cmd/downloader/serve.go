@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// Job states reported by GET /jobs
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+)
+
+// maxQueuedJobs is the capacity of the daemon download queue
+const maxQueuedJobs = 1000
+
+// job is a download of a track, album or playlist requested through the HTTP API
+type job struct {
+	ID       int                `json:"id"`
+	Input    string             `json:"input"`
+	Kind     yamusic.EntityKind `json:"kind"`
+	Status   string             `json:"status"`
+	Error    string             `json:"error,omitempty"`
+	Tracks   []jobTrack         `json:"tracks,omitempty"`
+	Created  time.Time          `json:"created"`
+	Finished *time.Time         `json:"finished,omitempty"`
+
+	// ref is the input resolved when the job was requested
+	ref yamusic.EntityRef
+}
+
+// jobTrack is the state of a single track of a job with its latest progress report
+type jobTrack struct {
+	yamusic.Progress
+	AlbumID string `json:"albumId,omitempty"`
+	Status  string `json:"status"`
+	Path    string `json:"path,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// snapshot copies the job for encoding; the caller must hold the daemon lock.
+// Finished is never modified after being set, so sharing the pointer is safe.
+func (j *job) snapshot() job {
+	copied := *j
+	copied.Tracks = slices.Clone(j.Tracks)
+	return copied
+}
+
+// errShuttingDown rejects the tracks not yet started when the daemon shuts down
+var errShuttingDown = errors.New("the daemon is shutting down")
+
+// daemon serves the HTTP API and runs queued downloads
+type daemon struct {
+	client      *yamusic.Client
+	quality     yamusic.AudioQuality
+	outputDir   string
+	concurrency int
+	token       string
+	log         *logger.Logger
+
+	mu     sync.Mutex
+	jobs   []*job
+	queue  chan *job
+	nextID int
+
+	// running is the job being downloaded, which progress reports are recorded in
+	running *job
+}
+
+// runServe runs the daemon until SIGINT/SIGTERM and returns the exit code.
+// The tracks in progress are finished before exiting; the rest of their job and queued
+// jobs are dropped.
+func runServe(client *yamusic.Client, addr, token string, quality yamusic.AudioQuality, outputDir string,
+	concurrency int, log *logger.Logger) int {
+	if token == "" {
+		log.Error("Error: -serve requires -serve-token to authenticate API requests")
+		return 1
+	}
+
+	d := &daemon{
+		client:      client,
+		quality:     quality,
+		outputDir:   outputDir,
+		concurrency: concurrency,
+		token:       token,
+		log:         log,
+		queue:       make(chan *job, maxQueuedJobs),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/download", d.authorized(d.handleDownload))
+	mux.HandleFunc("/jobs", d.authorized(d.handleJobs))
+	mux.HandleFunc("/healthz", d.authorized(d.handleHealth))
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Downloads are not cancelled on shutdown, so the tracks in progress are finished;
+	// the ones not yet started are rejected instead
+	client.SetProgressFunc(d.reportProgress)
+	client.SetPreDownloadHook(func(yamusic.TrackInfo) error {
+		if ctx.Err() != nil {
+			return errShuttingDown
+		}
+		return nil
+	})
+
+	worker := make(chan struct{})
+	go func() {
+		defer close(worker)
+		d.work(ctx)
+	}()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Info("Listening on %s", addr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Error: %v", err)
+			return 1
+		}
+	case <-ctx.Done():
+		log.Info("Shutting down, finishing in-flight downloads...")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	server.Shutdown(shutdownCtx)
+	stop()
+	<-worker
+
+	log.Info("Stopped")
+	return 0
+}
+
+// work processes queued jobs one at a time until ctx is cancelled
+func (d *daemon) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-d.queue:
+			d.run(ctx, j)
+		}
+	}
+}
+
+// run expands a job into its tracks and downloads them with the worker pool, recording
+// the progress of every track. Once ctx is cancelled the tracks not yet started are not
+// attempted.
+func (d *daemon) run(ctx context.Context, j *job) {
+	d.update(j, func(j *job) { j.Status = jobRunning })
+
+	items, err := d.expand(j.ref, j.Input)
+	if err != nil {
+		d.finish(j, err)
+		return
+	}
+	tracks := make([]jobTrack, len(items))
+	refs := make([]yamusic.TrackRef, len(items))
+	for i, item := range items {
+		tracks[i] = jobTrack{Progress: yamusic.Progress{TrackID: item.trackID}, AlbumID: item.albumID, Status: jobQueued}
+		refs[i] = yamusic.TrackRef{ID: item.trackID, AlbumID: item.albumID}
+	}
+	d.update(j, func(j *job) {
+		j.Tracks = tracks
+		d.running = j
+	})
+
+	results := d.client.DownloadTracksContext(context.Background(), refs, d.quality, d.outputDir, d.concurrency)
+
+	failed, dropped := 0, 0
+	d.update(j, func(j *job) {
+		d.running = nil
+		for i, result := range results {
+			track := &j.Tracks[i]
+			track.Status, track.Path, track.Done = jobDone, result.Path, true
+			switch {
+			case result.Err == nil:
+			case errors.Is(result.Err, errShuttingDown):
+				track.Status, track.Error = jobFailed, "not attempted: "+errShuttingDown.Error()
+				dropped++
+			default:
+				d.log.Error("Job %d: error downloading %s: %v", j.ID, result.ID, result.Err)
+				track.Status, track.Error = jobFailed, result.Err.Error()
+				failed++
+			}
+		}
+	})
+
+	switch {
+	case dropped > 0:
+		err = fmt.Errorf("interrupted, %d of %d tracks not downloaded", dropped, len(items))
+	case failed > 0:
+		err = fmt.Errorf("%d of %d tracks failed", failed, len(items))
+	}
+	d.finish(j, err)
+}
+
+// reportProgress records a progress report in the track of the running job
+func (d *daemon) reportProgress(progress yamusic.Progress) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.running == nil {
+		return
+	}
+	// The error is reported by the result of the track
+	progress.Err = nil
+	for i := range d.running.Tracks {
+		track := &d.running.Tracks[i]
+		if track.TrackID == progress.TrackID && track.Status != jobDone && track.Status != jobFailed {
+			track.Progress = progress
+			if !progress.Done {
+				track.Status = jobRunning
+			}
+			return
+		}
+	}
+}
+
+// expand turns the input of a job into tracks: albums into their available tracks and
+// playlists into all of theirs, as for -track and -playlist
+func (d *daemon) expand(ref yamusic.EntityRef, input string) ([]batchItem, error) {
+	switch ref.Kind {
+	case yamusic.EntityAlbum:
+		var summary batchSummary
+		_, items, err := expandAlbum(d.client, input, ref.ID, d.outputDir, false, &summary, d.log)
+		return items, err
+	case yamusic.EntityPlaylist:
+		_, items, err := expandPlaylist(d.client, ref.Owner, ref.ID, d.log)
+		return items, err
+	default:
+		return []batchItem{{input: input, trackID: ref.ID, albumID: ref.AlbumID, source: sourceTrack}}, nil
+	}
+}
+
+// update changes the job under the lock
+func (d *daemon) update(j *job, change func(*job)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	change(j)
+}
+
+// finish records the outcome of a job: failed with err, done otherwise
+func (d *daemon) finish(j *job, err error) {
+	d.update(j, func(j *job) {
+		j.Status = jobDone
+		if err != nil {
+			j.Status, j.Error = jobFailed, err.Error()
+		}
+		finished := time.Now()
+		j.Finished = &finished
+	})
+}
+
+// authorized wraps a handler with bearer token authentication
+func (d *daemon) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(d.token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleDownload enqueues the download of a track, album or playlist: POST /download {"url": "..."}
+func (d *daemon) handleDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "use POST"})
+		return
+	}
+
+	var request struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&request); err != nil || request.URL == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expected {\"url\": \"...\"}"})
+		return
+	}
+
+	ref, err := d.client.ResolveInputContext(r.Context(), request.URL)
+	switch {
+	case errors.Is(err, yamusic.ErrUnknownInput):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		// The short link could not be followed
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	case ref.Kind == yamusic.EntityArtist:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "not a track, album or playlist"})
+		return
+	}
+
+	// The job is encoded from a copy, as the worker may start on it right away
+	d.mu.Lock()
+	d.nextID++
+	j := &job{ID: d.nextID, Input: request.URL, Kind: ref.Kind, Status: jobQueued, Created: time.Now(), ref: ref}
+	accepted := j.snapshot()
+	d.mu.Unlock()
+
+	select {
+	case d.queue <- j:
+	default:
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "queue is full"})
+		return
+	}
+
+	d.mu.Lock()
+	d.jobs = append(d.jobs, j)
+	d.mu.Unlock()
+
+	d.log.Info("Queued job %d: %s %s", j.ID, j.Kind, j.Input)
+	writeJSON(w, http.StatusAccepted, accepted)
+}
+
+// handleJobs lists all jobs with the progress of their tracks: GET /jobs
+func (d *daemon) handleJobs(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	jobs := make([]job, len(d.jobs))
+	for i, j := range d.jobs {
+		jobs[i] = j.snapshot()
+	}
+	d.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+// handleHealth reports whether the token is still accepted: GET /healthz. The account
+// status is requested on every call, as the token may be revoked while the daemon runs;
+// the endpoint is authenticated like the others, so only API clients can spend requests.
+func (d *daemon) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if _, err := d.client.GetAccountStatusContext(r.Context()); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// testServeToken is the API token of the test daemon
+const testServeToken = "secret"
+
+// newTestDaemon creates a daemon whose client talks to a fake API with the given handlers;
+// other API requests fail with 404
+func newTestDaemon(t *testing.T, handlers map[string]http.HandlerFunc) *daemon {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for pattern, handler := range handlers {
+		mux.HandleFunc(pattern, handler)
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	log := logger.New(false)
+	client := yamusic.NewClient("test-token", "", log)
+	client.SetBaseURL(server.URL)
+	client.SetRetry(1, 0)
+
+	return &daemon{
+		client:      client,
+		quality:     api.QualityMin,
+		outputDir:   t.TempDir(),
+		concurrency: 2,
+		token:       testServeToken,
+		log:         log,
+		queue:       make(chan *job, maxQueuedJobs),
+	}
+}
+
+// serveRequest passes a request with the token to an authorized handler of the daemon
+func serveRequest(d *daemon, handler http.HandlerFunc, method, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	d.authorized(handler)(w, r)
+	return w
+}
+
+// TestServeDownload checks which inputs are queued and how they are classified
+func TestServeDownload(t *testing.T) {
+	d := newTestDaemon(t, nil)
+
+	// Test cases
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		body       string
+		wantStatus int
+		wantKind   yamusic.EntityKind
+	}{
+		{"Track link", http.MethodPost, testServeToken, `{"url": "https://music.yandex.ru/album/7/track/1"}`, http.StatusAccepted, yamusic.EntityTrack},
+		{"Track ID", http.MethodPost, testServeToken, `{"url": "64551568"}`, http.StatusAccepted, yamusic.EntityTrack},
+		{"Album link", http.MethodPost, testServeToken, `{"url": "https://music.yandex.ru/album/7"}`, http.StatusAccepted, yamusic.EntityAlbum},
+		{"Playlist", http.MethodPost, testServeToken, `{"url": "user:3"}`, http.StatusAccepted, yamusic.EntityPlaylist},
+		{"Artist link", http.MethodPost, testServeToken, `{"url": "https://music.yandex.ru/artist/41191"}`, http.StatusBadRequest, ""},
+		{"Unknown link", http.MethodPost, testServeToken, `{"url": "ftp://example.com/track"}`, http.StatusBadRequest, ""},
+		{"No URL", http.MethodPost, testServeToken, `{}`, http.StatusBadRequest, ""},
+		{"Wrong method", http.MethodGet, testServeToken, "", http.StatusMethodNotAllowed, ""},
+		{"Wrong token", http.MethodPost, "wrong", `{"url": "64551568"}`, http.StatusUnauthorized, ""},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveRequest(d, d.handleDownload, tt.method, tt.token, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusAccepted {
+				return
+			}
+
+			var accepted job
+			if err := json.Unmarshal(w.Body.Bytes(), &accepted); err != nil {
+				t.Fatalf("Error decoding response: %v", err)
+			}
+			if accepted.Kind != tt.wantKind || accepted.Status != jobQueued {
+				t.Errorf("Job kind %q, status %q, want %q, %q", accepted.Kind, accepted.Status, tt.wantKind, jobQueued)
+			}
+			if queued := <-d.queue; queued.ID != accepted.ID {
+				t.Errorf("Queued job %d, want %d", queued.ID, accepted.ID)
+			}
+		})
+	}
+}
+
+// TestServeJobs checks that albums and playlists are expanded into tracks whose progress
+// is reported by /jobs
+func TestServeJobs(t *testing.T) {
+	d := newTestDaemon(t, map[string]http.HandlerFunc{
+		"/albums/7/with-tracks": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"id":7,"title":"Album","available":true,"volumes":[[` +
+				`{"id":"1","title":"One","available":true},{"id":"2","title":"Two","available":true}]]}}`))
+		},
+		"/users/42/playlists/3": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"uid":42,"kind":3,"title":"Playlist","revision":1,"tracks":[` +
+				`{"id":3,"albumId":8},{"id":4,"albumId":8},{"id":5,"albumId":9}]}}`))
+		},
+	})
+
+	// Test cases
+	tests := []struct {
+		name   string
+		input  string
+		tracks []string
+	}{
+		{"Album", "https://music.yandex.ru/album/7", []string{"1", "2"}},
+		{"Playlist", "https://music.yandex.ru/users/42/playlists/3", []string{"3", "4", "5"}},
+	}
+
+	// Run tests
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveRequest(d, d.handleDownload, http.MethodPost, testServeToken, `{"url": "`+tt.input+`"}`)
+			if w.Code != http.StatusAccepted {
+				t.Fatalf("Status = %d, want %d: %s", w.Code, http.StatusAccepted, w.Body)
+			}
+			// The fake API serves no tracks, so every download fails
+			d.run(context.Background(), <-d.queue)
+
+			w = serveRequest(d, d.handleJobs, http.MethodGet, testServeToken, "")
+			var jobs []job
+			if err := json.Unmarshal(w.Body.Bytes(), &jobs); err != nil {
+				t.Fatalf("Error decoding response: %v", err)
+			}
+			if len(jobs) != i+1 {
+				t.Fatalf("Got %d jobs, want %d", len(jobs), i+1)
+			}
+
+			got := jobs[i]
+			if got.Status != jobFailed || got.Finished == nil {
+				t.Errorf("Job status %q, finished %v, want %q and finished", got.Status, got.Finished, jobFailed)
+			}
+			if len(got.Tracks) != len(tt.tracks) {
+				t.Fatalf("Job tracks %+v, want %v", got.Tracks, tt.tracks)
+			}
+			for j, track := range got.Tracks {
+				if track.TrackID != tt.tracks[j] || track.Status != jobFailed || track.Error == "" {
+					t.Errorf("Track %d = %+v, want %s failed with an error", j, track, tt.tracks[j])
+				}
+			}
+		})
+	}
+}
+
+// TestServeProgress checks that progress reports reach the tracks of the running job only
+func TestServeProgress(t *testing.T) {
+	d := newTestDaemon(t, nil)
+	running := &job{ID: 1, Status: jobRunning, Tracks: []jobTrack{
+		{Progress: yamusic.Progress{TrackID: "1"}, Status: jobQueued},
+		{Progress: yamusic.Progress{TrackID: "2"}, Status: jobQueued},
+	}}
+	d.jobs = []*job{running}
+
+	// Without a running job reports are dropped
+	d.reportProgress(yamusic.Progress{TrackID: "2", Downloaded: 10, Total: 100})
+	d.running = running
+	d.reportProgress(yamusic.Progress{TrackID: "2", Downloaded: 50, Total: 100})
+
+	w := serveRequest(d, d.handleJobs, http.MethodGet, testServeToken, "")
+	var jobs []job
+	if err := json.Unmarshal(w.Body.Bytes(), &jobs); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		name       string
+		status     string
+		downloaded int64
+		total      int64
+	}{
+		{"Track without reports", jobQueued, 0, 0},
+		{"Track with a report", jobRunning, 50, 100},
+	}
+
+	// Run tests
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := jobs[0].Tracks[i]
+			if got.Status != tt.status || got.Downloaded != tt.downloaded || got.Total != tt.total {
+				t.Errorf("Track = %+v, want status %q, %d of %d bytes", got, tt.status, tt.downloaded, tt.total)
+			}
+		})
+	}
+}
+
+// TestServeHealth checks that /healthz reports whether the token is accepted on every
+// authenticated call and requests nothing for others
+func TestServeHealth(t *testing.T) {
+	accepted := true
+	requests := 0
+	d := newTestDaemon(t, map[string]http.HandlerFunc{
+		"/account/status": func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if !accepted {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"result":{"account":{"uid":42,"login":"user"},"plus":{"hasPlus":true}}}`))
+		},
+	})
+
+	// Test cases
+	tests := []struct {
+		name         string
+		token        string
+		accepted     bool
+		wantStatus   int
+		wantRequests int
+	}{
+		{"Valid token", testServeToken, true, http.StatusOK, 1},
+		{"Revoked token", testServeToken, false, http.StatusServiceUnavailable, 2},
+		{"Wrong API token", "wrong", true, http.StatusUnauthorized, 2},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accepted = tt.accepted
+			w := serveRequest(d, d.handleHealth, http.MethodGet, tt.token, "")
+			if w.Code != tt.wantStatus {
+				t.Errorf("Status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Account status requested %d times, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
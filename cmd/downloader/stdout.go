@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// stdoutOutput is the -output value that streams the audio to standard output
+const stdoutOutput = "-"
+
+// runStdout streams a single decrypted track to standard output and returns the exit code
+func runStdout(client *yamusic.Client, input string, quality yamusic.AudioQuality, log *logger.Logger) int {
+	// Receiving SIGPIPE turns writes to a closed pipe into EPIPE errors
+	// instead of killing the process
+	signal.Notify(make(chan os.Signal, 1), syscall.SIGPIPE)
+
+	trackID, _ := utils.ExtractTrackRef(input)
+	meta, err := client.DownloadTrackTo(context.Background(), trackID, quality, os.Stdout)
+	if errors.Is(err, syscall.EPIPE) {
+		log.Debug("Output closed by the consumer, stopping")
+		return 0
+	}
+	if err != nil {
+		log.Error("Error: %v", err)
+		if hint := errorHint(err); hint != "" {
+			log.Warn("%s", hint)
+		}
+		return exitCode(err)
+	}
+
+	log.Info("Done: %s written to stdout", meta.FileName)
+	return 0
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// runVerify checks a library of downloaded files, prints the report and returns the exit code
+func runVerify(client *yamusic.Client, dir string, metadata bool, log *logger.Logger) int {
+	log.Info("Verifying library %s...", dir)
+
+	report, err := client.VerifyLibrary(dir, yamusic.VerifyOptions{Metadata: metadata})
+	if err != nil {
+		log.Error("Error: %v", err)
+		return 1
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Severity == yamusic.SeverityError {
+			log.Error("✗ %s: %s", issue.Path, issue.Problem)
+		} else {
+			log.Warn("⚠️ %s: %s", issue.Path, issue.Problem)
+		}
+	}
+
+	log.Info("Checked %d files, found %d issues", report.Files, len(report.Issues))
+
+	if report.HasErrors() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// watchStateName is the default name of the watch state file in the output directory
+const watchStateName = ".watch-state.json"
+
+// runWatch polls the playlist every interval and downloads newly added tracks until
+// SIGINT/SIGTERM, returning the exit code
+func runWatch(client *yamusic.Client, input, statePath string, interval time.Duration,
+	quality yamusic.AudioQuality, outputDir string, log *logger.Logger) int {
+	owner, kind, ok := utils.ExtractPlaylistRef(input)
+	kindNumber, err := strconv.Atoi(kind)
+	if !ok || err != nil {
+		log.Error("Invalid playlist %q: expected a playlist URL or owner:kind", input)
+		return 1
+	}
+
+	if statePath == "" {
+		statePath = filepath.Join(outputDir, watchStateName)
+	}
+	client.SetWatchState(statePath)
+
+	// Availability changes over a long run, so metadata is always requested anew
+	client.SetMemoryCache(0, 0)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Info("Watching playlist %s every %s, state in %s", input, interval, statePath)
+	var summary batchSummary
+	err = client.WatchPlaylist(ctx, owner, kindNumber, interval, func(track yamusic.TrackInfo) error {
+		albumID := ""
+		if len(track.Albums) > 0 {
+			albumID = track.Albums[0].ID.String()
+		}
+		item := batchItem{input: track.ID, trackID: track.ID, albumID: albumID, source: sourcePlaylist}
+		// Failed and interrupted downloads are retried on the next poll; unavailable tracks
+		// are not, as they would be attempted on every poll
+		failed, notAttempted := summary.Failed, summary.NotAttempted
+		runBatch(ctx, client, []batchItem{item}, quality, outputDir, 0, 1, &summary, nil, nil, log)
+		if summary.Failed > failed || summary.NotAttempted > notAttempted {
+			return fmt.Errorf("track %s was not downloaded", track.ID)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Error("Error watching playlist: %v", err)
+		return exitCode(err)
+	}
+
+	log.Info("Stopped. Downloaded: %d, already existing: %d, failed: %d, unavailable: %d",
+		summary.Downloaded, summary.Skipped, summary.Failed, summary.Unavailable)
+	return 0
+}
@@ -80,12 +80,23 @@ type Artist struct {
 	Cover       Cover       `json:"cover,omitempty"`
 	Genres      []string    `json:"genres"`
 	Disclaimers []string    `json:"disclaimers"`
+	Counts      *Counts     `json:"counts,omitempty"`
+}
+
+// Counts holds the numbers of tracks and albums of an artist
+type Counts struct {
+	Tracks       int `json:"tracks"`
+	DirectAlbums int `json:"directAlbums"`
+	AlsoAlbums   int `json:"alsoAlbums"`
+	AlsoTracks   int `json:"alsoTracks"`
 }
 
 // Album represents album information
 type Album struct {
 	ID                       json.Number   `json:"id"`
 	Title                    string        `json:"title"`
+	Version                  string        `json:"version,omitempty"`
+	Type                     string        `json:"type,omitempty"`
 	MetaType                 string        `json:"metaType,omitempty"`
 	ContentWarning           string        `json:"contentWarning,omitempty"`
 	Year                     int           `json:"year,omitempty"`
@@ -108,6 +119,13 @@ type Album struct {
 	Disclaimers              []string      `json:"disclaimers,omitempty"`
 	ListeningFinished        bool          `json:"listeningFinished,omitempty"`
 	TrackPosition            TrackPosition `json:"trackPosition,omitempty"`
+	Volumes                  [][]TrackInfo `json:"volumes,omitempty"`
+}
+
+// AlbumResponse represents the API response for an album with its tracks
+type AlbumResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         Album          `json:"result"`
 }
 
 // TrackResponse represents the API response for track information
@@ -121,12 +139,14 @@ type TrackInfo struct {
 	ID                       string        `json:"id"`
 	RealID                   string        `json:"realId"`
 	Title                    string        `json:"title"`
+	Version                  string        `json:"version,omitempty"`
 	Major                    Major         `json:"major,omitempty"`
 	Available                bool          `json:"available"`
 	AvailableForPremiumUsers bool          `json:"availableForPremiumUsers"`
 	AvailableForOptions      []string      `json:"availableForOptions"`
 	Disclaimers              []string      `json:"disclaimers"`
 	StorageDir               string        `json:"storageDir"`
+	ContentWarning           string        `json:"contentWarning,omitempty"`
 	DurationMs               int           `json:"durationMs"`
 	FileSize                 int           `json:"fileSize"`
 	R128                     R128          `json:"r128,omitempty"`
@@ -146,6 +166,25 @@ type TrackInfo struct {
 	SpecialAudioResources    []string      `json:"specialAudioResources"`
 }
 
+// LikedTrack is a reference to a track in the user's likes list
+type LikedTrack struct {
+	ID        string `json:"id"`
+	AlbumID   string `json:"albumId"`
+	Timestamp string `json:"timestamp"`
+}
+
+// LikesResponse represents the API response for the user's liked tracks
+type LikesResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         struct {
+		Library struct {
+			UID      json.Number  `json:"uid"`
+			Revision int          `json:"revision"`
+			Tracks   []LikedTrack `json:"tracks"`
+		} `json:"library"`
+	} `json:"result"`
+}
+
 // Major represents label information
 type Major struct {
 	ID   json.Number `json:"id"`
@@ -199,6 +238,310 @@ type LyricsInfo struct {
 	HasAvailableTextLyrics bool `json:"hasAvailableTextLyrics"`
 }
 
+// SearchResponse represents the API response for a search query
+type SearchResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         SearchResult   `json:"result"`
+}
+
+// SearchResult contains one page of results of each requested type
+type SearchResult struct {
+	Text              string `json:"text"`
+	Type              string `json:"type"`
+	Page              int    `json:"page"`
+	PerPage           int    `json:"perPage"`
+	MisspellCorrected bool   `json:"misspellCorrected"`
+	MisspellResult    string `json:"misspellResult,omitempty"`
+	Nocorrect         bool   `json:"nocorrect"`
+
+	// Only the types matching the search type are present
+	Tracks    *SearchPage[TrackInfo] `json:"tracks,omitempty"`
+	Albums    *SearchPage[Album]     `json:"albums,omitempty"`
+	Artists   *SearchPage[Artist]    `json:"artists,omitempty"`
+	Playlists *SearchPage[Playlist]  `json:"playlists,omitempty"`
+}
+
+// SearchPage is a page of search results of one type with the total count
+type SearchPage[T any] struct {
+	Total   int `json:"total"`
+	PerPage int `json:"perPage"`
+	Order   int `json:"order"`
+	Results []T `json:"results"`
+}
+
+// Playlist represents playlist information. Tracks is only set when the playlist
+// is requested with its tracks.
+type Playlist struct {
+	UID         json.Number     `json:"uid"`
+	Kind        json.Number     `json:"kind"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	TrackCount  int             `json:"trackCount"`
+	Revision    int             `json:"revision,omitempty"`
+	Owner       Owner           `json:"owner"`
+	Visibility  string          `json:"visibility,omitempty"`
+	Modified    string          `json:"modified,omitempty"`
+	Tracks      []PlaylistTrack `json:"tracks,omitempty"`
+}
+
+// PlaylistTrack is an entry of a playlist. Track holds the metadata when the API includes it.
+type PlaylistTrack struct {
+	ID        json.Number `json:"id"`
+	AlbumID   json.Number `json:"albumId,omitempty"`
+	Timestamp string      `json:"timestamp,omitempty"`
+	Track     *TrackInfo  `json:"track,omitempty"`
+}
+
+// PlaylistResponse represents the API response for a playlist
+type PlaylistResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         Playlist       `json:"result"`
+}
+
+// PlaylistsResponse represents the API response for the playlists of a user
+type PlaylistsResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         []Playlist     `json:"result"`
+}
+
+// LandingResponse represents the API response for landing page blocks
+type LandingResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         struct {
+		Blocks []LandingBlock `json:"blocks"`
+	} `json:"result"`
+}
+
+// LandingBlock is a block of the landing page
+type LandingBlock struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	Title    string          `json:"title"`
+	Entities []LandingEntity `json:"entities"`
+}
+
+// LandingEntity is an item of a landing block; the shape of Data depends on Type
+// (GeneratedPlaylist for "personal-playlist")
+type LandingEntity struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// GeneratedPlaylist is a personal playlist generated for the user, e.g. Playlist of the Day.
+// Type identifies the playlist (playlistOfTheDay, neverHeard, recentTracks, ...); the
+// contents change, but the type and the playlist UID and kind stay the same.
+type GeneratedPlaylist struct {
+	Type   string   `json:"type"`
+	Ready  bool     `json:"ready"`
+	Notify bool     `json:"notify"`
+	Data   Playlist `json:"data"`
+}
+
+// SimilarTracksResponse represents the API response for the tracks similar to a track
+type SimilarTracksResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         struct {
+		Track         TrackInfo   `json:"track"`
+		SimilarTracks []TrackInfo `json:"similarTracks"`
+	} `json:"result"`
+}
+
+// StationTracksResponse represents the API response for a batch of rotor station tracks
+type StationTracksResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         struct {
+		Sequence []StationItem `json:"sequence"`
+		BatchID  string        `json:"batchId"`
+	} `json:"result"`
+}
+
+// StationItem is an item of a rotor station batch; Type is "track" for tracks
+type StationItem struct {
+	Type  string    `json:"type"`
+	Track TrackInfo `json:"track"`
+	Liked bool      `json:"liked"`
+}
+
+// StationFeedback is a rotor station feedback event; the rotor serves further batches
+// as the listening session progresses
+type StationFeedback struct {
+	Type               string  `json:"type"`
+	Timestamp          string  `json:"timestamp"`
+	From               string  `json:"from,omitempty"`
+	TrackID            string  `json:"trackId,omitempty"`
+	TotalPlayedSeconds float64 `json:"totalPlayedSeconds,omitempty"`
+}
+
+// NewReleasesResponse represents the API response for the new releases
+type NewReleasesResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         struct {
+		Type        string        `json:"type"`
+		Title       string        `json:"title"`
+		NewReleases []json.Number `json:"newReleases"`
+	} `json:"result"`
+}
+
+// AlbumsResponse represents the API response for several albums
+type AlbumsResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         []Album        `json:"result"`
+}
+
+// ChartResponse represents the API response for a chart
+type ChartResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         ChartInfo      `json:"result"`
+}
+
+// ChartInfo is a chart with its description and the chart playlist
+type ChartInfo struct {
+	ID               string        `json:"id"`
+	Type             string        `json:"type"`
+	Title            string        `json:"title"`
+	ChartDescription string        `json:"chartDescription,omitempty"`
+	Chart            ChartPlaylist `json:"chart"`
+}
+
+// ChartPlaylist is the playlist of a chart with the tracks in chart order
+type ChartPlaylist struct {
+	Playlist
+	Tracks []ChartTrack `json:"tracks"`
+}
+
+// ChartTrack is a track of a chart with its position
+type ChartTrack struct {
+	ID    json.Number   `json:"id"`
+	Track TrackInfo     `json:"track"`
+	Chart ChartPosition `json:"chart"`
+}
+
+// ChartPosition describes the position of a track in a chart. Progress is "up", "down",
+// "same" or "new"; Shift is the change of the position since the last chart.
+type ChartPosition struct {
+	Position  int    `json:"position"`
+	Progress  string `json:"progress"`
+	Listeners int    `json:"listeners"`
+	Shift     int    `json:"shift"`
+}
+
+// Owner is the user owning a playlist
+type Owner struct {
+	UID   json.Number `json:"uid"`
+	Login string      `json:"login"`
+	Name  string      `json:"name"`
+}
+
+// ArtistResponse represents the API response for an artist
+type ArtistResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         ArtistInfo     `json:"result"`
+}
+
+// ArtistInfo is an artist with an overview of their releases
+type ArtistInfo struct {
+	Artist        Artist      `json:"artist"`
+	Albums        []Album     `json:"albums,omitempty"`
+	AlsoAlbums    []Album     `json:"alsoAlbums,omitempty"`
+	PopularTracks []TrackInfo `json:"popularTracks,omitempty"`
+}
+
+// ArtistAlbumsResponse represents the API response for a page of artist albums
+type ArtistAlbumsResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         ArtistAlbums   `json:"result"`
+}
+
+// ArtistAlbums is a page of albums of an artist
+type ArtistAlbums struct {
+	Albums []Album `json:"albums"`
+	Pager  Pager   `json:"pager"`
+}
+
+// Pager describes a page of a paginated list; Page is zero-based
+type Pager struct {
+	Total   int `json:"total"`
+	Page    int `json:"page"`
+	PerPage int `json:"perPage"`
+}
+
+// AccountStatusResponse represents the API response for the account status
+type AccountStatusResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Result         AccountStatus  `json:"result"`
+}
+
+// AccountStatus describes the account the token belongs to and its subscription
+type AccountStatus struct {
+	Account      Account      `json:"account"`
+	Permissions  Permissions  `json:"permissions"`
+	Subscription Subscription `json:"subscription"`
+	Plus         Plus         `json:"plus"`
+	DefaultEmail string       `json:"defaultEmail,omitempty"`
+}
+
+// Account contains the account details
+type Account struct {
+	UID              json.Number `json:"uid"`
+	Login            string      `json:"login"`
+	Region           int         `json:"region"`
+	FullName         string      `json:"fullName,omitempty"`
+	DisplayName      string      `json:"displayName,omitempty"`
+	ServiceAvailable bool        `json:"serviceAvailable"`
+	HostedUser       bool        `json:"hostedUser"`
+	RegisteredAt     string      `json:"registeredAt,omitempty"`
+	Now              string      `json:"now,omitempty"`
+}
+
+// Permissions lists the features available to the account
+type Permissions struct {
+	Until   string   `json:"until,omitempty"`
+	Values  []string `json:"values"`
+	Default []string `json:"default"`
+}
+
+// Subscription describes the paid subscriptions of the account
+type Subscription struct {
+	HadAnySubscription bool                `json:"hadAnySubscription"`
+	CanStartTrial      bool                `json:"canStartTrial"`
+	AutoRenewable      []RenewableProduct  `json:"autoRenewable,omitempty"`
+	NonAutoRenewable   *NonRenewableRemain `json:"nonAutoRenewable,omitempty"`
+}
+
+// RenewableProduct is an auto-renewable subscription
+type RenewableProduct struct {
+	Expires  string `json:"expires"`
+	Vendor   string `json:"vendor,omitempty"`
+	Finished bool   `json:"finished"`
+}
+
+// NonRenewableRemain is a subscription period without renewal
+type NonRenewableRemain struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Plus describes the Yandex Plus status of the account
+type Plus struct {
+	HasPlus             bool `json:"hasPlus"`
+	IsTutorialCompleted bool `json:"isTutorialCompleted"`
+}
+
+// LyricsResponse represents the API response for track lyrics
+type LyricsResponse struct {
+	Result         Lyrics         `json:"result"`
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+}
+
+// Lyrics points at the lyrics file of a track
+type Lyrics struct {
+	DownloadURL     string   `json:"downloadUrl"`
+	LyricID         int      `json:"lyricId"`
+	ExternalLyricID string   `json:"externalLyricId"`
+	Writers         []string `json:"writers"`
+}
+
 // DownloadInfoResponse represents the API response for download information
 type DownloadInfoResponse struct {
 	Result         DownloadInfoResult `json:"result"`
@@ -225,6 +568,57 @@ type DownloadInfo struct {
 	RealID    string   `json:"realId"`
 }
 
+// Extension returns the file extension matching the codec of the download.
+// FLAC and MP3 streams are served raw; every other codec comes in an MP4 container.
+func (d *DownloadInfo) Extension() string {
+	switch d.Codec {
+	case "flac":
+		return ".flac"
+	case "mp3":
+		return ".mp3"
+	default:
+		return ".m4a"
+	}
+}
+
+// ContentType returns the MIME type of the audio container of the download
+func (d *DownloadInfo) ContentType() string {
+	switch d.Extension() {
+	case ".flac":
+		return "audio/flac"
+	case ".mp3":
+		return "audio/mpeg"
+	default:
+		return "audio/mp4"
+	}
+}
+
+// LegacyDownloadInfoResponse represents the API response of the legacy download info endpoint
+type LegacyDownloadInfoResponse struct {
+	InvocationInfo InvocationInfo       `json:"invocationInfo"`
+	Result         []LegacyDownloadInfo `json:"result"`
+}
+
+// LegacyDownloadInfo describes an unencrypted stream of the legacy download API. The
+// DownloadInfoURL points to an XML document (LegacyFileInfo) describing the file location.
+type LegacyDownloadInfo struct {
+	Codec           string `json:"codec"`
+	Bitrate         int    `json:"bitrateInKbps"`
+	Gain            bool   `json:"gain"`
+	Preview         bool   `json:"preview"`
+	Direct          bool   `json:"direct"`
+	DownloadInfoURL string `json:"downloadInfoUrl"`
+}
+
+// LegacyFileInfo is the location of a file of the legacy download API
+type LegacyFileInfo struct {
+	Host   string `xml:"host"`
+	Path   string `xml:"path"`
+	TS     string `xml:"ts"`
+	Region string `xml:"region"`
+	S      string `xml:"s"`
+}
+
 // InvocationInfo contains metadata about the API request
 type InvocationInfo struct {
 	ReqID              string `json:"req-id"`
@@ -232,3 +626,15 @@ type InvocationInfo struct {
 	ExecDurationMillis int    `json:"exec-duration-millis"`
 	AppName            string `json:"app-name,omitempty"`
 }
+
+// ErrorResponse represents the API response for a failed request
+type ErrorResponse struct {
+	InvocationInfo InvocationInfo `json:"invocationInfo"`
+	Error          Error          `json:"error"`
+}
+
+// Error describes why a request failed, e.g. name "session-expired"
+type Error struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
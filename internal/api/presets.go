@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ClientPreset is a coherent set of headers identifying an official client
+type ClientPreset struct {
+	Name           string
+	UserAgent      string
+	Client         string
+	AcceptLanguage string
+}
+
+// Headers returns the HTTP headers of the preset
+func (p ClientPreset) Headers() map[string]string {
+	return map[string]string{
+		"User-Agent":            p.UserAgent,
+		"x-yandex-music-client": p.Client,
+		"Accept-Language":       p.AcceptLanguage,
+	}
+}
+
+// Client preset names
+const (
+	PresetDesktop = "desktop"
+	PresetAndroid = "android"
+	PresetIOS     = "ios"
+
+	// DefaultPreset is the preset used unless another one is selected
+	DefaultPreset = PresetDesktop
+)
+
+// ClientPresets holds the known client identities by name
+var ClientPresets = map[string]ClientPreset{
+	PresetDesktop: {
+		Name: PresetDesktop,
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) " +
+			"YandexMusic/5.54.0 Chrome/128.0.6613.186 Electron/32.2.7 Safari/537.36",
+		Client:         DefaultClient,
+		AcceptLanguage: "ru",
+	},
+	PresetAndroid: {
+		Name:           PresetAndroid,
+		UserAgent:      "Dalvik/2.1.0 (Linux; U; Android 13; Pixel 7 Build/TQ3A.230901.001)",
+		Client:         "YandexMusicAndroid/24024312",
+		AcceptLanguage: "ru",
+	},
+	PresetIOS: {
+		Name:           PresetIOS,
+		UserAgent:      "YandexMusic/7.14 (iPhone; iOS 17.5.1; Scale/3.00)",
+		Client:         "YandexMusicIOS/7.14",
+		AcceptLanguage: "ru",
+	},
+}
+
+// GetClientPreset returns the preset with the given name
+func GetClientPreset(name string) (ClientPreset, error) {
+	preset, ok := ClientPresets[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(ClientPresets))
+		for n := range ClientPresets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return ClientPreset{}, fmt.Errorf("unknown client preset %q (valid values: %s)", name, strings.Join(names, ", "))
+	}
+	return preset, nil
+}
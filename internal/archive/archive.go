@@ -0,0 +1,103 @@
+// Package archive provides the download archive: a text file listing the IDs of
+// downloaded tracks, one per line, so later runs can skip them.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Archive is an append-only list of track IDs. It is safe for concurrent use;
+// every ID is appended with a single write, so several processes sharing the
+// file don't interleave lines either.
+type Archive struct {
+	mu   sync.Mutex
+	file *os.File
+	ids  map[string]bool
+
+	// newline is set when the file doesn't end with a line break, e.g. after manual editing
+	newline bool
+}
+
+// Open reads the archive file, creating it when missing, and opens it for appending
+func Open(path string) (*Archive, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating archive directory: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading archive: %w", err)
+	}
+
+	a := &Archive{
+		ids:     make(map[string]bool),
+		newline: len(data) > 0 && data[len(data)-1] != '\n',
+	}
+
+	// Blank lines and surrounding spaces are tolerated
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			a.ids[id] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading archive: %w", err)
+	}
+
+	a.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening archive: %w", err)
+	}
+
+	return a, nil
+}
+
+// Contains reports whether the track is in the archive
+func (a *Archive) Contains(trackID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ids[trackID]
+}
+
+// Add appends the track to the archive; tracks already present are not written again
+func (a *Archive) Add(trackID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.ids[trackID] {
+		return nil
+	}
+
+	line := trackID + "\n"
+	if a.newline {
+		line = "\n" + line
+	}
+	if _, err := a.file.WriteString(line); err != nil {
+		return fmt.Errorf("error writing archive: %w", err)
+	}
+
+	a.ids[trackID] = true
+	a.newline = false
+	return nil
+}
+
+// Len returns the number of tracks in the archive
+func (a *Archive) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.ids)
+}
+
+// Close closes the archive file
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestArchive checks reading, appending and reopening the archive
+func TestArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.txt")
+
+	// A manually edited file without the final line break
+	os.WriteFile(path, []byte("1\n\n  2 \n3"), 0644)
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		id       string
+		expected bool
+	}{
+		{"1", true},
+		{"2", true},
+		{"3", true},
+		{"4", false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := a.Contains(tt.id); got != tt.expected {
+				t.Errorf("Contains(%q) = %v, want %v", tt.id, got, tt.expected)
+			}
+		})
+	}
+
+	a.Add("4")
+	a.Add("1")
+	a.Close()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "1\n\n  2 \n3\n4\n" {
+		t.Errorf("Archive content = %q", data)
+	}
+
+	a, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer a.Close()
+	if a.Len() != 4 || !a.Contains("4") {
+		t.Errorf("Reopened archive has %d tracks, want 4 including the added one", a.Len())
+	}
+}
+
+// TestArchiveConcurrent checks that concurrent additions produce whole lines
+func TestArchiveConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.txt")
+	a, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := a.Add(fmt.Sprint(i)); err != nil {
+				t.Errorf("Add() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	a.Close()
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 100 {
+		t.Errorf("Archive has %d lines, want 100", len(lines))
+	}
+}
@@ -0,0 +1,235 @@
+// Package cache provides a single-file on-disk store for API metadata.
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is the default time after which cached entries are considered stale
+const DefaultTTL = 7 * 24 * time.Hour
+
+// Entry kinds
+const (
+	KindTrack    = "track"
+	KindAlbum    = "album"
+	KindPlaylist = "playlist"
+)
+
+// record is a single line of the cache file
+type record struct {
+	Key       string          `json:"key"`
+	FetchedAt time.Time       `json:"fetchedAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Stats describes the cache contents
+type Stats struct {
+	// Entries is the number of entries per kind
+	Entries map[string]int
+
+	// Stale is the number of entries older than the TTL
+	Stale int
+
+	// Size is the cache file size in bytes
+	Size int64
+}
+
+// Store is an append-only JSON lines file keyed by kind and ID.
+// Later records override earlier ones; the file is compacted on open.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	ttl     time.Duration
+	file    *os.File
+	entries map[string]record
+
+	// now is replaceable in tests
+	now func() time.Time
+}
+
+// Open opens or creates the cache file. A corrupt file is dropped and rebuilt
+// from scratch; the returned warning describes what happened (empty otherwise).
+func Open(path string, ttl time.Duration) (*Store, string, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	s := &Store{
+		path:    path,
+		ttl:     ttl,
+		entries: make(map[string]record),
+		now:     time.Now,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, "", fmt.Errorf("error creating cache directory: %w", err)
+	}
+
+	warning, err := s.load()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.compact(); err != nil {
+		return nil, "", err
+	}
+
+	return s, warning, nil
+}
+
+// load reads the cache file into memory, dropping it when it is corrupt
+func (s *Store) load() (string, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading cache: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil || r.Key == "" {
+			s.entries = make(map[string]record)
+			return fmt.Sprintf("cache file %s is corrupt at line %d, rebuilding", s.path, line), nil
+		}
+		s.entries[r.Key] = r
+	}
+	if err := scanner.Err(); err != nil {
+		s.entries = make(map[string]record)
+		return fmt.Sprintf("cache file %s is unreadable (%v), rebuilding", s.path, err), nil
+	}
+
+	return "", nil
+}
+
+// compact rewrites the cache file with one line per entry and opens it for appending
+func (s *Store) compact() error {
+	tempPath := s.path + ".tmp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return fmt.Errorf("error writing cache: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, key := range s.keys() {
+		if err := encoder.Encode(s.entries[key]); err != nil {
+			file.Close()
+			return fmt.Errorf("error writing cache: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing cache: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing cache: %w", err)
+	}
+
+	if err := os.Rename(tempPath, s.path); err != nil {
+		return fmt.Errorf("error writing cache: %w", err)
+	}
+
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening cache: %w", err)
+	}
+
+	return nil
+}
+
+// keys returns the sorted entry keys
+func (s *Store) keys() []string {
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// key builds the entry key from kind and ID
+func key(kind, id string) string {
+	return kind + ":" + id
+}
+
+// Get decodes the cached entry into v. It reports whether an entry was found
+// and whether it is still within the TTL.
+func (s *Store) Get(kind, id string, v interface{}) (found, fresh bool) {
+	s.mu.Lock()
+	r, ok := s.entries[key(kind, id)]
+	s.mu.Unlock()
+
+	if !ok || json.Unmarshal(r.Data, v) != nil {
+		return false, false
+	}
+
+	return true, s.now().Sub(r.FetchedAt) < s.ttl
+}
+
+// Put stores v under kind and ID, appending it to the cache file
+func (s *Store) Put(kind, id string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding cache entry: %w", err)
+	}
+
+	r := record{Key: key(kind, id), FetchedAt: s.now(), Data: data}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("error encoding cache entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[r.Key] = r
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing cache: %w", err)
+	}
+
+	return nil
+}
+
+// Stats returns the entry counts and the cache file size
+func (s *Store) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := Stats{Entries: make(map[string]int)}
+	now := s.now()
+	for k, r := range s.entries {
+		kind, _, _ := strings.Cut(k, ":")
+		stats.Entries[kind]++
+		if now.Sub(r.FetchedAt) >= s.ttl {
+			stats.Stale++
+		}
+	}
+
+	if info, err := s.file.Stat(); err == nil {
+		stats.Size = info.Size()
+	}
+
+	return stats
+}
+
+// Close closes the cache file
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testEntry is a value stored in the cache
+type testEntry struct {
+	Title string `json:"title"`
+}
+
+// TestStore checks storing, reloading and TTL handling
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "metadata.jsonl")
+
+	store, warning, err := Open(path, time.Hour)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if warning != "" {
+		t.Errorf("Open() warning = %q, want none for a new cache", warning)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	store.Put(KindTrack, "1", testEntry{Title: "old"})
+	store.Put(KindTrack, "1", testEntry{Title: "new"})
+	store.Put(KindAlbum, "2", testEntry{Title: "album"})
+	store.Close()
+
+	// Reopen: the latest record wins and the file is compacted
+	store, _, err = Open(path, time.Hour)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer store.Close()
+	store.now = func() time.Time { return now.Add(30 * time.Minute) }
+
+	var entry testEntry
+	if found, fresh := store.Get(KindTrack, "1", &entry); !found || !fresh || entry.Title != "new" {
+		t.Errorf("Get() = %v, %v, %+v, want a fresh entry \"new\"", found, fresh, entry)
+	}
+	if found, _ := store.Get(KindTrack, "3", &entry); found {
+		t.Error("Get() found a missing entry")
+	}
+
+	stats := store.Stats()
+	if stats.Entries[KindTrack] != 1 || stats.Entries[KindAlbum] != 1 || stats.Size == 0 {
+		t.Errorf("Stats() = %+v, want 1 track, 1 album and a non-empty file", stats)
+	}
+
+	// Past the TTL entries are still returned but reported as stale
+	store.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if found, fresh := store.Get(KindTrack, "1", &entry); !found || fresh {
+		t.Errorf("Get() = %v, %v, want a stale entry", found, fresh)
+	}
+	if stats := store.Stats(); stats.Stale != 2 {
+		t.Errorf("Stats().Stale = %d, want 2", stats.Stale)
+	}
+}
+
+// TestStoreCorruption checks that a corrupt cache file is dropped and rebuilt
+func TestStoreCorruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.jsonl")
+	if err := os.WriteFile(path, []byte("{\"key\":\"track:1\",\"data\":{}}\n\x00\x01garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, warning, err := Open(path, 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if warning == "" {
+		t.Error("Open() warning is empty, want a corruption notice")
+	}
+
+	var entry testEntry
+	if found, _ := store.Get(KindTrack, "1", &entry); found {
+		t.Error("Get() returned an entry from a corrupt cache")
+	}
+
+	if err := store.Put(KindTrack, "1", testEntry{Title: "rebuilt"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	store.Close()
+
+	store, warning, err = Open(path, 0)
+	if err != nil || warning != "" {
+		t.Fatalf("Open() = %q, %v, want a healthy cache", warning, err)
+	}
+	defer store.Close()
+
+	if found, _ := store.Get(KindTrack, "1", &entry); !found || entry.Title != "rebuilt" {
+		t.Errorf("Get() = %v, %+v, want the rebuilt entry", found, entry)
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/hmac"
+	"crypto/md5"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -43,8 +45,31 @@ func GenerateSignatureFromParams(ts, trackId, quality, codecs, transports, signK
 	return GenerateSignature(dataString, signKey)
 }
 
-// DecryptAesCtr decrypts data encrypted with the AES algorithm in CTR mode.
-func DecryptAesCtr(encryptedData []byte, hexKey string) ([]byte, error) {
+// legacySignSalt is the salt of media path signatures of the legacy download API
+const legacySignSalt = "XGRlBW9FXlekgbPrRHuSiA"
+
+// GenerateLegacySignature generates the signature of a media path for the legacy download API:
+// the hex MD5 of the salt, the path without its leading slash and the s value of the download info.
+func GenerateLegacySignature(path, s string) string {
+	sum := md5.Sum([]byte(legacySignSalt + strings.TrimPrefix(path, "/") + s))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateKey checks that a hex-encoded key is a valid AES key
+func ValidateKey(hexKey string) error {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return fmt.Errorf("error decoding key: %w", err)
+	}
+	if _, err := aes.NewCipher(key); err != nil {
+		return fmt.Errorf("invalid key: %w", err)
+	}
+	return nil
+}
+
+// newAesCtrStream creates an AES-CTR keystream for a hex-encoded key.
+// The IV is 12 zero bytes of nonce followed by a 4-byte counter starting from 0.
+func newAesCtrStream(hexKey string) (cipher.Stream, error) {
 	// Convert key from hex to bytes
 	key, err := hex.DecodeString(hexKey)
 	if err != nil {
@@ -57,14 +82,29 @@ func DecryptAesCtr(encryptedData []byte, hexKey string) ([]byte, error) {
 		return nil, fmt.Errorf("error creating cipher: %w", err)
 	}
 
-	// Create nonce from 12 zero bytes and 4 zero bytes for the counter
-	// In total, we get 16 bytes (AES block size)
+	// In total, we get 16 bytes (AES block size) of zeros
 	iv := make([]byte, aes.BlockSize)
-	// First 12 bytes - nonce (can be all zeros)
-	// Last 4 bytes - counter (starts from 0)
 
-	// Create CTR mode with our IV
-	stream := cipher.NewCTR(block, iv)
+	return cipher.NewCTR(block, iv), nil
+}
+
+// NewDecryptReader returns a reader that decrypts data read from r with AES-CTR.
+// Memory usage is constant regardless of the data size.
+func NewDecryptReader(r io.Reader, hexKey string) (io.Reader, error) {
+	stream, err := newAesCtrStream(hexKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.StreamReader{S: stream, R: r}, nil
+}
+
+// DecryptAesCtr decrypts data encrypted with the AES algorithm in CTR mode.
+func DecryptAesCtr(encryptedData []byte, hexKey string) ([]byte, error) {
+	stream, err := newAesCtrStream(hexKey)
+	if err != nil {
+		return nil, err
+	}
 
 	// Decrypt data
 	decrypted := make([]byte, len(encryptedData))
@@ -1,6 +1,8 @@
 package crypto
 
 import (
+	"bytes"
+	"io"
 	"net/url"
 	"testing"
 )
@@ -59,6 +61,27 @@ func TestGenerateSignatureFromParams(t *testing.T) {
 	}
 }
 
+// TestGenerateLegacySignature checks the path signatures of the legacy download API
+func TestGenerateLegacySignature(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"Leading slash", "/abc/64551568.mp3"},
+		{"No leading slash", "abc/64551568.mp3"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateLegacySignature(tt.path, "0123abcd"); got != "710320662c090ac66e1156cdc47aceb1" {
+				t.Errorf("GenerateLegacySignature() = %v, want %v", got, "710320662c090ac66e1156cdc47aceb1")
+			}
+		})
+	}
+}
+
 // TestParseAndGenerateFromURL checks signature generation from URL
 func TestParseAndGenerateFromURL(t *testing.T) {
 	// Test URL
@@ -92,3 +115,34 @@ func TestParseAndGenerateFromURL(t *testing.T) {
 		t.Errorf("Generated signature %s doesn't match expected %s", generatedSign, expectedSign)
 	}
 }
+
+// TestDecryptReader checks that streaming decryption matches the in-memory variant
+func TestDecryptReader(t *testing.T) {
+	hexKey := "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	data := bytes.Repeat([]byte("yamusic-dl test data "), 10000)
+
+	// Encryption and decryption are the same operation in CTR mode
+	encrypted, err := DecryptAesCtr(data, hexKey)
+	if err != nil {
+		t.Fatalf("DecryptAesCtr() error = %v", err)
+	}
+
+	reader, err := NewDecryptReader(bytes.NewReader(encrypted), hexKey)
+	if err != nil {
+		t.Fatalf("NewDecryptReader() error = %v", err)
+	}
+
+	decrypted, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Reading decrypted data failed: %v", err)
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		t.Error("Streaming decryption result doesn't match the original data")
+	}
+
+	// Invalid keys must be rejected up front
+	if _, err := NewDecryptReader(bytes.NewReader(encrypted), "zz"); err == nil {
+		t.Error("NewDecryptReader() with invalid key error = nil, want error")
+	}
+}
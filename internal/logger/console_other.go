@@ -0,0 +1,6 @@
+//go:build !windows
+
+package logger
+
+// enableUTF8Console is a no-op: terminals on other platforms use UTF-8 already
+func enableUTF8Console() {}
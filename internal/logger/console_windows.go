@@ -0,0 +1,19 @@
+//go:build windows
+
+package logger
+
+import "syscall"
+
+// utf8CodePage is the Windows code page identifier for UTF-8
+const utf8CodePage = 65001
+
+// enableUTF8Console switches the console output code page to UTF-8,
+// so Cyrillic titles and status glyphs are not garbled on legacy code pages
+func enableUTF8Console() {
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	setConsoleOutputCP := kernel32.NewProc("SetConsoleOutputCP")
+	if setConsoleOutputCP.Find() != nil {
+		return
+	}
+	setConsoleOutputCP.Call(uintptr(utf8CodePage))
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DefaultDedupWindow is the default time window for collapsing repeated messages
+const DefaultDedupWindow = 30 * time.Second
+
+// idPattern matches numeric identifiers (track, album IDs) embedded in messages
+var idPattern = regexp.MustCompile(`\d{4,}`)
+
+// deduper collapses messages repeated within a time window into a single
+// "message repeated N times" line. Every message key is counted on its own, so messages
+// interleaved with others are collapsed too; the summaries are written once the window of
+// their first occurrence expires, as seen by the next message, or on flush.
+type deduper struct {
+	mu     sync.Mutex
+	window time.Duration
+	now    func() time.Time
+
+	// entries holds the messages seen within the window by key; order lists them by
+	// first occurrence, so the expired ones always come first
+	entries map[string]*dedupEntry
+	order   []*dedupEntry
+
+	// lastKey is the key of the last line written
+	lastKey string
+}
+
+// dedupEntry counts the repeats of one message key within the window
+type dedupEntry struct {
+	key      string
+	level    zerolog.Level
+	firstMsg string
+	lastMsg  string
+	firstAt  time.Time
+	repeated int
+}
+
+// newDeduper creates a deduplicator with the given time window
+func newDeduper(window time.Duration) *deduper {
+	if window <= 0 {
+		window = DefaultDedupWindow
+	}
+
+	return &deduper{
+		window:  window,
+		now:     time.Now,
+		entries: make(map[string]*dedupEntry),
+	}
+}
+
+// dedupKey builds the comparison key for a message.
+// Numeric IDs are scrubbed so that the same error for different tracks is treated as identical.
+func dedupKey(level zerolog.Level, msg string) string {
+	return level.String() + "|" + idPattern.ReplaceAllString(msg, "#")
+}
+
+// log writes the message unless it repeats one already written within the time window
+func (d *deduper) log(level zerolog.Level, msg string, write func(zerolog.Level, string)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.expireLocked(now, write)
+
+	key := dedupKey(level, msg)
+	if entry, ok := d.entries[key]; ok {
+		entry.repeated++
+		entry.lastMsg = msg
+		return
+	}
+
+	d.writeLocked(key, level, msg, write)
+	entry := &dedupEntry{key: key, level: level, firstMsg: msg, lastMsg: msg, firstAt: now}
+	d.entries[key] = entry
+	d.order = append(d.order, entry)
+}
+
+// flush writes out all pending repeat summaries
+func (d *deduper) flush(write func(zerolog.Level, string)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, entry := range d.order {
+		d.summarizeLocked(entry, write)
+	}
+	clear(d.entries)
+	d.order = nil
+	d.lastKey = ""
+}
+
+// expireLocked writes out the summaries of the messages whose window has expired by now
+// and forgets them; the caller must hold the mutex
+func (d *deduper) expireLocked(now time.Time, write func(zerolog.Level, string)) {
+	expired := 0
+	for _, entry := range d.order {
+		if now.Sub(entry.firstAt) < d.window {
+			break
+		}
+		d.summarizeLocked(entry, write)
+		delete(d.entries, entry.key)
+		expired++
+	}
+	d.order = d.order[expired:]
+}
+
+// summarizeLocked writes out the repeat summary of an entry; the caller must hold the mutex.
+// A summary right after the message it counts refers to the previous message, otherwise it
+// quotes the message. Errors always keep their last occurrence so the final failure is
+// visible verbatim.
+func (d *deduper) summarizeLocked(entry *dedupEntry, write func(zerolog.Level, string)) {
+	repeated := entry.repeated
+	if entry.level >= zerolog.ErrorLevel {
+		repeated--
+	}
+
+	if repeated > 0 {
+		summary := fmt.Sprintf("previous message repeated %d times", repeated)
+		if d.lastKey != entry.key {
+			summary = fmt.Sprintf("message repeated %d times: %s", repeated, entry.firstMsg)
+		}
+		d.writeLocked(entry.key, entry.level, summary, write)
+	}
+	if entry.level >= zerolog.ErrorLevel && entry.repeated > 0 {
+		d.writeLocked(entry.key, entry.level, entry.lastMsg, write)
+	}
+	entry.repeated = 0
+}
+
+// writeLocked writes a line for the message key; the caller must hold the mutex
+func (d *deduper) writeLocked(key string, level zerolog.Level, msg string, write func(zerolog.Level, string)) {
+	write(level, msg)
+	d.lastKey = key
+}
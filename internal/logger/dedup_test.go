@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// TestDeduper checks collapsing of repeated messages
+func TestDeduper(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		level    zerolog.Level
+		messages []string
+		expected []string
+	}{
+		{
+			name:     "Distinct messages are kept",
+			level:    zerolog.InfoLevel,
+			messages: []string{"a", "b", "c"},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "Repeated info is collapsed",
+			level:    zerolog.InfoLevel,
+			messages: []string{"a", "a", "a"},
+			expected: []string{"a", "previous message repeated 2 times"},
+		},
+		{
+			name:     "Interleaved repeats are collapsed",
+			level:    zerolog.InfoLevel,
+			messages: []string{"a", "b", "a", "b", "a"},
+			expected: []string{"a", "b", "message repeated 2 times: a", "message repeated 1 times: b"},
+		},
+		{
+			name:     "Repeated error keeps first and last occurrence",
+			level:    zerolog.ErrorLevel,
+			messages: []string{"track 10001: 401", "track 10002: 401", "track 10003: 401"},
+			expected: []string{"track 10001: 401", "previous message repeated 1 times", "track 10003: 401"},
+		},
+		{
+			name:     "Single repeated error is written verbatim",
+			level:    zerolog.ErrorLevel,
+			messages: []string{"track 10001: 401", "track 10002: 401"},
+			expected: []string{"track 10001: 401", "track 10002: 401"},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var written []string
+			write := func(_ zerolog.Level, msg string) {
+				written = append(written, msg)
+			}
+
+			d := newDeduper(time.Minute)
+			for _, msg := range tt.messages {
+				d.log(tt.level, msg, write)
+			}
+			d.flush(write)
+
+			if len(written) != len(tt.expected) {
+				t.Fatalf("got %d lines %q, want %q", len(written), written, tt.expected)
+			}
+			for i := range written {
+				if written[i] != tt.expected[i] {
+					t.Errorf("line %d = %q, want %q", i, written[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+// TestDeduperWindow checks that messages outside the window are written again
+func TestDeduperWindow(t *testing.T) {
+	var written []string
+	write := func(_ zerolog.Level, msg string) {
+		written = append(written, msg)
+	}
+
+	now := time.Now()
+	d := newDeduper(time.Second)
+	d.now = func() time.Time { return now }
+
+	d.log(zerolog.InfoLevel, "a", write)
+	now = now.Add(2 * time.Second)
+	d.log(zerolog.InfoLevel, "a", write)
+
+	if len(written) != 2 {
+		t.Errorf("got %q, want the message written twice", written)
+	}
+}
+
+// TestDeduperWindowSummary checks that repeats are summarized once their window expires
+func TestDeduperWindowSummary(t *testing.T) {
+	var written []string
+	write := func(_ zerolog.Level, msg string) {
+		written = append(written, msg)
+	}
+
+	now := time.Now()
+	d := newDeduper(time.Second)
+	d.now = func() time.Time { return now }
+
+	for _, msg := range []string{"a", "b", "a", "a"} {
+		d.log(zerolog.InfoLevel, msg, write)
+	}
+	now = now.Add(2 * time.Second)
+	d.log(zerolog.InfoLevel, "c", write)
+
+	expected := []string{"a", "b", "message repeated 2 times: a", "c"}
+	if strings.Join(written, "|") != strings.Join(expected, "|") {
+		t.Errorf("got %q, want %q", written, expected)
+	}
+}
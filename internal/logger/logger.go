@@ -2,88 +2,291 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 )
 
+// TimestampFormat defines how timestamps are rendered in log output
+type TimestampFormat string
+
+const (
+	// TimestampTime - time of day only (default)
+	TimestampTime TimestampFormat = "time"
+
+	// TimestampDateTime - date and time of day
+	TimestampDateTime TimestampFormat = "datetime"
+
+	// TimestampRFC3339 - full RFC3339 timestamp with time zone
+	TimestampRFC3339 TimestampFormat = "rfc3339"
+
+	// TimestampOff - no timestamps at all
+	TimestampOff TimestampFormat = "off"
+)
+
+// Format defines the log output format
+type Format string
+
+const (
+	// FormatConsole - human-readable console output (default)
+	FormatConsole Format = "console"
+
+	// FormatJSON - one JSON object per line
+	FormatJSON Format = "json"
+)
+
+// ParseTimestampFormat converts a user-supplied value to a TimestampFormat
+func ParseTimestampFormat(value string) (TimestampFormat, error) {
+	switch f := TimestampFormat(value); f {
+	case TimestampTime, TimestampDateTime, TimestampRFC3339, TimestampOff:
+		return f, nil
+	case "":
+		return TimestampTime, nil
+	default:
+		return "", fmt.Errorf("invalid timestamp format %q (valid values: time, datetime, rfc3339, off)", value)
+	}
+}
+
+// ParseFormat converts a user-supplied value to a Format
+func ParseFormat(value string) (Format, error) {
+	switch f := Format(value); f {
+	case FormatConsole, FormatJSON:
+		return f, nil
+	case "":
+		return FormatConsole, nil
+	default:
+		return "", fmt.Errorf("invalid log format %q (valid values: console, json)", value)
+	}
+}
+
+// layout returns the time layout for the timestamp format
+func (f TimestampFormat) layout() string {
+	switch f {
+	case TimestampDateTime:
+		return "2006-01-02 15:04:05"
+	case TimestampRFC3339:
+		return time.RFC3339
+	default:
+		return "15:04:05"
+	}
+}
+
+// Options configures a Logger
+type Options struct {
+	// Verbose enables debug level logging
+	Verbose bool
+
+	// Timestamp defines how timestamps are rendered (defaults to TimestampTime)
+	Timestamp TimestampFormat
+
+	// Format defines the output format (defaults to FormatConsole)
+	Format Format
+
+	// Output is the destination for log messages (defaults to os.Stdout)
+	Output io.Writer
+
+	// Dedup collapses identical consecutive messages into a repeat counter.
+	// It is ignored when Verbose is set, so debug output is never collapsed.
+	Dedup bool
+
+	// ASCII replaces decorative glyphs (✓, ⚠️, ❌) with plain text
+	ASCII bool
+
+	// DedupWindow is the time window for collapsing messages (defaults to DefaultDedupWindow)
+	DedupWindow time.Duration
+}
+
 // Logger wrapper around zerolog.Logger
 type Logger struct {
 	logger zerolog.Logger
 	level  zerolog.Level
+	dedup  *deduper
+	ascii  bool
+	prefix string
 }
 
+// asciiReplacer maps decorative glyphs to plain text for the ASCII UI
+var asciiReplacer = strings.NewReplacer(
+	"✓", "[OK]",
+	"✗", "[FAIL]",
+	"❌", "[FAIL]",
+	"⚠️", "[!]",
+	"⚠", "[!]",
+)
+
+// consoleOnce makes sure the console is configured only once
+var consoleOnce sync.Once
+
 // New creates a new logger instance.
 // If verbose=true, debug level logging will be enabled.
 func New(verbose bool) *Logger {
+	return NewWithOptions(Options{Verbose: verbose})
+}
+
+// NewWithOptions creates a new logger instance with the given options
+func NewWithOptions(opts Options) *Logger {
 	// Configure logging level
 	level := zerolog.InfoLevel
-	if verbose {
+	if opts.Verbose {
 		level = zerolog.DebugLevel
 	}
 
+	if opts.Timestamp == "" {
+		opts.Timestamp = TimestampTime
+	}
+
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
+		consoleOnce.Do(enableUTF8Console)
+	}
+
 	// Configure output
-	output := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: "15:04:05"}
+	var logger zerolog.Logger
+	if opts.Format == FormatJSON {
+		logger = zerolog.New(out).Level(level)
+		if opts.Timestamp != TimestampOff {
+			// Render the timestamp per logger instead of touching the global zerolog.TimeFieldFormat
+			layout := opts.Timestamp.layout()
+			logger = logger.Hook(zerolog.HookFunc(func(e *zerolog.Event, _ zerolog.Level, _ string) {
+				e.Str(zerolog.TimestampFieldName, time.Now().Format(layout))
+			}))
+		}
+	} else {
+		output := zerolog.ConsoleWriter{Out: out, TimeFormat: opts.Timestamp.layout()}
+		if opts.Timestamp == TimestampOff {
+			output.PartsExclude = []string{zerolog.TimestampFieldName}
+		}
 
-	// Create and configure logger
-	logger := zerolog.New(output).
-		Level(level).
-		With().
-		Timestamp().
-		Logger()
+		logger = zerolog.New(output).Level(level)
+		if opts.Timestamp != TimestampOff {
+			logger = logger.With().Timestamp().Logger()
+		}
+	}
 
-	return &Logger{
+	l := &Logger{
 		logger: logger,
 		level:  level,
+		ascii:  opts.ASCII,
+	}
+
+	if opts.Dedup && !opts.Verbose {
+		l.dedup = newDeduper(opts.DedupWindow)
+	}
+
+	return l
+}
+
+// With returns a sub-logger that attaches the given fields to every message.
+// The sub-logger shares level and deduplication state with its parent.
+func (l *Logger) With(fields map[string]interface{}) *Logger {
+	return &Logger{
+		logger: l.logger.With().Fields(fields).Logger(),
+		level:  l.level,
+		dedup:  l.dedup,
+		ascii:  l.ascii,
+		prefix: l.prefix,
 	}
 }
 
+// WithPrefix returns a sub-logger that prepends "[prefix] " to every message,
+// keeping interleaved output of concurrent workers readable
+func (l *Logger) WithPrefix(prefix string) *Logger {
+	sub := l.With(nil)
+	sub.prefix = "[" + prefix + "] "
+	return sub
+}
+
+// WithField returns a sub-logger that attaches a single field to every message
+func (l *Logger) WithField(key string, value interface{}) *Logger {
+	return l.With(map[string]interface{}{key: value})
+}
+
+// Flush writes out the pending "message repeated" summaries, if any.
+// It should be called before the program exits when deduplication is enabled.
+func (l *Logger) Flush() {
+	if l.dedup != nil {
+		l.dedup.flush(l.write)
+	}
+}
+
+// log formats the message and passes it through the deduplicator when enabled
+func (l *Logger) log(level zerolog.Level, format string, v ...interface{}) {
+	msg := l.prefix + fmt.Sprintf(format, v...)
+	if l.ascii {
+		msg = asciiReplacer.Replace(msg)
+	}
+
+	if level == zerolog.FatalLevel {
+		l.Flush()
+		l.logger.Fatal().Msg(msg)
+		return
+	}
+
+	if l.dedup == nil || level < l.level {
+		l.write(level, msg)
+		return
+	}
+
+	l.dedup.log(level, msg, l.write)
+}
+
+// write sends a message to the underlying zerolog logger
+func (l *Logger) write(level zerolog.Level, msg string) {
+	l.logger.WithLevel(level).Msg(msg)
+}
+
 // Debug logs debug messages
 func (l *Logger) Debug(format string, v ...interface{}) {
-	l.logger.Debug().Msgf(format, v...)
+	l.log(zerolog.DebugLevel, format, v...)
 }
 
 // Info logs informational messages
 func (l *Logger) Info(format string, v ...interface{}) {
-	l.logger.Info().Msgf(format, v...)
+	l.log(zerolog.InfoLevel, format, v...)
 }
 
 // Warn logs warning messages
 func (l *Logger) Warn(format string, v ...interface{}) {
-	l.logger.Warn().Msgf(format, v...)
+	l.log(zerolog.WarnLevel, format, v...)
 }
 
 // Error logs error messages
 func (l *Logger) Error(format string, v ...interface{}) {
-	l.logger.Error().Msgf(format, v...)
+	l.log(zerolog.ErrorLevel, format, v...)
 }
 
 // Fatal logs fatal messages and exits
 func (l *Logger) Fatal(format string, v ...interface{}) {
-	l.logger.Fatal().Msgf(format, v...)
+	l.log(zerolog.FatalLevel, format, v...)
 }
 
 // Infof logs formatted informational messages
 func (l *Logger) Infof(format string, v ...interface{}) {
-	l.logger.Info().Msgf(format, v...)
+	l.log(zerolog.InfoLevel, format, v...)
 }
 
 // Errorf logs formatted error messages
 func (l *Logger) Errorf(format string, v ...interface{}) {
-	l.logger.Error().Msgf(format, v...)
+	l.log(zerolog.ErrorLevel, format, v...)
 }
 
 // Debugf logs formatted debug messages
 func (l *Logger) Debugf(format string, v ...interface{}) {
-	l.logger.Debug().Msgf(format, v...)
+	l.log(zerolog.DebugLevel, format, v...)
 }
 
 // Warnf logs formatted warning messages
 func (l *Logger) Warnf(format string, v ...interface{}) {
-	l.logger.Warn().Msgf(format, v...)
+	l.log(zerolog.WarnLevel, format, v...)
 }
 
 // Fatalf logs formatted fatal messages and exits
 func (l *Logger) Fatalf(format string, v ...interface{}) {
-	l.logger.Fatal().Msgf(format, v...)
+	l.log(zerolog.FatalLevel, format, v...)
 }
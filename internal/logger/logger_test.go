@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// ansiPattern matches the color escape sequences of the console writer
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// TestTimestampFormats checks the timestamp rendered at the start of console and JSON lines
+func TestTimestampFormats(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name      string
+		format    Format
+		timestamp TimestampFormat
+		pattern   string
+	}{
+		{"Console time", FormatConsole, TimestampTime, `^\d{2}:\d{2}:\d{2} INF hello\n$`},
+		{"Console datetime", FormatConsole, TimestampDateTime, `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} INF hello\n$`},
+		{"Console RFC3339", FormatConsole, TimestampRFC3339,
+			`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-]\d{2}:\d{2}) INF hello\n$`},
+		{"Console without timestamps", FormatConsole, TimestampOff, `^INF hello\n$`},
+		{"JSON time", FormatJSON, TimestampTime, `^\{"level":"info","time":"\d{2}:\d{2}:\d{2}","message":"hello"\}\n$`},
+		{"JSON datetime", FormatJSON, TimestampDateTime,
+			`^\{"level":"info","time":"\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}","message":"hello"\}\n$`},
+		{"JSON RFC3339", FormatJSON, TimestampRFC3339,
+			`^\{"level":"info","time":"\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-]\d{2}:\d{2})","message":"hello"\}\n$`},
+		{"JSON without timestamps", FormatJSON, TimestampOff, `^\{"level":"info","message":"hello"\}\n$`},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := NewWithOptions(Options{Output: &buf, Format: tt.format, Timestamp: tt.timestamp})
+			log.Info("hello")
+
+			line := ansiPattern.ReplaceAllString(buf.String(), "")
+			if !regexp.MustCompile(tt.pattern).MatchString(line) {
+				t.Errorf("Output %q doesn't match %s", line, tt.pattern)
+			}
+		})
+	}
+}
+
+// TestFields checks that fields and prefixes of sub-loggers reach console and JSON output
+// and don't leak into the parent
+func TestFields(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name   string
+		format Format
+		sub    func(*Logger) *Logger
+		want   []string
+	}{
+		{"Console fields", FormatConsole, func(l *Logger) *Logger {
+			return l.With(map[string]interface{}{"track_id": "42", "phase": "download"})
+		}, []string{"hello", "phase=download", "track_id=42"}},
+		{"Console field", FormatConsole, func(l *Logger) *Logger {
+			return l.WithField("track_id", "42")
+		}, []string{"hello", "track_id=42"}},
+		{"Console prefix and field", FormatConsole, func(l *Logger) *Logger {
+			return l.WithPrefix("w1").WithField("phase", "tagging")
+		}, []string{"[w1] hello", "phase=tagging"}},
+		{"JSON fields", FormatJSON, func(l *Logger) *Logger {
+			return l.With(map[string]interface{}{"track_id": "42", "phase": "download"})
+		}, []string{`"message":"hello"`, `"phase":"download"`, `"track_id":"42"`}},
+		{"JSON field", FormatJSON, func(l *Logger) *Logger {
+			return l.WithField("track_id", "42")
+		}, []string{`"message":"hello"`, `"track_id":"42"`}},
+		{"JSON prefix and field", FormatJSON, func(l *Logger) *Logger {
+			return l.WithPrefix("w1").WithField("phase", "tagging")
+		}, []string{`"message":"[w1] hello"`, `"phase":"tagging"`}},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			parent := NewWithOptions(Options{Output: &buf, Format: tt.format, Timestamp: TimestampOff})
+			tt.sub(parent).Info("hello")
+			parent.Info("parent")
+
+			lines := strings.Split(strings.TrimSuffix(ansiPattern.ReplaceAllString(buf.String(), ""), "\n"), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("Got %d lines, want 2: %q", len(lines), buf.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(lines[0], want) {
+					t.Errorf("Sub-logger line %q doesn't contain %s", lines[0], want)
+				}
+			}
+			for _, leaked := range []string{"track_id", "phase", "[w1]"} {
+				if strings.Contains(lines[1], leaked) {
+					t.Errorf("Parent line %q contains %s of the sub-logger", lines[1], leaked)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Package media provides helpers for inspecting audio container files.
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Container identifies an audio container format
+type Container string
+
+const (
+	// ContainerUnknown - unrecognized data
+	ContainerUnknown Container = ""
+
+	// ContainerFLAC - native FLAC stream
+	ContainerFLAC Container = "flac"
+
+	// ContainerMP4 - ISO base media file (AAC or FLAC in MP4)
+	ContainerMP4 Container = "mp4"
+
+	// ContainerMP3 - MPEG audio, optionally with an ID3v2 header
+	ContainerMP3 Container = "mp3"
+)
+
+// HeaderSize is the number of leading bytes DetectContainer needs
+const HeaderSize = 12
+
+// ErrUnsupported is returned when an operation is not supported for the container
+var ErrUnsupported = errors.New("unsupported container")
+
+// DetectContainer recognizes the container from the first bytes of a file
+func DetectContainer(header []byte) Container {
+	switch {
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return ContainerFLAC
+	case len(header) >= 8 && string(header[4:8]) == "ftyp":
+		return ContainerMP4
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return ContainerMP3
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		// MPEG frame sync: 11 set bits
+		return ContainerMP3
+	default:
+		return ContainerUnknown
+	}
+}
+
+// ContainerForExtension returns the container expected for a file extension
+func ContainerForExtension(ext string) Container {
+	switch strings.ToLower(strings.TrimPrefix(ext, ".")) {
+	case "flac":
+		return ContainerFLAC
+	case "m4a", "mp4", "aac":
+		return ContainerMP4
+	case "mp3":
+		return ContainerMP3
+	default:
+		return ContainerUnknown
+	}
+}
+
+// DetectFile reads the header of a file and recognizes its container
+func DetectFile(path string) (Container, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ContainerUnknown, err
+	}
+	defer file.Close()
+
+	header := make([]byte, HeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return ContainerUnknown, err
+	}
+
+	return DetectContainer(header[:n]), nil
+}
+
+// ProbeDuration returns the playback duration stored in a FLAC or MP4 file
+func ProbeDuration(path string) (time.Duration, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	header := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return 0, fmt.Errorf("error reading header: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	switch DetectContainer(header) {
+	case ContainerFLAC:
+		return flacDuration(file)
+	case ContainerMP4:
+		return mp4Duration(file)
+	default:
+		return 0, ErrUnsupported
+	}
+}
+
+// flacDuration reads the total samples and sample rate from the STREAMINFO block
+func flacDuration(r io.Reader) (time.Duration, error) {
+	// "fLaC" marker, metadata block header and the 34-byte STREAMINFO block
+	buf := make([]byte, 4+4+34)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("error reading STREAMINFO: %w", err)
+	}
+
+	if buf[4]&0x7F != 0 {
+		return 0, fmt.Errorf("first metadata block is not STREAMINFO")
+	}
+
+	info := buf[8:]
+	sampleRate := uint64(info[10])<<12 | uint64(info[11])<<4 | uint64(info[12])>>4
+	totalSamples := uint64(info[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(info[14:18]))
+	if sampleRate == 0 {
+		return 0, fmt.Errorf("invalid sample rate")
+	}
+
+	return time.Duration(totalSamples * uint64(time.Second) / sampleRate), nil
+}
+
+// mp4Duration reads the duration from the moov/mvhd box
+func mp4Duration(r io.ReadSeeker) (time.Duration, error) {
+	moov, err := findBox(r, "moov", -1)
+	if err != nil {
+		return 0, err
+	}
+
+	mvhd, err := findBox(r, "mvhd", moov)
+	if err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 32)
+	n, err := io.ReadFull(r, buf[:min(int64(len(buf)), mvhd)])
+	if err != nil {
+		return 0, fmt.Errorf("error reading mvhd: %w", err)
+	}
+	buf = buf[:n]
+	if n == 0 {
+		return 0, fmt.Errorf("mvhd box is too short")
+	}
+
+	var timescale, duration uint64
+	if buf[0] == 1 {
+		// Version 1: 64-bit times
+		if len(buf) < 32 {
+			return 0, fmt.Errorf("mvhd box is too short")
+		}
+		timescale = uint64(binary.BigEndian.Uint32(buf[20:24]))
+		duration = binary.BigEndian.Uint64(buf[24:32])
+	} else {
+		if len(buf) < 20 {
+			return 0, fmt.Errorf("mvhd box is too short")
+		}
+		timescale = uint64(binary.BigEndian.Uint32(buf[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(buf[16:20]))
+	}
+
+	if timescale == 0 {
+		return 0, fmt.Errorf("invalid timescale")
+	}
+
+	return time.Duration(duration * uint64(time.Second) / timescale), nil
+}
+
+// findBox scans sibling boxes from the current position for the given type,
+// leaving the reader at the start of the box payload and returning the payload size.
+// A negative limit scans until EOF.
+func findBox(r io.ReadSeeker, boxType string, limit int64) (int64, error) {
+	header := make([]byte, 8)
+	for limit < 0 || limit >= 8 {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, fmt.Errorf("box %q not found", boxType)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+		switch size {
+		case 1:
+			// 64-bit extended size follows the type
+			ext := make([]byte, 8)
+			if _, err := io.ReadFull(r, ext); err != nil {
+				return 0, fmt.Errorf("error reading box size: %w", err)
+			}
+			size = int64(binary.BigEndian.Uint64(ext))
+			headerSize = 16
+		case 0:
+			// Box extends to the end of the file (or of the parent)
+			if limit < 0 {
+				size = 1<<62 - 1
+			} else {
+				size = limit
+			}
+		}
+
+		if size < headerSize {
+			return 0, fmt.Errorf("invalid box size %d", size)
+		}
+
+		if string(header[4:8]) == boxType {
+			return size - headerSize, nil
+		}
+
+		if _, err := r.Seek(size-headerSize, io.SeekCurrent); err != nil {
+			return 0, err
+		}
+		if limit >= 0 {
+			limit -= size
+		}
+	}
+
+	return 0, fmt.Errorf("box %q not found", boxType)
+}
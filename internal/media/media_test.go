@@ -0,0 +1,113 @@
+package media
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// flacData builds a minimal FLAC stream with a STREAMINFO block
+func flacData(sampleRate, totalSamples uint64) []byte {
+	info := make([]byte, 34)
+	info[10] = byte(sampleRate >> 12)
+	info[11] = byte(sampleRate >> 4)
+	info[12] = byte(sampleRate<<4) | 0x02 // 2 channels
+	info[13] = 0xF0 | byte(totalSamples>>32&0x0F)
+	binary.BigEndian.PutUint32(info[14:18], uint32(totalSamples))
+
+	data := []byte("fLaC")
+	data = append(data, 0x80, 0, 0, 34) // last block, STREAMINFO, length 34
+	return append(data, info...)
+}
+
+// box builds an MP4 box
+func box(boxType string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+
+	data := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(data, uint32(size))
+	copy(data[4:], boxType)
+	for _, p := range payload {
+		data = append(data, p...)
+	}
+	return data
+}
+
+// mp4Data builds a minimal MP4 file with an mvhd box
+func mp4Data(timescale, duration uint32) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], timescale)
+	binary.BigEndian.PutUint32(mvhd[16:20], duration)
+
+	return append(box("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		append(box("free", make([]byte, 16)), box("moov", box("trak"), box("mvhd", mvhd))...)...)
+}
+
+func TestDetectContainer(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		header   []byte
+		expected Container
+	}{
+		{"FLAC", []byte("fLaC\x00\x00\x00\x22"), ContainerFLAC},
+		{"MP4", []byte("\x00\x00\x00\x20ftypM4A "), ContainerMP4},
+		{"MP3 with ID3", []byte("ID3\x04\x00"), ContainerMP3},
+		{"MP3 frame sync", []byte{0xFF, 0xFB, 0x90, 0x00}, ContainerMP3},
+		{"Garbage", []byte{0x13, 0x37, 0x00, 0x42, 0x99, 0x01, 0x02, 0x03}, ContainerUnknown},
+		{"Empty", nil, ContainerUnknown},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectContainer(tt.header); got != tt.expected {
+				t.Errorf("DetectContainer() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProbeDuration(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		data     []byte
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"FLAC", flacData(44100, 44100*180), 180 * time.Second, false},
+		{"MP4", mp4Data(44100, 44100*90), 90 * time.Second, false},
+		{"MP4 with an empty mvhd", append(box("ftyp", []byte("M4A \x00\x00\x00\x00")),
+			box("moov", box("mvhd"))...), 0, true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ProbeDuration(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ProbeDuration() = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProbeDuration() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ProbeDuration() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
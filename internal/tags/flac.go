@@ -0,0 +1,180 @@
+package tags
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FLAC metadata block types
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+)
+
+// flacVendor is the vendor string of Vorbis comment blocks created by this package
+const flacVendor = "yamusic-dl"
+
+// flacBlock is a FLAC metadata block
+type flacBlock struct {
+	typ  byte
+	data []byte
+}
+
+// readFLACBlocks reads the "fLaC" marker and all metadata blocks,
+// leaving the reader at the first audio frame
+func readFLACBlocks(r io.Reader) ([]flacBlock, error) {
+	marker := make([]byte, 4)
+	if _, err := io.ReadFull(r, marker); err != nil || string(marker) != "fLaC" {
+		return nil, fmt.Errorf("not a FLAC file")
+	}
+
+	var blocks []flacBlock
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("error reading metadata block: %w", err)
+		}
+
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("error reading metadata block: %w", err)
+		}
+		blocks = append(blocks, flacBlock{typ: header[0] & 0x7F, data: data})
+
+		if header[0]&0x80 != 0 {
+			return blocks, nil
+		}
+	}
+}
+
+// parseVorbisComment decodes a Vorbis comment block into the vendor string and fields
+func parseVorbisComment(data []byte) (string, Tags, error) {
+	errCorrupt := fmt.Errorf("corrupt Vorbis comment block")
+
+	if len(data) < 4 {
+		return "", nil, errCorrupt
+	}
+	n := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+	if len(data) < n+4 {
+		return "", nil, errCorrupt
+	}
+	vendor := string(data[:n])
+	data = data[n:]
+
+	count := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+
+	var tags Tags
+	for i := 0; i < count; i++ {
+		if len(data) < 4 {
+			return "", nil, errCorrupt
+		}
+		n := int(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+		if len(data) < n {
+			return "", nil, errCorrupt
+		}
+
+		key, value, _ := strings.Cut(string(data[:n]), "=")
+		tags = append(tags, Tag{Key: key, Value: value})
+		data = data[n:]
+	}
+
+	return vendor, tags, nil
+}
+
+// encodeVorbisComment encodes a Vorbis comment block
+func encodeVorbisComment(vendor string, tags Tags) []byte {
+	var buf []byte
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(vendor)))
+	buf = append(buf, vendor...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(tags)))
+	for _, tag := range tags {
+		field := strings.ToUpper(tag.Key) + "=" + tag.Value
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(field)))
+		buf = append(buf, field...)
+	}
+	return buf
+}
+
+// writeFLAC rewrites the Vorbis comment block and copies the audio frames
+func writeFLAC(r io.ReadSeeker, w io.Writer, tags Tags) error {
+	blocks, err := readFLACBlocks(r)
+	if err != nil {
+		return err
+	}
+
+	index := -1
+	for i, block := range blocks {
+		if block.typ == flacVorbisComment {
+			index = i
+			break
+		}
+	}
+
+	vendor := flacVendor
+	var existing Tags
+	if index >= 0 {
+		if vendor, existing, err = parseVorbisComment(blocks[index].data); err != nil {
+			return err
+		}
+	} else {
+		// The Vorbis comment block goes right after STREAMINFO
+		index = 1
+		blocks = append(blocks[:1], append([]flacBlock{{typ: flacVorbisComment}}, blocks[1:]...)...)
+	}
+
+	merged := make(Tags, 0, len(existing)+len(tags))
+	for _, tag := range existing {
+		if !tags.has(tag.Key) {
+			merged = append(merged, tag)
+		}
+	}
+	merged = append(merged, tags...)
+
+	blocks[index].data = encodeVorbisComment(vendor, merged)
+	if len(blocks[index].data) >= 1<<24 {
+		return fmt.Errorf("tags are too large for a FLAC metadata block")
+	}
+
+	if _, err := w.Write([]byte("fLaC")); err != nil {
+		return err
+	}
+	for i, block := range blocks {
+		typ := block.typ
+		if i == len(blocks)-1 {
+			typ |= 0x80
+		}
+		size := len(block.data)
+		if _, err := w.Write([]byte{typ, byte(size >> 16), byte(size >> 8), byte(size)}); err != nil {
+			return err
+		}
+		if _, err := w.Write(block.data); err != nil {
+			return err
+		}
+	}
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// readFLAC returns the fields of the Vorbis comment block
+func readFLAC(r io.Reader) (Tags, error) {
+	blocks, err := readFLACBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, block := range blocks {
+		if block.typ == flacVorbisComment {
+			_, tags, err := parseVorbisComment(block.data)
+			return tags, err
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,206 @@
+package tags
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/media"
+)
+
+// KeyITunSMPB is the freeform MP4 tag holding the gapless info of AAC streams
+const KeyITunSMPB = "iTunSMPB"
+
+// Gapless describes the encoder priming and padding of an AAC stream, in samples
+type Gapless struct {
+	Delay   int64
+	Padding int64
+	Samples int64
+}
+
+// ITunSMPB formats the gapless info as the value of an iTunSMPB tag
+func (g Gapless) ITunSMPB() string {
+	fields := []string{
+		"00000000",
+		fmt.Sprintf("%08X", g.Delay),
+		fmt.Sprintf("%08X", g.Padding),
+		fmt.Sprintf("%016X", g.Samples),
+	}
+	for range 8 {
+		fields = append(fields, "00000000")
+	}
+	return " " + strings.Join(fields, " ")
+}
+
+// ReadGapless derives the gapless info of an AAC stream in an MP4 file from the edit list
+// of its first track: the media time of the edit is the priming, its duration the
+// number of audio samples and the rest of the track the padding. ok is false for files
+// that are not AAC in MP4, have no usable edit list or keep their samples in fragments.
+func ReadGapless(path string) (gapless Gapless, ok bool, err error) {
+	container, err := media.DetectFile(path)
+	if err != nil || container != media.ContainerMP4 {
+		return Gapless{}, false, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Gapless{}, false, err
+	}
+	defer file.Close()
+
+	_, _, moov, err := readMoov(file)
+	if err != nil {
+		return Gapless{}, false, err
+	}
+
+	gapless, ok = moovGapless(moov)
+	return gapless, ok, nil
+}
+
+// moovGapless computes the gapless info from the boxes of moov
+func moovGapless(moov []mp4Box) (Gapless, bool) {
+	movieScale, ok := timescale(moov, "mvhd")
+	if !ok {
+		return Gapless{}, false
+	}
+	trak, ok := childBoxes(moov, "trak")
+	if !ok {
+		return Gapless{}, false
+	}
+	mdia, ok := childBoxes(trak, "mdia")
+	if !ok {
+		return Gapless{}, false
+	}
+	// The media time scale of audio tracks is their sample rate
+	mediaScale, ok := timescale(mdia, "mdhd")
+	if !ok {
+		return Gapless{}, false
+	}
+	stbl, ok := boxPath(mdia, "minf", "stbl")
+	if !ok || sampleEntry(stbl) != "mp4a" {
+		return Gapless{}, false
+	}
+	total := sttsDuration(stbl)
+	edts, ok := childBoxes(trak, "edts")
+	if !ok || total == 0 {
+		return Gapless{}, false
+	}
+	duration, mediaTime, ok := firstEdit(edts)
+	if !ok {
+		return Gapless{}, false
+	}
+
+	samples := duration * mediaScale / movieScale
+	padding := total - mediaTime - samples
+	if samples <= 0 || padding < 0 {
+		return Gapless{}, false
+	}
+	return Gapless{Delay: mediaTime, Padding: padding, Samples: samples}, true
+}
+
+// childBoxes parses the children of the first box of the given type
+func childBoxes(boxes []mp4Box, typ string) ([]mp4Box, bool) {
+	i := findBox(boxes, typ)
+	if i < 0 {
+		return nil, false
+	}
+	children, err := parseBoxes(boxes[i].payload)
+	return children, err == nil
+}
+
+// boxPath parses the children of nested boxes along the path of types
+func boxPath(boxes []mp4Box, path ...string) ([]mp4Box, bool) {
+	for _, typ := range path {
+		var ok bool
+		if boxes, ok = childBoxes(boxes, typ); !ok {
+			return nil, false
+		}
+	}
+	return boxes, true
+}
+
+// timescale returns the time scale of an mvhd or mdhd box
+func timescale(boxes []mp4Box, typ string) (int64, bool) {
+	i := findBox(boxes, typ)
+	if i < 0 || len(boxes[i].payload) < 4 {
+		return 0, false
+	}
+	payload := boxes[i].payload
+
+	// Version 1 has 64-bit creation and modification times
+	offset := 12
+	if payload[0] == 1 {
+		offset = 20
+	}
+	if len(payload) < offset+4 {
+		return 0, false
+	}
+	scale := int64(binary.BigEndian.Uint32(payload[offset:]))
+	return scale, scale > 0
+}
+
+// sampleEntry returns the type of the first sample description of stbl, e.g. "mp4a"
+func sampleEntry(stbl []mp4Box) string {
+	i := findBox(stbl, "stsd")
+	if i < 0 || len(stbl[i].payload) < 16 {
+		return ""
+	}
+	// Version and flags, entry count, then the size and type of the first entry
+	return string(stbl[i].payload[12:16])
+}
+
+// sttsDuration returns the total duration of the samples of stbl in media time scale units
+func sttsDuration(stbl []mp4Box) int64 {
+	i := findBox(stbl, "stts")
+	if i < 0 || len(stbl[i].payload) < 8 {
+		return 0
+	}
+	payload := stbl[i].payload
+	count := int(binary.BigEndian.Uint32(payload[4:]))
+	if len(payload) < 8+count*8 {
+		return 0
+	}
+
+	var total int64
+	for entry := payload[8 : 8+count*8]; len(entry) > 0; entry = entry[8:] {
+		total += int64(binary.BigEndian.Uint32(entry)) * int64(binary.BigEndian.Uint32(entry[4:]))
+	}
+	return total
+}
+
+// firstEdit returns the duration (movie time scale) and media time (media time scale)
+// of the first edit of edts that is not an empty edit
+func firstEdit(edts []mp4Box) (int64, int64, bool) {
+	i := findBox(edts, "elst")
+	if i < 0 || len(edts[i].payload) < 8 {
+		return 0, 0, false
+	}
+	payload := edts[i].payload
+	version := payload[0]
+	count := int(binary.BigEndian.Uint32(payload[4:]))
+
+	entrySize := 12
+	if version == 1 {
+		entrySize = 20
+	}
+	if len(payload) < 8+count*entrySize {
+		return 0, 0, false
+	}
+
+	for entry := payload[8 : 8+count*entrySize]; len(entry) > 0; entry = entry[entrySize:] {
+		var duration, mediaTime int64
+		if version == 1 {
+			duration = int64(binary.BigEndian.Uint64(entry))
+			mediaTime = int64(binary.BigEndian.Uint64(entry[8:]))
+		} else {
+			duration = int64(binary.BigEndian.Uint32(entry))
+			mediaTime = int64(int32(binary.BigEndian.Uint32(entry[4:])))
+		}
+		// A media time of -1 marks an empty edit
+		if mediaTime >= 0 {
+			return duration, mediaTime, true
+		}
+	}
+	return 0, 0, false
+}
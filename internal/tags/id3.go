@@ -0,0 +1,311 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// ID3v2 text encodings
+const (
+	id3UTF16 = 1
+	id3UTF8  = 3
+)
+
+// id3Frames maps standard keys to native frames. DATE is written as TDRC in ID3v2.4
+// and as the year in TYER in ID3v2.3; LYRICS goes into an unsynchronised lyrics frame.
+var id3Frames = map[string]string{
+	KeyTitle:       "TIT2",
+	KeyArtist:      "TPE1",
+	KeyAlbum:       "TALB",
+	KeyAlbumArtist: "TPE2",
+	KeyTrackNumber: "TRCK",
+	KeyDiscNumber:  "TPOS",
+	KeyDate:        "TDRC",
+	KeyGenre:       "TCON",
+	KeyLyrics:      "USLT",
+	KeyLabel:       "TPUB",
+}
+
+// id3Language is the language of lyrics frames; the API doesn't tell it
+const id3Language = "XXX"
+
+// id3Frame is a raw ID3v2 frame
+type id3Frame struct {
+	id    string
+	flags []byte
+	data  []byte
+}
+
+// syncsafe decodes a 28-bit syncsafe integer
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// putSyncsafe encodes a 28-bit syncsafe integer
+func putSyncsafe(b []byte, n int) {
+	b[0] = byte(n>>21) & 0x7F
+	b[1] = byte(n>>14) & 0x7F
+	b[2] = byte(n>>7) & 0x7F
+	b[3] = byte(n) & 0x7F
+}
+
+// readID3Frames reads an existing ID3v2.3/2.4 tag, leaving the reader at the audio data.
+// It returns version 4 and no frames when the file has no tag.
+func readID3Frames(r io.ReadSeeker) (byte, []id3Frame, error) {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:3]) != "ID3" {
+		_, err := r.Seek(0, io.SeekStart)
+		return 4, nil, err
+	}
+
+	version, flags := header[3], header[5]
+	if version != 3 && version != 4 {
+		return 0, nil, ErrUnsupported
+	}
+	// Unsynchronisation and extended headers are not supported
+	if flags&0xC0 != 0 {
+		return 0, nil, ErrUnsupported
+	}
+
+	data := make([]byte, syncsafe(header[6:10]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, fmt.Errorf("error reading ID3 tag: %w", err)
+	}
+	if flags&0x10 != 0 {
+		// Skip the footer
+		if _, err := r.Seek(10, io.SeekCurrent); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var frames []id3Frame
+	for len(data) >= 10 && data[0] != 0 {
+		size := int(binary.BigEndian.Uint32(data[4:8]))
+		if version == 4 {
+			size = syncsafe(data[4:8])
+		}
+		if 10+size > len(data) {
+			return 0, nil, fmt.Errorf("corrupt ID3 frame %q", data[:4])
+		}
+
+		frames = append(frames, id3Frame{id: string(data[:4]), flags: data[8:10], data: data[10 : 10+size]})
+		data = data[10+size:]
+	}
+
+	return version, frames, nil
+}
+
+// encodeID3Text encodes a string for the tag version, returning the encoding byte,
+// the encoded text and the string terminator
+func encodeID3Text(version byte, s string) (byte, []byte, []byte) {
+	if version == 4 {
+		return id3UTF8, []byte(s), []byte{0}
+	}
+
+	// ID3v2.3 has no UTF-8; use UTF-16 with a byte order mark
+	buf := []byte{0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		buf = append(buf, byte(u), byte(u>>8))
+	}
+	return id3UTF16, buf, []byte{0, 0}
+}
+
+// decodeID3Text decodes a string with the given encoding
+func decodeID3Text(encoding byte, b []byte) string {
+	if encoding != 1 && encoding != 2 {
+		return string(bytes.TrimRight(b, "\x00"))
+	}
+
+	bigEndian := encoding == 2
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		bigEndian, b = true, b[2:]
+	} else if len(b) >= 2 && b[0] == 0xFF && b[1] == 0xFE {
+		bigEndian, b = false, b[2:]
+	}
+
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		} else {
+			units = append(units, uint16(b[i+1])<<8|uint16(b[i]))
+		}
+	}
+	for len(units) > 0 && units[len(units)-1] == 0 {
+		units = units[:len(units)-1]
+	}
+	return string(utf16.Decode(units))
+}
+
+// splitID3Text splits a frame body of encoding + description + value at the terminator
+func splitID3Text(data []byte) (byte, string, string) {
+	if len(data) == 0 {
+		return 0, "", ""
+	}
+	encoding, data := data[0], data[1:]
+
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return encoding, decodeID3Text(encoding, data[:i]), decodeID3Text(encoding, data[i+2:])
+			}
+		}
+		return encoding, decodeID3Text(encoding, data), ""
+	}
+
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return encoding, string(data[:i]), decodeID3Text(encoding, data[i+1:])
+	}
+	return encoding, string(data), ""
+}
+
+// txxxFrame builds a user-defined text frame
+func txxxFrame(version byte, description, value string) id3Frame {
+	encoding, desc, terminator := encodeID3Text(version, description)
+	// Each UTF-16 string carries its own byte order mark
+	_, text, _ := encodeID3Text(version, value)
+
+	data := append([]byte{encoding}, desc...)
+	data = append(data, terminator...)
+	data = append(data, text...)
+	return id3Frame{id: "TXXX", flags: []byte{0, 0}, data: data}
+}
+
+// textFrame builds a text information frame
+func textFrame(version byte, id, value string) id3Frame {
+	encoding, text, _ := encodeID3Text(version, value)
+	return id3Frame{id: id, flags: []byte{0, 0}, data: append([]byte{encoding}, text...)}
+}
+
+// usltFrame builds an unsynchronised lyrics frame without a content descriptor
+func usltFrame(version byte, lyrics string) id3Frame {
+	encoding, text, terminator := encodeID3Text(version, lyrics)
+	if encoding == id3UTF16 {
+		// The empty descriptor carries its own byte order mark
+		terminator = []byte{0xFF, 0xFE, 0, 0}
+	}
+
+	data := append([]byte{encoding}, id3Language...)
+	data = append(data, terminator...)
+	data = append(data, text...)
+	return id3Frame{id: "USLT", flags: []byte{0, 0}, data: data}
+}
+
+// nativeText returns the value of a native frame
+func nativeText(frame id3Frame) string {
+	if frame.id != "USLT" {
+		return decodeID3Text(frame.data[0], frame.data[1:])
+	}
+	if len(frame.data) < 4 {
+		return ""
+	}
+	// Skip the language; the descriptor is split off like that of TXXX
+	_, _, lyrics := splitID3Text(append([]byte{frame.data[0]}, frame.data[4:]...))
+	return lyrics
+}
+
+// id3Key returns the standard key of a native text frame, or ""
+func id3Key(id string) string {
+	if id == "TYER" {
+		return KeyDate
+	}
+	for key, frame := range id3Frames {
+		if frame == id {
+			return key
+		}
+	}
+	return ""
+}
+
+// nativeFrame builds the native frame of a standard key for the tag version
+func nativeFrame(version byte, key, value string) id3Frame {
+	if key == KeyDate && version == 3 {
+		year, _, _ := strings.Cut(value, "-")
+		return textFrame(version, "TYER", year)
+	}
+	if key == KeyLyrics {
+		return usltFrame(version, value)
+	}
+	return textFrame(version, id3Frames[key], value)
+}
+
+// writeID3 rewrites the ID3v2 tag and copies the audio data
+func writeID3(r io.ReadSeeker, w io.Writer, tags Tags) error {
+	version, frames, err := readID3Frames(r)
+	if err != nil {
+		return err
+	}
+
+	kept := frames[:0]
+	for _, frame := range frames {
+		if frame.id == "TXXX" {
+			if _, description, _ := splitID3Text(frame.data); tags.has(description) {
+				continue
+			}
+		} else if key := id3Key(frame.id); key != "" && tags.has(key) {
+			continue
+		}
+		kept = append(kept, frame)
+	}
+	written := make(map[string]bool)
+	for _, tag := range tags {
+		key := strings.ToUpper(tag.Key)
+		_, native := id3Frames[key]
+		switch {
+		case !native:
+			kept = append(kept, txxxFrame(version, tag.Key, tag.Value))
+		case !written[key]:
+			kept = append(kept, nativeFrame(version, key, tag.Value))
+			written[key] = true
+		}
+	}
+
+	var body []byte
+	for _, frame := range kept {
+		header := make([]byte, 10)
+		copy(header, frame.id)
+		if version == 4 {
+			putSyncsafe(header[4:8], len(frame.data))
+		} else {
+			binary.BigEndian.PutUint32(header[4:8], uint32(len(frame.data)))
+		}
+		copy(header[8:], frame.flags)
+		body = append(body, header...)
+		body = append(body, frame.data...)
+	}
+
+	header := []byte{'I', 'D', '3', version, 0, 0, 0, 0, 0, 0}
+	putSyncsafe(header[6:10], len(body))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// readID3 returns the standard and user-defined text frames of the tag
+func readID3(r io.ReadSeeker) (Tags, error) {
+	_, frames, err := readID3Frames(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags Tags
+	for _, frame := range frames {
+		if frame.id == "TXXX" {
+			_, description, value := splitID3Text(frame.data)
+			tags = append(tags, Tag{Key: description, Value: value})
+		} else if key := id3Key(frame.id); key != "" && len(frame.data) > 0 {
+			tags = append(tags, Tag{Key: key, Value: nativeText(frame)})
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,561 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// freeformMean is the namespace of custom MP4 tags
+const freeformMean = "com.apple.iTunes"
+
+// mp4Atoms maps standard keys to native ilst items
+var mp4Atoms = map[string]string{
+	KeyTitle:       "\xa9nam",
+	KeyArtist:      "\xa9ART",
+	KeyAlbum:       "\xa9alb",
+	KeyAlbumArtist: "aART",
+	KeyTrackNumber: "trkn",
+	KeyDiscNumber:  "disk",
+	KeyDate:        "\xa9day",
+	KeyGenre:       "\xa9gen",
+	KeyLyrics:      "\xa9lyr",
+	KeyAdvisory:    "rtng",
+}
+
+// mp4Box is a box with its payload held in memory
+type mp4Box struct {
+	typ     string
+	payload []byte
+}
+
+// topBox describes a top-level box of the file
+type topBox struct {
+	typ    string
+	offset int64
+	size   int64
+}
+
+// bytes serializes the box
+func (b mp4Box) bytes() []byte {
+	return makeBox(b.typ, b.payload)
+}
+
+// makeBox serializes a box with a 32-bit size from its payload parts
+func makeBox(typ string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+
+	buf := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(buf, uint32(size))
+	copy(buf[4:], typ)
+	for _, p := range payload {
+		buf = append(buf, p...)
+	}
+	return buf
+}
+
+// parseBoxes splits the payload of a container box into child boxes
+func parseBoxes(data []byte) ([]mp4Box, error) {
+	var boxes []mp4Box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("truncated box header")
+		}
+
+		size := uint64(binary.BigEndian.Uint32(data))
+		typ := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("truncated box header")
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			header = 16
+		}
+
+		if size < header || size > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid size of box %q", typ)
+		}
+
+		boxes = append(boxes, mp4Box{typ: typ, payload: data[header:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// joinBoxes serializes boxes back into a container payload
+func joinBoxes(boxes []mp4Box) []byte {
+	var buf []byte
+	for _, b := range boxes {
+		buf = append(buf, b.bytes()...)
+	}
+	return buf
+}
+
+// scanTopLevel lists the top-level boxes of the file
+func scanTopLevel(r io.ReadSeeker) ([]topBox, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var boxes []topBox
+	header := make([]byte, 16)
+	for offset := int64(0); offset < end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil, fmt.Errorf("error reading box header: %w", err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header))
+		typ := string(header[4:8])
+		switch size {
+		case 0:
+			size = end - offset
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil, fmt.Errorf("error reading box header: %w", err)
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+		}
+
+		if size < 8 || offset+size > end {
+			return nil, fmt.Errorf("invalid size of box %q", typ)
+		}
+
+		boxes = append(boxes, topBox{typ: typ, offset: offset, size: size})
+		offset += size
+	}
+
+	return boxes, nil
+}
+
+// readMoov finds and reads the moov box
+func readMoov(r io.ReadSeeker) ([]topBox, int, []mp4Box, error) {
+	top, err := scanTopLevel(r)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	for i, box := range top {
+		if box.typ != "moov" {
+			continue
+		}
+
+		data := make([]byte, box.size)
+		if _, err := r.Seek(box.offset, io.SeekStart); err != nil {
+			return nil, 0, nil, err
+		}
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, 0, nil, fmt.Errorf("error reading moov: %w", err)
+		}
+
+		// moov is a container; parse it as a single box to handle extended sizes
+		boxes, err := parseBoxes(data)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		children, err := parseBoxes(boxes[0].payload)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return top, i, children, nil
+	}
+
+	return nil, 0, nil, fmt.Errorf("moov box not found")
+}
+
+// metaLayout splits the meta payload into the optional full-box header and children.
+// iTunes-style meta is a full box; QuickTime-style meta has no version/flags.
+func metaLayout(payload []byte) ([]byte, []byte) {
+	if len(payload) >= 8 && string(payload[4:8]) == "hdlr" {
+		return nil, payload
+	}
+	if len(payload) < 4 {
+		return []byte{0, 0, 0, 0}, nil
+	}
+	return payload[:4], payload[4:]
+}
+
+// findBox returns the index of the first child of the given type, or -1
+func findBox(boxes []mp4Box, typ string) int {
+	for i, b := range boxes {
+		if b.typ == typ {
+			return i
+		}
+	}
+	return -1
+}
+
+// ilstItems returns the ilst items stored under moov/udta/meta
+func ilstItems(moov []mp4Box) ([]mp4Box, error) {
+	udta := findBox(moov, "udta")
+	if udta < 0 {
+		return nil, nil
+	}
+	udtaChildren, err := parseBoxes(moov[udta].payload)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := findBox(udtaChildren, "meta")
+	if meta < 0 {
+		return nil, nil
+	}
+	_, metaPayload := metaLayout(udtaChildren[meta].payload)
+	metaChildren, err := parseBoxes(metaPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	ilst := findBox(metaChildren, "ilst")
+	if ilst < 0 {
+		return nil, nil
+	}
+	return parseBoxes(metaChildren[ilst].payload)
+}
+
+// setIlstItems replaces the ilst items under moov/udta/meta, creating the boxes as needed
+func setIlstItems(moov []mp4Box, items []mp4Box) ([]mp4Box, error) {
+	udta := findBox(moov, "udta")
+	if udta < 0 {
+		moov = append(moov, mp4Box{typ: "udta"})
+		udta = len(moov) - 1
+	}
+	udtaChildren, err := parseBoxes(moov[udta].payload)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := findBox(udtaChildren, "meta")
+	if meta < 0 {
+		udtaChildren = append(udtaChildren, mp4Box{typ: "meta", payload: []byte{0, 0, 0, 0}})
+		meta = len(udtaChildren) - 1
+	}
+	metaHeader, metaPayload := metaLayout(udtaChildren[meta].payload)
+	metaChildren, err := parseBoxes(metaPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	if findBox(metaChildren, "hdlr") < 0 {
+		// Metadata handler: pre_defined, handler_type "mdir", reserved "appl", empty name
+		hdlr := append([]byte{0, 0, 0, 0, 0, 0, 0, 0}, "mdirappl"...)
+		hdlr = append(hdlr, make([]byte, 9)...)
+		metaChildren = append([]mp4Box{{typ: "hdlr", payload: hdlr}}, metaChildren...)
+	}
+
+	ilst := findBox(metaChildren, "ilst")
+	if ilst < 0 {
+		metaChildren = append(metaChildren, mp4Box{typ: "ilst"})
+		ilst = len(metaChildren) - 1
+	}
+	metaChildren[ilst].payload = joinBoxes(items)
+
+	udtaChildren[meta].payload = append(append([]byte{}, metaHeader...), joinBoxes(metaChildren)...)
+	moov[udta].payload = joinBoxes(udtaChildren)
+
+	return moov, nil
+}
+
+// freeformItem builds a "----" item with a UTF-8 value
+func freeformItem(name, value string) mp4Box {
+	return mp4Box{typ: "----", payload: append(append(
+		makeBox("mean", []byte{0, 0, 0, 0}, []byte(freeformMean)),
+		makeBox("name", []byte{0, 0, 0, 0}, []byte(name))...),
+		dataBox(1, []byte(value))...)}
+}
+
+// dataBox builds a data box with the given well-known type
+func dataBox(dataType uint32, value []byte) []byte {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, dataType)
+	return makeBox("data", header, value)
+}
+
+// nativeItem builds the native ilst item of a standard key
+func nativeItem(atom, value string) mp4Box {
+	if atom == "rtng" {
+		// A single byte, well-known type 21 (signed integer)
+		rating, _ := strconv.Atoi(strings.TrimSpace(value))
+		return mp4Box{typ: atom, payload: dataBox(21, []byte{byte(rating)})}
+	}
+	if atom != "trkn" && atom != "disk" {
+		return mp4Box{typ: atom, payload: dataBox(1, []byte(value))}
+	}
+
+	// Reserved, number, total and (for trkn) two trailing reserved bytes
+	number, total := splitNumber(value)
+	payload := make([]byte, 6, 8)
+	binary.BigEndian.PutUint16(payload[2:], uint16(number))
+	binary.BigEndian.PutUint16(payload[4:], uint16(total))
+	if atom == "trkn" {
+		payload = append(payload, 0, 0)
+	}
+	return mp4Box{typ: atom, payload: dataBox(0, payload)}
+}
+
+// nativeValue returns the value of a native ilst item as a tag value
+func nativeValue(item mp4Box) string {
+	children, err := parseBoxes(item.payload)
+	if err != nil {
+		return ""
+	}
+	data := findBox(children, "data")
+	if data < 0 || len(children[data].payload) < 8 {
+		return ""
+	}
+	value := children[data].payload[8:]
+
+	if item.typ == "rtng" {
+		if len(value) == 0 {
+			return ""
+		}
+		return strconv.Itoa(int(int8(value[0])))
+	}
+	if item.typ != "trkn" && item.typ != "disk" {
+		return string(bytes.TrimRight(value, "\x00"))
+	}
+	if len(value) < 6 {
+		return ""
+	}
+	number, total := binary.BigEndian.Uint16(value[2:]), binary.BigEndian.Uint16(value[4:])
+	if total > 0 {
+		return fmt.Sprintf("%d/%d", number, total)
+	}
+	return fmt.Sprint(number)
+}
+
+// mp4Key returns the standard key of a native ilst item, or ""
+func mp4Key(atom string) string {
+	for key, a := range mp4Atoms {
+		if a == atom {
+			return key
+		}
+	}
+	return ""
+}
+
+// freeformName returns the name of a "----" item
+func freeformName(item mp4Box) (string, []byte) {
+	children, err := parseBoxes(item.payload)
+	if err != nil {
+		return "", nil
+	}
+
+	var name string
+	var value []byte
+	for _, child := range children {
+		switch {
+		case child.typ == "name" && len(child.payload) >= 4:
+			name = string(child.payload[4:])
+		case child.typ == "data" && len(child.payload) >= 8 && value == nil:
+			value = child.payload[8:]
+		}
+	}
+	return name, value
+}
+
+// writeMP4 rewrites moov with the new ilst items and copies the other boxes
+func writeMP4(r io.ReadSeeker, w io.Writer, tags Tags) error {
+	top, moovIndex, moov, err := readMoov(r)
+	if err != nil {
+		return err
+	}
+
+	items, err := ilstItems(moov)
+	if err != nil {
+		return err
+	}
+
+	// Drop items being replaced
+	kept := items[:0]
+	for _, item := range items {
+		if item.typ == "----" {
+			if name, _ := freeformName(item); tags.has(name) {
+				continue
+			}
+		} else if key := mp4Key(item.typ); key != "" && tags.has(key) {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	written := make(map[string]bool)
+	for _, tag := range tags {
+		key := strings.ToUpper(tag.Key)
+		atom, native := mp4Atoms[key]
+		switch {
+		case !native:
+			kept = append(kept, freeformItem(tag.Key, tag.Value))
+		case !written[key]:
+			kept = append(kept, nativeItem(atom, tag.Value))
+			written[key] = true
+		}
+	}
+
+	if moov, err = setIlstItems(moov, kept); err != nil {
+		return err
+	}
+
+	newSize := int64(8 + len(joinBoxes(moov)))
+	delta := newSize - top[moovIndex].size
+
+	// Media data after moov moves by delta; absolute offsets pointing at it must follow
+	if delta != 0 {
+		mediaAfter := false
+		for _, box := range top[moovIndex+1:] {
+			if box.typ == "mdat" || box.typ == "moof" {
+				mediaAfter = true
+			}
+		}
+		if mediaAfter {
+			if err := shiftChunkOffsets(moov, delta); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, box := range top {
+		switch {
+		case i == moovIndex:
+			if _, err := w.Write(makeBox("moov", joinBoxes(moov))); err != nil {
+				return err
+			}
+		case i > moovIndex && delta != 0 && box.typ == "mfra":
+			// The optional random access index holds absolute moof offsets; drop it
+			// rather than leave it pointing at the wrong places
+			continue
+		case i > moovIndex && delta != 0 && box.typ == "moof":
+			if err := copyMoof(r, w, box, delta); err != nil {
+				return err
+			}
+		default:
+			if _, err := r.Seek(box.offset, io.SeekStart); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(w, r, box.size); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// shiftChunkOffsets adds delta to the stco/co64 chunk offsets of all tracks
+func shiftChunkOffsets(boxes []mp4Box, delta int64) error {
+	for i := range boxes {
+		box := &boxes[i]
+		switch box.typ {
+		case "trak", "mdia", "minf", "stbl":
+			children, err := parseBoxes(box.payload)
+			if err != nil {
+				return err
+			}
+			if err := shiftChunkOffsets(children, delta); err != nil {
+				return err
+			}
+			box.payload = joinBoxes(children)
+		case "stco":
+			payload := append([]byte{}, box.payload...)
+			for p := 8; p+4 <= len(payload); p += 4 {
+				offset := int64(binary.BigEndian.Uint32(payload[p:])) + delta
+				if offset < 0 || offset > 0xFFFFFFFF {
+					return ErrUnsupported
+				}
+				binary.BigEndian.PutUint32(payload[p:], uint32(offset))
+			}
+			box.payload = payload
+		case "co64":
+			payload := append([]byte{}, box.payload...)
+			for p := 8; p+8 <= len(payload); p += 8 {
+				binary.BigEndian.PutUint64(payload[p:], uint64(int64(binary.BigEndian.Uint64(payload[p:]))+delta))
+			}
+			box.payload = payload
+		}
+	}
+	return nil
+}
+
+// copyMoof copies a movie fragment, shifting explicit base data offsets by delta
+func copyMoof(r io.ReadSeeker, w io.Writer, box topBox, delta int64) error {
+	data := make([]byte, box.size)
+	if _, err := r.Seek(box.offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+
+	boxes, err := parseBoxes(data)
+	if err != nil {
+		return err
+	}
+	children, err := parseBoxes(boxes[0].payload)
+	if err != nil {
+		return err
+	}
+
+	for i := range children {
+		if children[i].typ != "traf" {
+			continue
+		}
+		traf, err := parseBoxes(children[i].payload)
+		if err != nil {
+			return err
+		}
+		for j := range traf {
+			// tfhd flag 0x000001: base-data-offset present (absolute file offset)
+			p := traf[j].payload
+			if traf[j].typ == "tfhd" && len(p) >= 16 && p[3]&0x01 != 0 {
+				p = append([]byte{}, p...)
+				binary.BigEndian.PutUint64(p[8:], uint64(int64(binary.BigEndian.Uint64(p[8:]))+delta))
+				traf[j].payload = p
+			}
+		}
+		children[i].payload = joinBoxes(traf)
+	}
+
+	_, err = w.Write(makeBox("moof", joinBoxes(children)))
+	return err
+}
+
+// readMP4 returns the standard and freeform tags of the file
+func readMP4(r io.ReadSeeker) (Tags, error) {
+	_, _, moov, err := readMoov(r)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := ilstItems(moov)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags Tags
+	for _, item := range items {
+		if key := mp4Key(item.typ); key != "" {
+			tags = append(tags, Tag{Key: key, Value: nativeValue(item)})
+			continue
+		}
+		if item.typ != "----" {
+			continue
+		}
+		if name, value := freeformName(item); name != "" {
+			tags = append(tags, Tag{Key: name, Value: string(bytes.TrimRight(value, "\x00"))})
+		}
+	}
+
+	return tags, nil
+}
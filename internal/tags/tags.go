@@ -0,0 +1,179 @@
+// Package tags writes and reads metadata tags of FLAC, MP4 and MP3 files.
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/media"
+)
+
+// ErrUnsupported is returned when the file layout can't be tagged safely
+var ErrUnsupported = errors.New("tagging is not supported for this file")
+
+// Standard keys with a native mapping in every container. TRACKNUMBER and DISCNUMBER
+// take a number optionally followed by "/" and the total ("3/12"); DATE takes a date
+// or a year; LYRICS takes plain or LRC text.
+const (
+	KeyTitle       = "TITLE"
+	KeyArtist      = "ARTIST"
+	KeyAlbum       = "ALBUM"
+	KeyAlbumArtist = "ALBUMARTIST"
+	KeyTrackNumber = "TRACKNUMBER"
+	KeyDiscNumber  = "DISCNUMBER"
+	KeyDate        = "DATE"
+	KeyGenre       = "GENRE"
+	KeyLyrics      = "LYRICS"
+)
+
+// KeyLabel is the record label; it is native in Vorbis comments and ID3 (TPUB) and written
+// as a custom field in MP4, which has no label atom
+const KeyLabel = "LABEL"
+
+// KeyAdvisory is the content advisory: "1" for explicit, "2" for clean and "0" for none.
+// It is native in MP4 (rtng) and written as a custom field elsewhere.
+const KeyAdvisory = "ITUNESADVISORY"
+
+// Tag is a single metadata field. Keys follow the Vorbis comment naming (e.g. "TITLE");
+// keys without a native mapping are written as custom fields
+// (freeform atoms in MP4, TXXX frames in MP3). Native fields of MP4 and MP3 hold
+// a single value, so only the first value of a standard key is written there.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Tags is an ordered list of metadata fields
+type Tags []Tag
+
+// Set replaces all values of the key with a single value
+func (t *Tags) Set(key, value string) {
+	t.Delete(key)
+	*t = append(*t, Tag{Key: key, Value: value})
+}
+
+// Get returns the first value of the key
+func (t Tags) Get(key string) (string, bool) {
+	for _, tag := range t {
+		if strings.EqualFold(tag.Key, key) {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// Delete removes all values of the key
+func (t *Tags) Delete(key string) {
+	kept := (*t)[:0]
+	for _, tag := range *t {
+		if !strings.EqualFold(tag.Key, key) {
+			kept = append(kept, tag)
+		}
+	}
+	*t = kept
+}
+
+// has reports whether the key is present
+func (t Tags) has(key string) bool {
+	_, ok := t.Get(key)
+	return ok
+}
+
+// splitNumber parses "n" or "n/total", returning zero for missing or invalid parts
+func splitNumber(value string) (int, int) {
+	number, total, _ := strings.Cut(value, "/")
+	n, _ := strconv.Atoi(strings.TrimSpace(number))
+	t, _ := strconv.Atoi(strings.TrimSpace(total))
+	return max(n, 0), max(t, 0)
+}
+
+// WriteFile writes the tags into the file, replacing existing values of the same keys
+// and keeping everything else. The file is rewritten through a temporary file
+// in the same directory and replaced atomically.
+func WriteFile(path string, tags Tags) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	container, err := media.DetectFile(path)
+	if err != nil {
+		return err
+	}
+
+	var write func(r io.ReadSeeker, w io.Writer, tags Tags) error
+	switch container {
+	case media.ContainerFLAC:
+		write = writeFLAC
+	case media.ContainerMP4:
+		write = writeMP4
+	case media.ContainerMP3:
+		write = writeID3
+	default:
+		return ErrUnsupported
+	}
+
+	return rewrite(path, func(r io.ReadSeeker, w io.Writer) error {
+		return write(r, w, tags)
+	})
+}
+
+// ReadFile reads the tags of a file
+func ReadFile(path string) (Tags, error) {
+	container, err := media.DetectFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch container {
+	case media.ContainerFLAC:
+		return readFLAC(file)
+	case media.ContainerMP4:
+		return readMP4(file)
+	case media.ContainerMP3:
+		return readID3(file)
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// rewrite streams the file through fn into a temporary file and replaces the original
+func rewrite(path string, fn func(r io.ReadSeeker, w io.Writer) error) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tagging-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := fn(src, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if info, err := src.Stat(); err == nil {
+		os.Chmod(tmpPath, info.Mode().Perm())
+	}
+	src.Close()
+
+	return os.Rename(tmpPath, path)
+}
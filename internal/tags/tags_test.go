@@ -0,0 +1,291 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testAudio is the payload that must survive tagging untouched
+var testAudio = bytes.Repeat([]byte{0xAB, 0xCD}, 512)
+
+// flacFile builds a FLAC file with a STREAMINFO block followed by audio frames
+func flacFile() []byte {
+	data := []byte("fLaC")
+	data = append(data, 0x80, 0, 0, 34)
+	data = append(data, make([]byte, 34)...)
+	return append(data, testAudio...)
+}
+
+// box builds an MP4 box
+func box(typ string, payload ...[]byte) []byte {
+	return makeBox(typ, payload...)
+}
+
+// mp4File builds an MP4 file with moov before mdat and a chunk offset pointing at the audio
+func mp4File() []byte {
+	ftyp := box("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	edts := box("edts", box("elst", make([]byte, 8)))
+
+	stco := func(offset uint32) []byte {
+		payload := make([]byte, 12)
+		binary.BigEndian.PutUint32(payload[4:], 1)
+		binary.BigEndian.PutUint32(payload[8:], offset)
+		return box("stco", payload)
+	}
+	moov := func(offset uint32) []byte {
+		return box("moov", box("mvhd", make([]byte, 100)),
+			box("trak", edts, box("mdia", box("minf", box("stbl", stco(offset))))))
+	}
+
+	// The audio starts after ftyp, moov and the mdat header
+	offset := uint32(len(ftyp) + len(moov(0)) + 8)
+	return append(append(ftyp, moov(offset)...), box("mdat", testAudio)...)
+}
+
+// mp3File builds an MP3 file without a tag
+func mp3File() []byte {
+	return append([]byte{0xFF, 0xFB, 0x90, 0x00}, testAudio...)
+}
+
+// mp4ChunkData returns the bytes the first chunk offset of the file points at
+func mp4ChunkData(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	i := bytes.Index(data, []byte("stco"))
+	if i < 0 {
+		t.Fatal("stco box not found")
+	}
+	offset := int(binary.BigEndian.Uint32(data[i+12:]))
+	if offset+len(testAudio) > len(data) {
+		t.Fatalf("chunk offset %d points past the end of the file", offset)
+	}
+	return data[offset : offset+len(testAudio)]
+}
+
+// TestWriteFile checks tagging of each container and that the audio data is kept intact
+func TestWriteFile(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"FLAC", flacFile()},
+		{"MP4", mp4File()},
+		{"MP3", mp3File()},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			first := Tags{{"YANDEX_FADE_IN_START", "0.00"}, {"YANDEX_FADE_OUT_STOP", "181.50"}}
+			if err := WriteFile(path, first); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			// A second write replaces values of the same keys and keeps the others
+			if err := WriteFile(path, Tags{{"YANDEX_FADE_OUT_STOP", "182.00"}, {"COMMENT", "Привет"}}); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			got, err := ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+
+			expected := map[string]string{
+				"YANDEX_FADE_IN_START": "0.00",
+				"YANDEX_FADE_OUT_STOP": "182.00",
+				"COMMENT":              "Привет",
+			}
+			if len(got) != len(expected) {
+				t.Errorf("ReadFile() = %v, want %d tags", got, len(expected))
+			}
+			for key, value := range expected {
+				if v, _ := got.Get(key); v != value {
+					t.Errorf("Tag %s = %q, want %q", key, v, value)
+				}
+			}
+
+			data, _ := os.ReadFile(path)
+			if tt.name == "MP4" {
+				if !bytes.Equal(mp4ChunkData(t, data), testAudio) {
+					t.Error("Chunk offset doesn't point at the audio data after tagging")
+				}
+				if !bytes.Contains(data, []byte("elst")) {
+					t.Error("Edit list was dropped")
+				}
+			} else if !bytes.HasSuffix(data, testAudio) {
+				t.Error("Audio data was modified")
+			}
+		})
+	}
+}
+
+// TestWriteFileStandard checks that standard keys round-trip through the native fields
+func TestWriteFileStandard(t *testing.T) {
+	// An empty ID3v2.3 tag in front of the audio
+	mp3v3 := append([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 0}, mp3File()...)
+
+	// Test cases
+	tests := []struct {
+		name   string
+		data   []byte
+		native string
+		date   string
+	}{
+		{"FLAC", flacFile(), "TITLE=", "2019-05-17"},
+		{"MP4", mp4File(), "\xa9nam", "2019-05-17"},
+		{"MP3 ID3v2.4", mp3File(), "TIT2", "2019-05-17"},
+		{"MP3 ID3v2.3", mp3v3, "TIT2", "2019"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			tags := Tags{
+				{KeyTitle, "Звезда по имени Солнце"},
+				{KeyArtist, "Кино"},
+				{KeyAlbum, "Звезда по имени Солнце"},
+				{KeyAlbumArtist, "Кино"},
+				{KeyTrackNumber, "3/10"},
+				{KeyDiscNumber, "1"},
+				{KeyDate, "2019-05-17"},
+				{KeyGenre, "rock"},
+				{KeyLabel, "Moroz Records"},
+				{KeyAdvisory, "1"},
+				{KeyLyrics, "[00:12.50]Белый снег, серый лёд\n[00:17.80]На растрескавшейся земле"},
+				{"YANDEX_TRACK_ID", "64551568"},
+			}
+			if err := WriteFile(path, tags); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			// Rewriting must replace the native fields rather than duplicate them
+			if err := WriteFile(path, Tags{{KeyGenre, "pop"}}); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			got, err := ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if len(got) != len(tags) {
+				t.Errorf("ReadFile() = %v, want %d tags", got, len(tags))
+			}
+
+			tags.Set(KeyGenre, "pop")
+			tags.Set(KeyDate, tt.date)
+			for _, tag := range tags {
+				if v, _ := got.Get(tag.Key); v != tag.Value {
+					t.Errorf("Tag %s = %q, want %q", tag.Key, v, tag.Value)
+				}
+			}
+
+			data, _ := os.ReadFile(path)
+			if !bytes.Contains(data, []byte(tt.native)) {
+				t.Errorf("File has no native %q field", tt.native)
+			}
+		})
+	}
+}
+
+// TestWriteFileUnsupported checks that unknown content is left alone
+func TestWriteFileUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track")
+	os.WriteFile(path, []byte("not audio at all"), 0644)
+
+	if err := WriteFile(path, Tags{{"TITLE", "x"}}); err != ErrUnsupported {
+		t.Errorf("WriteFile() error = %v, want ErrUnsupported", err)
+	}
+}
+
+// aacFile builds an AAC MP4 file of 88200 samples at 44.1 kHz with 2112 samples of
+// priming, 824 of padding and an edit list describing them
+func aacFile() []byte {
+	u32 := func(values ...uint32) []byte {
+		buf := make([]byte, 4*len(values))
+		for i, v := range values {
+			binary.BigEndian.PutUint32(buf[4*i:], v)
+		}
+		return buf
+	}
+
+	mvhd := box("mvhd", u32(0, 0, 0, 1000, 2000), make([]byte, 80))
+	elst := box("elst", u32(0, 1, 2000, 2112, 0x00010000))
+	mdhd := box("mdhd", u32(0, 0, 0, 44100, 91136, 0))
+	stsd := box("stsd", u32(0, 1), box("mp4a", make([]byte, 28)))
+	stts := box("stts", u32(0, 1, 89, 1024))
+	trak := box("trak", box("edts", elst), box("mdia", mdhd, box("minf", box("stbl", stsd, stts))))
+
+	ftyp := box("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	return append(append(ftyp, box("moov", mvhd, trak)...), box("mdat", testAudio)...)
+}
+
+// TestReadGapless checks the gapless info derived from the edit list and that tagging
+// keeps it intact
+func TestReadGapless(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name   string
+		data   []byte
+		wantOK bool
+	}{
+		{"AAC with edit list", aacFile(), true},
+		{"MP4 without edit", mp4File(), false},
+		{"FLAC", flacFile(), false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			gapless, ok, err := ReadGapless(path)
+			if err != nil || ok != tt.wantOK {
+				t.Fatalf("ReadGapless() = %v, %v, want ok %v", ok, err, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+
+			want := Gapless{Delay: 2112, Padding: 824, Samples: 88200}
+			if gapless != want {
+				t.Errorf("ReadGapless() = %+v, want %+v", gapless, want)
+			}
+			smpb := " 00000000 00000840 00000338 0000000000015888" + strings.Repeat(" 00000000", 8)
+			if gapless.ITunSMPB() != smpb {
+				t.Errorf("ITunSMPB() = %q, want %q", gapless.ITunSMPB(), smpb)
+			}
+
+			if err := WriteFile(path, Tags{{KeyITunSMPB, smpb}}); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			got, err := ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if v, _ := got.Get(KeyITunSMPB); v != smpb {
+				t.Errorf("iTunSMPB = %q, want %q", v, smpb)
+			}
+			if again, _, _ := ReadGapless(path); again != want {
+				t.Errorf("ReadGapless() after tagging = %+v, want %+v", again, want)
+			}
+		})
+	}
+}
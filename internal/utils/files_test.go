@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteFileAtomic checks that files are created or replaced whole, with no temporary
+// files left behind
+func TestWriteFileAtomic(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		path     string
+		existing string
+	}{
+		{"New file", "state.json", ""},
+		{"Replaced file", "state.json", "old state"},
+		{"Missing directory", "nested/dir/state.json", ""},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.path)
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0600); err != nil {
+					t.Fatalf("Error creating existing file: %v", err)
+				}
+			}
+
+			if err := WriteFileAtomic(path, []byte("new state"), 0644); err != nil {
+				t.Fatalf("WriteFileAtomic() error = %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil || string(data) != "new state" {
+				t.Errorf("File content = %q (error %v), want %q", data, err, "new state")
+			}
+			if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0644 {
+				t.Errorf("File mode = %v, want 0644", info.Mode().Perm())
+			}
+			if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+				t.Errorf("Directory holds %d entries, want only the file", len(entries))
+			}
+		})
+	}
+}
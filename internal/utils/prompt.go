@@ -0,0 +1,82 @@
+// Package utils provides helper functions.
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// NonInteractiveError is returned when user input is required in non-interactive mode
+type NonInteractiveError struct {
+	// Prompt is the question that could not be asked
+	Prompt string
+
+	// Instructions explain how to provide the value without a prompt
+	Instructions string
+}
+
+// Error implements the error interface
+func (e *NonInteractiveError) Error() string {
+	msg := fmt.Sprintf("input required (%s) but running in non-interactive mode", strings.TrimSpace(e.Prompt))
+	if e.Instructions != "" {
+		msg += ": " + e.Instructions
+	}
+	return msg
+}
+
+var (
+	promptMu       sync.Mutex
+	nonInteractive bool
+	stdinReader    *bufio.Reader
+)
+
+// SetNonInteractive enables or disables non-interactive mode.
+// In non-interactive mode Prompt never reads from stdin.
+func SetNonInteractive(value bool) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	nonInteractive = value
+}
+
+// IsInteractive reports whether prompting the user is allowed
+func IsInteractive() bool {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	return !nonInteractive
+}
+
+// StdinIsTerminal reports whether standard input is attached to a terminal
+func StdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Prompt shows the prompt using show and reads a line from stdin.
+// All interactive input must go through this function, so unattended runs
+// fail with a NonInteractiveError instead of blocking forever.
+func Prompt(prompt, instructions string, show func(format string, v ...interface{})) (string, error) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	if nonInteractive {
+		return "", &NonInteractiveError{Prompt: prompt, Instructions: instructions}
+	}
+
+	if stdinReader == nil {
+		stdinReader = bufio.NewReader(os.Stdin)
+	}
+
+	show("%s", prompt)
+	input, err := stdinReader.ReadString('\n')
+	if err != nil && input == "" {
+		return "", fmt.Errorf("error reading input: %w", err)
+	}
+
+	return strings.TrimSpace(input), nil
+}
@@ -0,0 +1,39 @@
+// Package utils provides helper functions.
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseByteSize parses a human-readable size such as 512k, 64M or 2G.
+// Suffixes are binary (k = 1024) and case-insensitive; a trailing "b"/"ib" is allowed.
+// A plain number is interpreted as bytes.
+func ParseByteSize(value string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "b"), "i")
+	if s == "" {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	multiplier := int64(1)
+	switch s[len(s)-1] {
+	case 'k':
+		multiplier = 1 << 10
+	case 'm':
+		multiplier = 1 << 20
+	case 'g':
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		s = s[:len(s)-1]
+	}
+
+	number, err := strconv.ParseFloat(s, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	return int64(number * float64(multiplier)), nil
+}
@@ -6,23 +6,73 @@ import (
 	"strings"
 )
 
+// trackURLPattern matches track URLs, optionally with the album segment
+var trackURLPattern = regexp.MustCompile(`(?:/album/(\d+))?/track/(\d+)`)
+
+// albumURLPattern matches album URLs without a track segment
+var albumURLPattern = regexp.MustCompile(`/album/(\d+)/?(?:[?#].*)?$`)
+
+// ExtractAlbumID extracts the album ID from an album URL such as
+// https://music.yandex.ru/album/10376938. Track URLs are not album inputs.
+func ExtractAlbumID(input string) (string, bool) {
+	if !strings.Contains(input, "music.yandex") {
+		return "", false
+	}
+
+	if matches := albumURLPattern.FindStringSubmatch(input); len(matches) > 1 {
+		return matches[1], true
+	}
+	return "", false
+}
+
+// artistURLPattern matches artist URLs, including their tabs such as /artist/{id}/tracks
+var artistURLPattern = regexp.MustCompile(`/artist/(\d+)(?:[/?#]|$)`)
+
+// ExtractArtistID extracts the artist ID from an artist URL such as
+// https://music.yandex.ru/artist/41191
+func ExtractArtistID(input string) (string, bool) {
+	if !strings.Contains(input, "music.yandex") {
+		return "", false
+	}
+
+	if matches := artistURLPattern.FindStringSubmatch(input); len(matches) > 1 {
+		return matches[1], true
+	}
+	return "", false
+}
+
+// compositeIDPattern matches "trackId:albumId" identifiers
+var compositeIDPattern = regexp.MustCompile(`^(\d+):(\d+)$`)
+
+// SplitTrackID splits a "trackId:albumId" identifier, as used by likes, playlists and
+// some share links, into its parts. Other IDs are returned as is with an empty album ID.
+func SplitTrackID(id string) (trackID, albumID string) {
+	if matches := compositeIDPattern.FindStringSubmatch(id); matches != nil {
+		return matches[1], matches[2]
+	}
+	return id, ""
+}
+
 // ExtractTrackID extracts track ID from different formats:
 // - Full URL: https://music.yandex.ru/album/10376938/track/64551568
+// - URL without the album: https://music.yandex.com/track/64551568
 // - URL with params: https://music.yandex.ru/album/10376938/track/64551568?utm_source=desktop
 // - Just track ID: 64551568
+// - Track ID with album ID: 64551568:10376938
 func ExtractTrackID(input string) string {
 	// If input is already just a track ID (only digits)
 	if matched, _ := regexp.MatchString(`^\d+$`, input); matched {
 		return input
 	}
+	if trackID, albumID := SplitTrackID(input); albumID != "" {
+		return trackID
+	}
 
 	// Check if it's a Yandex Music URL
 	if strings.Contains(input, "music.yandex") {
 		// Extract track ID from URL
-		re := regexp.MustCompile(`/track/(\d+)`)
-		matches := re.FindStringSubmatch(input)
-		if len(matches) > 1 {
-			return matches[1]
+		if matches := trackURLPattern.FindStringSubmatch(input); len(matches) > 2 {
+			return matches[2]
 		}
 	}
 
@@ -30,3 +80,40 @@ func ExtractTrackID(input string) string {
 	// (this will likely fail later, but we're being lenient)
 	return input
 }
+
+// ExtractTrackRef extracts the track ID and, when the input is an
+// /album/{albumId}/track/{trackId} URL or a "trackId:albumId" ID, the album ID
+// (empty otherwise)
+func ExtractTrackRef(input string) (trackID, albumID string) {
+	if strings.Contains(input, "music.yandex") {
+		if matches := trackURLPattern.FindStringSubmatch(input); len(matches) > 2 {
+			return matches[2], matches[1]
+		}
+	}
+	if trackID, albumID := SplitTrackID(input); albumID != "" {
+		return trackID, albumID
+	}
+
+	return ExtractTrackID(input), ""
+}
+
+// playlistURLPattern matches playlist URLs: /users/{owner}/playlists/{kind}
+var playlistURLPattern = regexp.MustCompile(`/users/([^/?#]+)/playlists/(\d+)`)
+
+// playlistIDPattern matches "owner:kind" playlist identifiers
+var playlistIDPattern = regexp.MustCompile(`^([^:/]+):(\d+)$`)
+
+// ExtractPlaylistRef extracts the owner (UID or login) and kind of a playlist from a URL
+// such as https://music.yandex.ru/users/test/playlists/3 or an "owner:kind" identifier
+func ExtractPlaylistRef(input string) (owner, kind string, ok bool) {
+	if strings.Contains(input, "music.yandex") {
+		if matches := playlistURLPattern.FindStringSubmatch(input); matches != nil {
+			return matches[1], matches[2], true
+		}
+		return "", "", false
+	}
+	if matches := playlistIDPattern.FindStringSubmatch(input); matches != nil {
+		return matches[1], matches[2], true
+	}
+	return "", "", false
+}
@@ -0,0 +1,93 @@
+package utils
+
+import "testing"
+
+// TestExtractTrackRef checks track and album IDs extracted from CLI inputs
+func TestExtractTrackRef(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name    string
+		input   string
+		trackID string
+		albumID string
+	}{
+		{"Plain ID", "64551568", "64551568", ""},
+		{"Composite ID", "64551568:10376938", "64551568", "10376938"},
+		{"Track URL", "https://music.yandex.ru/track/64551568", "64551568", ""},
+		{"Track URL on .com", "https://music.yandex.com/track/64551568?utm_medium=copy_link", "64551568", ""},
+		{"Album track URL", "https://music.yandex.ru/album/10376938/track/64551568?utm_source=desktop",
+			"64551568", "10376938"},
+		{"Malformed composite ID", "64551568:", "64551568:", ""},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trackID, albumID := ExtractTrackRef(tt.input)
+			if trackID != tt.trackID || albumID != tt.albumID {
+				t.Errorf("ExtractTrackRef(%q) = %q, %q, want %q, %q",
+					tt.input, trackID, albumID, tt.trackID, tt.albumID)
+			}
+			if got := ExtractTrackID(tt.input); got != tt.trackID {
+				t.Errorf("ExtractTrackID(%q) = %q, want %q", tt.input, got, tt.trackID)
+			}
+		})
+	}
+}
+
+// TestExtractPlaylistRef checks playlist owners and kinds extracted from CLI inputs
+func TestExtractPlaylistRef(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name  string
+		input string
+		owner string
+		kind  string
+		ok    bool
+	}{
+		{"Playlist URL", "https://music.yandex.ru/users/test/playlists/3", "test", "3", true},
+		{"Playlist URL with params", "https://music.yandex.ru/users/test.user/playlists/1003?utm_source=web", "test.user", "1003", true},
+		{"Owner and kind", "42:3", "42", "3", true},
+		{"Login and kind", "test:3", "test", "3", true},
+		{"Album URL", "https://music.yandex.ru/album/10376938", "", "", false},
+		{"Plain ID", "3", "", "", false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, kind, ok := ExtractPlaylistRef(tt.input)
+			if owner != tt.owner || kind != tt.kind || ok != tt.ok {
+				t.Errorf("ExtractPlaylistRef(%q) = %q, %q, %v, want %q, %q, %v",
+					tt.input, owner, kind, ok, tt.owner, tt.kind, tt.ok)
+			}
+		})
+	}
+}
+
+// TestExtractArtistID checks artist IDs extracted from artist URLs
+func TestExtractArtistID(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name  string
+		input string
+		id    string
+		ok    bool
+	}{
+		{"Artist URL", "https://music.yandex.ru/artist/41191", "41191", true},
+		{"Artist tab URL", "https://music.yandex.com/artist/41191/tracks?utm_source=web", "41191", true},
+		{"Track URL", "https://music.yandex.ru/track/64551568", "", false},
+		{"Other site", "https://example.com/artist/41191", "", false},
+		{"Plain ID", "41191", "", false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := ExtractArtistID(tt.input)
+			if id != tt.id || ok != tt.ok {
+				t.Errorf("ExtractArtistID(%q) = %q, %v, want %q, %v", tt.input, id, ok, tt.id, tt.ok)
+			}
+		})
+	}
+}
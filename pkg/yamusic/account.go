@@ -0,0 +1,67 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// AccountStatus describes the account the token belongs to and its subscription
+type AccountStatus = api.AccountStatus
+
+// ErrNoSubscription is reported when a download fails for an account without an active subscription
+var ErrNoSubscription = errors.New("the account has no active subscription, lossless and full tracks are unavailable")
+
+// GetAccountStatus retrieves the status of the account the token belongs to.
+// It is a cheap way to check that the token is valid.
+func (c *Client) GetAccountStatus() (*AccountStatus, error) {
+	return c.GetAccountStatusContext(context.Background())
+}
+
+// GetAccountStatusContext is GetAccountStatus with cancellation through ctx. Unlike the
+// subscription check of downloads, the status is requested anew on every call.
+func (c *Client) GetAccountStatusContext(ctx context.Context) (*AccountStatus, error) {
+	return c.getAccountStatus(ctx, c.logger)
+}
+
+// getAccountStatus retrieves the account status logging to log
+func (c *Client) getAccountStatus(ctx context.Context, log *logger.Logger) (*AccountStatus, error) {
+	log.Debug("Getting account status")
+
+	data, err := c.apiGet(ctx, "/account/status", "/account/status", log)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.AccountStatusResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	status := &response.Result
+	if status.Account.UID == "" {
+		return nil, fmt.Errorf("token is not associated with an account")
+	}
+
+	log.Debug("Account %s (uid %s), plus: %v", status.Account.Login, status.Account.UID, status.Plus.HasPlus)
+	return status, nil
+}
+
+// hasSubscription reports whether the account has an active subscription. It is checked
+// once per client; when the status can't be retrieved the account is assumed subscribed.
+func (c *Client) hasSubscription(ctx context.Context, log *logger.Logger) bool {
+	c.subscriptionOnce.Do(func() {
+		status, err := c.getAccountStatus(ctx, log)
+		if err != nil {
+			log.Debug("Subscription check inconclusive: %v", err)
+			c.subscribed = true
+			return
+		}
+		c.subscribed = status.Plus.HasPlus
+	})
+	return c.subscribed
+}
@@ -0,0 +1,68 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// TestGetAccountStatus checks parsing of the account status
+func TestGetAccountStatus(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/account/status": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"account":{"uid":42,"login":"test","region":225,"serviceAvailable":true},` +
+				`"permissions":{"values":["landing-play"]},"subscription":{"hadAnySubscription":true},` +
+				`"plus":{"hasPlus":true}}}`))
+		},
+	})
+
+	status, err := client.GetAccountStatus()
+	if err != nil {
+		t.Fatalf("GetAccountStatus() error = %v", err)
+	}
+	if status.Account.UID != "42" || status.Account.Login != "test" || status.Account.Region != 225 {
+		t.Errorf("Account = %+v", status.Account)
+	}
+	if !status.Plus.HasPlus || !status.Subscription.HadAnySubscription {
+		t.Errorf("Plus = %+v, subscription = %+v, want an active subscription", status.Plus, status.Subscription)
+	}
+}
+
+// TestNoSubscription checks that download info failures of accounts without a subscription
+// are reported as ErrNoSubscription
+func TestNoSubscription(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name           string
+		hasPlus        bool
+		noSubscription bool
+	}{
+		{"Subscribed", true, false},
+		{"Not subscribed", false, true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{
+				"/account/status": func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprintf(w, `{"result":{"account":{"uid":42},"plus":{"hasPlus":%v}}}`, tt.hasPlus)
+				},
+				"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusForbidden)
+				},
+			})
+
+			_, err := client.GetDownloadInfo("1", api.QualityLossless)
+			if err == nil {
+				t.Fatal("GetDownloadInfo() succeeded, want an error")
+			}
+			if got := errors.Is(err, ErrNoSubscription); got != tt.noSubscription {
+				t.Errorf("GetDownloadInfo() error = %v, want ErrNoSubscription %v", err, tt.noSubscription)
+			}
+		})
+	}
+}
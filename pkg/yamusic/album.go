@@ -0,0 +1,180 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// ErrIncompleteAlbum is returned when a complete album is required but some tracks are unavailable
+var ErrIncompleteAlbum = errors.New("album is only partially available")
+
+// AlbumTrack is a track with its original position in the album
+type AlbumTrack struct {
+	Track *TrackInfo
+
+	// Volume and Position are 1-based; gaps left by unavailable tracks are kept
+	Volume   int
+	Position int
+}
+
+// AlbumTrackResult is the outcome of downloading one album track
+type AlbumTrackResult struct {
+	AlbumTrack
+	Path string
+	Err  error
+}
+
+// GetAlbum retrieves album metadata with the tracks grouped by volume. Multi-disc releases
+// keep one volume per disc; unavailable tracks stay in place with Available unset.
+func (c *Client) GetAlbum(albumID string) (*Album, error) {
+	return c.GetAlbumContext(context.Background(), albumID)
+}
+
+// GetAlbumContext retrieves album metadata; the request is cancelled with ctx
+func (c *Client) GetAlbumContext(ctx context.Context, albumID string) (*Album, error) {
+	log := c.logger.WithField("album_id", albumID)
+	log.Debug("Getting album metadata")
+
+	if album, ok := c.cachedAlbum(albumID); ok {
+		log.Debug("Album metadata served from cache")
+		return album, nil
+	}
+
+	path := fmt.Sprintf("/albums/%s/with-tracks", url.PathEscape(albumID))
+	responseData, err := c.apiGet(ctx, path, "/albums/with-tracks", log)
+	if err != nil {
+		return nil, err
+	}
+
+	var albumResponse api.AlbumResponse
+	if err := json.Unmarshal(responseData, &albumResponse); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	album := &albumResponse.Result
+	if album.ID == "" {
+		return nil, fmt.Errorf("no album information found in API response")
+	}
+
+	c.albumLayouts.Store(album.ID.String(), newAlbumLayout(album))
+	c.storeAlbum(albumID, album)
+
+	log.Debug("Album title: %s, volumes: %d", album.Title, len(album.Volumes))
+	return album, nil
+}
+
+// AlbumTracks splits the album tracks into available and unavailable ones,
+// keeping their original volume and position numbers
+func AlbumTracks(album *Album) (available, unavailable []AlbumTrack) {
+	for v, volume := range album.Volumes {
+		for i := range volume {
+			track := AlbumTrack{Track: &volume[i], Volume: v + 1, Position: i + 1}
+			if volume[i].Available {
+				available = append(available, track)
+			} else {
+				unavailable = append(unavailable, track)
+			}
+		}
+	}
+	return available, unavailable
+}
+
+// SetRequireComplete makes album downloads fail with ErrIncompleteAlbum instead of
+// downloading the available subset of a partially available album
+func (c *Client) SetRequireComplete(require bool) {
+	c.requireComplete = require
+}
+
+// CheckAlbumAvailability reports unavailable tracks of an album up front and returns
+// ErrIncompleteAlbum when the client requires complete albums. Tracks excluded by the
+// track filter (see SetTrackFilter) are not counted.
+func (c *Client) CheckAlbumAvailability(album *Album, log *logger.Logger) error {
+	available, unavailable := AlbumTracks(album)
+	available, _ = c.FilterAlbumTracks(album, available)
+	unavailable, _ = c.FilterAlbumTracks(album, unavailable)
+	if len(unavailable) == 0 {
+		return nil
+	}
+
+	total := len(available) + len(unavailable)
+
+	log.Warn("Album %q is partially available: %d of %d tracks unavailable", album.Title, len(unavailable), total)
+	for _, track := range unavailable {
+		log.Warn("  %d-%02d %s [%s]", track.Volume, track.Position, track.Track.Title, track.Track.ID)
+	}
+
+	if c.requireComplete {
+		return fmt.Errorf("%w: %d of %d tracks unavailable", ErrIncompleteAlbum, len(unavailable), total)
+	}
+	return nil
+}
+
+// DownloadAlbum downloads all available tracks of an album. Unavailable tracks are
+// reported up front and returned with an error in their original place, so the
+// results keep the album numbering; so are tracks excluded by the track filter, with
+// ErrFiltered. Individual failures don't stop the download.
+// The album cover and metadata file are saved once when enabled with SetSaveCover and
+// SetSidecarFormat.
+func (c *Client) DownloadAlbum(albumID string, quality AudioQuality, outputDir string) ([]AlbumTrackResult, error) {
+	ctx := context.Background()
+	log := c.logger.WithField("album_id", albumID)
+
+	album, err := c.GetAlbumContext(ctx, albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.CheckAlbumAvailability(album, log); err != nil {
+		return nil, err
+	}
+
+	// The cover is shared by all tracks; a missing cover doesn't fail the album
+	if c.coverFile != "" {
+		if _, err := c.DownloadAlbumCover(album, "", c.AlbumDir(album, outputDir)); err != nil {
+			log.Warn("Cover not saved: %v", err)
+		}
+	}
+	if _, err := c.WriteAlbumSidecar(album, c.AlbumDir(album, outputDir)); err != nil {
+		log.Warn("Album metadata file not saved: %v", err)
+	}
+
+	// Share the metadata requests; tracks missing from the response are fetched one by one
+	available, _ := AlbumTracks(album)
+	available, _ = c.FilterAlbumTracks(album, available)
+	ids := make([]string, len(available))
+	for i, track := range available {
+		ids[i] = track.Track.ID
+	}
+	infos, err := c.getTracks(ctx, ids)
+	if err != nil {
+		log.Warn("Error getting metadata for %d tracks, falling back to per-track requests: %v", len(ids), err)
+	}
+
+	var results []AlbumTrackResult
+	for v, volume := range album.Volumes {
+		for i := range volume {
+			result := AlbumTrackResult{AlbumTrack: AlbumTrack{Track: &volume[i], Volume: v + 1, Position: i + 1}}
+			if reason := c.trackFilter.exclusion(album, result.AlbumTrack); reason != "" {
+				log.Info("Skipping %d-%02d %s: %s", result.Volume, result.Position, volume[i].Title, reason)
+				result.Err = fmt.Errorf("%w: %s", ErrFiltered, reason)
+			} else if volume[i].Available {
+				download, err := c.downloadTrack(ctx, volume[i].ID, albumID, infos[volume[i].ID], quality, outputDir, c.logger)
+				if errors.Is(err, errExists) {
+					err = nil
+				}
+				result.Path, result.Err = download.Path, err
+			} else {
+				result.Err = fmt.Errorf("track is unavailable")
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,49 @@
+package yamusic
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestGetAlbum checks that volumes and availability flags are preserved
+func TestGetAlbum(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/albums/10376938/with-tracks": serveFixture(t, "album.json"),
+	})
+
+	album, err := client.GetAlbum("10376938")
+	if err != nil {
+		t.Fatalf("GetAlbum() error = %v", err)
+	}
+	if album.Title != "Акустический альбом" || len(album.Volumes) != 2 {
+		t.Fatalf("GetAlbum() = %q with %d volumes, want 2 volumes", album.Title, len(album.Volumes))
+	}
+
+	available, unavailable := AlbumTracks(album)
+
+	// Test cases
+	tests := []struct {
+		name     string
+		tracks   []AlbumTrack
+		expected []string // "volume-position id"
+	}{
+		{"Available", available, []string{"1-1 64551564", "2-1 64551566", "2-2 64551567"}},
+		{"Unavailable", unavailable, []string{"1-2 64551565"}},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.tracks) != len(tt.expected) {
+				t.Fatalf("Got %d tracks, want %d", len(tt.tracks), len(tt.expected))
+			}
+			for i, track := range tt.tracks {
+				got := fmt.Sprintf("%d-%d %s", track.Volume, track.Position, track.Track.ID)
+				if got != tt.expected[i] {
+					t.Errorf("Track %d = %s, want %s", i, got, tt.expected[i])
+				}
+			}
+		})
+	}
+}
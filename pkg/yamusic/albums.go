@@ -0,0 +1,130 @@
+package yamusic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// AlbumPolicy selects the album used for naming when a track appears on several albums
+type AlbumPolicy string
+
+const (
+	// AlbumAll - keep all albums (names list every album of the track)
+	AlbumAll AlbumPolicy = ""
+
+	// AlbumFirst - the album the API lists first
+	AlbumFirst AlbumPolicy = "first"
+
+	// AlbumOriginal - the album with the earliest release date
+	AlbumOriginal AlbumPolicy = "original"
+
+	// AlbumLatest - the album with the latest release date
+	AlbumLatest AlbumPolicy = "latest"
+)
+
+// ParseAlbumPolicy parses an album policy name
+func ParseAlbumPolicy(value string) (AlbumPolicy, error) {
+	switch policy := AlbumPolicy(value); policy {
+	case AlbumAll, AlbumFirst, AlbumOriginal, AlbumLatest:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid album policy %q (valid values: original, latest, first)", value)
+	}
+}
+
+// SetAlbumPolicy sets the policy for picking an album when a track appears on several albums
+// and the input doesn't specify one
+func (c *Client) SetAlbumPolicy(policy AlbumPolicy) {
+	c.albumPolicy = policy
+}
+
+// selectAlbum picks the album of a track: the album with albumID when it is given and present,
+// otherwise the one chosen by the policy. It returns nil when all albums should be kept.
+func selectAlbum(trackInfo *api.TrackInfo, albumID string, policy AlbumPolicy) *api.Album {
+	albums := trackInfo.Albums
+	if len(albums) == 0 {
+		return nil
+	}
+
+	if albumID != "" {
+		for i := range albums {
+			if albums[i].ID.String() == albumID {
+				return &albums[i]
+			}
+		}
+	}
+
+	switch policy {
+	case AlbumFirst:
+		return &albums[0]
+	case AlbumOriginal, AlbumLatest:
+		best := -1
+		var bestDate time.Time
+		for i := range albums {
+			date, ok := albumReleaseDate(&albums[i])
+			if !ok {
+				continue
+			}
+			if best < 0 ||
+				(policy == AlbumOriginal && date.Before(bestDate)) ||
+				(policy == AlbumLatest && date.After(bestDate)) {
+				best, bestDate = i, date
+			}
+		}
+		// Albums without dates can't be compared; fall back to the API order
+		if best < 0 {
+			best = 0
+		}
+		return &albums[best]
+	default:
+		return nil
+	}
+}
+
+// albumReleaseDate returns the release date of an album, falling back to the release year
+func albumReleaseDate(album *api.Album) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if date, err := time.Parse(layout, album.ReleaseDate); err == nil {
+			return date, true
+		}
+	}
+	if album.Year > 0 {
+		return time.Date(album.Year, time.January, 1, 0, 0, 0, 0, time.UTC), true
+	}
+	return time.Time{}, false
+}
+
+// albumYear returns the release year of an album, derived from the release date when the
+// year is missing; zero when neither is known
+func albumYear(album *api.Album) int {
+	if album.Year > 0 {
+		return album.Year
+	}
+	if date, ok := albumReleaseDate(album); ok {
+		return date.Year()
+	}
+	return 0
+}
+
+// albumLabel returns the names of the labels of an album separated by commas
+func albumLabel(album *api.Album) string {
+	names := make([]string, 0, len(album.Labels))
+	for _, label := range album.Labels {
+		if label.Name != "" {
+			names = append(names, label.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// singleAlbum returns the album describing a track in tags and results: the selected one,
+// or the first one when all albums are kept
+func singleAlbum(trackInfo *api.TrackInfo, albumID string, policy AlbumPolicy) *api.Album {
+	if policy == AlbumAll {
+		policy = AlbumFirst
+	}
+	return selectAlbum(trackInfo, albumID, policy)
+}
@@ -0,0 +1,76 @@
+package yamusic
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// TestSelectAlbum checks album selection for each policy and for an explicit album ID
+func TestSelectAlbum(t *testing.T) {
+	trackInfo := &api.TrackInfo{
+		Albums: []api.Album{
+			{ID: "3", Title: "Greatest Hits 2020", ReleaseDate: "2020-06-01T00:00:00+03:00"},
+			{ID: "1", Title: "Original", Year: 1999},
+			{ID: "2", Title: "Undated"},
+			{ID: "4", Title: "Deluxe", ReleaseDate: "2021-02-01T00:00:00+03:00"},
+		},
+	}
+
+	// Test cases
+	tests := []struct {
+		name     string
+		albumID  string
+		policy   AlbumPolicy
+		expected string
+	}{
+		{"All albums", "", AlbumAll, ""},
+		{"First", "", AlbumFirst, "Greatest Hits 2020"},
+		{"Original", "", AlbumOriginal, "Original"},
+		{"Latest", "", AlbumLatest, "Deluxe"},
+		{"Album ID overrides policy", "2", AlbumOriginal, "Undated"},
+		{"Album ID without policy", "4", AlbumAll, "Deluxe"},
+		{"Unknown album ID falls back to policy", "99", AlbumFirst, "Greatest Hits 2020"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			album := selectAlbum(trackInfo, tt.albumID, tt.policy)
+			got := ""
+			if album != nil {
+				got = album.Title
+			}
+			if got != tt.expected {
+				t.Errorf("selectAlbum() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	// Without comparable dates the API order is kept
+	undated := &api.TrackInfo{Albums: []api.Album{{Title: "A"}, {Title: "B"}}}
+	if album := selectAlbum(undated, "", AlbumLatest); album == nil || album.Title != "A" {
+		t.Errorf("selectAlbum() = %v, want album A", album)
+	}
+}
+
+// TestDownloadCompositeID checks that "trackId:albumId" IDs are downloaded by the track ID
+// and named after the album from the ID
+func TestDownloadCompositeID(t *testing.T) {
+	client := newDownloadServer(t, "64551568", []byte("fLaC composite ID test audio"))
+	outputDir := t.TempDir()
+
+	result := client.DownloadTracks([]string{"64551568:2843017"}, QualityHigh, outputDir, 1)[0]
+	if result.Err != nil {
+		t.Fatalf("DownloadTracks() error = %v", result.Err)
+	}
+	if result.ID != "64551568" || result.AlbumID != "2843017" {
+		t.Errorf("TrackRef = %+v, want {ID: 64551568, AlbumID: 2843017}", result.TrackRef)
+	}
+
+	expected := "Кукла колдуна - Король и Шут & Князь (Лучшее_ Ноты, рифмы) [64551568].flac"
+	if got := filepath.Base(result.Path); got != expected {
+		t.Errorf("File name = %q, want %q", got, expected)
+	}
+}
@@ -0,0 +1,131 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// DefaultArtistAlbumsPageSize is the page size used by GetAllArtistAlbums
+const DefaultArtistAlbumsPageSize = 50
+
+type (
+	// ArtistInfo is an artist with an overview of their releases
+	ArtistInfo = api.ArtistInfo
+
+	// ArtistAlbums is a page of albums of an artist with the pager
+	ArtistAlbums = api.ArtistAlbums
+)
+
+// AlbumFilter selects the albums of an artist discography. The zero value keeps all albums.
+type AlbumFilter struct {
+	// NoCompilations drops compilations and collections of various artists
+	NoCompilations bool
+
+	// NoSingles drops singles
+	NoSingles bool
+}
+
+// Match reports whether the album passes the filter
+func (f AlbumFilter) Match(album *Album) bool {
+	if f.NoCompilations && (album.Type == "compilation" || album.MetaType == "compilation") {
+		return false
+	}
+	if f.NoSingles && album.Type == "single" {
+		return false
+	}
+	return true
+}
+
+// FilterAlbums returns the albums that pass the filter, keeping their order
+func FilterAlbums(albums []Album, filter AlbumFilter) []Album {
+	filtered := make([]Album, 0, len(albums))
+	for i := range albums {
+		if filter.Match(&albums[i]) {
+			filtered = append(filtered, albums[i])
+		}
+	}
+	return filtered
+}
+
+// GetArtist retrieves artist metadata with the release counts and an overview of the albums
+func (c *Client) GetArtist(artistID string) (*ArtistInfo, error) {
+	log := c.logger.WithField("artist_id", artistID)
+	log.Debug("Getting artist metadata")
+
+	path := fmt.Sprintf("/artists/%s", url.PathEscape(artistID))
+	responseData, err := c.apiGet(context.Background(), path, "/artists", log)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.ArtistResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	artist := &response.Result
+	if artist.Artist.ID == "" {
+		return nil, fmt.Errorf("no artist information found in API response")
+	}
+
+	log.Debug("Artist name: %s", artist.Artist.Name)
+	return artist, nil
+}
+
+// GetArtistAlbums retrieves one page of the artist's own albums (not the ones they only
+// appear on), newest first. page is zero-based; Pager.Total tells when to stop.
+func (c *Client) GetArtistAlbums(artistID string, page, pageSize int) (*ArtistAlbums, error) {
+	return c.getArtistAlbums(context.Background(), artistID, page, pageSize)
+}
+
+// getArtistAlbums retrieves one page of the artist's direct albums
+func (c *Client) getArtistAlbums(ctx context.Context, artistID string, page, pageSize int) (*ArtistAlbums, error) {
+	log := c.logger.WithField("artist_id", artistID)
+	log.Debug("Getting artist albums, page %d", page)
+
+	params := url.Values{}
+	params.Set("page", strconv.Itoa(page))
+	params.Set("page-size", strconv.Itoa(pageSize))
+	params.Set("sort-by", "year")
+
+	path := fmt.Sprintf("/artists/%s/direct-albums?%s", url.PathEscape(artistID), params.Encode())
+	responseData, err := c.apiGet(ctx, path, "/artists/direct-albums", log)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.ArtistAlbumsResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	return &response.Result, nil
+}
+
+// GetAllArtistAlbums pages through the artist's own albums and returns the ones passing
+// the filter; their IDs can be passed to DownloadAlbum
+func (c *Client) GetAllArtistAlbums(artistID string, filter AlbumFilter) ([]Album, error) {
+	ctx := context.Background()
+
+	var albums []Album
+	for page := 0; ; page++ {
+		result, err := c.getArtistAlbums(ctx, artistID, page, DefaultArtistAlbumsPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("error getting albums page %d: %w", page, err)
+		}
+		albums = append(albums, FilterAlbums(result.Albums, filter)...)
+
+		// An empty page also ends the listing in case the pager is missing
+		if len(result.Albums) == 0 || (page+1)*DefaultArtistAlbumsPageSize >= result.Pager.Total {
+			break
+		}
+	}
+
+	c.logger.WithField("artist_id", artistID).Debug("Artist albums: %d", len(albums))
+	return albums, nil
+}
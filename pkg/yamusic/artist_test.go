@@ -0,0 +1,116 @@
+package yamusic
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// TestGetArtist checks parsing of the artist metadata
+func TestGetArtist(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/artists/41191": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"artist":{"id":41191,"name":"Король и Шут",` +
+				`"counts":{"tracks":300,"directAlbums":60,"alsoAlbums":5}},` +
+				`"albums":[{"id":10376938,"title":"Акустический альбом","year":1999}]}}`))
+		},
+	})
+
+	artist, err := client.GetArtist("41191")
+	if err != nil {
+		t.Fatalf("GetArtist() error = %v", err)
+	}
+	if artist.Artist.Name != "Король и Шут" || artist.Artist.Counts == nil || artist.Artist.Counts.DirectAlbums != 60 {
+		t.Errorf("GetArtist() = %+v, want the artist with counts", artist.Artist)
+	}
+	if len(artist.Albums) != 1 || artist.Albums[0].ID.String() != "10376938" {
+		t.Errorf("GetArtist() albums = %+v", artist.Albums)
+	}
+}
+
+// TestGetAllArtistAlbums checks paging through the direct albums and the album filter
+func TestGetAllArtistAlbums(t *testing.T) {
+	// 52 albums on two pages; every 10th is a single, every 25th a compilation
+	const total = 52
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/artists/41191/direct-albums": func(w http.ResponseWriter, r *http.Request) {
+			var page, pageSize int
+			fmt.Sscan(r.FormValue("page"), &page)
+			fmt.Sscan(r.FormValue("page-size"), &pageSize)
+
+			fmt.Fprint(w, `{"result":{"albums":[`)
+			for i := page * pageSize; i < min((page+1)*pageSize, total); i++ {
+				albumType := ""
+				switch {
+				case i%25 == 0:
+					albumType = "compilation"
+				case i%10 == 0:
+					albumType = "single"
+				}
+				if i > page*pageSize {
+					fmt.Fprint(w, ",")
+				}
+				fmt.Fprintf(w, `{"id":%d,"title":"Album %d","type":%q,"metaType":"music"}`, i, i, albumType)
+			}
+			fmt.Fprintf(w, `],"pager":{"total":%d,"page":%d,"perPage":%d}}}`, total, page, pageSize)
+		},
+	})
+
+	// Test cases
+	tests := []struct {
+		name    string
+		filter  AlbumFilter
+		count   int
+		missing []string
+	}{
+		{"All", AlbumFilter{}, 52, nil},
+		{"No compilations", AlbumFilter{NoCompilations: true}, 49, []string{"0", "25", "50"}},
+		{"No singles", AlbumFilter{NoSingles: true}, 48, []string{"10", "20", "30", "40"}},
+		{"Albums only", AlbumFilter{NoCompilations: true, NoSingles: true}, 45, []string{"0", "10", "50"}},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			albums, err := client.GetAllArtistAlbums("41191", tt.filter)
+			if err != nil {
+				t.Fatalf("GetAllArtistAlbums() error = %v", err)
+			}
+			if len(albums) != tt.count {
+				t.Errorf("GetAllArtistAlbums() returned %d albums, want %d", len(albums), tt.count)
+			}
+
+			ids := make(map[string]bool, len(albums))
+			for _, album := range albums {
+				ids[album.ID.String()] = true
+			}
+			for _, id := range tt.missing {
+				if ids[id] {
+					t.Errorf("Album %s passed the filter", id)
+				}
+			}
+		})
+	}
+}
+
+// TestGetArtistAlbums checks the paging parameters of a single page
+func TestGetArtistAlbums(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/artists/1/direct-albums": func(w http.ResponseWriter, r *http.Request) {
+			got := []string{r.FormValue("page"), r.FormValue("page-size")}
+			if !reflect.DeepEqual(got, []string{"3", "20"}) {
+				t.Errorf("page, page-size = %q, want 3, 20", got)
+			}
+			w.Write([]byte(`{"result":{"albums":[{"id":5,"title":"A","type":"single"}],"pager":{"total":61,"page":3,"perPage":20}}}`))
+		},
+	})
+
+	result, err := client.GetArtistAlbums("1", 3, 20)
+	if err != nil {
+		t.Fatalf("GetArtistAlbums() error = %v", err)
+	}
+	if result.Pager.Total != 61 || len(result.Albums) != 1 || result.Albums[0].Type != "single" {
+		t.Errorf("GetArtistAlbums() = %+v", result)
+	}
+}
@@ -0,0 +1,123 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// Reasons of UnavailableError
+const (
+	// ReasonRegion - the track is blocked for the account, typically in its region
+	ReasonRegion = "not available in your region"
+
+	// ReasonSubscription - the track is available to subscribers only
+	ReasonSubscription = "requires subscription"
+)
+
+// UnavailableError is returned for tracks the metadata marks as unavailable to the account,
+// before any download is attempted. It matches ErrNotAvailable, and ErrNoSubscription
+// when a subscription would make the track available.
+type UnavailableError struct {
+	TrackID string
+
+	// Reason is ReasonRegion or ReasonSubscription
+	Reason string
+
+	// Disclaimers are the legal disclaimers of the track, e.g. "modal"
+	Disclaimers []string
+}
+
+// Error implements the error interface
+func (e *UnavailableError) Error() string {
+	msg := fmt.Sprintf("track %s is not available: %s", e.TrackID, e.Reason)
+	if e.Reason == ReasonRegion {
+		msg = fmt.Sprintf("track %s is %s", e.TrackID, e.Reason)
+	}
+	if len(e.Disclaimers) > 0 {
+		msg += fmt.Sprintf(" (disclaimers: %s)", strings.Join(e.Disclaimers, ", "))
+	}
+	return msg
+}
+
+// Is matches the error against ErrNotAvailable and ErrNoSubscription
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrNotAvailable || (target == ErrNoSubscription && e.Reason == ReasonSubscription)
+}
+
+// checkAvailable returns an UnavailableError when the metadata marks the track as unavailable
+func checkAvailable(trackInfo *api.TrackInfo) error {
+	if trackInfo.Available {
+		return nil
+	}
+
+	reason := ReasonRegion
+	if trackInfo.AvailableForPremiumUsers {
+		reason = ReasonSubscription
+	}
+	return &UnavailableError{TrackID: trackInfo.ID, Reason: reason, Disclaimers: trackInfo.Disclaimers}
+}
+
+// AvailabilityStatus is the availability of a track to the account, as told by its metadata
+type AvailabilityStatus string
+
+// Availability statuses
+const (
+	// TrackAvailable - the track can be downloaded
+	TrackAvailable AvailabilityStatus = "available"
+
+	// TrackPremiumOnly - the track is available to subscribers only
+	TrackPremiumOnly AvailabilityStatus = "premium-only"
+
+	// TrackRegionBlocked - the track is blocked for the account, typically in its region
+	TrackRegionBlocked AvailabilityStatus = "region-blocked"
+
+	// TrackNotFound - the track doesn't exist
+	TrackNotFound AvailabilityStatus = "not-found"
+)
+
+// CheckAvailability classifies tracks by their availability with batched /tracks requests,
+// without requesting download info or media. The result is keyed by the given IDs, which
+// may be "trackId:albumId" IDs.
+func (c *Client) CheckAvailability(trackIDs []string) (map[string]AvailabilityStatus, error) {
+	return c.CheckAvailabilityContext(context.Background(), trackIDs)
+}
+
+// CheckAvailabilityContext is CheckAvailability with cancellation through ctx
+func (c *Client) CheckAvailabilityContext(ctx context.Context, trackIDs []string) (map[string]AvailabilityStatus, error) {
+	ids := make([]string, len(trackIDs))
+	for i, id := range trackIDs {
+		ids[i], _ = splitTrackRef(id, "")
+	}
+
+	infos, err := c.getTracks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]AvailabilityStatus, len(trackIDs))
+	for i, id := range trackIDs {
+		statuses[id] = availabilityStatus(infos[ids[i]])
+	}
+	return statuses, nil
+}
+
+// availabilityStatus classifies the metadata of a track; nil means the track wasn't found
+func availabilityStatus(trackInfo *api.TrackInfo) AvailabilityStatus {
+	if trackInfo == nil {
+		return TrackNotFound
+	}
+
+	var unavailableErr *UnavailableError
+	switch err := checkAvailable(trackInfo); {
+	case err == nil:
+		return TrackAvailable
+	case errors.As(err, &unavailableErr) && unavailableErr.Reason == ReasonSubscription:
+		return TrackPremiumOnly
+	default:
+		return TrackRegionBlocked
+	}
+}
@@ -0,0 +1,125 @@
+package yamusic
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// TestCheckAvailable checks the reasons reported for unavailable tracks
+func TestCheckAvailable(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name           string
+		info           api.TrackInfo
+		wantErr        bool
+		noSubscription bool
+		category       string
+		message        string
+	}{
+		{"Available", api.TrackInfo{ID: "1", Available: true}, false, false, "", ""},
+		{"Region", api.TrackInfo{ID: "2"}, true, false, CategoryRegionRestricted,
+			"track 2 is not available in your region"},
+		{"Subscription", api.TrackInfo{ID: "3", AvailableForPremiumUsers: true}, true, true, CategoryUnauthorized,
+			"track 3 is not available: requires subscription"},
+		{"Disclaimers", api.TrackInfo{ID: "4", Disclaimers: []string{"modal"}}, true, false, CategoryRegionRestricted,
+			"track 4 is not available in your region (disclaimers: modal)"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAvailable(&tt.info)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkAvailable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			if !errors.Is(err, ErrNotAvailable) {
+				t.Errorf("errors.Is(%v, ErrNotAvailable) = false", err)
+			}
+			if errors.Is(err, ErrNoSubscription) != tt.noSubscription {
+				t.Errorf("errors.Is(%v, ErrNoSubscription) = %v, want %v", err, !tt.noSubscription, tt.noSubscription)
+			}
+			if got := ErrorCategory(err); got != tt.category {
+				t.Errorf("ErrorCategory() = %q, want %q", got, tt.category)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.message)
+			}
+		})
+	}
+}
+
+// TestDownloadUnavailableTrack checks that unavailable tracks are reported before any
+// download info is requested
+func TestDownloadUnavailableTrack(t *testing.T) {
+	var infoRequests atomic.Int32
+	client := newDownloadServerWith(t, "64551568", []byte("fLaC"), map[string]http.HandlerFunc{
+		"/tracks/64551568": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":[{"id":"64551568","title":"Кукла колдуна","available":false,` +
+				`"availableForPremiumUsers":true,"artists":[{"id":1,"name":"Король и Шут"}],` +
+				`"albums":[{"id":10376938,"title":"Акустический альбом"}]}]}`))
+		},
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			infoRequests.Add(1)
+			http.Error(w, "unexpected request", http.StatusInternalServerError)
+		},
+	})
+
+	_, err := client.DownloadTrack("64551568", api.QualityHigh, t.TempDir())
+	if !errors.Is(err, ErrNotAvailable) {
+		t.Fatalf("DownloadTrack() error = %v, want ErrNotAvailable", err)
+	}
+	if !strings.Contains(err.Error(), ReasonSubscription) {
+		t.Errorf("DownloadTrack() error = %q, want the subscription reason", err)
+	}
+	if n := infoRequests.Load(); n != 0 {
+		t.Errorf("Download info requested %d times, want 0", n)
+	}
+}
+
+// TestCheckAvailability checks the classification of tracks from one batched request
+func TestCheckAvailability(t *testing.T) {
+	var requests int
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks": func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Write([]byte(`{"result":[` +
+				`{"id":"1","available":true},` +
+				`{"id":"2","available":false,"availableForPremiumUsers":true},` +
+				`{"id":"3","available":false}]}`))
+		},
+	})
+
+	statuses, err := client.CheckAvailability([]string{"1", "2:20", "3", "4"})
+	if err != nil {
+		t.Fatalf("CheckAvailability() error = %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		id   string
+		want AvailabilityStatus
+	}{
+		{"1", TrackAvailable},
+		{"2:20", TrackPremiumOnly},
+		{"3", TrackRegionBlocked},
+		{"4", TrackNotFound},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		if got := statuses[tt.id]; got != tt.want {
+			t.Errorf("CheckAvailability()[%q] = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Made %d /tracks requests, want 1", requests)
+	}
+}
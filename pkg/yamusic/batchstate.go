@@ -0,0 +1,194 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+// Statuses of the tracks of a batch state
+const (
+	BatchPending   = "pending"
+	BatchCompleted = "completed"
+	BatchFailed    = "failed"
+
+	// BatchSkipped - skipped without a file of its own, e.g. as already in the download
+	// archive or explicit (see SetSkipExplicit); final like BatchCompleted
+	BatchSkipped = "skipped"
+)
+
+// batchStateVersion is the format version of batch state files
+const batchStateVersion = 1
+
+// BatchTrack is a track of a batch with its outcome so far
+type BatchTrack struct {
+	ID      string `json:"id"`
+	AlbumID string `json:"albumId,omitempty"`
+	Status  string `json:"status"`
+
+	// Path is the file of a completed track
+	Path string `json:"path,omitempty"`
+
+	// Error is the error of a failed track
+	Error string `json:"error,omitempty"`
+}
+
+// BatchState is the progress of a batch download persisted in a JSON file, so an
+// interrupted batch can be resumed (see ResumeBatch). Unlike the download archive it
+// describes one job, including its quality and output settings. It is safe for
+// concurrent use.
+type BatchState struct {
+	Version     int          `json:"version"`
+	Quality     AudioQuality `json:"quality"`
+	OutputDir   string       `json:"outputDir"`
+	Concurrency int          `json:"concurrency,omitempty"`
+	Tracks      []BatchTrack `json:"tracks"`
+
+	mu    sync.Mutex
+	path  string
+	index map[string]int
+}
+
+// NewBatchState creates the state file of a batch with every track pending
+func NewBatchState(path string, tracks []TrackRef, quality AudioQuality, outputDir string,
+	concurrency int) (*BatchState, error) {
+	state := &BatchState{
+		Version:     batchStateVersion,
+		Quality:     quality,
+		OutputDir:   outputDir,
+		Concurrency: concurrency,
+		Tracks:      make([]BatchTrack, 0, len(tracks)),
+		path:        path,
+	}
+	for _, track := range tracks {
+		id, albumID := splitTrackRef(track.ID, track.AlbumID)
+		state.Tracks = append(state.Tracks, BatchTrack{ID: id, AlbumID: albumID, Status: BatchPending})
+	}
+	state.buildIndex()
+
+	if err := state.save(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// OpenBatchState reads the state file of a batch
+func OpenBatchState(path string) (*BatchState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading batch state: %w", err)
+	}
+
+	state := &BatchState{path: path}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error parsing batch state %s: %w", path, err)
+	}
+	if state.Version != batchStateVersion {
+		return nil, fmt.Errorf("unsupported batch state version %d in %s", state.Version, path)
+	}
+	state.buildIndex()
+
+	return state, nil
+}
+
+// buildIndex maps the track IDs to their entries
+func (s *BatchState) buildIndex() {
+	s.index = make(map[string]int, len(s.Tracks))
+	for i, track := range s.Tracks {
+		s.index[track.ID] = i
+	}
+}
+
+// Pending returns the tracks still to download: pending and failed ones, and completed
+// ones whose file no longer exists, which are marked pending again. Skipped tracks are
+// never pending again.
+func (s *BatchState) Pending() []TrackRef {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []TrackRef
+	for i := range s.Tracks {
+		track := &s.Tracks[i]
+		if track.Status == BatchSkipped {
+			continue
+		}
+		if track.Status == BatchCompleted {
+			if _, err := os.Stat(track.Path); err == nil {
+				continue
+			}
+			track.Status, track.Path = BatchPending, ""
+		}
+		pending = append(pending, TrackRef{ID: track.ID, AlbumID: track.AlbumID})
+	}
+	return pending
+}
+
+// record stores the outcome of a track and saves the state. Tracks interrupted by
+// cancellation stay pending; tracks not in the batch are ignored.
+func (s *BatchState) record(result *TrackResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.index[result.ID]
+	if !ok {
+		return nil
+	}
+
+	track := &s.Tracks[i]
+	switch {
+	case result.Err == nil && result.Path == "":
+		// Skips without a file, which the completed check of Pending would not find
+		track.Status, track.Path, track.Error = BatchSkipped, "", ""
+	case result.Err == nil:
+		track.Status, track.Path, track.Error = BatchCompleted, result.Path, ""
+	case errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, ErrSkipped):
+		track.Status = BatchPending
+	default:
+		track.Status, track.Path, track.Error = BatchFailed, "", result.Err.Error()
+	}
+
+	return s.save()
+}
+
+// save replaces the state file atomically; the caller holds mu unless the state is not shared yet
+func (s *BatchState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding batch state: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(s.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing batch state: %w", err)
+	}
+	return nil
+}
+
+// SetBatchState makes DownloadTracks record the outcome of every track of the batch in
+// state as soon as it finishes. A nil state disables recording.
+func (c *Client) SetBatchState(state *BatchState) {
+	c.batch = state
+}
+
+// ResumeBatch continues the batch of the state file where it left off, with the quality and
+// output settings of the batch. Completed tracks whose file still exists are not downloaded
+// again; the results cover the remaining tracks only.
+func (c *Client) ResumeBatch(statePath string) ([]TrackResult, error) {
+	return c.ResumeBatchContext(context.Background(), statePath)
+}
+
+// ResumeBatchContext is ResumeBatch with cancellation through ctx
+func (c *Client) ResumeBatchContext(ctx context.Context, statePath string) ([]TrackResult, error) {
+	state, err := OpenBatchState(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := state.Pending()
+	c.logger.Info("Resuming batch %s: %d of %d tracks left", statePath, len(pending), len(state.Tracks))
+	return c.downloadTracks(ctx, pending, state.Quality, state.OutputDir, state.Concurrency, state), nil
+}
@@ -0,0 +1,126 @@
+package yamusic
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+)
+
+// TestResumeBatch checks that batch progress is saved per track and resumed from the state file
+func TestResumeBatch(t *testing.T) {
+	var mediaRequests atomic.Int32
+	audio := append([]byte("fLaC"), make([]byte, 1024)...)
+	encrypted, err := crypto.DecryptAesCtr(audio, testDecryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to encrypt test audio: %v", err)
+	}
+	client := newDownloadServerWith(t, "64551568", audio, map[string]http.HandlerFunc{
+		"/media/64551568": func(w http.ResponseWriter, r *http.Request) {
+			mediaRequests.Add(1)
+			w.Write(encrypted)
+		},
+	})
+
+	outputDir := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "batch.json")
+	state, err := NewBatchState(statePath, []TrackRef{{ID: "64551568"}, {ID: "999"}}, api.QualityHigh, outputDir, 1)
+	if err != nil {
+		t.Fatalf("NewBatchState() error = %v", err)
+	}
+
+	client.SetBatchState(state)
+	client.DownloadTracksContext(context.Background(), []TrackRef{{ID: "64551568"}, {ID: "999"}},
+		api.QualityHigh, outputDir, 1)
+	client.SetBatchState(nil)
+
+	saved, err := OpenBatchState(statePath)
+	if err != nil {
+		t.Fatalf("OpenBatchState() error = %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		id     string
+		status string
+	}{
+		{"64551568", BatchCompleted},
+		{"999", BatchFailed},
+	}
+
+	// Run tests
+	for i, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := saved.Tracks[i]; got.ID != tt.id || got.Status != tt.status {
+				t.Errorf("Track %d = %s %s, want %s %s", i, got.ID, got.Status, tt.id, tt.status)
+			}
+		})
+	}
+	if saved.Quality != api.QualityHigh || saved.OutputDir != outputDir {
+		t.Errorf("Batch settings = %s %s, want %s %s", saved.Quality, saved.OutputDir, api.QualityHigh, outputDir)
+	}
+
+	// A completed track whose file still exists is not downloaded again
+	if pending := saved.Pending(); len(pending) != 1 || pending[0].ID != "999" {
+		t.Errorf("Pending() = %v, want only the failed track", pending)
+	}
+
+	// A completed track whose file was removed is downloaded again
+	if err := os.Remove(saved.Tracks[0].Path); err != nil {
+		t.Fatalf("Failed to remove the downloaded file: %v", err)
+	}
+	results, err := client.ResumeBatch(statePath)
+	if err != nil {
+		t.Fatalf("ResumeBatch() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Err != nil || results[1].Err == nil {
+		t.Errorf("ResumeBatch() = %v, want the removed track downloaded and the failed one retried", results)
+	}
+	if got := mediaRequests.Load(); got != 2 {
+		t.Errorf("Made %d media requests, want 2", got)
+	}
+}
+
+// TestBatchStateSkipped checks that tracks skipped without a file are not pending again
+func TestBatchStateSkipped(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "batch.json")
+	state, err := NewBatchState(statePath, []TrackRef{{ID: "1"}, {ID: "2"}}, api.QualityHigh, t.TempDir(), 1)
+	if err != nil {
+		t.Fatalf("NewBatchState() error = %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		name   string
+		result TrackResult
+		status string
+	}{
+		{"Skipped without a file", TrackResult{TrackRef: TrackRef{ID: "1"}, Skipped: true}, BatchSkipped},
+		{"Interrupted", TrackResult{TrackRef: TrackRef{ID: "2"}, Err: context.Canceled}, BatchPending},
+	}
+
+	// Run tests
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := state.record(&tt.result); err != nil {
+				t.Fatalf("record() error = %v", err)
+			}
+			if got := state.Tracks[i].Status; got != tt.status {
+				t.Errorf("Status = %s, want %s", got, tt.status)
+			}
+		})
+	}
+
+	saved, err := OpenBatchState(statePath)
+	if err != nil {
+		t.Fatalf("OpenBatchState() error = %v", err)
+	}
+	if pending := saved.Pending(); len(pending) != 1 || pending[0].ID != "2" {
+		t.Errorf("Pending() = %v, want only the interrupted track", pending)
+	}
+}
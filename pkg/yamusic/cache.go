@@ -0,0 +1,128 @@
+package yamusic
+
+import (
+	"errors"
+
+	"github.com/Kud1nov/yamusic-dl/internal/cache"
+)
+
+// ErrOffline is returned when a request needs the network in offline mode
+var ErrOffline = errors.New("not available offline: no cached data")
+
+// MetadataCache stores API metadata between runs (see internal/cache for the on-disk store)
+type MetadataCache interface {
+	// Get decodes the cached entry into v, reporting whether it was found and is within the TTL
+	Get(kind, id string, v interface{}) (found, fresh bool)
+
+	// Put stores v under kind and ID
+	Put(kind, id string, v interface{}) error
+}
+
+// SetCache enables a metadata cache consulted before the network
+func (c *Client) SetCache(metadataCache MetadataCache) {
+	c.cache = metadataCache
+}
+
+// SetOffline forbids network requests: metadata is served from the cache only,
+// stale entries included, and everything else fails with ErrOffline
+func (c *Client) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// cachedTrack returns the cached track metadata when it can be used instead of a request
+func (c *Client) cachedTrack(trackID string) (*TrackInfo, bool) {
+	if trackInfo, ok := c.memoryTrack(trackID); ok {
+		return trackInfo, true
+	}
+	if c.cache == nil {
+		return nil, false
+	}
+
+	var trackInfo TrackInfo
+	found, fresh := c.cache.Get(cache.KindTrack, trackID, &trackInfo)
+	if !found || (!fresh && !c.offline) {
+		return nil, false
+	}
+
+	c.memCache.put(cache.KindTrack, trackID, &trackInfo)
+	return &trackInfo, true
+}
+
+// storeTrack saves track metadata to the in-process and metadata caches
+func (c *Client) storeTrack(trackInfo *TrackInfo) {
+	if trackInfo.ID == "" {
+		return
+	}
+
+	c.memCache.put(cache.KindTrack, trackInfo.ID, trackInfo)
+	if c.cache == nil {
+		return
+	}
+
+	if err := c.cache.Put(cache.KindTrack, trackInfo.ID, trackInfo); err != nil {
+		c.logger.Warn("Error saving metadata to cache: %v", err)
+	}
+}
+
+// cachedAlbum returns the cached album metadata when it can be used instead of a request
+func (c *Client) cachedAlbum(albumID string) (*Album, bool) {
+	if album, ok := c.memoryAlbum(albumID); ok {
+		return album, true
+	}
+	if c.cache == nil {
+		return nil, false
+	}
+
+	var album Album
+	found, fresh := c.cache.Get(cache.KindAlbum, albumID, &album)
+	if !found || (!fresh && !c.offline) {
+		return nil, false
+	}
+
+	// Track numbering relies on the layout of albums fetched with GetAlbum
+	c.albumLayouts.Store(album.ID.String(), newAlbumLayout(&album))
+	c.memCache.put(cache.KindAlbum, albumID, &album)
+	return &album, true
+}
+
+// storeAlbum saves album metadata to the in-process and metadata caches
+func (c *Client) storeAlbum(albumID string, album *Album) {
+	c.memCache.put(cache.KindAlbum, albumID, album)
+	if c.cache == nil {
+		return
+	}
+
+	if err := c.cache.Put(cache.KindAlbum, albumID, album); err != nil {
+		c.logger.Warn("Error saving metadata to cache: %v", err)
+	}
+}
+
+// playlistCacheID is the metadata cache ID of a playlist, keyed by the owner as given
+func playlistCacheID(userID, kind string) string {
+	return userID + ":" + kind
+}
+
+// cachedPlaylist returns the cached playlist when it can be used instead of a request
+func (c *Client) cachedPlaylist(userID, kind string) (*Playlist, bool) {
+	if c.cache == nil {
+		return nil, false
+	}
+
+	var playlist Playlist
+	found, fresh := c.cache.Get(cache.KindPlaylist, playlistCacheID(userID, kind), &playlist)
+	if !found || (!fresh && !c.offline) {
+		return nil, false
+	}
+	return &playlist, true
+}
+
+// storePlaylist saves a playlist to the metadata cache
+func (c *Client) storePlaylist(userID, kind string, playlist *Playlist) {
+	if c.cache == nil {
+		return
+	}
+
+	if err := c.cache.Put(cache.KindPlaylist, playlistCacheID(userID, kind), playlist); err != nil {
+		c.logger.Warn("Error saving metadata to cache: %v", err)
+	}
+}
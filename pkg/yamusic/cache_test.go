@@ -0,0 +1,111 @@
+package yamusic
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/cache"
+)
+
+// TestMetadataCache checks that cached metadata is reused and offline mode never hits the network
+func TestMetadataCache(t *testing.T) {
+	requests := 0
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568": func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			serveFixture(t, "track.json")(w, r)
+		},
+	})
+
+	store, _, err := cache.Open(filepath.Join(t.TempDir(), "cache.jsonl"), 0)
+	if err != nil {
+		t.Fatalf("cache.Open() error = %v", err)
+	}
+	defer store.Close()
+	client.SetCache(store)
+	client.SetMemoryCache(0, 0)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetTrackInfo("64551568"); err != nil {
+			t.Fatalf("GetTrackInfo() error = %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Made %d requests, want 1 with a warm cache", requests)
+	}
+
+	client.SetOffline(true)
+
+	trackInfo, err := client.GetTrackInfo("64551568")
+	if err != nil || trackInfo.Title != "Кукла колдуна" {
+		t.Errorf("GetTrackInfo() offline = %v, %v, want the cached track", trackInfo, err)
+	}
+
+	if _, err := client.GetTrackInfo("1"); !errors.Is(err, ErrOffline) {
+		t.Errorf("GetTrackInfo() offline for an uncached track error = %v, want ErrOffline", err)
+	}
+	if requests != 1 {
+		t.Errorf("Made %d requests, want none in offline mode", requests-1)
+	}
+}
+
+// TestMetadataCacheAlbumPlaylist checks that albums and playlists are cached between clients
+// and served offline
+func TestMetadataCacheAlbumPlaylist(t *testing.T) {
+	requests := map[string]int{}
+	handlers := map[string]http.HandlerFunc{
+		"/albums/10376938/with-tracks": func(w http.ResponseWriter, r *http.Request) {
+			requests[r.URL.Path]++
+			serveFixture(t, "album.json")(w, r)
+		},
+		"/users/test/playlists/3": func(w http.ResponseWriter, r *http.Request) {
+			requests[r.URL.Path]++
+			w.Write([]byte(`{"result":{"uid":42,"kind":3,"title":"Road","revision":5,"tracks":[{"id":2,"albumId":20}]}}`))
+		},
+	}
+	cachePath := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	// Every run is a new client with its own in-process cache over the same cache file
+	run := func(offline bool) (*Album, *Playlist) {
+		t.Helper()
+		client, _ := newTestServer(t, handlers)
+		store, _, err := cache.Open(cachePath, 0)
+		if err != nil {
+			t.Fatalf("cache.Open() error = %v", err)
+		}
+		defer store.Close()
+		client.SetCache(store)
+		client.SetOffline(offline)
+
+		album, err := client.GetAlbum("10376938")
+		if err != nil {
+			t.Fatalf("GetAlbum() error = %v", err)
+		}
+		playlist, err := client.GetPlaylist("test", "3")
+		if err != nil {
+			t.Fatalf("GetPlaylist() error = %v", err)
+		}
+		return album, playlist
+	}
+
+	run(false)
+	run(false)
+	album, playlist := run(true)
+
+	for path, count := range requests {
+		if count != 1 {
+			t.Errorf("Made %d requests to %s, want 1 with a warm cache", count, path)
+		}
+	}
+	if len(requests) != 2 {
+		t.Errorf("Requested %d endpoints, want 2", len(requests))
+	}
+	if album.Title != "Акустический альбом" || len(album.Volumes) != 2 {
+		t.Errorf("Cached album = %q with %d volumes", album.Title, len(album.Volumes))
+	}
+	if playlist.Title != "Road" || len(playlist.Tracks) != 1 {
+		t.Errorf("Cached playlist = %q with %d tracks", playlist.Title, len(playlist.Tracks))
+	}
+}
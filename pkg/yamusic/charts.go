@@ -0,0 +1,109 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// Chart types
+const (
+	ChartRussia = "russia"
+	ChartWorld  = "world"
+)
+
+type (
+	// Chart is a chart with its tracks ordered by position
+	Chart = api.ChartInfo
+
+	// ChartTrack is a chart entry: the track with its position
+	ChartTrack = api.ChartTrack
+)
+
+// albumsBatchSize is the maximum number of album IDs per /albums request
+const albumsBatchSize = 100
+
+// GetNewReleases retrieves the new releases, newest first, with the album metadata.
+// A positive limit keeps only the first limit albums; the API returns the whole list
+// at once, so the limit only saves the metadata requests.
+func (c *Client) GetNewReleases(limit int) ([]Album, error) {
+	ctx := context.Background()
+	c.logger.Debug("Getting new releases")
+
+	responseData, err := c.apiGet(ctx, "/landing3/new-releases", "/landing3/new-releases", c.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.NewReleasesResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	ids := make([]string, 0, len(response.Result.NewReleases))
+	for _, id := range response.Result.NewReleases {
+		ids = append(ids, id.String())
+	}
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	c.logger.Debug("New releases: %d", len(ids))
+
+	return c.getAlbums(ctx, ids)
+}
+
+// getAlbums retrieves the metadata of many albums, without tracks, with one /albums request
+// per batch. The API order is kept; unknown albums are dropped.
+func (c *Client) getAlbums(ctx context.Context, albumIDs []string) ([]Album, error) {
+	var albums []Album
+	for start := 0; start < len(albumIDs); start += albumsBatchSize {
+		end := min(start+albumsBatchSize, len(albumIDs))
+		form := url.Values{}
+		form.Set("album-ids", strings.Join(albumIDs[start:end], ","))
+
+		responseData, err := c.apiPostForm(ctx, "/albums", "/albums", form, c.logger)
+		if err != nil {
+			return nil, err
+		}
+
+		var response api.AlbumsResponse
+		if err := json.Unmarshal(responseData, &response); err != nil {
+			return nil, fmt.Errorf("response parsing error: %w", err)
+		}
+		albums = append(albums, response.Result...)
+	}
+	return albums, nil
+}
+
+// GetChart retrieves a chart (ChartRussia, ChartWorld; empty for the default chart).
+// A positive limit keeps only the top limit tracks.
+func (c *Client) GetChart(chartType string, limit int) (*Chart, error) {
+	log := c.logger.WithField("chart", chartType)
+	log.Debug("Getting chart")
+
+	path := "/landing3/chart"
+	if chartType != "" {
+		path += "/" + url.PathEscape(chartType)
+	}
+	responseData, err := c.apiGet(context.Background(), path, "/landing3/chart", log)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.ChartResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	chart := &response.Result
+	if limit > 0 && len(chart.Chart.Tracks) > limit {
+		chart.Chart.Tracks = chart.Chart.Tracks[:limit]
+	}
+
+	log.Debug("Chart %q: %d tracks", chart.Title, len(chart.Chart.Tracks))
+	return chart, nil
+}
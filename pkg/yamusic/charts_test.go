@@ -0,0 +1,75 @@
+package yamusic
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestGetChart checks the chart parsing and the limit
+func TestGetChart(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name      string
+		chartType string
+		limit     int
+		path      string
+		expected  string
+	}{
+		{"Default chart", "", 0, "/landing3/chart", "111 222 333"},
+		{"Russia top 2", ChartRussia, 2, "/landing3/chart/russia", "111 222"},
+		{"Limit above the size", ChartWorld, 10, "/landing3/chart/world", "111 222 333"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{tt.path: serveFixture(t, "chart.json")})
+
+			chart, err := client.GetChart(tt.chartType, tt.limit)
+			if err != nil {
+				t.Fatalf("GetChart() error = %v", err)
+			}
+
+			var ids []string
+			for _, entry := range chart.Chart.Tracks {
+				ids = append(ids, entry.Track.ID)
+			}
+			if got := strings.Join(ids, " "); got != tt.expected {
+				t.Errorf("Tracks = %s, want %s", got, tt.expected)
+			}
+			if second := chart.Chart.Tracks[1].Chart; second.Position != 2 || second.Progress != "up" || second.Shift != 3 {
+				t.Errorf("Second position = %+v", second)
+			}
+		})
+	}
+}
+
+// TestGetNewReleases checks that the album metadata is fetched for the limited list
+func TestGetNewReleases(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/landing3/new-releases": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"type":"new-releases","title":"Новые релизы","newReleases":[30,20,10]}}`))
+		},
+		"/albums": func(w http.ResponseWriter, r *http.Request) {
+			ids := strings.Split(r.PostFormValue("album-ids"), ",")
+			if len(ids) != 2 || ids[0] != "30" || ids[1] != "20" {
+				t.Errorf("album-ids = %v, want [30 20]", ids)
+			}
+			var albums []string
+			for _, id := range ids {
+				albums = append(albums, fmt.Sprintf(`{"id":%s,"title":"Album %s","year":2024}`, id, id))
+			}
+			fmt.Fprintf(w, `{"result":[%s]}`, strings.Join(albums, ","))
+		},
+	})
+
+	albums, err := client.GetNewReleases(2)
+	if err != nil {
+		t.Fatalf("GetNewReleases() error = %v", err)
+	}
+	if len(albums) != 2 || albums[0].Title != "Album 30" || albums[1].ID.String() != "20" {
+		t.Errorf("GetNewReleases() = %+v, want albums 30 and 20", albums)
+	}
+}
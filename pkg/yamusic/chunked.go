@@ -0,0 +1,186 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// DefaultDownloadChunks is the number of connections of a chunked download
+const DefaultDownloadChunks = 4
+
+// minChunkSize is the smallest byte range worth a connection of its own
+const minChunkSize = 1 << 20
+
+// errNoChunking means a file is to be downloaded as a single stream: the server doesn't
+// serve byte ranges or the file is too small to split
+var errNoChunking = errors.New("chunked download not possible")
+
+// SetChunkedDownload makes media files be downloaded over n connections at once, each
+// fetching a byte range into a temporary file that is decrypted when complete. This helps
+// when the CDN limits the speed of a single connection. Zero selects DefaultDownloadChunks;
+// 1 disables chunking (the default). Files of servers without range support, files too
+// small to split and previews are downloaded as a single stream.
+func (c *Client) SetChunkedDownload(n int) {
+	if n == 0 {
+		n = DefaultDownloadChunks
+	}
+	c.chunks = max(n, 1)
+}
+
+// downloadChunked downloads a media file in byte ranges over several connections into
+// a preallocated temporary file and returns it rewound; the caller closes and removes it.
+// The chunks share the transfer slot of the mirror host. errNoChunking is returned when
+// the file is to be downloaded as a single stream.
+func (c *Client) downloadChunked(ctx context.Context, mirrors []string, progress *progressTracker,
+	log *logger.Logger) (*os.File, error) {
+	fileURL, release, err := c.hosts.acquire(ctx, mirrors, log)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	size, err := c.probeRanges(ctx, fileURL)
+	if err != nil {
+		log.Debug("Downloading as a single stream: %v", err)
+		return nil, errNoChunking
+	}
+	chunks := int(min(int64(c.chunks), size/minChunkSize))
+	if chunks < 2 {
+		log.Debug("Downloading as a single stream: %d bytes are too few to split", size)
+		return nil, errNoChunking
+	}
+	progress.setTotal(size)
+
+	file, err := os.CreateTemp("", "yamusic-chunks-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary file: %w", err)
+	}
+	complete := false
+	defer func() {
+		if !complete {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+	if err := file.Truncate(size); err != nil {
+		return nil, fmt.Errorf("error creating temporary file: %w", err)
+	}
+
+	log.Debug("Downloading %d bytes in %d chunks", size, chunks)
+
+	// The first failure, or a stall, cancels the other chunks
+	chunkCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	chunkSize := size / int64(chunks)
+	for i := range chunks {
+		start, end := int64(i)*chunkSize, int64(i+1)*chunkSize
+		if i == chunks-1 {
+			end = size
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stalled := func() { cancel(ErrStalled) }
+			if err := c.downloadRange(chunkCtx, fileURL, file, start, end, progress, stalled, log); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := context.Cause(chunkCtx); err != nil {
+		if ctx.Err() == nil && errors.Is(err, ErrStalled) {
+			return nil, fmt.Errorf("%w: no data received for %s", ErrStalled, c.stallTimeout)
+		}
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error reading temporary file: %w", err)
+	}
+
+	complete = true
+	return file, nil
+}
+
+// probeRanges requests the first byte of the file and returns the file size when the server
+// serves byte ranges
+func (c *Client) probeRanges(ctx context.Context, fileURL string) (int64, error) {
+	resp, err := c.doMedia(ctx, fileURL, "bytes=0-0")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("byte ranges not supported, status: %s", resp.Status)
+	}
+	start, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return 0, err
+	}
+	if start != 0 || size <= 0 {
+		return 0, fmt.Errorf("unexpected content range %q", resp.Header.Get("Content-Range"))
+	}
+	return size, nil
+}
+
+// downloadRange downloads the bytes [start, end) of the file into the same offsets of w.
+// Retries resume after the bytes already written.
+func (c *Client) downloadRange(ctx context.Context, fileURL string, w io.WriterAt, start, end int64,
+	progress *progressTracker, stalled func(), log *logger.Logger) error {
+	writer := io.NewOffsetWriter(w, start)
+	next := start
+
+	return c.withRetry(ctx, log, func() error {
+		resp, err := c.doMedia(ctx, fileURL, fmt.Sprintf("bytes=%d-%d", next, end-1))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusPartialContent {
+			return newMediaStatusError(resp)
+		}
+		if first, _, err := parseContentRange(resp.Header.Get("Content-Range")); err != nil || first != next {
+			return fmt.Errorf("unexpected content range %q for bytes from %d", resp.Header.Get("Content-Range"), next)
+		}
+
+		body := io.Reader(resp.Body)
+		if c.stallTimeout > 0 {
+			stall := newStallReader(resp.Body, c.stallTimeout, stalled)
+			defer stall.stop()
+			body = stall
+		}
+
+		reader := &progressReader{r: c.throttle(ctx, io.LimitReader(body, end-next)), tracker: progress}
+		n, err := io.Copy(writer, reader)
+		next += n
+		if err != nil {
+			return err
+		}
+		if next < end {
+			return fmt.Errorf("error downloading file: %w after %d of %d bytes of the chunk",
+				io.ErrUnexpectedEOF, next-start, end-start)
+		}
+		return nil
+	})
+}
+
+// parseContentRange parses a "bytes first-last/size" Content-Range header
+func parseContentRange(value string) (first, size int64, err error) {
+	var last int64
+	if _, err := fmt.Sscanf(value, "bytes %d-%d/%d", &first, &last, &size); err != nil {
+		return 0, 0, fmt.Errorf("invalid content range %q", value)
+	}
+	return first, size, nil
+}
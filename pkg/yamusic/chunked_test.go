@@ -0,0 +1,289 @@
+package yamusic
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+)
+
+// TestParseContentRange checks the parsing of Content-Range headers
+func TestParseContentRange(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		value   string
+		first   int64
+		size    int64
+		wantErr bool
+	}{
+		{"bytes 0-0/4194304", 0, 4194304, false},
+		{"bytes 1048576-2097151/4194304", 1048576, 4194304, false},
+		{"bytes 0-0/*", 0, 0, true},
+		{"bytes */4194304", 0, 0, true},
+		{"", 0, 0, true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			first, size, err := parseContentRange(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseContentRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (first != tt.first || size != tt.size) {
+				t.Errorf("parseContentRange() = %d, %d, want %d, %d", first, size, tt.first, tt.size)
+			}
+		})
+	}
+}
+
+// chunkedAudio returns FLAC-looking audio of the given size
+func chunkedAudio(size int) []byte {
+	audio := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(audio)
+	copy(audio, "fLaC")
+	return audio
+}
+
+// rangeLog records the Range headers of media requests
+type rangeLog struct {
+	mu     sync.Mutex
+	ranges []string
+}
+
+// add records a request
+func (l *rangeLog) add(r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ranges = append(l.ranges, r.Header.Get("Range"))
+}
+
+// count returns the number of requests with and without a Range header
+func (l *rangeLog) count() (ranged, plain int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, r := range l.ranges {
+		if r != "" {
+			ranged++
+		} else {
+			plain++
+		}
+	}
+	return ranged, plain
+}
+
+// TestChunkedDownload checks chunked downloads against servers with and without range support
+func TestChunkedDownload(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name   string
+		size   int
+		chunks int
+		ranges bool
+
+		// Expected ranged and plain media requests; the probe is a ranged request
+		wantRanged int
+		wantPlain  int
+	}{
+		{"Four chunks", 4*minChunkSize + 123, 4, true, 5, 0},
+		{"Default number of chunks", 6 * minChunkSize, 0, true, 1 + DefaultDownloadChunks, 0},
+		{"Fewer chunks for a smaller file", 2*minChunkSize + 1, 8, true, 3, 0},
+		{"Too small to split", minChunkSize, 4, true, 1, 1},
+		{"No range support", 4 * minChunkSize, 4, false, 1, 1},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMPDIR", t.TempDir())
+			audio := chunkedAudio(tt.size)
+			encrypted, err := crypto.DecryptAesCtr(audio, testDecryptionKey)
+			if err != nil {
+				t.Fatalf("Failed to encrypt test audio: %v", err)
+			}
+
+			var requests rangeLog
+			client := newDownloadServerWith(t, "64551568", audio, map[string]http.HandlerFunc{
+				"/media/64551568": func(w http.ResponseWriter, r *http.Request) {
+					requests.add(r)
+					if !tt.ranges {
+						w.Write(encrypted)
+						return
+					}
+					http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(encrypted))
+				},
+			})
+			client.SetChunkedDownload(tt.chunks)
+			client.SetStandardTags(false)
+			client.SetExtraTags(false)
+
+			var mu sync.Mutex
+			var last Progress
+			client.SetProgressFunc(func(p Progress) {
+				mu.Lock()
+				defer mu.Unlock()
+				last = p
+			})
+
+			var buf bytes.Buffer
+			if _, err := client.DownloadTrackTo(context.Background(), "64551568", api.QualityHigh, &buf); err != nil {
+				t.Fatalf("DownloadTrackTo() error = %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), audio) {
+				t.Errorf("Downloaded audio differs from the served audio")
+			}
+
+			ranged, plain := requests.count()
+			if ranged != tt.wantRanged || plain != tt.wantPlain {
+				t.Errorf("Media requests: %d ranged, %d plain, want %d and %d", ranged, plain, tt.wantRanged, tt.wantPlain)
+			}
+			if !last.Done || last.Downloaded != int64(tt.size) {
+				t.Errorf("Final progress = %+v, want %d bytes done", last, tt.size)
+			}
+			if entries, _ := os.ReadDir(os.Getenv("TMPDIR")); len(entries) != 0 {
+				t.Errorf("Temporary files left: %v", entries)
+			}
+		})
+	}
+}
+
+// TestChunkedDownloadRetry checks that a failed chunk is retried on its own
+func TestChunkedDownloadRetry(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	audio := chunkedAudio(4 * minChunkSize)
+	encrypted, err := crypto.DecryptAesCtr(audio, testDecryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to encrypt test audio: %v", err)
+	}
+
+	var mu sync.Mutex
+	failed := make(map[string]bool)
+	client := newDownloadServerWith(t, "64551568", audio, map[string]http.HandlerFunc{
+		"/media/64551568": func(w http.ResponseWriter, r *http.Request) {
+			// The first request of every chunk but the probe fails
+			mu.Lock()
+			rangeHeader := r.Header.Get("Range")
+			fail := rangeHeader != "bytes=0-0" && !failed[rangeHeader]
+			failed[rangeHeader] = true
+			mu.Unlock()
+			if fail {
+				http.Error(w, "try again", http.StatusServiceUnavailable)
+				return
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(encrypted))
+		},
+	})
+	client.SetChunkedDownload(4)
+	client.SetRetry(2, time.Millisecond)
+
+	var buf bytes.Buffer
+	if _, err := client.DownloadTrackTo(context.Background(), "64551568", api.QualityHigh, &buf); err != nil {
+		t.Fatalf("DownloadTrackTo() error = %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), audio) {
+		t.Errorf("Downloaded audio differs from the served audio")
+	}
+}
+
+// TestChunkedDownloadErrors checks that broken range responses fail the download and
+// leave no temporary files
+func TestChunkedDownloadErrors(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name    string
+		handler func(encrypted []byte) http.HandlerFunc
+		message string
+	}{
+		{
+			"Wrong range",
+			func(encrypted []byte) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("Range") != "bytes=0-0" {
+						// Always the same bytes, whatever was asked
+						r.Header.Set("Range", "bytes=1024-2047")
+					}
+					http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(encrypted))
+				}
+			},
+			"unexpected content range",
+		},
+		{
+			"Chunk not found",
+			func(encrypted []byte) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("Range") != "bytes=0-0" {
+						http.NotFound(w, r)
+						return
+					}
+					http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(encrypted))
+				}
+			},
+			"404",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMPDIR", t.TempDir())
+			audio := chunkedAudio(4 * minChunkSize)
+			encrypted, err := crypto.DecryptAesCtr(audio, testDecryptionKey)
+			if err != nil {
+				t.Fatalf("Failed to encrypt test audio: %v", err)
+			}
+
+			client := newDownloadServerWith(t, "64551568", audio, map[string]http.HandlerFunc{
+				"/media/64551568": tt.handler(encrypted),
+			})
+			client.SetChunkedDownload(4)
+			client.SetRetry(1, 0)
+
+			var buf bytes.Buffer
+			_, err = client.DownloadTrackTo(context.Background(), "64551568", api.QualityHigh, &buf)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Fatalf("DownloadTrackTo() error = %v, want %q", err, tt.message)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("Wrote %d bytes of a failed download", buf.Len())
+			}
+			if entries, _ := os.ReadDir(os.Getenv("TMPDIR")); len(entries) != 0 {
+				t.Errorf("Temporary files left: %v", entries)
+			}
+		})
+	}
+}
+
+// TestChunkedDownloadPreview checks that previews are not downloaded in chunks
+func TestChunkedDownloadPreview(t *testing.T) {
+	audio := chunkedAudio(4 * minChunkSize)
+	encrypted, err := crypto.DecryptAesCtr(audio, testDecryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to encrypt test audio: %v", err)
+	}
+
+	var requests rangeLog
+	client := newDownloadServerWith(t, "64551568", audio, map[string]http.HandlerFunc{
+		"/media/64551568": func(w http.ResponseWriter, r *http.Request) {
+			requests.add(r)
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(encrypted))
+		},
+	})
+	client.SetChunkedDownload(4)
+	client.SetPreview(true)
+
+	var buf bytes.Buffer
+	if _, err := client.DownloadTrackTo(context.Background(), "64551568", api.QualityHigh, &buf); err != nil {
+		t.Fatalf("DownloadTrackTo() error = %v", err)
+	}
+	if ranged, plain := requests.count(); ranged != 0 || plain != 1 {
+		t.Errorf("Media requests: %d ranged, %d plain, want a single plain one", ranged, plain)
+	}
+}
@@ -2,21 +2,25 @@
 package yamusic
 
 import (
+	"bufio"
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
-
 	"github.com/Kud1nov/yamusic-dl/internal/api"
 	"github.com/Kud1nov/yamusic-dl/internal/crypto"
 	"github.com/Kud1nov/yamusic-dl/internal/logger"
@@ -30,15 +34,142 @@ type (
 
 	// ApiTrackQuality defines the quality of the track in the Yandex Music API
 	ApiTrackQuality = api.TrackQuality
+
+	// TrackInfo represents detailed information about a track
+	TrackInfo = api.TrackInfo
+
+	// Artist represents artist information
+	Artist = api.Artist
+
+	// Album represents album information
+	Album = api.Album
+
+	// Fade holds the fade-in and fade-out points of a track in seconds
+	Fade = api.Fade
 )
 
+// Download quality levels
+const (
+	// QualityMin - minimum quality
+	QualityMin = api.QualityMin
+
+	// QualityStandard - standard quality
+	QualityStandard = api.QualityStandard
+
+	// QualityHigh - maximum quality
+	QualityHigh = api.QualityHigh
+)
+
+// Processing phases reported in the "phase" log field
+const (
+	phaseMetadata = "metadata"
+	phaseDownload = "download"
+	phaseTag      = "tag"
+)
+
+// partSuffix is appended to the names of tracks being downloaded
+const partSuffix = ".part"
+
 // Client provides methods for working with the Yandex Music API
 type Client struct {
 	accessToken string
-	signKey     string
 	headers     map[string]string
-	logger      *logger.Logger
-	httpClient  *http.Client
+
+	// mediaHeaders are the identity headers of media requests, without the token
+	mediaHeaders map[string]string
+
+	// signKeys are the candidate sign keys, the one that worked last first
+	signMu   sync.Mutex
+	signKeys []string
+
+	preset          api.ClientPreset
+	headerOverrides map[string]string
+	logger          *logger.Logger
+	httpClient      *http.Client
+	baseURL         string
+	mediaBaseURL    *url.URL
+
+	// CDN transfers share the transport with API requests but have no overall timeout;
+	// they are aborted when no data arrives for stallTimeout instead.
+	// transport is nil when the transport was injected by the user.
+	transport      *http.Transport
+	downloadClient *http.Client
+	stallTimeout   time.Duration
+	hosts          *hostLimiter
+	limiter        *rateLimiter
+	speedLimiter   *rateLimiter
+	mediaSlots     chan struct{}
+
+	slowThreshold time.Duration
+	stats         apiStats
+	albumPolicy   AlbumPolicy
+	layout        DirLayout
+
+	// albumLayouts maps the IDs of albums fetched with GetAlbum to their *albumLayout
+	albumLayouts sync.Map
+
+	// fileOwners maps the paths of files saved or being saved in this session to their track IDs
+	fileOwners    sync.Map
+	lockStaleness time.Duration
+
+	// recordings maps the realId of downloaded tracks to their files (see SetDeduplication)
+	recordings sync.Map
+	noDedup    bool
+
+	noLegacyFallback bool
+
+	retryAttempts int
+	retryDelay    time.Duration
+
+	progress ProgressFunc
+
+	coverFile   string
+	coverSize   string
+	saveLyrics  bool
+	embedLyrics bool
+	scrobble    bool
+	preview     bool
+
+	// chunks is the number of connections of a chunked download; 1 disables chunking
+	chunks int
+
+	releaseMtime bool
+
+	preflightOnce sync.Once
+	preflightErr  error
+
+	subscriptionOnce sync.Once
+	subscribed       bool
+
+	uidMu sync.Mutex
+	uid   string
+
+	cache    MetadataCache
+	memCache *memoryCache
+	offline  bool
+	archive  DownloadArchive
+
+	watchState string
+	batch      *BatchState
+
+	nameTemplate string
+
+	noStandardTags bool
+	noFallback     bool
+	codecs         string
+	overwrite      OverwritePolicy
+	noExtraTags    bool
+	skipExplicit   bool
+	keepEncrypted  bool
+	strictQuality  bool
+	trackFilter    TrackFilter
+
+	sidecar          SidecarFormat
+	preDownloadHook  PreDownloadHook
+	postDownloadHook PostDownloadHook
+
+	requireComplete bool
+	failFast        bool
 }
 
 // NewClient creates a new client for working with the Yandex Music API
@@ -51,93 +182,167 @@ func NewClient(accessToken, signKey string, log *logger.Logger) *Client {
 		log = logger.New(false)
 	}
 
+	transport := newTransport(DefaultMaxConnsPerHost)
+
 	client := &Client{
 		accessToken: accessToken,
-		signKey:     signKey,
+		signKeys:    []string{signKey},
 		logger:      log,
-		httpClient:  &http.Client{Timeout: 30 * time.Second},
-	}
+		httpClient:  &http.Client{Timeout: DefaultAPITimeout, Transport: transport},
+		baseURL:     api.BaseURL,
 
-	client.headers = map[string]string{
-		"Accept-Language":       "ru",
-		"Authorization":         fmt.Sprintf("OAuth %s", accessToken),
-		"x-yandex-music-client": api.DefaultClient,
+		transport:      transport,
+		downloadClient: &http.Client{Transport: transport},
+		hosts:          newHostLimiter(DefaultMaxConnsPerHost),
+		limiter:        newRateLimiter(DefaultRequestsPerSecond, DefaultRequestBurst),
+		mediaSlots:     make(chan struct{}, DefaultMaxMediaDownloads),
+
+		slowThreshold: DefaultSlowCallThreshold,
+		retryAttempts: DefaultRetryAttempts,
+		retryDelay:    DefaultRetryDelay,
+		stallTimeout:  DefaultStallTimeout,
+		codecs:        api.Codecs,
+
+		memCache: newMemoryCache(DefaultMemoryCacheTTL, DefaultMemoryCacheSize),
+
+		lockStaleness: DefaultLockStaleness,
 	}
 
+	client.preset = api.ClientPresets[api.DefaultPreset]
+	client.buildHeaders()
+
 	return client
 }
 
-// GetTrackInfo retrieves track metadata
-func (c *Client) GetTrackInfo(trackID string) (map[string]interface{}, error) {
-	c.logger.Debug("Getting track metadata %s", trackID)
+// SetClientPreset switches the client identity headers to the named preset
+// (see api.ClientPresets). Headers set with SetHeader keep overriding the preset.
+func (c *Client) SetClientPreset(name string) error {
+	preset, err := api.GetClientPreset(name)
+	if err != nil {
+		return err
+	}
+
+	c.preset = preset
+	c.buildHeaders()
+	return nil
+}
 
-	// Create multipart form
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+// SetHeader overrides a single request header on top of the client preset.
+// An empty value removes the header. The headers go to media requests too, except
+// the token.
+func (c *Client) SetHeader(key, value string) {
+	if c.headerOverrides == nil {
+		c.headerOverrides = make(map[string]string)
+	}
+	c.headerOverrides[key] = value
+	c.buildHeaders()
+}
 
-	// Add fields to the form
-	_ = writer.WriteField("trackIds", trackID)
-	_ = writer.WriteField("removeDuplicates", "false")
-	_ = writer.WriteField("withProgress", "true")
-	writer.Close()
+// buildHeaders assembles the request headers from the preset, the overrides and the token
+func (c *Client) buildHeaders() {
+	headers := c.preset.Headers()
+	headers["Accept-Encoding"] = "gzip"
 
-	// Create request
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/tracks", api.BaseURL), body)
-	if err != nil {
-		return nil, fmt.Errorf("request creation error: %w", err)
+	for key, value := range c.headerOverrides {
+		// Header names are case-insensitive; drop the preset spelling of the same header
+		for existing := range headers {
+			if strings.EqualFold(existing, key) {
+				delete(headers, existing)
+			}
+		}
+		if value != "" {
+			headers[key] = value
+		}
 	}
 
-	// Set headers
-	for key, value := range c.headers {
-		req.Header.Set(key, value)
+	// Media hosts get the identity headers only: the token is not theirs, and media
+	// must not be compressed on the wire
+	mediaHeaders := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if key != "Accept-Encoding" {
+			mediaHeaders[key] = value
+		}
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	// Execute request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request execution error: %w", err)
+	headers["Authorization"] = fmt.Sprintf("OAuth %s", c.accessToken)
+	c.headers = headers
+	c.mediaHeaders = mediaHeaders
+}
+
+// SetBaseURL points the client at a different API server, e.g. a test server or a caching proxy
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetMediaBaseURL sends media requests (audio files, covers and lyrics) to a different
+// server, e.g. a test server or a caching proxy: the scheme and host of the media URLs
+// returned by the API are replaced, and the path and query are appended to the path of
+// baseURL. An empty baseURL restores the original hosts.
+func (c *Client) SetMediaBaseURL(baseURL string) error {
+	if baseURL == "" {
+		c.mediaBaseURL = nil
+		return nil
 	}
-	defer resp.Body.Close()
 
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		responseBody, _ := io.ReadAll(resp.Body)
-		c.logger.Debug("API error response: %s", string(responseBody))
-		return nil, fmt.Errorf("API returned an error: %s", resp.Status)
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid media base URL %q", baseURL)
 	}
+	c.mediaBaseURL = u
+	return nil
+}
 
-	// Read response body for debugging and parsing
-	responseData, err := io.ReadAll(resp.Body)
+// mediaURL rewrites a media URL to the media base URL when one is set
+func (c *Client) mediaURL(fileURL string) string {
+	if c.mediaBaseURL == nil {
+		return fileURL
+	}
+
+	u, err := url.Parse(fileURL)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
+		return fileURL
 	}
+	rewritten := *c.mediaBaseURL
+	rewritten.Path += u.Path
+	rewritten.RawPath = ""
+	rewritten.RawQuery = u.RawQuery
+	return rewritten.String()
+}
 
-	// Log raw response for debugging
-	c.logger.Debug("Raw API response: %s", string(responseData))
+// trackLogger returns a sub-logger carrying the track ID and processing phase
+func (c *Client) trackLogger(trackID, phase string) *logger.Logger {
+	return c.logger.With(map[string]interface{}{
+		"track_id": trackID,
+		"phase":    phase,
+	})
+}
 
-	// First try to parse as generic map to inspect structure
-	var rawResponse map[string]interface{}
-	if err := json.Unmarshal(responseData, &rawResponse); err != nil {
-		return nil, fmt.Errorf("error parsing raw response: %w", err)
-	}
+// GetTrackInfo retrieves track metadata
+func (c *Client) GetTrackInfo(trackID string) (*api.TrackInfo, error) {
+	return c.GetTrackInfoContext(context.Background(), trackID)
+}
 
-	// Check raw response structure
-	result, ok := rawResponse["result"].([]interface{})
-	if !ok || len(result) == 0 {
-		return nil, fmt.Errorf("invalid result format in raw response")
-	}
+// GetTrackInfoContext retrieves track metadata; the request is cancelled with ctx
+func (c *Client) GetTrackInfoContext(ctx context.Context, trackID string) (*api.TrackInfo, error) {
+	return c.getTrackInfo(ctx, trackID, c.trackLogger(trackID, phaseMetadata))
+}
+
+// getTrackInfo retrieves track metadata logging to log
+func (c *Client) getTrackInfo(ctx context.Context, trackID string, log *logger.Logger) (*api.TrackInfo, error) {
+	trackID, _ = splitTrackRef(trackID, "")
+	log.Debug("Getting track metadata")
 
-	// Log raw track info for debugging
-	trackMap, ok := result[0].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid track format in raw response")
+	if trackInfo, ok := c.cachedTrack(trackID); ok {
+		log.Debug("Track metadata served from cache")
+		return trackInfo, nil
 	}
 
-	c.logger.Debug("Raw track title: %v", trackMap["title"])
-	c.logger.Debug("Raw artists: %v", trackMap["artists"])
+	responseData, err := c.apiGet(ctx, fmt.Sprintf("/tracks/%s", url.PathEscape(trackID)), "/tracks", log)
+	if err != nil {
+		return nil, err
+	}
 
-	// Parse response using our defined structure
+	// Parse response
 	var trackResponse api.TrackResponse
 	if err := json.Unmarshal(responseData, &trackResponse); err != nil {
 		return nil, fmt.Errorf("response parsing error: %w", err)
@@ -145,106 +350,425 @@ func (c *Client) GetTrackInfo(trackID string) (map[string]interface{}, error) {
 
 	// Validate response
 	if len(trackResponse.Result) == 0 {
-		return nil, fmt.Errorf("no track information found in API response")
-	}
-
-	// Log structured data for debugging
-	c.logger.Debug("Structured track title: %s", trackResponse.Result[0].Title)
-	if len(trackResponse.Result[0].Artists) > 0 {
-		c.logger.Debug("Structured artist name: %s", trackResponse.Result[0].Artists[0].Name)
-	} else {
-		c.logger.Debug("No artists found in structured response")
-	}
-
-	// Create trackInfo from raw response instead of structured
-	trackInfo := make(map[string]interface{})
-
-	// Basic track info
-	trackInfo["id"] = trackMap["id"]
-	trackInfo["title"] = trackMap["title"]
-	trackInfo["durationMs"] = trackMap["durationMs"]
-
-	// Add artists from raw response
-	if rawArtists, ok := trackMap["artists"].([]interface{}); ok && len(rawArtists) > 0 {
-		artists := make([]map[string]interface{}, len(rawArtists))
-		for i, rawArtist := range rawArtists {
-			if artistMap, ok := rawArtist.(map[string]interface{}); ok {
-				artist := make(map[string]interface{})
-				artist["id"] = artistMap["id"]
-				artist["name"] = artistMap["name"]
-				artists[i] = artist
-				c.logger.Debug("Adding artist: %v", artist["name"])
-			}
+		return nil, fmt.Errorf("no track information found in API response: %w", ErrTrackNotFound)
+	}
+
+	trackInfo := &trackResponse.Result[0]
+	c.storeTrack(trackInfo)
+	log.Debug("Track title: %s, artists: %d, albums: %d", trackInfo.Title, len(trackInfo.Artists), len(trackInfo.Albums))
+
+	return trackInfo, nil
+}
+
+// DownloadTrackTo downloads a track and streams the decrypted audio into w without creating
+// any files, e.g. to pipe it into an HTTP response. It returns the metadata of the track with
+// the content type of the audio and the file name DownloadTrack would have used. Cancelling
+// ctx aborts the requests and the transfer. The audio goes through the same verification as
+// file downloads, so nothing is written to w when the decryption produces noise.
+func (c *Client) DownloadTrackTo(ctx context.Context, trackID string, quality AudioQuality, w io.Writer) (meta *TrackMeta, err error) {
+	trackID, albumID := splitTrackRef(trackID, "")
+
+	progress := c.newProgress(trackID)
+	defer func() { progress.finish(err) }()
+
+	log := c.trackLogger(trackID, phaseMetadata).WithField("quality", string(quality))
+	trackInfo, err := c.getTrackInfo(ctx, trackID, log)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAvailable(trackInfo); err != nil {
+		return nil, err
+	}
+
+	if c.preview {
+		quality = api.QualityMin
+	}
+	log = c.trackLogger(trackID, phaseDownload).WithField("quality", string(quality))
+	downloadInfo, err := c.trackDownloadInfo(ctx, trackInfo, trackID, api.ConvertQuality(quality), log)
+	if err != nil {
+		return nil, err
+	}
+	meta = newTrackMeta(trackInfo, downloadInfo, albumID, c.albumPolicy)
+	meta.FileName = c.trackFileName(trackInfo, trackID, albumID, downloadInfo.Extension(), log)
+	progress.setTotal(int64(downloadInfo.Size))
+
+	var limit int64
+	if c.preview {
+		meta.FileName = previewName(meta.FileName)
+		limit = previewSize(trackInfo, downloadInfo)
+	}
+
+	mirrors, err := downloadMirrors(downloadInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.downloadDecrypted(ctx, mirrors, downloadInfo, w, "", limit, progress, log); err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+// trackFileName forms the output file name from the track metadata and the codec extension:
+// Track Title - Artist1 & Artist2 (Album1, Album2) [ID трека].m4a
+// Uploaded tracks keep the name of the uploaded file (see ugcFileName).
+func (c *Client) trackFileName(trackInfo *api.TrackInfo, trackID, albumID, ext string, log *logger.Logger) string {
+	if isUGC(trackInfo, trackID) {
+		return ugcFileName(trackInfo, trackID, ext)
+	}
+
+	title, artist, albumsStr := trackNames(trackInfo)
+	if album := selectAlbum(trackInfo, albumID, c.albumPolicy); album != nil && album.Title != "" {
+		log.Debug("Selected album %s (%s) of %d", album.ID, album.Title, len(trackInfo.Albums))
+		albumsStr = album.Title
+	}
+	log.Debug("Track: %s, artists: %s, albums: %s", title, artist, albumsStr)
+
+	// Tracks of an album fetched with GetAlbum sort in album order
+	album := singleAlbum(trackInfo, albumID, c.albumPolicy)
+	var numbering trackNumbering
+	inAlbum := false
+	if album != nil {
+		numbering, inAlbum = c.numbering(trackInfo, album)
+	}
+
+	if c.nameTemplate != "" {
+		year, genre, label := albumFields(album)
+		track, disc := "", ""
+		if numbering.Index > 0 {
+			track = fmt.Sprintf("%0*d", numbering.Width, numbering.Index)
+		}
+		if numbering.Volume > 0 {
+			disc = strconv.Itoa(numbering.Volume)
+		}
+		return templateFileName(c.nameTemplate, map[string]string{
+			FieldTitle:  title,
+			FieldArtist: artist,
+			FieldAlbum:  albumsStr,
+			FieldID:     trackID,
+			FieldYear:   year,
+			FieldGenre:  genre,
+			FieldLabel:  label,
+			FieldTrack:  track,
+			FieldDisc:   disc,
+		}, ext)
+	}
+
+	// Clean names from invalid characters
+	safeTitle := utils.CleanFileName(title)
+	safeArtist := utils.CleanFileName(artist)
+	safeAlbums := utils.CleanFileName(albumsStr)
+
+	prefix := ""
+	if inAlbum {
+		prefix = numbering.prefix(c.layout == LayoutArtistAlbum)
+	}
+	return fmt.Sprintf("%s%s - %s (%s) [%s]%s", prefix, safeTitle, safeArtist, safeAlbums, trackID, ext)
+}
+
+// downloadDecrypted downloads the first working mirror URL, holding a per-host transfer slot,
+// and writes the decrypted audio to w. AES-CTR is a stream cipher, so the response body is
+// decrypted on the fly and memory usage is constant regardless of the track size.
+// The transfer is aborted when ctx is done. Failures to start the transfer are retried;
+// once audio has been written to w, an interrupted transfer fails. Transfers of an unexpected
+// size and audio not starting with the container signature of the codec fail as well.
+// A positive limit stops the transfer after that many bytes of audio, without the size check.
+// With chunked downloads enabled the file is first downloaded in byte ranges (see
+// SetChunkedDownload) and decrypted from the temporary file.
+func (c *Client) downloadDecrypted(ctx context.Context, mirrors []string, downloadInfo *api.DownloadInfo, w io.Writer,
+	encryptedPath string, limit int64, progress *progressTracker, log *logger.Logger) error {
+	releaseSlot, err := c.acquireMediaSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer releaseSlot()
+
+	// The transfer is cancelled on its own when it stalls
+	transferCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// The encrypted file, counted in progress as it is downloaded
+	var source io.Reader
+	if c.chunks > 1 && limit <= 0 {
+		file, err := c.downloadChunked(transferCtx, mirrors, progress, log)
+		switch {
+		case err == nil:
+			defer os.Remove(file.Name())
+			defer file.Close()
+			source = file
+		case !errors.Is(err, errNoChunking):
+			return err
 		}
-		trackInfo["artists"] = artists
+	}
+	if source == nil {
+		var resp *http.Response
+		var release func()
+		err = c.withRetry(ctx, log, func() error {
+			resp, release, err = c.openMirror(transferCtx, mirrors, log)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		defer release()
+		defer resp.Body.Close()
+		progress.setTotal(resp.ContentLength)
+
+		body := io.Reader(resp.Body)
+		if c.stallTimeout > 0 {
+			stall := newStallReader(resp.Body, c.stallTimeout, func() { cancel(ErrStalled) })
+			defer stall.stop()
+			body = stall
+		}
+		source = &progressReader{r: c.throttle(transferCtx, body), tracker: progress}
 	}
 
-	// Add albums from raw response
-	if rawAlbums, ok := trackMap["albums"].([]interface{}); ok && len(rawAlbums) > 0 {
-		albums := make([]map[string]interface{}, len(rawAlbums))
-		for i, rawAlbum := range rawAlbums {
-			if albumMap, ok := rawAlbum.(map[string]interface{}); ok {
-				album := make(map[string]interface{})
-				album["id"] = albumMap["id"]
-				album["title"] = albumMap["title"]
-				albums[i] = album
+	// The encrypted file is saved completely before decryption, so it is kept whole even
+	// when the decrypted data turns out to be corrupt
+	if encryptedPath != "" && downloadInfo.Transport != legacyTransport && limit <= 0 {
+		file, err := saveEncrypted(ctx, source, encryptedPath)
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(context.Cause(transferCtx), ErrStalled) {
+				return fmt.Errorf("%w: no data received for %s", ErrStalled, c.stallTimeout)
 			}
+			return err
 		}
-		trackInfo["albums"] = albums
+		defer file.Close()
+		log.Debug("Encrypted file kept: %s", encryptedPath)
+		source = file
 	}
 
-	// Verify the trackInfo map has the expected values
-	c.logger.Debug("Track title in trackInfo: %v", trackInfo["title"])
-	if artists, ok := trackInfo["artists"].([]map[string]interface{}); ok && len(artists) > 0 {
-		c.logger.Debug("First artist name in trackInfo: %v", artists[0]["name"])
+	counter := &countingReader{r: source}
+	// Files of the legacy download API are not encrypted
+	var reader io.Reader = counter
+	if downloadInfo.Transport != legacyTransport {
+		reader, err = crypto.NewDecryptReader(reader, downloadInfo.Key)
+		if err != nil {
+			return fmt.Errorf("error decrypting file: %w", err)
+		}
+	}
+	if limit > 0 {
+		reader = io.LimitReader(reader, limit)
 	}
 
-	return trackInfo, nil
+	checked := &headerCheckWriter{w: w, expected: expectedContainer(downloadInfo)}
+	if _, err := io.Copy(checked, reader); err != nil {
+		if errors.Is(err, ErrCorruptAudio) {
+			return err
+		}
+		if ctx.Err() == nil && errors.Is(context.Cause(transferCtx), ErrStalled) {
+			return fmt.Errorf("%w: no data received for %s after %d bytes", ErrStalled, c.stallTimeout, counter.n)
+		}
+		return fmt.Errorf("error writing decrypted audio: %w", err)
+	}
+	if err := checked.flush(); err != nil {
+		if errors.Is(err, ErrCorruptAudio) {
+			return err
+		}
+		return fmt.Errorf("error writing decrypted audio: %w", err)
+	}
+
+	if limit > 0 {
+		log.Debug("Downloaded %d bytes of %d, cut at %d bytes of audio", counter.n, downloadInfo.Size, limit)
+		return nil
+	}
+	if err := checkSize(downloadInfo, counter.n); err != nil {
+		return err
+	}
+	log.Debug("Downloaded %d bytes, container %s verified", counter.n, checked.expected)
+	return nil
 }
 
-// GetDownloadInfo retrieves information for downloading a track
-func (c *Client) GetDownloadInfo(trackID string, quality ApiTrackQuality) (map[string]interface{}, error) {
-	c.logger.Debug("Getting download info for track %s", trackID)
+// getMedia requests a media file and checks the response status
+func (c *Client) getMedia(ctx context.Context, fileURL string) (*http.Response, error) {
+	resp, err := c.doMedia(ctx, fileURL, "")
+	if err != nil {
+		return nil, err
+	}
 
-	// Form request parameters
-	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, newMediaStatusError(resp)
+	}
 
-	// Request parameters as a map for URL formation convenience
-	params := map[string]string{
-		"ts":         ts,
-		"trackId":    trackID,
-		"quality":    string(quality),
-		"codecs":     api.Codecs,
-		"transports": api.Transport,
+	return resp, nil
+}
+
+// doMedia sends a media request with the media headers and, unless empty, a Range header
+func (c *Client) doMedia(ctx context.Context, fileURL, byteRange string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.mediaURL(fileURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("request creation error: %w", err)
+	}
+	for key, value := range c.mediaHeaders {
+		req.Header.Set(key, value)
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
 	}
 
-	// Generate signature
-	// Important: assemble the data string in the correct order
-	dataString := ts + trackID + string(quality) + api.Codecs + api.Transport
-	params["sign"] = crypto.GenerateSignature(dataString, c.signKey)
+	resp, err := c.downloadClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading file: %w", err)
+	}
+	return resp, nil
+}
 
-	// Log parameters and signature
-	c.logger.Debug("Request parameters: ts=%s, trackId=%s, quality=%s", ts, trackID, quality)
-	c.logger.Debug("Generated signature: %s", params["sign"])
+// readBody reads the whole response body, decoding it according to Content-Encoding.
+// Since the client sets Accept-Encoding itself, the transport does not decompress transparently,
+// and a custom transport or proxy may compress responses even when not asked to.
+func (c *Client) readBody(resp *http.Response, log *logger.Logger) ([]byte, error) {
+	encodings := contentEncodings(resp.Header.Get("Content-Encoding"))
+	if len(encodings) == 0 {
+		return io.ReadAll(resp.Body)
+	}
+
+	// Count compressed bytes for the debug log
+	counter := &countingReader{r: resp.Body}
+	var reader io.Reader = counter
+
+	// Codings are listed in the order they were applied, so they are undone in reverse
+	for i := len(encodings) - 1; i >= 0; i-- {
+		decoder, err := newDecoder(reader, encodings[i])
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		reader = decoder
+	}
 
-	// Form URL with parameters
-	baseURL := fmt.Sprintf("%s/get-file-info", api.BaseURL)
-	reqURL, err := url.Parse(baseURL)
+	data, err := io.ReadAll(reader)
 	if err != nil {
-		return nil, fmt.Errorf("URL formation error: %w", err)
+		return nil, fmt.Errorf("%s decoding error: %w", strings.Join(encodings, ", "), err)
 	}
 
-	// Add request parameters
-	query := reqURL.Query()
-	for key, value := range params {
-		query.Add(key, value)
+	log.Debug("Response size: %d bytes compressed, %d bytes decoded", counter.n, len(data))
+	return data, nil
+}
+
+// contentEncodings returns the lowercased codings of a Content-Encoding header,
+// leaving out identity
+func contentEncodings(header string) []string {
+	var encodings []string
+	for _, encoding := range strings.Split(header, ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding != "" && encoding != "identity" {
+			encodings = append(encodings, encoding)
+		}
 	}
-	reqURL.RawQuery = query.Encode()
+	return encodings
+}
 
-	// Create request
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
+// newDecoder returns a reader undoing one content coding. Deflate is accepted both
+// zlib-wrapped, as the standard requires, and raw, as some servers send it.
+// Brotli has no decoder in the standard library and is reported as unsupported.
+func newDecoder(r io.Reader, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("gzip decoding error: %w", err)
+		}
+		return gzipReader, nil
+	case "deflate":
+		buffered := bufio.NewReader(r)
+		header, err := buffered.Peek(2)
+		if err != nil {
+			return nil, fmt.Errorf("deflate decoding error: %w", err)
+		}
+		if !isZlibHeader(header) {
+			return flate.NewReader(buffered), nil
+		}
+		zlibReader, err := zlib.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("deflate decoding error: %w", err)
+		}
+		return zlibReader, nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+// isZlibHeader reports whether the first two bytes of a stream are a zlib header
+// with the deflate compression method
+func isZlibHeader(header []byte) bool {
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// apiGet performs an authorized GET request to the API and returns the response body.
+// The endpoint is used as the key for call statistics.
+func (c *Client) apiGet(ctx context.Context, path, endpoint string, log *logger.Logger) ([]byte, error) {
+	var data []byte
+	err := c.withRetry(ctx, log, func() error {
+		// Create request
+		req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+		if err != nil {
+			return fmt.Errorf("request creation error: %w", err)
+		}
+
+		data, err = c.apiDo(req, endpoint, log)
+		return err
+	})
+	return data, err
+}
+
+// apiPostForm performs an authorized form-urlencoded POST request to the API
+func (c *Client) apiPostForm(ctx context.Context, path, endpoint string, form url.Values, log *logger.Logger) ([]byte, error) {
+	var data []byte
+	err := c.withRetry(ctx, log, func() error {
+		// Create request
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, strings.NewReader(form.Encode()))
+		if err != nil {
+			return fmt.Errorf("request creation error: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		data, err = c.apiDo(req, endpoint, log)
+		return err
+	})
+	return data, err
+}
+
+// apiPostJSON performs an authorized POST request to the API with a JSON body
+func (c *Client) apiPostJSON(ctx context.Context, path, endpoint string, body interface{}, log *logger.Logger) ([]byte, error) {
+	payload, err := json.Marshal(body)
 	if err != nil {
-		return nil, fmt.Errorf("request creation error: %w", err)
+		return nil, fmt.Errorf("request encoding error: %w", err)
+	}
+
+	var data []byte
+	err = c.withRetry(ctx, log, func() error {
+		// Create request
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("request creation error: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		data, err = c.apiDo(req, endpoint, log)
+		return err
+	})
+	return data, err
+}
+
+// apiDo executes an API request with the client headers and returns the response body
+func (c *Client) apiDo(req *http.Request, endpoint string, log *logger.Logger) ([]byte, error) {
+	if c.offline {
+		return nil, fmt.Errorf("%s: %w", endpoint, ErrOffline)
 	}
 
 	// Set headers
@@ -252,7 +776,15 @@ func (c *Client) GetDownloadInfo(trackID string, quality ApiTrackQuality) (map[s
 		req.Header.Set(key, value)
 	}
 
+	log.Debug("%s %s (client preset: %s, x-yandex-music-client: %s)",
+		req.Method, req.URL.Path, c.preset.Name, req.Header.Get("x-yandex-music-client"))
+
+	if err := c.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+
 	// Execute request
+	started := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request execution error: %w", err)
@@ -261,300 +793,424 @@ func (c *Client) GetDownloadInfo(trackID string, quality ApiTrackQuality) (map[s
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned an error: %s", resp.Status)
+		responseBody, _ := c.readBody(resp, log)
+		log.Debug("API error response: %s", string(responseBody))
+		apiErr := newAPIError(resp, responseBody)
+		withReqID(log, apiErr.ReqID).Debug("API call %s failed: status %d, total %s",
+			endpoint, resp.StatusCode, time.Since(started).Round(time.Millisecond))
+		return nil, apiErr
 	}
 
-	// Parse response
-	var response map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("response parsing error: %w", err)
+	// Read response body for debugging and parsing
+	responseData, err := c.readBody(resp, log)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
+	c.recordCall(endpoint, time.Since(started), responseData, log)
 
-	// Check for result and downloadInfo presence
-	result, ok := response["result"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid response format: %v", response)
-	}
+	// Log raw response for debugging
+	log.Debug("Raw API response: %s", string(responseData))
 
-	downloadInfo, ok := result["downloadInfo"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid response format: %v", response)
-	}
+	return responseData, nil
+}
 
-	return downloadInfo, nil
+// tracksBatchSize is the maximum number of track IDs per /tracks request
+const tracksBatchSize = 100
+
+// GetTracks retrieves metadata for many tracks with one request per batch of up to 100 IDs,
+// keyed by track ID. Unknown tracks are absent from the returned map.
+func (c *Client) GetTracks(trackIDs []string) (map[string]*TrackInfo, error) {
+	return c.getTracks(context.Background(), trackIDs)
 }
 
-// DownloadTrack downloads and decrypts a track
-func (c *Client) DownloadTrack(trackID string, quality AudioQuality, outputDir string) (string, error) {
-	// Get track metadata
-	trackInfo, err := c.GetTrackInfo(trackID)
-	if err != nil {
-		c.logger.Error("Error getting track metadata %s: %v", trackID, err)
-		return "", err
+// GetTracksContext is GetTracks with cancellation through ctx
+func (c *Client) GetTracksContext(ctx context.Context, trackIDs []string) (map[string]*TrackInfo, error) {
+	return c.getTracks(ctx, trackIDs)
+}
+
+// getTracks retrieves metadata for many tracks with one /tracks request per batch.
+// Tracks missing from the response are absent from the returned map.
+func (c *Client) getTracks(ctx context.Context, trackIDs []string) (map[string]*api.TrackInfo, error) {
+	result := make(map[string]*api.TrackInfo, len(trackIDs))
+
+	// Only request tracks missing from the cache
+	var missing []string
+	for _, id := range trackIDs {
+		if trackInfo, ok := c.cachedTrack(id); ok {
+			result[id] = trackInfo
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) < len(trackIDs) {
+		c.logger.Debug("Metadata for %d of %d tracks served from cache", len(trackIDs)-len(missing), len(trackIDs))
 	}
 
-	// Form filename from metadata
-	artist := "Unknown"
-	title := "Unknown"
-	albumsStr := "Unknown"
-	artists := []string{}
-	albums := []string{}
+	for start := 0; start < len(missing); start += tracksBatchSize {
+		end := min(start+tracksBatchSize, len(missing))
+		batch := missing[start:end]
+		c.logger.Debug("Getting metadata for %d tracks", len(batch))
 
-	// Log the trackInfo structure for debugging
-	c.logger.Debug("TrackInfo structure: %+v", trackInfo)
+		form := url.Values{}
+		form.Set("trackIds", strings.Join(batch, ","))
+		form.Set("removeDuplicates", "false")
 
-	// Extract track title
-	if trackTitle, ok := trackInfo["title"].(string); ok {
-		title = trackTitle
-		c.logger.Debug("Found track title: %s", title)
-	}
+		responseData, err := c.apiPostForm(ctx, "/tracks", "/tracks", form, c.logger)
+		if err != nil {
+			return nil, err
+		}
 
-	// Extract artists - collect all artists
-	if artistsList, ok := trackInfo["artists"].([]map[string]interface{}); ok && len(artistsList) > 0 {
-		// Format returned by our improved GetTrackInfo
-		for _, artistMap := range artistsList {
-			if artistName, ok := artistMap["name"].(string); ok {
-				artists = append(artists, artistName)
-				c.logger.Debug("Found artist: %s", artistName)
-			}
+		var trackResponse api.TrackResponse
+		if err := json.Unmarshal(responseData, &trackResponse); err != nil {
+			return nil, fmt.Errorf("response parsing error: %w", err)
 		}
-	} else if artistsList, ok := trackInfo["artists"].([]interface{}); ok && len(artistsList) > 0 {
-		// Original format
-		for _, a := range artistsList {
-			if artistMap, ok := a.(map[string]interface{}); ok {
-				if artistName, ok := artistMap["name"].(string); ok {
-					artists = append(artists, artistName)
-					c.logger.Debug("Found artist: %s", artistName)
-				}
-			}
+
+		for i := range trackResponse.Result {
+			trackInfo := &trackResponse.Result[i]
+			result[trackInfo.ID] = trackInfo
+			c.storeTrack(trackInfo)
 		}
 	}
 
-	// Join artists with &
-	if len(artists) > 0 {
-		artist = strings.Join(artists, " & ")
-		c.logger.Debug("Combined artists: %s", artist)
-	}
+	return result, nil
+}
 
-	// Extract album titles
-	if albumsList, ok := trackInfo["albums"].([]map[string]interface{}); ok && len(albumsList) > 0 {
-		for _, albumMap := range albumsList {
-			if albumTitle, ok := albumMap["title"].(string); ok {
-				albums = append(albums, albumTitle)
-				c.logger.Debug("Found album: %s", albumTitle)
-			}
-		}
-	} else if albumsList, ok := trackInfo["albums"].([]interface{}); ok && len(albumsList) > 0 {
-		for _, a := range albumsList {
-			if albumMap, ok := a.(map[string]interface{}); ok {
-				if albumTitle, ok := albumMap["title"].(string); ok {
-					albums = append(albums, albumTitle)
-					c.logger.Debug("Found album: %s", albumTitle)
-				}
-			}
-		}
+// splitTrackRef splits a "trackId:albumId" ID; the album ID from the ID is used as the
+// album hint unless albumID is already given
+func splitTrackRef(id, albumID string) (string, string) {
+	trackID, idAlbumID := utils.SplitTrackID(id)
+	if albumID == "" {
+		albumID = idAlbumID
 	}
+	return trackID, albumID
+}
 
-	// Join albums with comma
+// trackNames returns the title, joined artist names and joined album titles of a track.
+// Missing values are reported as "Unknown".
+func trackNames(trackInfo *api.TrackInfo) (title, artist, albums string) {
+	title = "Unknown"
+	artist = "Unknown"
+	albums = "Unknown"
 
-	if len(albums) > 0 {
-		albumsStr = strings.Join(albums, ", ")
-		c.logger.Debug("Combined albums: %s", albumsStr)
+	if trackInfo.Title != "" {
+		title = trackInfo.Title
 	}
 
-	// If still no title, artist or albums, try to get them directly from the API again
-	if title == "Unknown" || artist == "Unknown" || (len(albums) == 0 && albumsStr == "Unknown") {
-		c.logger.Debug("Missing title, artist or album, trying direct API access")
+	if names := joinArtists(trackInfo.Artists); names != "" {
+		artist = names
+	}
 
-		// This is a fallback method to get track info if the structured approach failed
-		apiTitle, apiArtist, apiAlbum := c.getTrackInfoFallback(trackID)
-		if title == "Unknown" && apiTitle != "" {
-			title = apiTitle
-			c.logger.Debug("Using fallback title: %s", title)
-		}
-		if artist == "Unknown" && apiArtist != "" {
-			artist = apiArtist
-			c.logger.Debug("Using fallback artist: %s", artist)
-		}
-		if albumsStr == "Unknown" && apiAlbum != "" {
-			albumsStr = apiAlbum
-			c.logger.Debug("Using fallback album: %s", albumsStr)
+	// Join albums with comma
+	albumTitles := make([]string, 0, len(trackInfo.Albums))
+	for _, a := range trackInfo.Albums {
+		if a.Title != "" {
+			albumTitles = append(albumTitles, a.Title)
 		}
 	}
+	if len(albumTitles) > 0 {
+		albums = strings.Join(albumTitles, ", ")
+	}
 
-	// Clean names from invalid characters
-	safeTitle := utils.CleanFileName(title)
-	safeArtist := utils.CleanFileName(artist)
-	safeAlbums := utils.CleanFileName(albumsStr)
+	return title, artist, albums
+}
+
+// GetDownloadInfo retrieves information for downloading a track: the media URLs,
+// the decryption key and the codec and bitrate actually delivered
+func (c *Client) GetDownloadInfo(trackID string, quality ApiTrackQuality) (*api.DownloadInfo, error) {
+	return c.GetDownloadInfoContext(context.Background(), trackID, quality)
+}
+
+// GetDownloadInfoContext retrieves information for downloading a track; the request is cancelled with ctx
+func (c *Client) GetDownloadInfoContext(ctx context.Context, trackID string, quality ApiTrackQuality) (*api.DownloadInfo, error) {
+	log := c.trackLogger(trackID, phaseDownload).WithField("quality", string(quality))
+	if isUGCID(trackID) {
+		return c.ugcDownloadInfo(ctx, trackID, quality, log)
+	}
+	return c.getDownloadInfo(ctx, trackID, quality, log)
+}
 
-	// Format: Track Title - Artist1 & Artist2 (Album1, Album2) [ID трека]
-	fileName := fmt.Sprintf("%s - %s (%s) [%s].m4a", safeTitle, safeArtist, safeAlbums, trackID)
+// requestDownloadInfo retrieves information for downloading a track in exactly the given quality
+func (c *Client) requestDownloadInfo(ctx context.Context, trackID string, quality ApiTrackQuality,
+	log *logger.Logger) (*api.DownloadInfo, error) {
+	log.Debug("Getting download info for quality %s", quality)
+
+	var responseData []byte
+	err := c.withSignKey(ctx, log, func(signKey string) error {
+		// Form request parameters
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+		// Request parameters as a map for URL formation convenience
+		params := map[string]string{
+			"ts":         ts,
+			"trackId":    trackID,
+			"quality":    string(quality),
+			"codecs":     c.codecs,
+			"transports": api.Transport,
+		}
 
-	c.logger.Info("Got information: %s", fileName)
+		// Generate signature
+		// Important: assemble the data string in the correct order
+		dataString := ts + trackID + string(quality) + c.codecs + api.Transport
+		params["sign"] = crypto.GenerateSignature(dataString, signKey)
 
-	// Get download information considering the selected quality
-	apiQuality := api.ConvertQuality(quality)
-	downloadInfo, err := c.GetDownloadInfo(trackID, apiQuality)
+		// Log parameters and signature
+		log.Debug("Request parameters: ts=%s, trackId=%s, quality=%s", ts, trackID, quality)
+		log.Debug("Generated signature: %s", params["sign"])
+
+		// Add request parameters
+		query := url.Values{}
+		for key, value := range params {
+			query.Add(key, value)
+		}
+
+		var err error
+		responseData, err = c.apiGet(ctx, "/get-file-info?"+query.Encode(), "/get-file-info", log)
+		return err
+	})
 	if err != nil {
-		c.logger.Error("Error getting download information for track %s: %v", trackID, err)
-		return "", err
+		return nil, err
 	}
 
-	fileURL, ok := downloadInfo["url"].(string)
-	if !ok {
-		return "", fmt.Errorf("download URL not found")
+	// Parse response
+	var response api.DownloadInfoResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
 	}
 
-	decryptionKey, ok := downloadInfo["key"].(string)
-	if !ok {
-		return "", fmt.Errorf("decryption key not found")
+	// Validate response
+	downloadInfo := &response.Result.DownloadInfo
+	if downloadInfo.Url == "" && len(downloadInfo.Urls) == 0 {
+		return nil, fmt.Errorf("invalid response format: download URL not found")
 	}
+	if downloadInfo.Key == "" {
+		return nil, fmt.Errorf("invalid response format: decryption key not found")
+	}
+
+	log.Debug("Download info: codec %s, bitrate %d, size %d bytes", downloadInfo.Codec, downloadInfo.Bitrate, downloadInfo.Size)
+
+	return downloadInfo, nil
+}
+
+// DownloadTrack downloads and decrypts a track
+func (c *Client) DownloadTrack(trackID string, quality AudioQuality, outputDir string) (string, error) {
+	return c.DownloadAlbumTrackContext(context.Background(), trackID, "", quality, outputDir)
+}
 
-	// Create temporary files
-	tempID := uuid.New().String()
-	encryptedPath := fmt.Sprintf("encrypted_%s.raw", tempID)
+// DownloadTrackContext downloads and decrypts a track. Cancelling ctx aborts the
+// requests and the transfer and removes the partially downloaded temporary file.
+func (c *Client) DownloadTrackContext(ctx context.Context, trackID string, quality AudioQuality, outputDir string) (string, error) {
+	return c.DownloadAlbumTrackContext(ctx, trackID, "", quality, outputDir)
+}
+
+// DownloadAlbumTrack downloads and decrypts a track in the context of an album.
+// When the track appears on several albums, the album with albumID is used for naming;
+// an empty albumID selects the album according to the client album policy.
+func (c *Client) DownloadAlbumTrack(trackID, albumID string, quality AudioQuality, outputDir string) (string, error) {
+	return c.DownloadAlbumTrackContext(context.Background(), trackID, albumID, quality, outputDir)
+}
 
-	// Create a deferred function for cleaning up temporary files
-	// It will be called at any exit from the function - both normal and on error or panic
-	defer func() {
-		if _, err := os.Stat(encryptedPath); err == nil {
-			c.logger.Debug("Deleting temporary file: %s", encryptedPath)
-			os.Remove(encryptedPath)
+// DownloadAlbumTrackContext is DownloadAlbumTrack with cancellation through ctx.
+// A track skipped as already downloaded returns the existing file and no error; the path
+// is empty when no file of its own exists, e.g. for tracks in the download archive.
+// Explicit tracks skipped with SetSkipExplicit return ErrExplicit.
+func (c *Client) DownloadAlbumTrackContext(ctx context.Context, trackID, albumID string, quality AudioQuality,
+	outputDir string) (string, error) {
+	result, err := c.downloadTrack(ctx, trackID, albumID, nil, quality, outputDir, c.logger)
+	if errors.Is(err, errExists) {
+		if result.SkipReason == SkipExplicit {
+			return "", ErrExplicit
 		}
-	}()
+		return result.Path, nil
+	}
+	return result.Path, err
+}
 
-	// Check and create directory for saving, if needed
+// downloadTrack downloads and decrypts a track logging to a sub-logger of base and describes
+// the result. The result is returned on failures too, with the details known by then.
+// The metadata is fetched when trackInfo is nil.
+func (c *Client) downloadTrack(ctx context.Context, trackID, albumID string, trackInfo *api.TrackInfo,
+	quality AudioQuality, outputDir string, base *logger.Logger) (result *DownloadResult, err error) {
+	// Download info requests are signed with the plain track ID
+	trackID, albumID = splitTrackRef(trackID, albumID)
+	result = &DownloadResult{TrackID: trackID}
+
+	// Per-track logger; the phase field is updated as the download progresses
+	trackLog := base.With(map[string]interface{}{
+		"track_id": trackID,
+		"quality":  string(quality),
+	})
+	log := trackLog.WithField("phase", phaseMetadata)
+
+	// Resolve the output directory, if needed
 	if outputDir == "" {
 		currentDir, err := os.Getwd()
 		if err != nil {
-			return "", fmt.Errorf("error getting current directory: %w", err)
+			return result, fmt.Errorf("error getting current directory: %w", err)
 		}
 		outputDir = currentDir
 	}
 
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return "", fmt.Errorf("error creating directory: %w", err)
+	// Tracks downloaded by an earlier run are found by ID, so renamed titles still match
+	if !c.preview && c.skipArchived(result, log) {
+		return result, errExists
 	}
 
-	outputPath := filepath.Join(outputDir, fileName)
-
-	// Download encrypted file
-	c.logger.Info("Downloading track...")
-	resp, err := http.Get(fileURL)
-	if err != nil {
-		return "", fmt.Errorf("error downloading file: %w", err)
+	// The directory of a nested layout depends on the metadata, so it is checked later
+	if c.layout == LayoutFlat && c.skipExisting(outputDir, result, log) {
+		return result, errExists
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("error downloading file, status: %s", resp.Status)
+	progress := c.newProgress(trackID)
+	defer func() { progress.finish(err) }()
+
+	// Get track metadata
+	if trackInfo == nil {
+		trackInfo, err = c.getTrackInfo(ctx, trackID, log)
+		if err != nil {
+			log.Error("Error getting track metadata: %v", err)
+			return result, err
+		}
 	}
 
-	// Save encrypted file
-	encryptedFile, err := os.Create(encryptedPath)
-	if err != nil {
-		return "", fmt.Errorf("error creating temporary file: %w", err)
+	result.describeTrack(trackInfo, albumID, c.albumPolicy)
+	if err := checkAvailable(trackInfo); err != nil {
+		log.Warn("%v", err)
+		return result, err
+	}
+	if c.skipExplicitTrack(result, log) {
+		return result, errExists
+	}
+	if !c.preview && c.skipDuplicate(trackInfo, result, log) {
+		return result, errExists
 	}
 
-	_, err = io.Copy(encryptedFile, resp.Body)
-	encryptedFile.Close()
-	if err != nil {
-		return "", fmt.Errorf("error saving encrypted file: %w", err)
+	outputDir = c.trackDir(trackInfo, albumID, outputDir)
+	if c.layout != LayoutFlat && c.skipExisting(outputDir, result, log) {
+		return result, errExists
+	}
+	if c.skipExistingName(outputDir, trackInfo, albumID, result, log) {
+		return result, errExists
+	}
+	if err := c.runPreDownloadHook(trackInfo, result, log); err != nil {
+		return result, err
 	}
 
-	// Decrypt file
-	c.logger.Debug("Decryption key: %s", decryptionKey)
+	log = trackLog.WithField("phase", phaseDownload)
 
-	// Read encrypted data
-	encryptedData, err := os.ReadFile(encryptedPath)
+	// Get download information considering the selected quality; previews are cut anyway
+	if c.preview {
+		quality = api.QualityMin
+	}
+	apiQuality := api.ConvertQuality(quality)
+	downloadInfo, err := c.trackDownloadInfo(ctx, trackInfo, trackID, apiQuality, log)
 	if err != nil {
-		return "", fmt.Errorf("error reading encrypted file: %w", err)
+		log.Error("Error getting download information: %v", err)
+		return result, err
+	}
+	result.describeDownload(downloadInfo)
+	result.RequestedQuality, result.Downgraded = apiQuality, downgraded(apiQuality, downloadInfo)
+	if err := c.checkDelivered(apiQuality, downloadInfo, log); err != nil {
+		log.Error("%v", err)
+		return result, err
 	}
 
-	// Decrypt data
-	decrypted, err := crypto.DecryptAesCtr(encryptedData, decryptionKey)
-	if err != nil {
-		return "", fmt.Errorf("error decrypting file: %w", err)
+	// Form filename from metadata; the extension follows the delivered codec
+	fileName := c.trackFileName(trackInfo, trackID, albumID, downloadInfo.Extension(), log)
+	var limit int64
+	if c.preview {
+		fileName = previewName(fileName)
+		limit = previewSize(trackInfo, downloadInfo)
 	}
 
-	c.logger.Info("Saving file...")
+	log.Info("Got information: %s (%s, %s, %d kbps)", fileName, downloadInfo.Quality, downloadInfo.Codec, downloadInfo.Bitrate)
 
-	// Save decrypted file
-	err = os.WriteFile(outputPath, decrypted, 0644)
+	mirrors, err := downloadMirrors(downloadInfo)
 	if err != nil {
-		return "", fmt.Errorf("error saving decrypted file: %w", err)
+		return result, err
 	}
 
-	c.logger.Info("Done: %s", outputPath)
-	return outputPath, nil
-}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return result, fmt.Errorf("error creating directory: %w", err)
+	}
 
-// getTrackInfoFallback is a simpler method to extract basic track info when the main method fails
-func (c *Client) getTrackInfoFallback(trackID string) (title, artist, album string) {
-	// Create a simple GET request instead of POST with multipart form
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/tracks/%s", api.BaseURL, trackID), nil)
+	// Download and decrypt into a partial file next to the target; it replaces the target
+	// only once it is complete and tagged, so an interrupted run never leaves a corrupt
+	// file under the final name
+	outputPath, outputFile, finish, err := c.createPart(filepath.Join(outputDir, fileName), trackID, log)
+	if errors.Is(err, errLocked) {
+		log.Info("Already being downloaded by another process: %s", outputPath)
+		result.Path = outputPath
+		result.skip(SkipLocked)
+		return result, errExists
+	}
 	if err != nil {
-		c.logger.Debug("Fallback request creation error: %v", err)
-		return "", "", ""
+		return result, err
 	}
+	saved := false
+	defer func() { finish(saved) }()
+	partPath := outputPath + partSuffix
 
-	// Set headers
-	for key, value := range c.headers {
-		req.Header.Set(key, value)
-	}
+	log.Info("Downloading track...")
+	log.Debug("Decryption key: %s", downloadInfo.Key)
 
-	// Execute request
-	resp, err := c.httpClient.Do(req)
+	progress.setTotal(int64(downloadInfo.Size))
+	var encryptedPath string
+	if c.keepEncrypted {
+		encryptedPath = outputPath + encryptedSuffix
+	}
+	err = c.downloadDecrypted(ctx, mirrors, downloadInfo, outputFile, encryptedPath, limit, progress, log)
+	if closeErr := outputFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error saving decrypted file: %w", closeErr)
+	}
 	if err != nil {
-		c.logger.Debug("Fallback request execution error: %v", err)
-		return "", "", ""
+		return result, err
 	}
-	defer resp.Body.Close()
 
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		c.logger.Debug("Fallback API returned error status: %s", resp.Status)
-		return "", "", ""
+	log = trackLog.WithField("phase", phaseTag)
+
+	// Lyrics are fetched before tagging, so embedding them doesn't rewrite the file again
+	var lyrics, lyricsFormat string
+	if c.saveLyrics || c.embedLyrics {
+		lyrics, lyricsFormat = c.trackLyrics(ctx, trackID, trackInfo, log)
 	}
 
-	// Parse response as generic map
-	var response map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		c.logger.Debug("Fallback response parsing error: %v", err)
-		return "", "", ""
+	c.writeTags(partPath, trackInfo, albumID, lyrics, log)
+	if err := os.Rename(partPath, outputPath); err != nil {
+		return result, fmt.Errorf("error saving decrypted file: %w", err)
+	}
+	saved = true
+	if c.releaseMtime {
+		c.setReleaseTime(outputPath, trackInfo, albumID, log)
 	}
 
-	// Try to extract track info
-	var extractedTitle, extractedArtist, extractedAlbum string
+	if c.saveLyrics && lyrics != "" {
+		c.writeLyrics(outputPath, lyrics, lyricsFormat, log)
+	}
+	if c.sidecar != SidecarNone {
+		c.writeTrackSidecar(outputPath, trackInfo, albumID, log)
+	}
 
-	// Extract from result
-	if result, ok := response["result"].(map[string]interface{}); ok {
-		// Extract title
-		if trackTitle, ok := result["title"].(string); ok {
-			extractedTitle = trackTitle
-			c.logger.Debug("Fallback found title: %s", extractedTitle)
-		}
+	if c.scrobble && !c.preview {
+		c.scrobbleTrack(ctx, trackID, trackInfo, albumID, log)
+	}
 
-		// Extract artist
-		if artists, ok := result["artists"].([]interface{}); ok && len(artists) > 0 {
-			if artistMap, ok := artists[0].(map[string]interface{}); ok {
-				if artistName, ok := artistMap["name"].(string); ok {
-					extractedArtist = artistName
-					c.logger.Debug("Fallback found artist: %s", extractedArtist)
-				}
-			}
-		}
+	result.Path = outputPath
+	if info, err := os.Stat(outputPath); err == nil {
+		result.Size = info.Size()
+	}
 
-		// Extract album
-		if albums, ok := result["albums"].([]interface{}); ok && len(albums) > 0 {
-			if albumMap, ok := albums[0].(map[string]interface{}); ok {
-				if albumTitle, ok := albumMap["title"].(string); ok {
-					extractedAlbum = albumTitle
-					c.logger.Debug("Fallback found album: %s", extractedAlbum)
-				}
-			}
+	if c.archive != nil && !c.preview {
+		if err := c.archive.Add(trackID); err != nil {
+			log.Warn("Track not added to the download archive: %v", err)
 		}
 	}
+	if !c.preview {
+		c.addRecording(trackInfo, trackID, outputPath, log)
+	}
+	if err := c.runPostDownloadHook(result, log); err != nil {
+		return result, err
+	}
 
-	return extractedTitle, extractedArtist, extractedAlbum
+	log.Info("Done: %s", outputPath)
+	return result, nil
 }
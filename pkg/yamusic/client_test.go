@@ -0,0 +1,628 @@
+package yamusic
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+// newTestServer starts a fake API server serving the given handlers and a client pointed at it
+func newTestServer(t *testing.T, handlers map[string]http.HandlerFunc) (*Client, *httptest.Server) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for pattern, handler := range handlers {
+		mux.HandleFunc(pattern, handler)
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := NewClient("test-token", "", logger.New(false))
+	client.baseURL = server.URL
+
+	return client, server
+}
+
+// testDecryptionKey is the AES key used by the fake media server
+const testDecryptionKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+// newDownloadServer starts a fake API and CDN serving one track with the given audio content
+func newDownloadServer(t *testing.T, trackID string, audio []byte) *Client {
+	t.Helper()
+	return newDownloadServerWith(t, trackID, audio, nil)
+}
+
+// newDownloadServerWith is newDownloadServer serving additional API handlers
+func newDownloadServerWith(t *testing.T, trackID string, audio []byte, extra map[string]http.HandlerFunc) *Client {
+	t.Helper()
+
+	// Encryption and decryption are the same operation in CTR mode
+	encrypted, err := crypto.DecryptAesCtr(audio, testDecryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to encrypt test audio: %v", err)
+	}
+
+	var serverURL string
+	handlers := map[string]http.HandlerFunc{
+		"/tracks/" + trackID: serveFixture(t, "track.json"),
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"invocationInfo":{"req-id":"test"},"result":{"downloadInfo":{`+
+				`"trackId":%q,"quality":"lossless","codec":"flac","bitrate":0,"transport":"encraw",`+
+				`"key":%q,"size":%d,"urls":[%q],"url":%q,"realId":%q}}}`,
+				trackID, testDecryptionKey, len(encrypted),
+				serverURL+"/media/"+trackID, serverURL+"/media/"+trackID, trackID)
+		},
+		"/media/" + trackID: func(w http.ResponseWriter, r *http.Request) {
+			w.Write(encrypted)
+		},
+	}
+	for pattern, handler := range extra {
+		handlers[pattern] = handler
+	}
+	client, server := newTestServer(t, handlers)
+	serverURL = server.URL
+
+	return client
+}
+
+// serveFixture returns a handler that writes a file from testdata
+func serveFixture(t *testing.T, name string) http.HandlerFunc {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// TestGetTrackInfo checks that metadata is fetched with a GET request and
+// the extracted names match what the multipart/raw-map implementation produced
+func TestGetTrackInfo(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				t.Errorf("Method = %s, want GET", r.Method)
+			}
+			if got := r.Header.Get("Authorization"); got != "OAuth test-token" {
+				t.Errorf("Authorization = %q, want %q", got, "OAuth test-token")
+			}
+			serveFixture(t, "track.json")(w, r)
+		},
+	})
+
+	trackInfo, err := client.GetTrackInfo("64551568")
+	if err != nil {
+		t.Fatalf("GetTrackInfo() error = %v", err)
+	}
+
+	// Values produced by the previous implementation for the same response
+	title, artist, albums := trackNames(trackInfo)
+	if title != "Кукла колдуна" {
+		t.Errorf("title = %q, want %q", title, "Кукла колдуна")
+	}
+	if artist != "Король и Шут & Князь" {
+		t.Errorf("artist = %q, want %q", artist, "Король и Шут & Князь")
+	}
+	if albums != "Акустический альбом, Лучшее: Ноты, рифмы" {
+		t.Errorf("albums = %q, want %q", albums, "Акустический альбом, Лучшее: Ноты, рифмы")
+	}
+}
+
+// TestGetTrackInfoErrors checks error handling for bad responses
+func TestGetTrackInfoErrors(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "Not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"error":{"name":"not-found"}}`, http.StatusNotFound)
+			},
+		},
+		{
+			name: "Empty result",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"invocationInfo":{},"result":[]}`))
+			},
+		},
+		{
+			name: "Malformed JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"result":`))
+			},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{"/tracks/1": tt.handler})
+
+			if _, err := client.GetTrackInfo("1"); err == nil {
+				t.Error("GetTrackInfo() error = nil, want error")
+			}
+		})
+	}
+}
+
+// TestGetTracks checks that metadata of many tracks is requested in batches and keyed by ID
+func TestGetTracks(t *testing.T) {
+	var batches []int
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				t.Errorf("Method = %s, want POST", r.Method)
+			}
+			ids := strings.Split(r.FormValue("trackIds"), ",")
+			batches = append(batches, len(ids))
+
+			// The last track is unknown to the server
+			var results []string
+			for _, id := range ids {
+				if id != "250" {
+					results = append(results, fmt.Sprintf(`{"id":%q,"title":"Track %s"}`, id, id))
+				}
+			}
+			fmt.Fprintf(w, `{"invocationInfo":{},"result":[%s]}`, strings.Join(results, ","))
+		},
+	})
+
+	ids := make([]string, 250)
+	for i := range ids {
+		ids[i] = fmt.Sprint(i + 1)
+	}
+
+	tracks, err := client.GetTracks(ids)
+	if err != nil {
+		t.Fatalf("GetTracks() error = %v", err)
+	}
+
+	if fmt.Sprint(batches) != "[100 100 50]" {
+		t.Errorf("Batch sizes = %v, want [100 100 50]", batches)
+	}
+	if len(tracks) != 249 {
+		t.Errorf("len(tracks) = %d, want 249", len(tracks))
+	}
+	if track := tracks["42"]; track == nil || track.Title != "Track 42" {
+		t.Errorf("tracks[42] = %v, want Track 42", track)
+	}
+	if _, ok := tracks["250"]; ok {
+		t.Error("Unknown track is present in the result")
+	}
+}
+
+// TestTrackNamesUnknown checks the fallback values for missing metadata
+func TestTrackNamesUnknown(t *testing.T) {
+	title, artist, albums := trackNames(&api.TrackInfo{})
+	if title != "Unknown" || artist != "Unknown" || albums != "Unknown" {
+		t.Errorf("trackNames() = %q, %q, %q, want all Unknown", title, artist, albums)
+	}
+}
+
+// TestGetTrackInfoGzip checks decoding of compressed and identity responses
+func TestGetTrackInfoGzip(t *testing.T) {
+	data, err := os.ReadFile("testdata/track.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write(data)
+	gzipWriter.Close()
+
+	var zlibbed bytes.Buffer
+	zlibWriter := zlib.NewWriter(&zlibbed)
+	zlibWriter.Write(data)
+	zlibWriter.Close()
+
+	var deflated bytes.Buffer
+	flateWriter, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	flateWriter.Write(data)
+	flateWriter.Close()
+
+	// Gzip applied over deflate, listed in the order of application
+	var layered bytes.Buffer
+	gzipWriter = gzip.NewWriter(&layered)
+	gzipWriter.Write(zlibbed.Bytes())
+	gzipWriter.Close()
+
+	// Test cases
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		wantErr  string
+	}{
+		{name: "Gzip response", encoding: "gzip", body: gzipped.Bytes()},
+		{name: "Gzip in upper case", encoding: "GZIP", body: gzipped.Bytes()},
+		{name: "x-gzip response", encoding: "x-gzip", body: gzipped.Bytes()},
+		{name: "Zlib deflate response", encoding: "deflate", body: zlibbed.Bytes()},
+		{name: "Raw deflate response", encoding: "deflate", body: deflated.Bytes()},
+		{name: "Layered codings", encoding: "deflate, gzip", body: layered.Bytes()},
+		{name: "Identity response", encoding: "", body: data},
+		{name: "Explicit identity", encoding: "identity", body: data},
+		{name: "Brotli is unsupported", encoding: "br", body: []byte{0x1b, 0x00}, wantErr: `unsupported content encoding "br"`},
+		{name: "Corrupt gzip", encoding: "gzip", body: data, wantErr: "gzip decoding error"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{
+				"/tracks/64551568": func(w http.ResponseWriter, r *http.Request) {
+					if got := r.Header.Get("Accept-Encoding"); got != "gzip" {
+						t.Errorf("Accept-Encoding = %q, want gzip", got)
+					}
+					w.Header().Set("Content-Type", "application/json")
+					if tt.encoding != "" {
+						w.Header().Set("Content-Encoding", tt.encoding)
+					}
+					w.Write(tt.body)
+				},
+			})
+
+			trackInfo, err := client.GetTrackInfo("64551568")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetTrackInfo() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetTrackInfo() error = %v", err)
+			}
+			if trackInfo.Title != "Кукла колдуна" {
+				t.Errorf("Title = %q, want %q", trackInfo.Title, "Кукла колдуна")
+			}
+		})
+	}
+}
+
+// TestDownloadTrackNonInteractive checks that a download completes with stdin closed
+func TestDownloadTrackNonInteractive(t *testing.T) {
+	audio := bytes.Repeat([]byte("fLaC audio data "), 1024)
+	client := newDownloadServer(t, "64551568", audio)
+
+	// Replace stdin with a closed file and forbid prompting
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	stdin.Close()
+
+	originalStdin := os.Stdin
+	os.Stdin = stdin
+	utils.SetNonInteractive(true)
+	t.Cleanup(func() {
+		os.Stdin = originalStdin
+		utils.SetNonInteractive(false)
+	})
+
+	done := make(chan struct{})
+	var path string
+	go func() {
+		defer close(done)
+		path, err = client.DownloadTrack("64551568", api.QualityHigh, t.TempDir())
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("DownloadTrack() blocked with stdin closed")
+	}
+
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if !bytes.Equal(data, audio) {
+		t.Error("Downloaded file content doesn't match the original audio")
+	}
+}
+
+// TestGetDownloadInfo checks that the codec and bitrate are decoded and select the file extension
+func TestGetDownloadInfo(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		codec     string
+		bitrate   int
+		extension string
+	}{
+		{"flac", 0, ".flac"},
+		{"flac-mp4", 0, ".m4a"},
+		{"aac-mp4", 256, ".m4a"},
+		{"mp3", 320, ".mp3"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{
+				"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprintf(w, `{"result":{"downloadInfo":{"codec":%q,"bitrate":%d,"key":"k","urls":["u"]}}}`,
+						tt.codec, tt.bitrate)
+				},
+			})
+
+			downloadInfo, err := client.GetDownloadInfo("1", api.QualityLossless)
+			if err != nil {
+				t.Fatalf("GetDownloadInfo() error = %v", err)
+			}
+			if downloadInfo.Codec != tt.codec || downloadInfo.Bitrate != tt.bitrate {
+				t.Errorf("GetDownloadInfo() = %s/%d, want %s/%d", downloadInfo.Codec, downloadInfo.Bitrate, tt.codec, tt.bitrate)
+			}
+			if got := downloadInfo.Extension(); got != tt.extension {
+				t.Errorf("Extension() = %q, want %q", got, tt.extension)
+			}
+		})
+	}
+}
+
+// patternReader produces n bytes of a deterministic pattern in small chunks,
+// starting with the FLAC signature
+type patternReader struct {
+	n   int64
+	off int64
+}
+
+// Read implements io.Reader
+func (r *patternReader) Read(p []byte) (int, error) {
+	if r.off >= r.n {
+		return 0, io.EOF
+	}
+	p = p[:min(int64(len(p)), r.n-r.off, 4096)]
+	for i := range p {
+		if off := r.off + int64(i); off < 4 {
+			p[i] = "fLaC"[off]
+		} else {
+			p[i] = byte(off * 7)
+		}
+	}
+	r.off += int64(len(p))
+	return len(p), nil
+}
+
+// TestDownloadTrackStreaming checks that a large track is decrypted as a stream:
+// the allocated memory must stay well below the track size
+func TestDownloadTrackStreaming(t *testing.T) {
+	const size = 32 << 20
+
+	var serverURL string
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568": serveFixture(t, "track.json"),
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"result":{"downloadInfo":{"codec":"flac","key":%q,"url":%q}}}`,
+				testDecryptionKey, serverURL+"/media")
+		},
+		"/media": func(w http.ResponseWriter, r *http.Request) {
+			// Encryption and decryption are the same operation in CTR mode
+			encrypted, _ := crypto.NewDecryptReader(&patternReader{n: size}, testDecryptionKey)
+			io.Copy(w, encrypted)
+		},
+	})
+	serverURL = server.URL
+	// Tagging rewrites the file; only the download itself is checked here
+	client.SetStandardTags(false)
+	client.SetExtraTags(false)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	path, err := client.DownloadTrack("64551568", api.QualityHigh, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > size/4 {
+		t.Errorf("Download allocated %d MiB for a %d MiB track, want constant memory usage",
+			allocated>>20, size>>20)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open downloaded file: %v", err)
+	}
+	defer file.Close()
+
+	expected, actual := sha256.New(), sha256.New()
+	io.Copy(expected, &patternReader{n: size})
+	io.Copy(actual, file)
+	if !bytes.Equal(expected.Sum(nil), actual.Sum(nil)) {
+		t.Error("Downloaded file content doesn't match the original audio")
+	}
+}
+
+// TestDownloadTrackContextCancel checks that cancelling a download mid-transfer removes the partial file
+func TestDownloadTrackContextCancel(t *testing.T) {
+	started := make(chan struct{})
+	var serverURL string
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568": serveFixture(t, "track.json"),
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"result":{"downloadInfo":{"codec":"flac","key":%q,"url":%q}}}`,
+				testDecryptionKey, serverURL+"/media")
+		},
+		"/media": func(w http.ResponseWriter, r *http.Request) {
+			// Send a part of the file, then stall until the client gives up
+			encrypted, _ := crypto.DecryptAesCtr(append([]byte("fLaC"), make([]byte, 64*1024)...), testDecryptionKey)
+			w.Write(encrypted)
+			w.(http.Flusher).Flush()
+			close(started)
+			<-r.Context().Done()
+		},
+	})
+	serverURL = server.URL
+
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	var during []string
+	go func() {
+		<-started
+		// Give the client a moment to write what it received
+		time.Sleep(50 * time.Millisecond)
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			during = append(during, entry.Name())
+		}
+		cancel()
+	}()
+
+	if _, err := client.DownloadTrackContext(ctx, "64551568", api.QualityHigh, dir); err == nil {
+		t.Fatal("DownloadTrackContext() error = nil, want error after cancellation")
+	}
+
+	// Only the partial file and its lock may exist while the track is being downloaded
+	if len(during) != 2 || !strings.HasSuffix(during[0], lockSuffix) || !strings.HasSuffix(during[1], partSuffix) {
+		t.Errorf("Files during the download = %q, want a %s and a %s file", during, lockSuffix, partSuffix)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Cancelled download left %d files, want none", len(entries))
+	}
+}
+
+// TestDownloadTrackToCancel checks that cancelling a streamed download stops the transfer
+func TestDownloadTrackToCancel(t *testing.T) {
+	started := make(chan struct{})
+	var serverURL string
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568": serveFixture(t, "track.json"),
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"result":{"downloadInfo":{"codec":"flac","key":%q,"url":%q}}}`,
+				testDecryptionKey, serverURL+"/media")
+		},
+		"/media": func(w http.ResponseWriter, r *http.Request) {
+			encrypted, _ := crypto.DecryptAesCtr(append([]byte("fLaC"), make([]byte, 64*1024)...), testDecryptionKey)
+			w.Write(encrypted)
+			w.(http.Flusher).Flush()
+			close(started)
+			<-r.Context().Done()
+		},
+	})
+	serverURL = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	var buf bytes.Buffer
+	meta, err := client.DownloadTrackTo(ctx, "64551568", api.QualityHigh, &buf)
+	if err == nil || meta != nil {
+		t.Fatalf("DownloadTrackTo() = %v, %v, want an error after cancellation", meta, err)
+	}
+	if ctx.Err() == nil {
+		t.Error("DownloadTrackTo() returned before the cancellation")
+	}
+}
+
+// TestPreflight checks the missing-scope detection and result caching
+func TestPreflight(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected error
+	}{
+		{"Valid token", http.StatusOK, `{"result":{"downloadInfo":{"url":"x","key":"y"}}}`, nil},
+		{"Missing scope", http.StatusForbidden, `{"error":{"name":"forbidden","message":"no scope"}}`, ErrMissingScope},
+		{"Forbidden for another reason", http.StatusForbidden,
+			`{"error":{"name":"forbidden","message":"not available in your region"}}`, ErrUnauthorized},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{
+				"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+					calls++
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.body))
+				},
+			})
+
+			for i := 0; i < 2; i++ {
+				err := client.Preflight()
+				if !errors.Is(err, tt.expected) || (tt.expected == nil && err != nil) {
+					t.Errorf("Preflight() error = %v, want %v", err, tt.expected)
+				}
+				if tt.expected != ErrMissingScope && errors.Is(err, ErrMissingScope) {
+					t.Errorf("Preflight() error = %v, want no missing scope", err)
+				}
+			}
+			if calls != 1 {
+				t.Errorf("Preflight() made %d requests, want 1", calls)
+			}
+		})
+	}
+}
+
+// TestClientPreset checks that presets swap the identity headers and overrides stay on top
+func TestClientPreset(t *testing.T) {
+	var got http.Header
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/1": func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header.Clone()
+			w.Write([]byte(`{"result":[{"id":"1"}]}`))
+		},
+	})
+
+	client.SetHeader("accept-language", "en")
+	if err := client.SetClientPreset("android"); err != nil {
+		t.Fatalf("SetClientPreset() error = %v", err)
+	}
+	if err := client.SetClientPreset("winamp"); err == nil {
+		t.Error("SetClientPreset() with an unknown preset error = nil, want error")
+	}
+
+	if _, err := client.GetTrackInfo("1"); err != nil {
+		t.Fatalf("GetTrackInfo() error = %v", err)
+	}
+
+	android := api.ClientPresets[api.PresetAndroid]
+	if got.Get("x-yandex-music-client") != android.Client || got.Get("User-Agent") != android.UserAgent {
+		t.Errorf("Identity headers = %q / %q, want the android preset",
+			got.Get("x-yandex-music-client"), got.Get("User-Agent"))
+	}
+	if got.Get("Accept-Language") != "en" {
+		t.Errorf("Accept-Language = %q, want the override %q", got.Get("Accept-Language"), "en")
+	}
+	if got.Get("Authorization") != "OAuth test-token" {
+		t.Errorf("Authorization = %q, want the token", got.Get("Authorization"))
+	}
+}
@@ -0,0 +1,111 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// createPart picks the file the track is saved to, starting with path, locks it and
+// creates its partial file. A name taken by another track, saved in this session or
+// being saved by this or another process, is a collision: the track ID is added to the
+// name, then " (2)", " (3)" and so on, and a warning names both tracks. A file being
+// saved by another download of the same track is reported with errLocked. An existing
+// file not saved in this session is replaced, or kept with OverwriteRename, which moves
+// on to " (1)", " (2)". The returned function unlocks the file; unless the track was
+// saved, it also removes the partial file and gives the name up.
+func (c *Client) createPart(path, trackID string, log *logger.Logger) (string, *os.File, func(saved bool), error) {
+	collided := false
+	collide := func(candidate string, owner any) {
+		if !collided {
+			log.Warn("File name collision: %s is already taken by track %s, renaming track %s",
+				filepath.Base(candidate), owner, trackID)
+		}
+		collided = true
+	}
+
+	for i := 0; ; i++ {
+		candidate := candidatePath(path, trackID, i, collided)
+		if owner, ok := c.fileOwners.Load(candidate); ok && owner != trackID {
+			collide(candidate, owner)
+			continue
+		}
+
+		lock, holder, err := c.lockFile(candidate, trackID, log)
+		if errors.Is(err, errLocked) {
+			if holder == trackID {
+				return candidate, nil, nil, err
+			}
+			collide(candidate, holder)
+			continue
+		}
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		// The name may have been saved by another track since it was checked
+		owner, loaded := c.fileOwners.LoadOrStore(candidate, trackID)
+		if loaded && owner != trackID {
+			lock.unlock()
+			collide(candidate, owner)
+			continue
+		}
+		unclaim := func() {
+			if !loaded {
+				c.fileOwners.Delete(candidate)
+			}
+		}
+
+		if c.overwrite == OverwriteRename {
+			if _, err := os.Stat(candidate); err == nil {
+				unclaim()
+				lock.unlock()
+				continue
+			}
+		}
+
+		// A partial file left by an interrupted run is replaced
+		partPath := candidate + partSuffix
+		if _, err := os.Stat(partPath); err == nil {
+			log.Debug("Replacing a leftover partial file: %s", partPath)
+		}
+		part, err := os.OpenFile(partPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			unclaim()
+			lock.unlock()
+			return "", nil, nil, fmt.Errorf("error saving decrypted file: %w", err)
+		}
+
+		if collided {
+			log.Warn("Track %s is saved as %s", trackID, filepath.Base(candidate))
+		}
+		return candidate, part, func(saved bool) {
+			if !saved {
+				log.Debug("Deleting partial file: %s", partPath)
+				os.Remove(partPath)
+				unclaim()
+			}
+			lock.unlock()
+		}, nil
+	}
+}
+
+// candidatePath returns the i-th name tried for a track file: path itself, then with the
+// track ID added after a collision, then numbered
+func candidatePath(path, trackID string, i int, collided bool) string {
+	if i == 0 {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	marker := "[" + trackID + "]"
+	if collided && i == 1 && !strings.Contains(filepath.Base(base), marker) {
+		return fmt.Sprintf("%s %s%s", base, marker, ext)
+	}
+	return fmt.Sprintf("%s (%d)%s", base, i, ext)
+}
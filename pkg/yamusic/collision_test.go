@@ -0,0 +1,96 @@
+package yamusic
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestFileNameCollision checks that different tracks with the same file name don't overwrite each other
+func TestFileNameCollision(t *testing.T) {
+	audio := []byte("fLaC collision test audio")
+	client := newDownloadServerWith(t, "64551568", audio, map[string]http.HandlerFunc{
+		"/tracks/777": serveFixture(t, "track.json"),
+		"/tracks/888": serveFixture(t, "track.json"),
+	})
+	if err := client.SetFileNameTemplate("{title}"); err != nil {
+		t.Fatalf("SetFileNameTemplate() error = %v", err)
+	}
+	// The fixture is served for every ID, so all tracks share a recording
+	client.SetDeduplication(false)
+	outputDir := t.TempDir()
+
+	results := client.DownloadTracks([]string{"64551568", "777", "888"}, QualityHigh, outputDir, 3)
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatalf("DownloadTracks() error for %s = %v", result.ID, result.Err)
+		}
+	}
+
+	// The same track downloaded again replaces its own file
+	again, err := client.DownloadTrackResult("777", QualityHigh, outputDir)
+	if err != nil {
+		t.Fatalf("DownloadTrackResult() error = %v", err)
+	}
+	if again.Path != results[1].Path {
+		t.Errorf("Second download of 777 saved to %s, want %s", again.Path, results[1].Path)
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	// The first track to claim the name keeps it; the others get their ID added, which
+	// is unique, so no numbering is needed
+	plain := 0
+	for _, result := range results {
+		name := filepath.Base(result.Path)
+		switch name {
+		case "Кукла колдуна.flac":
+			plain++
+		case "Кукла колдуна [" + result.ID + "].flac":
+		default:
+			t.Errorf("Track %s saved as %q", result.ID, name)
+		}
+		if !slices.Contains(names, name) {
+			t.Errorf("File of track %s is missing: %v", result.ID, names)
+		}
+	}
+	if plain != 1 || len(names) != 3 {
+		t.Errorf("Files = %v, want one plain name and two with the track ID", names)
+	}
+}
+
+// TestCandidatePath checks the names tried for a track file
+func TestCandidatePath(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		path     string
+		i        int
+		collided bool
+		expected string
+	}{
+		{"Original", "out/Song.flac", 0, true, "out/Song.flac"},
+		{"Track ID after a collision", "out/Song.flac", 1, true, "out/Song [5].flac"},
+		{"Numbered after a collision", "out/Song.flac", 2, true, "out/Song (2).flac"},
+		{"ID already in the name", "out/Song [5].flac", 1, true, "out/Song [5] (1).flac"},
+		{"Renamed existing file", "out/Song.flac", 1, false, "out/Song (1).flac"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := candidatePath(tt.path, "5", tt.i, tt.collided); got != tt.expected {
+				t.Errorf("candidatePath() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
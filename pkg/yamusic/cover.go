@@ -0,0 +1,182 @@
+package yamusic
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+const (
+	// DefaultCoverFile is the file name of saved covers
+	DefaultCoverFile = "cover.jpg"
+
+	// DefaultCoverSize is the size of saved covers
+	DefaultCoverSize = "1000x1000"
+
+	// CoverSizeOriginal selects the cover in its original resolution, which may be a PNG
+	CoverSizeOriginal = "orig"
+)
+
+// coverSizes are the cover sizes the CDN serves
+var coverSizes = []string{"200x200", "400x400", DefaultCoverSize, CoverSizeOriginal}
+
+// ValidateCoverSize checks that the CDN serves covers of the size
+func ValidateCoverSize(size string) error {
+	for _, known := range coverSizes {
+		if size == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid cover size %q (valid values: %s)", size, strings.Join(coverSizes, ", "))
+}
+
+// SetSaveCover makes album downloads save the album cover once into the output directory
+// under name (e.g. "cover.jpg" or "folder.jpg", which media servers pick up as album art).
+// An empty size selects DefaultCoverSize (see ValidateCoverSize for the known sizes).
+// An empty name disables saving (the default).
+func (c *Client) SetSaveCover(name, size string) {
+	c.coverFile = name
+	c.coverSize = size
+}
+
+// coverFileName returns the file name of a cover image: PNG images, which the original
+// resolution may be, get the .png extension instead of the configured one
+func coverFileName(name, contentType string) string {
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "image/png" {
+		return name
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".png"
+}
+
+// coverURL forms the image URL from a cover URI of the API ("avatars.yandex.net/.../%%")
+func coverURL(uri, size string) string {
+	uri = strings.Replace(uri, "%%", size, 1)
+	if !strings.Contains(uri, "://") {
+		uri = "https://" + uri
+	}
+	return uri
+}
+
+// albumCoverURI returns the cover URI of an album, falling back to its OpenGraph image
+func albumCoverURI(album *api.Album) string {
+	if album.CoverUri != "" {
+		return album.CoverUri
+	}
+	return album.OgImage
+}
+
+// DownloadCover saves the cover of a track or an album into outputDir and returns the
+// file path. The input is a track or album URL or a track ID, like the inputs of
+// DownloadTrack; a track gets the cover of its album (see SetAlbumPolicy) or its own.
+// An empty size selects the configured or default size.
+func (c *Client) DownloadCover(trackOrAlbumID, size, outputDir string) (string, error) {
+	ctx := context.Background()
+
+	if albumID, ok := utils.ExtractAlbumID(trackOrAlbumID); ok {
+		album, err := c.GetAlbum(albumID)
+		if err != nil {
+			return "", err
+		}
+		return c.DownloadAlbumCover(album, size, outputDir)
+	}
+
+	trackID, albumID := utils.ExtractTrackRef(trackOrAlbumID)
+	log := c.logger.WithField("track_id", trackID)
+	trackInfo, err := c.getTrackInfo(ctx, trackID, log)
+	if err != nil {
+		return "", err
+	}
+
+	policy := c.albumPolicy
+	if policy == AlbumAll {
+		policy = AlbumFirst
+	}
+	uri := trackInfo.CoverUri
+	if album := selectAlbum(trackInfo, albumID, policy); album != nil && albumCoverURI(album) != "" {
+		uri = albumCoverURI(album)
+	}
+	if uri == "" {
+		uri = trackInfo.OgImage
+	}
+
+	return c.saveCover(ctx, uri, size, outputDir, log)
+}
+
+// DownloadAlbumCover saves the cover of already fetched album metadata into outputDir
+// and returns the file path. An empty size selects the configured or default size.
+func (c *Client) DownloadAlbumCover(album *Album, size, outputDir string) (string, error) {
+	log := c.logger.WithField("album_id", album.ID.String())
+	return c.saveCover(context.Background(), albumCoverURI(album), size, outputDir, log)
+}
+
+// saveCover downloads the cover image and writes it atomically: the image is written
+// to a temporary file that replaces the target only when complete
+func (c *Client) saveCover(ctx context.Context, uri, size, outputDir string, log *logger.Logger) (string, error) {
+	if uri == "" {
+		return "", fmt.Errorf("no cover found in metadata")
+	}
+	if size == "" {
+		size = c.coverSize
+	}
+	if size == "" {
+		size = DefaultCoverSize
+	}
+	if err := ValidateCoverSize(size); err != nil {
+		return "", err
+	}
+	name := c.coverFile
+	if name == "" {
+		name = DefaultCoverFile
+	}
+
+	imageURL := coverURL(uri, size)
+	log.Debug("Downloading cover: %s", imageURL)
+
+	var resp *http.Response
+	err := c.withRetry(ctx, log, func() (err error) {
+		resp, err = c.getMedia(ctx, imageURL)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("error downloading cover: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating directory: %w", err)
+	}
+
+	// Create the temporary file next to the target so the rename stays on one file system
+	tmp, err := os.CreateTemp(filepath.Join(outputDir, "."), ".cover-*")
+	if err != nil {
+		return "", fmt.Errorf("error saving cover: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("error saving cover: %w", err)
+	}
+
+	path := filepath.Join(outputDir, coverFileName(name, resp.Header.Get("Content-Type")))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("error saving cover: %w", err)
+	}
+	// Temporary files are private; covers get the usual file permissions
+	os.Chmod(path, 0644)
+
+	log.Info("Cover saved: %s", path)
+	return path, nil
+}
@@ -0,0 +1,140 @@
+package yamusic
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCoverURL checks that cover URIs of the API are turned into image URLs
+func TestCoverURL(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		uri      string
+		size     string
+		expected string
+	}{
+		{"avatars.yandex.net/get-music-content/123/abc/%%", "400x400", "https://avatars.yandex.net/get-music-content/123/abc/400x400"},
+		{"http://localhost/covers/%%", "1000x1000", "http://localhost/covers/1000x1000"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		if got := coverURL(tt.uri, tt.size); got != tt.expected {
+			t.Errorf("coverURL(%q, %q) = %q, want %q", tt.uri, tt.size, got, tt.expected)
+		}
+	}
+}
+
+// TestDownloadCover checks cover resolution for track and album inputs
+func TestDownloadCover(t *testing.T) {
+	var requested []string
+	var serverURL string
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/1": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"result":[{"id":"1","coverUri":%q,"albums":[{"id":5,"coverUri":%q}]}]}`,
+				serverURL+"/track-cover/%%", serverURL+"/album-cover/%%")
+		},
+		"/albums/7/with-tracks": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"result":{"id":7,"title":"Album","ogImage":%q}}`, serverURL+"/og/%%")
+		},
+		"/": func(w http.ResponseWriter, r *http.Request) {
+			requested = append(requested, r.URL.Path)
+			w.Write([]byte("JPEG " + r.URL.Path))
+		},
+	})
+	serverURL = server.URL
+	client.SetSaveCover("folder.jpg", "")
+
+	// Test cases
+	tests := []struct {
+		name     string
+		input    string
+		size     string
+		expected string
+	}{
+		{"Track gets the album cover", "1", "400x400", "/album-cover/400x400"},
+		{"Album falls back to the OpenGraph image", "https://music.yandex.ru/album/7", "", "/og/" + DefaultCoverSize},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested = nil
+			outputDir := t.TempDir()
+
+			path, err := client.DownloadCover(tt.input, tt.size, outputDir)
+			if err != nil {
+				t.Fatalf("DownloadCover() error = %v", err)
+			}
+			if path != filepath.Join(outputDir, "folder.jpg") {
+				t.Errorf("DownloadCover() = %q, want folder.jpg in the output directory", path)
+			}
+			if len(requested) != 1 || requested[0] != tt.expected {
+				t.Errorf("Requested %v, want %s", requested, tt.expected)
+			}
+
+			data, _ := os.ReadFile(path)
+			if string(data) != "JPEG "+tt.expected {
+				t.Errorf("Cover content = %q", data)
+			}
+			if entries, _ := os.ReadDir(outputDir); len(entries) != 1 {
+				t.Errorf("Output directory has %d entries, want only the cover", len(entries))
+			}
+		})
+	}
+}
+
+// TestValidateCoverSize checks the known cover sizes
+func TestValidateCoverSize(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		size    string
+		wantErr bool
+	}{
+		{"200x200", false},
+		{"400x400", false},
+		{"1000x1000", false},
+		{"orig", false},
+		{"300x300", true},
+		{"", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		if err := ValidateCoverSize(tt.size); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateCoverSize(%q) error = %v, wantErr %v", tt.size, err, tt.wantErr)
+		}
+	}
+}
+
+// TestDownloadOriginalCover checks that original covers served as PNG are saved as .png
+func TestDownloadOriginalCover(t *testing.T) {
+	var serverURL string
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/albums/7/with-tracks": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"result":{"id":7,"title":"Album","coverUri":%q}}`, serverURL+"/cover/%%")
+		},
+		"/cover/orig": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG"))
+		},
+	})
+	serverURL = server.URL
+	client.SetSaveCover("cover.jpg", "")
+
+	outputDir := t.TempDir()
+	path, err := client.DownloadCover("https://music.yandex.ru/album/7", CoverSizeOriginal, outputDir)
+	if err != nil {
+		t.Fatalf("DownloadCover() error = %v", err)
+	}
+	if path != filepath.Join(outputDir, "cover.png") {
+		t.Errorf("DownloadCover() = %q, want cover.png in the output directory", path)
+	}
+
+	if _, err := client.DownloadCover("https://music.yandex.ru/album/7", "300x300", outputDir); err == nil {
+		t.Error("DownloadCover() with an unknown size succeeded")
+	}
+}
@@ -0,0 +1,62 @@
+package yamusic
+
+import (
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// recording is a downloaded track file of a recording
+type recording struct {
+	trackID string
+	path    string
+}
+
+// SetDeduplication enables or disables skipping tracks that are another release of a
+// recording already downloaded. The same recording appears on albums, compilations and
+// singles under different track IDs sharing the realId of the original track. Recordings
+// are remembered for the lifetime of the client and, with a download archive, across runs.
+// Deduplication is enabled by default.
+func (c *Client) SetDeduplication(enabled bool) {
+	c.noDedup = !enabled
+}
+
+// skipDuplicate reports whether the track of result is to be skipped because its recording
+// was already downloaded under another track ID, filling in the path of that file when known
+func (c *Client) skipDuplicate(trackInfo *api.TrackInfo, result *DownloadResult, log *logger.Logger) bool {
+	realID := trackInfo.RealID
+	if c.noDedup || realID == "" {
+		return false
+	}
+
+	if value, ok := c.recordings.Load(realID); ok {
+		if rec := value.(recording); rec.trackID != result.TrackID {
+			log.Info("Same recording as track %s, already downloaded: %s", rec.trackID, rec.path)
+			result.Path = rec.path
+			result.skip(SkipDuplicate)
+			return true
+		}
+	}
+
+	// The archive lists the original track ID of every downloaded recording
+	if realID != result.TrackID && c.archive != nil && c.archive.Contains(realID) {
+		log.Info("Same recording as track %s, already in the download archive", realID)
+		result.skip(SkipDuplicate)
+		return true
+	}
+	return false
+}
+
+// addRecording remembers the file of a downloaded track under its recording
+func (c *Client) addRecording(trackInfo *api.TrackInfo, trackID, path string, log *logger.Logger) {
+	realID := trackInfo.RealID
+	if c.noDedup || realID == "" {
+		return
+	}
+
+	c.recordings.LoadOrStore(realID, recording{trackID: trackID, path: path})
+	if realID != trackID && c.archive != nil {
+		if err := c.archive.Add(realID); err != nil {
+			log.Warn("Recording not added to the download archive: %v", err)
+		}
+	}
+}
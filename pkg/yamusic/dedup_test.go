@@ -0,0 +1,83 @@
+package yamusic
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"testing"
+)
+
+// newDedupServer starts a fake API serving track 64551568 and track 111, another release
+// of the same recording
+func newDedupServer(t *testing.T) *Client {
+	t.Helper()
+
+	fixture, err := os.ReadFile("testdata/track.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	release := bytes.Replace(fixture, []byte(`"id": "64551568"`), []byte(`"id": "111"`), 1)
+
+	return newDownloadServerWith(t, "64551568", []byte("fLaC dedup test audio"), map[string]http.HandlerFunc{
+		"/tracks/111": func(w http.ResponseWriter, r *http.Request) {
+			w.Write(release)
+		},
+	})
+}
+
+// TestDeduplication checks that another release of a downloaded recording is skipped
+// within a session and, with the archive, across sessions
+func TestDeduplication(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name        string
+		dedup       bool
+		wantSkipped bool
+	}{
+		{"Deduplication", true, true},
+		{"No deduplication", false, false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newDedupServer(t)
+			client.SetDeduplication(tt.dedup)
+			outputDir := t.TempDir()
+
+			results := client.DownloadTracks([]string{"64551568", "111"}, QualityHigh, outputDir, 1)
+			if results[0].Err != nil || results[1].Err != nil {
+				t.Fatalf("DownloadTracks() errors = %v, %v", results[0].Err, results[1].Err)
+			}
+			if results[1].Skipped != tt.wantSkipped {
+				t.Errorf("Skipped = %v, want %v", results[1].Skipped, tt.wantSkipped)
+			}
+			if tt.wantSkipped && results[1].Path != results[0].Path {
+				t.Errorf("Path = %q, want %q", results[1].Path, results[0].Path)
+			}
+		})
+	}
+}
+
+// TestDeduplicationArchive checks that the archive records the original track of a recording
+func TestDeduplicationArchive(t *testing.T) {
+	archive := memoryArchive{}
+	client := newDedupServer(t)
+	client.SetArchive(archive)
+
+	result := client.DownloadTracks([]string{"111"}, QualityHigh, t.TempDir(), 1)[0]
+	if result.Err != nil || result.Skipped {
+		t.Fatalf("First download = {Err: %v, Skipped: %v}, want a download", result.Err, result.Skipped)
+	}
+	if !archive["111"] || !archive["64551568"] {
+		t.Errorf("Archive = %v, want the track and its original", archive)
+	}
+
+	// A later session skips other releases of the recording, found in the archive by realId
+	client = newDedupServer(t)
+	client.SetArchive(memoryArchive{"64551568": true})
+	result = client.DownloadTracks([]string{"111"}, QualityHigh, t.TempDir(), 1)[0]
+	if result.Err != nil || !result.Skipped {
+		t.Errorf("Second download = {Err: %v, Skipped: %v}, want skipped", result.Err, result.Skipped)
+	}
+}
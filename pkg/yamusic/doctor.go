@@ -0,0 +1,195 @@
+package yamusic
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+)
+
+// DoctorTrackID is a publicly available track used for self-test requests
+const DoctorTrackID = "32988399"
+
+// Decryption test vector: AES-256-CTR with a zero IV
+const (
+	doctorKey        = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	doctorPlaintext  = "yamusic-dl doctor test vector!!!"
+	doctorCiphertext = "8bf16dc35920fcfdcd9fba0eb24d03ef827d02cb39cdbf93c395ef5e3ae3171c"
+)
+
+// CheckResult describes the outcome of a single self-test check
+type CheckResult struct {
+	Name     string
+	OK       bool
+	Critical bool
+	Detail   string
+	Hint     string
+}
+
+// Doctor runs a battery of self-test checks: token validity, signed API access,
+// CDN reachability, decryption and write access to the output and temp directories.
+func (c *Client) Doctor(outputDir string) []CheckResult {
+	var results []CheckResult
+
+	results = append(results, c.checkToken())
+
+	fileURL, signResult := c.checkSignature()
+	results = append(results, signResult)
+	results = append(results, c.checkCDN(fileURL))
+
+	results = append(results, checkDecryption())
+
+	if outputDir == "" {
+		outputDir = "."
+	}
+	results = append(results, checkWritable("Output directory", outputDir))
+	results = append(results, checkWritable("Temp directory", os.TempDir()))
+
+	return results
+}
+
+// checkToken verifies the access token via the account status endpoint
+func (c *Client) checkToken() CheckResult {
+	result := CheckResult{
+		Name:     "Access token",
+		Critical: true,
+		Hint:     "Obtain a new token with yamusic-auth",
+	}
+
+	status, err := c.GetAccountStatus()
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+
+	result.OK = true
+	result.Detail = fmt.Sprintf("logged in as %s (uid %s)", status.Account.Login, status.Account.UID)
+	if !status.Plus.HasPlus {
+		result.Detail += ", no active subscription"
+	}
+	return result
+}
+
+// checkSignature performs a signed get-file-info request and returns the file URL on success
+func (c *Client) checkSignature() (string, CheckResult) {
+	result := CheckResult{
+		Name:     "Signed download info request",
+		Critical: true,
+		Hint:     "The sign key may have been rotated, or the token lacks the music:content scope",
+	}
+
+	downloadInfo, err := c.GetDownloadInfo(DoctorTrackID, api.QualityLow)
+	if err != nil {
+		result.Detail = err.Error()
+		return "", result
+	}
+
+	mirrors, err := downloadMirrors(downloadInfo)
+	if err != nil {
+		result.Detail = err.Error()
+		return "", result
+	}
+
+	result.OK = true
+	result.Detail = fmt.Sprintf("track %s, codec %s, bitrate %d", DoctorTrackID, downloadInfo.Codec, downloadInfo.Bitrate)
+	return mirrors[0], result
+}
+
+// checkCDN fetches the first kilobyte of the media file
+func (c *Client) checkCDN(fileURL string) CheckResult {
+	result := CheckResult{
+		Name:     "CDN access",
+		Critical: true,
+		Hint:     "The storage hosts may be blocked by your network; try a VPN or proxy",
+	}
+
+	if fileURL == "" {
+		result.Detail = "skipped: no download URL"
+		return result
+	}
+
+	req, err := http.NewRequest("GET", fileURL, nil)
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	req.Header.Set("Range", "bytes=0-1023")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		result.Detail = fmt.Sprintf("status %s", resp.Status)
+		return result
+	}
+
+	n, err := io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+
+	result.OK = true
+	result.Detail = fmt.Sprintf("received %d bytes", n)
+	return result
+}
+
+// checkDecryption decrypts a known test vector
+func checkDecryption() CheckResult {
+	result := CheckResult{
+		Name:     "Decryption",
+		Critical: true,
+		Hint:     "The binary may be broken; rebuild it from source",
+	}
+
+	encrypted, _ := hex.DecodeString(doctorCiphertext)
+	decrypted, err := crypto.DecryptAesCtr(encrypted, doctorKey)
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+
+	if !bytes.Equal(decrypted, []byte(doctorPlaintext)) {
+		result.Detail = "test vector mismatch"
+		return result
+	}
+
+	result.OK = true
+	result.Detail = "test vector decrypted correctly"
+	return result
+}
+
+// checkWritable verifies that a file can be created in the directory
+func checkWritable(name, dir string) CheckResult {
+	result := CheckResult{
+		Name:     name,
+		Critical: true,
+		Hint:     fmt.Sprintf("Check that %s exists and is writable, or choose another directory", dir),
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+
+	file, err := os.CreateTemp(dir, ".yamusic-doctor-*")
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	result.OK = true
+	result.Detail = dir
+	return result
+}
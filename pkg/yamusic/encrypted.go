@@ -0,0 +1,99 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+	"github.com/Kud1nov/yamusic-dl/internal/media"
+)
+
+// encryptedSuffix is appended to the names of track files to form the names of their kept
+// encrypted files
+const encryptedSuffix = ".enc"
+
+// SetKeepEncrypted keeps the encrypted file downloaded from the CDN next to the track file,
+// named after it with an ".enc" suffix, also when decryption fails, for inspection with
+// DecryptFile. Previews and unencrypted legacy downloads have no such file.
+func (c *Client) SetKeepEncrypted(enabled bool) {
+	c.keepEncrypted = enabled
+}
+
+// DecryptFile decrypts an encrypted file kept with SetKeepEncrypted into outputPath with
+// the hex-encoded key. The data is streamed, so memory usage doesn't depend on the file
+// size. When the extension of outputPath names a known container, the decrypted data must
+// match it, or ErrCorruptAudio is returned and no file is written.
+func (c *Client) DecryptFile(inputPath, hexKey, outputPath string) (err error) {
+	// The key is checked before any file is touched
+	if err := crypto.ValidateKey(hexKey); err != nil {
+		return err
+	}
+
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("error opening encrypted file: %w", err)
+	}
+	defer input.Close()
+
+	reader, err := crypto.NewDecryptReader(input, hexKey)
+	if err != nil {
+		return fmt.Errorf("error decrypting file: %w", err)
+	}
+
+	partPath := outputPath + partSuffix
+	output, err := os.Create(partPath)
+	if err != nil {
+		return fmt.Errorf("error saving decrypted file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(partPath)
+		}
+	}()
+
+	checked := &headerCheckWriter{w: output, expected: media.ContainerForExtension(filepath.Ext(outputPath))}
+	_, err = io.Copy(checked, reader)
+	if err == nil {
+		err = checked.flush()
+	}
+	if closeErr := output.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		if errors.Is(err, ErrCorruptAudio) {
+			return err
+		}
+		return fmt.Errorf("error saving decrypted file: %w", err)
+	}
+
+	if err := os.Rename(partPath, outputPath); err != nil {
+		return fmt.Errorf("error saving decrypted file: %w", err)
+	}
+	return nil
+}
+
+// saveEncrypted copies the whole encrypted source into the file at path and returns the
+// file positioned at its start, to be decrypted from
+func saveEncrypted(ctx context.Context, source io.Reader, path string) (*os.File, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error saving encrypted file: %w", err)
+	}
+
+	if _, err := io.Copy(file, source); err != nil {
+		file.Close()
+		if ctx.Err() != nil {
+			os.Remove(path)
+		}
+		return nil, fmt.Errorf("error downloading encrypted file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error saving encrypted file: %w", err)
+	}
+	return file, nil
+}
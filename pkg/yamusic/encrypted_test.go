@@ -0,0 +1,78 @@
+package yamusic
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+)
+
+// TestKeepEncrypted checks that the encrypted file is kept and decrypts back to the track
+func TestKeepEncrypted(t *testing.T) {
+	audio := testFLAC(1)
+	client := newDownloadServer(t, "64551568", audio)
+	client.SetKeepEncrypted(true)
+	client.SetStandardTags(false)
+	client.SetExtraTags(false)
+	dir := t.TempDir()
+
+	path, err := client.DownloadTrack("64551568", QualityHigh, dir)
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+
+	encrypted, err := os.ReadFile(path + encryptedSuffix)
+	if err != nil {
+		t.Fatalf("Encrypted file not kept: %v", err)
+	}
+	if want, _ := crypto.DecryptAesCtr(audio, testDecryptionKey); !bytes.Equal(encrypted, want) {
+		t.Error("Kept file doesn't match the encrypted CDN payload")
+	}
+
+	// Test cases
+	tests := []struct {
+		name    string
+		key     string
+		output  string
+		wantErr bool
+		corrupt bool
+	}{
+		{"Right key", testDecryptionKey, "right.flac", false, false},
+		{"Wrong key", "ff" + testDecryptionKey[2:], "wrong.flac", true, true},
+		{"Short key", "0011", "short.flac", true, false},
+		{"Not hex", "zz", "bad.flac", true, false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(dir, tt.output)
+			err := client.DecryptFile(path+encryptedSuffix, tt.key, output)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("DecryptFile() error = %v", err)
+				}
+				if data, _ := os.ReadFile(output); !bytes.Equal(data, audio) {
+					t.Error("Decrypted file doesn't match the audio")
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("DecryptFile() error = nil, want error")
+			}
+			if tt.corrupt && !errors.Is(err, ErrCorruptAudio) {
+				t.Errorf("DecryptFile() error = %v, want ErrCorruptAudio", err)
+			}
+			if _, statErr := os.Stat(output); statErr == nil {
+				t.Error("DecryptFile() left an output file after an error")
+			}
+			if _, statErr := os.Stat(output + partSuffix); statErr == nil {
+				t.Error("DecryptFile() left a partial file after an error")
+			}
+		})
+	}
+}
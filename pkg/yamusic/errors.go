@@ -0,0 +1,163 @@
+package yamusic
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+var (
+	// ErrUnauthorized is matched by errors of requests rejected because of the token:
+	// it is invalid, expired or lacks the required permissions
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrTrackNotFound is matched by errors of requests for tracks (or other objects)
+	// that don't exist
+	ErrTrackNotFound = errors.New("track not found")
+
+	// ErrNotAvailable is matched by errors of requests for content that exists but isn't
+	// available to the account, e.g. because of region restrictions
+	ErrNotAvailable = errors.New("not available")
+
+	// ErrRateLimited is matched by errors of requests rejected with 429 Too Many Requests
+	// after the retries ran out
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// APIError is returned for API responses with a non-200 status. Use errors.Is with
+// ErrUnauthorized, ErrTrackNotFound, ErrNotAvailable, ErrRateLimited and ErrInvalidSign to classify it.
+type APIError struct {
+	// Status is the HTTP status code
+	Status int
+
+	// Name is the error name from the response body, e.g. "session-expired"
+	Name string
+
+	// Message is the error message from the response body
+	Message string
+
+	// ReqID is the request ID from the response, useful when reporting problems
+	ReqID string
+
+	// RetryAfter is the delay requested in the Retry-After header
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API returned an error: %d %s", e.Status, http.StatusText(e.Status))
+	switch {
+	case e.Name != "" && e.Message != "" && e.Message != e.Name:
+		msg += fmt.Sprintf(": %s (%s)", e.Name, e.Message)
+	case e.Name != "":
+		msg += ": " + e.Name
+	case e.Message != "":
+		msg += ": " + e.Message
+	}
+	if e.ReqID != "" {
+		msg += fmt.Sprintf(" [req-id %s]", e.ReqID)
+	}
+	return msg
+}
+
+// Is matches the error against the sentinel errors of the package
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden
+	case ErrTrackNotFound:
+		return e.Status == http.StatusNotFound || e.Name == "not-found"
+	case ErrNotAvailable:
+		return e.Status == http.StatusUnavailableForLegalReasons || strings.Contains(e.Name, "region") ||
+			strings.Contains(e.Name, "not-available")
+	case ErrRateLimited:
+		return e.Status == http.StatusTooManyRequests
+	case ErrInvalidSign:
+		return e.Status == http.StatusBadRequest && (strings.Contains(strings.ToLower(e.Message), "sign") ||
+			strings.Contains(strings.ToLower(e.Name), "sign"))
+	default:
+		return false
+	}
+}
+
+// newAPIError creates an error for a failed response, extracting the error details from the body
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	// Proxies and load balancers may answer with HTML; the status alone is reported then
+	var response api.ErrorResponse
+	json.Unmarshal(body, &response)
+
+	return &APIError{
+		Status:     resp.StatusCode,
+		Name:       response.Error.Name,
+		Message:    response.Error.Message,
+		ReqID:      response.InvocationInfo.ReqID,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// Error categories reported by ErrorCategory
+const (
+	CategoryUnauthorized     = "unauthorized"
+	CategoryRegionRestricted = "region-restricted"
+	CategoryNetwork          = "network"
+	CategoryDecryption       = "decryption"
+	CategoryDisk             = "disk"
+	CategoryOther            = "other"
+)
+
+// ErrorCategory classifies a download error. Region restrictions are permanent;
+// the other categories may succeed on retry.
+func ErrorCategory(err error) string {
+	var apiErr *APIError
+	var unavailableErr *UnavailableError
+	var pathErr *fs.PathError
+	var urlErr *url.Error
+	var netErr net.Error
+	var hexErr hex.InvalidByteError
+	var keySizeErr aes.KeySizeError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrMissingScope):
+		return CategoryUnauthorized
+	case errors.Is(err, ErrStalled):
+		return CategoryNetwork
+	case errors.As(err, &unavailableErr):
+		if unavailableErr.Reason == ReasonSubscription {
+			return CategoryUnauthorized
+		}
+		return CategoryRegionRestricted
+	case errors.As(err, &apiErr):
+		switch {
+		case errors.Is(apiErr, ErrNotAvailable):
+			return CategoryRegionRestricted
+		case errors.Is(apiErr, ErrUnauthorized):
+			return CategoryUnauthorized
+		}
+		return CategoryOther
+	case errors.As(err, &hexErr), errors.As(err, &keySizeErr), errors.Is(err, hex.ErrLength):
+		return CategoryDecryption
+	case errors.As(err, &pathErr):
+		return CategoryDisk
+	case errors.As(err, &urlErr), errors.As(err, &netErr):
+		return CategoryNetwork
+	default:
+		return CategoryOther
+	}
+}
+
+// IsPermanent reports whether retrying an error of the category is pointless
+func IsPermanent(category string) bool {
+	return category == CategoryRegionRestricted
+}
@@ -0,0 +1,183 @@
+package yamusic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// TestErrorCategory checks the classification of download errors
+func TestErrorCategory(t *testing.T) {
+	_, decryptErr := crypto.DecryptAesCtr([]byte("data"), "not a hex key")
+	_, diskErr := os.Open("/nonexistent/yamusic-dl/file")
+	_, networkErr := http.Get("http://127.0.0.1:0/")
+
+	// Test cases
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"Missing scope", ErrMissingScope, CategoryUnauthorized},
+		{"Expired token", &APIError{Status: 401}, CategoryUnauthorized},
+		{"Region", &APIError{Status: 400, Name: "not-available-in-region"}, CategoryRegionRestricted},
+		{"Other API error", &APIError{Status: 500}, CategoryOther},
+		{"Decryption", fmt.Errorf("error decrypting file: %w", decryptErr), CategoryDecryption},
+		{"Disk", fmt.Errorf("error saving: %w", diskErr), CategoryDisk},
+		{"Network", fmt.Errorf("request execution error: %w", networkErr), CategoryNetwork},
+		{"Unknown", fmt.Errorf("something else"), CategoryOther},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCategory(tt.err); got != tt.expected {
+				t.Errorf("ErrorCategory(%v) = %q, want %q", tt.err, got, tt.expected)
+			}
+		})
+	}
+
+	if !IsPermanent(CategoryRegionRestricted) || IsPermanent(CategoryNetwork) {
+		t.Error("Only region restrictions must be permanent")
+	}
+}
+
+// TestAPIError checks that API failures carry the response details and match the sentinel errors
+func TestAPIError(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/": func(w http.ResponseWriter, r *http.Request) {
+			var status int
+			fmt.Sscan(r.URL.Path[len("/tracks/"):], &status)
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"invocationInfo":{"req-id":"req-%d"},"error":{"name":"err-%d","message":"message %d"}}`,
+				status, status, status)
+		},
+	})
+	client.SetRetry(1, 0)
+
+	// Test cases
+	tests := []struct {
+		status   int
+		sentinel error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusNotFound, ErrTrackNotFound},
+		{http.StatusUnavailableForLegalReasons, ErrNotAvailable},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusBadRequest, nil},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			_, err := client.GetTrackInfo(fmt.Sprint(tt.status))
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("GetTrackInfo() error = %v, want an APIError", err)
+			}
+			want := APIError{Status: tt.status, Name: fmt.Sprintf("err-%d", tt.status),
+				Message: fmt.Sprintf("message %d", tt.status), ReqID: fmt.Sprintf("req-%d", tt.status)}
+			if *apiErr != want {
+				t.Errorf("APIError = %+v, want %+v", *apiErr, want)
+			}
+
+			for _, sentinel := range []error{ErrUnauthorized, ErrTrackNotFound, ErrNotAvailable, ErrRateLimited, ErrInvalidSign} {
+				if got := errors.Is(err, sentinel); got != (sentinel == tt.sentinel) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, sentinel, got)
+				}
+			}
+		})
+	}
+}
+
+// TestAPIErrorMessage checks that the error name and message of the response body reach the error text
+func TestAPIErrorMessage(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"invocationInfo":{"req-id":"1750200603-42"},` +
+				`"error":{"name":"validate","message":"Parameter 'sign' is invalid"}}`))
+		},
+		"/account/status": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"account":{"uid":42},"plus":{"hasPlus":true}}}`))
+		},
+	})
+	client.SetQualityFallback(false)
+
+	_, err := client.GetDownloadInfo("12345", "lossless")
+	want := "API returned an error: 400 Bad Request: validate (Parameter 'sign' is invalid) [req-id 1750200603-42]"
+	if err == nil || err.Error() != want {
+		t.Errorf("GetDownloadInfo() error = %v, want %q", err, want)
+	}
+
+	// Test cases
+	tests := []struct {
+		err      APIError
+		expected string
+	}{
+		{APIError{Status: 502}, "API returned an error: 502 Bad Gateway"},
+		{APIError{Status: 401, Name: "session-expired"}, "API returned an error: 401 Unauthorized: session-expired"},
+		{APIError{Status: 404, Name: "not-found", Message: "not-found"}, "API returned an error: 404 Not Found: not-found"},
+		{APIError{Status: 400, Message: "Track id is invalid", ReqID: "r1"}, "API returned an error: 400 Bad Request: Track id is invalid [req-id r1]"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.expected {
+			t.Errorf("Error() = %q, want %q", got, tt.expected)
+		}
+	}
+}
+
+// TestRequestIDLogging checks that verbose logs carry the request IDs of successful and
+// failed API calls
+func TestRequestIDLogging(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/1": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"invocationInfo":{"req-id":"req-ok","exec-duration-millis":7},"result":[{"id":"1"}]}`))
+		},
+		"/tracks/2": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"invocationInfo":{"req-id":"req-failed"},"error":{"name":"validate"}}`))
+		},
+	})
+	var buf bytes.Buffer
+	client.logger = logger.NewWithOptions(logger.Options{Output: &buf, Verbose: true, Format: logger.FormatJSON})
+	client.SetRetry(1, 0)
+
+	client.GetTrackInfo("1")
+	client.GetTrackInfo("2")
+
+	// Test cases
+	tests := []struct {
+		reqID   string
+		message string
+	}{
+		{"req-ok", "API call /tracks: total"},
+		{"req-failed", "API call /tracks failed: status 400"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.reqID, func(t *testing.T) {
+			for _, line := range strings.Split(buf.String(), "\n") {
+				if strings.Contains(line, tt.message) {
+					if !strings.Contains(line, `"req_id":"`+tt.reqID+`"`) {
+						t.Errorf("Log line %s has no req_id %q", line, tt.reqID)
+					}
+					return
+				}
+			}
+			t.Errorf("No log line with %q in %s", tt.message, buf.String())
+		})
+	}
+}
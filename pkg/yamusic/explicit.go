@@ -0,0 +1,48 @@
+package yamusic
+
+import (
+	"errors"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// Content warnings of tracks
+const (
+	contentExplicit = "explicit"
+	contentClean    = "clean"
+)
+
+// ErrExplicit is returned by DownloadTrack and its variants for explicit tracks skipped with
+// SetSkipExplicit
+var ErrExplicit = errors.New("skipped as explicit")
+
+// SetSkipExplicit skips tracks marked explicit, e.g. in album and playlist downloads.
+// Skipped tracks are reported with Skipped and Explicit set and SkipExplicit as the reason.
+func (c *Client) SetSkipExplicit(skip bool) {
+	c.skipExplicit = skip
+}
+
+// skipExplicitTrack reports whether the track of result is to be skipped because it is explicit
+func (c *Client) skipExplicitTrack(result *DownloadResult, log *logger.Logger) bool {
+	if !c.skipExplicit || !result.Explicit {
+		return false
+	}
+
+	log.Info("Skipping explicit track")
+	result.skip(SkipExplicit)
+	return true
+}
+
+// advisory returns the content advisory tag value of a track: "1" for explicit, "2" for
+// clean and "" when the track has no content warning
+func advisory(trackInfo *api.TrackInfo) string {
+	switch trackInfo.ContentWarning {
+	case contentExplicit:
+		return "1"
+	case contentClean:
+		return "2"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,72 @@
+package yamusic
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/tags"
+)
+
+// TestSkipExplicit checks the explicit flag of results, the advisory tag and the filter
+func TestSkipExplicit(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/track.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	explicit := bytes.Replace(fixture, []byte(`"disclaimers": [],`),
+		[]byte(`"disclaimers": ["modal"], "contentWarning": "explicit",`), 1)
+
+	// Test cases
+	tests := []struct {
+		name        string
+		skip        bool
+		wantSkipped bool
+	}{
+		{"Explicit track downloaded", false, false},
+		{"Explicit track skipped", true, true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newDownloadServerWith(t, "64551568", testFLAC(1), map[string]http.HandlerFunc{
+				"/tracks/64551568": func(w http.ResponseWriter, r *http.Request) {
+					w.Write(explicit)
+				},
+			})
+			client.SetSkipExplicit(tt.skip)
+
+			result, err := client.DownloadTrackResult("64551568", QualityHigh, t.TempDir())
+			if err != nil {
+				t.Fatalf("DownloadTrackResult() error = %v", err)
+			}
+			if !result.Explicit || len(result.Disclaimers) != 1 {
+				t.Errorf("Explicit = %v, Disclaimers = %v, want an explicit track with a disclaimer",
+					result.Explicit, result.Disclaimers)
+			}
+			if result.Skipped != tt.wantSkipped {
+				t.Fatalf("Skipped = %v, want %v", result.Skipped, tt.wantSkipped)
+			}
+			if tt.wantSkipped {
+				if result.Path != "" || result.SkipReason != SkipExplicit {
+					t.Errorf("Path = %q, SkipReason = %q, want no file and %q", result.Path, result.SkipReason, SkipExplicit)
+				}
+				if _, err := client.DownloadTrack("64551568", QualityHigh, t.TempDir()); !errors.Is(err, ErrExplicit) {
+					t.Errorf("DownloadTrack() error = %v, want ErrExplicit", err)
+				}
+				return
+			}
+
+			fileTags, err := tags.ReadFile(result.Path)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if v, _ := fileTags.Get(tags.KeyAdvisory); v != "1" {
+				t.Errorf("Advisory tag = %q, want \"1\"", v)
+			}
+		})
+	}
+}
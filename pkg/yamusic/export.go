@@ -0,0 +1,70 @@
+package yamusic
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// likesCSVHeader is the header row of the likes CSV export
+var likesCSVHeader = []string{"id", "title", "artists", "album", "duration_ms", "year", "explicit", "available", "liked_at"}
+
+// WriteLikesCSV writes liked tracks as CSV with one row per track.
+// Tracks without metadata are written with the ID and like time only.
+func WriteLikesCSV(w io.Writer, likes []LikedTrackInfo) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(likesCSVHeader); err != nil {
+		return err
+	}
+
+	for _, like := range likes {
+		track := like.Track
+		if track == nil {
+			if err := writer.Write([]string{like.ID, "", "", "", "", "", "", "false", like.LikedAt}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		artists := make([]string, 0, len(track.Artists))
+		for _, a := range track.Artists {
+			artists = append(artists, a.Name)
+		}
+
+		var album, year string
+		if selected := selectAlbum(track, like.AlbumID, AlbumFirst); selected != nil {
+			album = selected.Title
+			if selected.Year > 0 {
+				year = strconv.Itoa(selected.Year)
+			}
+		}
+
+		record := []string{
+			track.ID,
+			track.Title,
+			strings.Join(artists, " & "),
+			album,
+			strconv.Itoa(track.DurationMs),
+			year,
+			strconv.FormatBool(track.ContentWarning == "explicit"),
+			strconv.FormatBool(track.Available),
+			like.LikedAt,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteLikesJSON writes liked tracks as an indented JSON array of LikedTrackInfo
+func WriteLikesJSON(w io.Writer, likes []LikedTrackInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(likes)
+}
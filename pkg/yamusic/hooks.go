@@ -0,0 +1,61 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// ErrRejected is reported for tracks skipped because the pre-download hook returned an
+// error, which is wrapped along with it (see SetPreDownloadHook)
+var ErrRejected = errors.New("rejected by the pre-download hook")
+
+// PreDownloadHook is called with the metadata of a track before it is downloaded
+type PreDownloadHook func(TrackInfo) error
+
+// PostDownloadHook is called with the result of a track once its file is saved
+type PostDownloadHook func(DownloadResult) error
+
+// SetPreDownloadHook sets a hook called before every track file is downloaded, once its
+// metadata is known and it is not skipped for other reasons. An error skips the track:
+// its result reports the error wrapped with ErrRejected. In batch downloads the hook runs
+// in the worker of the track, so it must be safe for concurrent use. Nil removes the hook.
+func (c *Client) SetPreDownloadHook(hook PreDownloadHook) {
+	c.preDownloadHook = hook
+}
+
+// SetPostDownloadHook sets a hook called after every track file is saved and tagged, e.g.
+// to normalize its loudness. An error is reported in the result of the track, which keeps
+// its saved file. In batch downloads the hook runs in the worker of the track, so it must
+// be safe for concurrent use. Nil removes the hook.
+func (c *Client) SetPostDownloadHook(hook PostDownloadHook) {
+	c.postDownloadHook = hook
+}
+
+// runPreDownloadHook calls the pre-download hook for a track, returning the error
+// rejecting it
+func (c *Client) runPreDownloadHook(trackInfo *api.TrackInfo, result *DownloadResult, log *logger.Logger) error {
+	if c.preDownloadHook == nil {
+		return nil
+	}
+	if err := c.preDownloadHook(*trackInfo); err != nil {
+		log.Info("Skipping track rejected by the pre-download hook: %v", err)
+		result.skip(SkipRejected)
+		return fmt.Errorf("%w: %w", ErrRejected, err)
+	}
+	return nil
+}
+
+// runPostDownloadHook calls the post-download hook for a saved track
+func (c *Client) runPostDownloadHook(result *DownloadResult, log *logger.Logger) error {
+	if c.postDownloadHook == nil {
+		return nil
+	}
+	if err := c.postDownloadHook(*result); err != nil {
+		log.Error("Post-download hook failed: %v", err)
+		return fmt.Errorf("post-download hook: %w", err)
+	}
+	return nil
+}
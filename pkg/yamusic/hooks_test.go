@@ -0,0 +1,79 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+// TestDownloadHooks checks that hook errors skip or fail the track in its result
+func TestDownloadHooks(t *testing.T) {
+	errHook := errors.New("hook error")
+
+	// Test cases
+	tests := []struct {
+		name        string
+		rejectPre   bool
+		failPost    bool
+		wantSkipped bool
+		wantErr     error
+		wantFile    bool
+		wantPost    bool
+	}{
+		{name: "Hooks pass", wantFile: true, wantPost: true},
+		{name: "Pre-download hook rejects", rejectPre: true, wantSkipped: true, wantErr: ErrRejected},
+		{name: "Post-download hook fails", failPost: true, wantErr: errHook, wantFile: true, wantPost: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newDownloadServerWith(t, "64551568", testFLAC(1), nil)
+			client.SetFailFast(true)
+
+			var preTitle string
+			client.SetPreDownloadHook(func(info TrackInfo) error {
+				preTitle = info.Title
+				if tt.rejectPre {
+					return errHook
+				}
+				return nil
+			})
+			postCalled := false
+			client.SetPostDownloadHook(func(result DownloadResult) error {
+				postCalled = true
+				if _, err := os.Stat(result.Path); err != nil {
+					t.Errorf("Saved file missing in the post-download hook: %v", err)
+				}
+				if tt.failPost {
+					return errHook
+				}
+				return nil
+			})
+
+			results := client.DownloadTracksContext(context.Background(),
+				[]TrackRef{{ID: "64551568"}}, QualityHigh, t.TempDir(), 1)
+			result := results[0]
+
+			if preTitle != "Кукла колдуна" {
+				t.Errorf("Pre-download hook got title %q, want %q", preTitle, "Кукла колдуна")
+			}
+			if postCalled != tt.wantPost {
+				t.Errorf("Post-download hook called = %v, want %v", postCalled, tt.wantPost)
+			}
+			if result.Skipped != tt.wantSkipped {
+				t.Errorf("Skipped = %v, want %v", result.Skipped, tt.wantSkipped)
+			}
+			if tt.wantErr == nil && result.Err != nil {
+				t.Fatalf("Err = %v, want nil", result.Err)
+			}
+			if tt.wantErr != nil && (!errors.Is(result.Err, tt.wantErr) || !errors.Is(result.Err, errHook)) {
+				t.Fatalf("Err = %v, want %v wrapping the hook error", result.Err, tt.wantErr)
+			}
+			if tt.wantFile != (result.Path != "") {
+				t.Errorf("Path = %q, want a saved file: %v", result.Path, tt.wantFile)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package yamusic
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// DefaultMaxConnsPerHost is the default number of simultaneous transfers per CDN host
+const DefaultMaxConnsPerHost = 4
+
+// hostLimiter limits the number of simultaneous transfers targeting the same host
+// and spreads transfers across mirrors
+type hostLimiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active map[string]int
+}
+
+// newHostLimiter creates a limiter allowing limit transfers per host
+func newHostLimiter(limit int) *hostLimiter {
+	l := &hostLimiter{limit: limit, active: make(map[string]int)}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire picks the mirror whose host has the fewest active transfers, blocking
+// while every host is at the limit or until ctx is done. The returned release
+// function must be called when the transfer is finished.
+func (l *hostLimiter) acquire(ctx context.Context, urls []string, log *logger.Logger) (string, func(), error) {
+	// Wake up the waiters when the context is done so they can give up
+	stop := context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.cond.Broadcast()
+	})
+	defer stop()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", nil, err
+		}
+
+		best, bestHost := "", ""
+		for _, u := range urls {
+			host := urlHost(u)
+			if l.active[host] >= l.limit {
+				continue
+			}
+			if best == "" || l.active[host] < l.active[bestHost] {
+				best, bestHost = u, host
+			}
+		}
+
+		if best != "" {
+			l.active[bestHost]++
+			log.Debug("Transfer host: %s (active transfers: %s)", bestHost, l.distribution())
+
+			var once sync.Once
+			return best, func() { once.Do(func() { l.release(bestHost) }) }, nil
+		}
+
+		l.cond.Wait()
+	}
+}
+
+// release frees a transfer slot of the host
+func (l *hostLimiter) release(host string) {
+	l.mu.Lock()
+	l.active[host]--
+	if l.active[host] <= 0 {
+		delete(l.active, host)
+	}
+	l.mu.Unlock()
+
+	l.cond.Broadcast()
+}
+
+// distribution formats the active transfer counts per host; the caller must hold the lock
+func (l *hostLimiter) distribution() string {
+	hosts := make([]string, 0, len(l.active))
+	for host := range l.active {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	parts := make([]string, len(hosts))
+	for i, host := range hosts {
+		parts[i] = host + "=" + strconv.Itoa(l.active[host])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// urlHost returns the host name of a URL, or the URL itself when it can't be parsed
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Hostname()
+}
+
+// newTransport creates the transport shared by API and CDN requests
+func newTransport(maxConnsPerHost int) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = maxConnsPerHost
+	transport.MaxIdleConnsPerHost = maxConnsPerHost
+	return transport
+}
+
+// SetMaxConnsPerHost limits the number of simultaneous connections and transfers
+// per host. When the download info lists several mirrors, transfers are spread
+// across them. A zero limit restores DefaultMaxConnsPerHost. A transport injected
+// with WithHTTPClient or WithTransport is kept; only transfers are limited then.
+func (c *Client) SetMaxConnsPerHost(limit int) {
+	if limit <= 0 {
+		limit = DefaultMaxConnsPerHost
+	}
+	if c.transport != nil {
+		proxy := c.transport.Proxy
+		c.transport = newTransport(limit)
+		c.transport.Proxy = proxy
+		c.httpClient.Transport = c.transport
+		c.downloadClient.Transport = c.transport
+	}
+	c.hosts = newHostLimiter(limit)
+}
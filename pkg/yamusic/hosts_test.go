@@ -0,0 +1,76 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// TestHostLimiter checks mirror spreading and the per-host limit
+func TestHostLimiter(t *testing.T) {
+	l := newHostLimiter(1)
+	log := logger.New(false)
+	mirrors := []string{"https://a.example/track", "https://b.example/track"}
+
+	// Transfers are spread across mirrors
+	ctx := context.Background()
+	first, releaseFirst, _ := l.acquire(ctx, mirrors, log)
+	second, releaseSecond, _ := l.acquire(ctx, mirrors, log)
+	if urlHost(first) == urlHost(second) {
+		t.Errorf("Both transfers target %s, want different mirrors", urlHost(first))
+	}
+
+	// A third transfer must wait until a host frees up
+	acquired := make(chan string)
+	var releaseThird func()
+	go func() {
+		var u string
+		u, releaseThird, _ = l.acquire(ctx, mirrors, log)
+		acquired <- u
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquire() didn't block while all hosts were at the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releaseSecond()
+	releaseSecond() // Release is idempotent
+
+	select {
+	case u := <-acquired:
+		if u != second {
+			t.Errorf("acquire() = %s, want the freed mirror %s", u, second)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire() didn't unblock after release")
+	}
+
+	// A waiting transfer gives up when its context is cancelled
+	cancelled, cancel := context.WithCancel(ctx)
+	failed := make(chan error)
+	go func() {
+		_, _, err := l.acquire(cancelled, mirrors, log)
+		failed <- err
+	}()
+	cancel()
+
+	select {
+	case err := <-failed:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("acquire() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire() didn't return after the context was cancelled")
+	}
+
+	releaseThird()
+	releaseFirst()
+	if len(l.active) != 0 {
+		t.Errorf("active = %v, want empty after all releases", l.active)
+	}
+}
@@ -0,0 +1,125 @@
+package yamusic
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// StreamInfo describes a stream of a track available for download
+type StreamInfo struct {
+	Quality ApiTrackQuality `json:"quality"`
+	Codec   string          `json:"codec"`
+	Bitrate int             `json:"bitrate"`
+
+	// Size is the size of the audio in bytes, without tags
+	Size int64 `json:"size"`
+}
+
+// TrackInspection describes what downloading a track would produce
+type TrackInspection struct {
+	TrackID    string   `json:"trackId"`
+	Title      string   `json:"title"`
+	Artists    []string `json:"artists"`
+	Albums     []string `json:"albums"`
+	DurationMs int      `json:"durationMs"`
+
+	Explicit    bool     `json:"explicit"`
+	Disclaimers []string `json:"disclaimers,omitempty"`
+
+	// Stream is the stream the download would use, after quality fallback
+	Stream StreamInfo `json:"stream"`
+
+	// Available lists the streams of every quality the track is available in, best first
+	Available []StreamInfo `json:"available"`
+
+	// Variants lists the codec and bitrate combinations of the legacy download API
+	// (see ListDownloadVariants); empty when they couldn't be listed
+	Variants []DownloadVariant `json:"variants,omitempty"`
+
+	// FileName is the path the track would be saved to, relative to the output directory
+	FileName string `json:"fileName"`
+}
+
+// InspectTrack performs the metadata and download info requests of a download without
+// transferring the audio and describes what the download would produce
+func (c *Client) InspectTrack(trackID string, quality AudioQuality) (*TrackInspection, error) {
+	return c.InspectTrackContext(context.Background(), TrackRef{ID: trackID}, quality)
+}
+
+// InspectTrackContext inspects a track, named after the album of track.AlbumID when given;
+// the requests are cancelled with ctx
+func (c *Client) InspectTrackContext(ctx context.Context, track TrackRef, quality AudioQuality) (*TrackInspection, error) {
+	trackID, albumID := splitTrackRef(track.ID, track.AlbumID)
+	log := c.trackLogger(trackID, phaseMetadata).WithField("quality", string(quality))
+
+	trackInfo, err := c.getTrackInfo(ctx, trackID, log)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadInfo, err := c.trackDownloadInfo(ctx, trackInfo, trackID, api.ConvertQuality(quality), log)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DownloadResult{TrackID: trackID}
+	result.describeTrack(trackInfo, albumID, c.albumPolicy)
+
+	inspection := &TrackInspection{
+		TrackID:     trackID,
+		Title:       result.Title,
+		Artists:     result.Artists,
+		Albums:      result.Albums,
+		DurationMs:  result.DurationMs,
+		Explicit:    result.Explicit,
+		Disclaimers: result.Disclaimers,
+		Stream:      streamInfo(downloadInfo),
+		FileName: filepath.Join(c.trackDir(trackInfo, albumID, ""),
+			c.trackFileName(trackInfo, trackID, albumID, downloadInfo.Extension(), log)),
+	}
+	if c.preview {
+		inspection.FileName = previewName(inspection.FileName)
+	}
+
+	// Every quality is requested on its own: the server answers a request for an
+	// unavailable quality with an error or a lower one
+	for _, q := range qualityChain(api.QualityLossless) {
+		info, err := c.requestDownloadInfo(ctx, trackID, q, log)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Debug("Quality %s is not available: %v", q, err)
+			continue
+		}
+		if info.Quality == "" {
+			info.Quality = string(q)
+		}
+		if info.Quality == string(q) {
+			inspection.Available = append(inspection.Available, streamInfo(info))
+		}
+	}
+
+	variants, err := c.ListDownloadVariantsContext(ctx, trackID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Debug("Download variants are not available: %v", err)
+	}
+	inspection.Variants = variants
+
+	return inspection, nil
+}
+
+// streamInfo describes the stream of a download info
+func streamInfo(downloadInfo *api.DownloadInfo) StreamInfo {
+	return StreamInfo{
+		Quality: ApiTrackQuality(downloadInfo.Quality),
+		Codec:   downloadInfo.Codec,
+		Bitrate: downloadInfo.Bitrate,
+		Size:    int64(downloadInfo.Size),
+	}
+}
@@ -0,0 +1,60 @@
+package yamusic
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestInspectTrack checks that inspection describes the download without transferring the audio
+func TestInspectTrack(t *testing.T) {
+	// Lossless is not available; lower qualities are served in AAC
+	streams := map[string]string{"nq": `"codec":"aac","bitrate":192,"size":4800000`,
+		"lq": `"codec":"he-aac","bitrate":64,"size":1600000`}
+
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568": serveFixture(t, "track.json"),
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			quality := r.URL.Query().Get("quality")
+			stream, ok := streams[quality]
+			if !ok {
+				http.Error(w, `{"error":{"name":"not-found"}}`, http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, `{"invocationInfo":{},"result":{"downloadInfo":{"trackId":"64551568",`+
+				`"quality":%q,%s,"transport":"encraw","key":%q,"url":"http://media.invalid/%s"}}}`,
+				quality, stream, testDecryptionKey, quality)
+		},
+		"/account/status": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"invocationInfo":{},"result":{"plus":{"hasPlus":true}}}`))
+		},
+	})
+
+	inspection, err := client.InspectTrack("64551568:2843017", QualityHigh)
+	if err != nil {
+		t.Fatalf("InspectTrack() error = %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"TrackID", inspection.TrackID, "64551568"},
+		{"Title", inspection.Title, "Кукла колдуна"},
+		{"Albums", fmt.Sprint(inspection.Albums), "[Лучшее: Ноты, рифмы]"},
+		{"Stream", inspection.Stream, StreamInfo{Quality: "nq", Codec: "aac", Bitrate: 192, Size: 4800000}},
+		{"Available", fmt.Sprint(inspection.Available), "[{nq aac 192 4800000} {lq he-aac 64 1600000}]"},
+		{"FileName", inspection.FileName, "Кукла колдуна - Король и Шут & Князь (Лучшее_ Ноты, рифмы) [64551568].m4a"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
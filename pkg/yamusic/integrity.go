@@ -0,0 +1,81 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/media"
+)
+
+var (
+	// ErrSizeMismatch is returned when the downloaded file size differs from the size
+	// reported by the API, e.g. after a silently truncated transfer
+	ErrSizeMismatch = errors.New("downloaded size doesn't match the expected size")
+
+	// ErrCorruptAudio is returned when the decrypted audio doesn't start with the container
+	// signature of the reported codec, which almost always means a wrong decryption key
+	ErrCorruptAudio = errors.New("decrypted audio doesn't match the expected format")
+)
+
+// expectedContainer returns the container the decrypted audio of the download must be in
+func expectedContainer(downloadInfo *api.DownloadInfo) media.Container {
+	return media.ContainerForExtension(downloadInfo.Extension())
+}
+
+// checkSize compares the number of downloaded bytes with the size reported by the API;
+// an unknown (zero) size always matches
+func checkSize(downloadInfo *api.DownloadInfo, downloaded int64) error {
+	if downloadInfo.Size > 0 && downloaded != int64(downloadInfo.Size) {
+		return fmt.Errorf("%w: got %d bytes, expected %d", ErrSizeMismatch, downloaded, downloadInfo.Size)
+	}
+	return nil
+}
+
+// headerCheckWriter holds back the first bytes written until the container signature is
+// verified, so nothing is written when the decrypted data is noise
+type headerCheckWriter struct {
+	w        io.Writer
+	expected media.Container
+	header   []byte
+	checked  bool
+}
+
+// Write implements io.Writer
+func (hw *headerCheckWriter) Write(p []byte) (int, error) {
+	if hw.checked {
+		return hw.w.Write(p)
+	}
+
+	n := min(len(p), media.HeaderSize-len(hw.header))
+	hw.header = append(hw.header, p[:n]...)
+	if len(hw.header) < media.HeaderSize {
+		return len(p), nil
+	}
+	if err := hw.flush(); err != nil {
+		return 0, err
+	}
+
+	written, err := hw.w.Write(p[n:])
+	return n + written, err
+}
+
+// flush verifies the held back header and writes it; it must be called after the last
+// write in case the data is shorter than the header
+func (hw *headerCheckWriter) flush() error {
+	if hw.checked {
+		return nil
+	}
+	hw.checked = true
+
+	if got := media.DetectContainer(hw.header); hw.expected != media.ContainerUnknown && got != hw.expected {
+		if got == media.ContainerUnknown {
+			got = "unknown data"
+		}
+		return fmt.Errorf("%w: expected %s, got %s (header % x)", ErrCorruptAudio, hw.expected, got, hw.header)
+	}
+
+	_, err := hw.w.Write(hw.header)
+	return err
+}
@@ -0,0 +1,123 @@
+package yamusic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+	"github.com/Kud1nov/yamusic-dl/internal/media"
+)
+
+// TestHeaderCheckWriter checks the container signature verification
+func TestHeaderCheckWriter(t *testing.T) {
+	mp4 := append([]byte{0, 0, 0, 0x20}, []byte("ftypM4A audio")...)
+
+	// Test cases
+	tests := []struct {
+		name     string
+		expected media.Container
+		data     []byte
+		wantErr  bool
+	}{
+		{"FLAC", media.ContainerFLAC, []byte("fLaC audio data"), false},
+		{"MP4", media.ContainerMP4, mp4, false},
+		{"MP3 with ID3", media.ContainerMP3, []byte("ID3\x04\x00 audio data"), false},
+		{"MP3 frame", media.ContainerMP3, []byte{0xFF, 0xFB, 0x90, 0x64, 1, 2, 3, 4, 5, 6, 7, 8}, false},
+		{"Short FLAC", media.ContainerFLAC, []byte("fLaC"), false},
+		{"Noise", media.ContainerFLAC, []byte{0x3a, 0x91, 0x07, 0xee, 1, 2, 3, 4, 5, 6, 7, 8, 9}, true},
+		{"Wrong container", media.ContainerMP4, []byte("fLaC audio data"), true},
+		{"Unknown codec", media.ContainerUnknown, []byte("anything at all"), false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			hw := &headerCheckWriter{w: &buf, expected: tt.expected}
+
+			// Write byte by byte to exercise the buffering of the header
+			var err error
+			for i := range tt.data {
+				if _, err = hw.Write(tt.data[i : i+1]); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				err = hw.flush()
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrCorruptAudio) || buf.Len() > 0 {
+					t.Errorf("Write() error = %v with %d bytes written, want ErrCorruptAudio and nothing written", err, buf.Len())
+				}
+				return
+			}
+			if !bytes.Equal(buf.Bytes(), tt.data) {
+				t.Errorf("Written %q, want %q", buf.Bytes(), tt.data)
+			}
+		})
+	}
+}
+
+// TestDownloadIntegrity checks that truncated transfers and wrongly decrypted audio fail
+// without leaving a file behind
+func TestDownloadIntegrity(t *testing.T) {
+	audio := bytes.Repeat([]byte("fLaC checked audio "), 64)
+	encrypted, err := crypto.DecryptAesCtr(audio, testDecryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to encrypt test audio: %v", err)
+	}
+	const wrongKey = "ff0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+	// Test cases
+	tests := []struct {
+		name     string
+		key      string
+		size     int
+		body     []byte
+		expected error
+	}{
+		{"Complete", testDecryptionKey, len(encrypted), encrypted, nil},
+		{"Unknown size", testDecryptionKey, 0, encrypted, nil},
+		{"Truncated", testDecryptionKey, len(encrypted), encrypted[:len(encrypted)/2], ErrSizeMismatch},
+		{"Wrong key", wrongKey, len(encrypted), encrypted, ErrCorruptAudio},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var serverURL string
+			client, server := newTestServer(t, map[string]http.HandlerFunc{
+				"/tracks/64551568": serveFixture(t, "track.json"),
+				"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprintf(w, `{"result":{"downloadInfo":{"codec":"flac","key":%q,"size":%d,"url":%q}}}`,
+						tt.key, tt.size, serverURL+"/media")
+				},
+				"/media": func(w http.ResponseWriter, r *http.Request) {
+					// No Content-Length, so the truncation is only noticed through the size
+					w.(http.Flusher).Flush()
+					w.Write(tt.body)
+				},
+			})
+			serverURL = server.URL
+
+			outputDir := t.TempDir()
+			_, err := client.DownloadTrack("64551568", QualityHigh, outputDir)
+			if !errors.Is(err, tt.expected) || (err != nil) != (tt.expected != nil) {
+				t.Fatalf("DownloadTrack() error = %v, want %v", err, tt.expected)
+			}
+
+			entries, _ := os.ReadDir(outputDir)
+			if err != nil && len(entries) > 0 {
+				t.Errorf("Failed download left %d files behind", len(entries))
+			}
+		})
+	}
+}
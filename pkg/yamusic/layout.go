@@ -0,0 +1,120 @@
+package yamusic
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+// DirLayout decides where in the output directory track files are saved
+type DirLayout string
+
+const (
+	// LayoutFlat - save all files directly into the output directory
+	LayoutFlat DirLayout = ""
+
+	// LayoutArtistAlbum - save files under "Artist/Album (Year)/", with "CD1", "CD2"
+	// subdirectories for multi-disc albums
+	LayoutArtistAlbum DirLayout = "artist-album"
+)
+
+// VariousArtists is the artist directory of compilations by various artists
+const VariousArtists = "Various Artists"
+
+// ParseDirLayout parses a directory layout name
+func ParseDirLayout(value string) (DirLayout, error) {
+	switch layout := DirLayout(value); layout {
+	case "flat":
+		return LayoutFlat, nil
+	case LayoutFlat, LayoutArtistAlbum:
+		return layout, nil
+	default:
+		return "", fmt.Errorf("invalid directory layout %q (valid values: flat, artist-album)", value)
+	}
+}
+
+// SetDirLayout sets the directory layout of downloaded files. The layout is flat by default.
+func (c *Client) SetDirLayout(layout DirLayout) {
+	c.layout = layout
+}
+
+// trackDir returns the directory of a track file under outputDir. The album is the one used
+// for naming, or the first album of the track when all albums are kept. The artist is the
+// first artist of the track. Tracks of a volume other than the first, or of any volume of
+// an album fetched with GetAlbum and known to have several, go into a "CD<n>" subdirectory.
+// Tracks without a position in their metadata take the volume of their place in the album.
+func (c *Client) trackDir(trackInfo *api.TrackInfo, albumID, outputDir string) string {
+	if c.layout != LayoutArtistAlbum {
+		return outputDir
+	}
+
+	album := selectAlbum(trackInfo, albumID, c.albumPolicy)
+	if album == nil && len(trackInfo.Albums) > 0 {
+		album = &trackInfo.Albums[0]
+	}
+
+	artist := ""
+	if len(trackInfo.Artists) > 0 {
+		artist = trackInfo.Artists[0].Name
+	}
+	if album == nil {
+		return filepath.Join(outputDir, dirName(artist))
+	}
+	if isVarious(album) {
+		artist = VariousArtists
+	}
+
+	dir := filepath.Join(outputDir, dirName(artist), albumDirName(album))
+	numbering, _ := c.numbering(trackInfo, album)
+	if numbering.Volume > 1 || (numbering.Volume > 0 && numbering.Discs > 1) {
+		dir = filepath.Join(dir, fmt.Sprintf("CD%d", numbering.Volume))
+	}
+	return dir
+}
+
+// AlbumDir returns the directory of an album under outputDir according to the layout,
+// where its cover belongs
+func (c *Client) AlbumDir(album *Album, outputDir string) string {
+	if c.layout != LayoutArtistAlbum {
+		return outputDir
+	}
+
+	artist := ""
+	if isVarious(album) {
+		artist = VariousArtists
+	} else if len(album.Artists) > 0 {
+		artist = album.Artists[0].Name
+	}
+	return filepath.Join(outputDir, dirName(artist), albumDirName(album))
+}
+
+// albumDirName forms the directory name of an album: Title (Year)
+func albumDirName(album *api.Album) string {
+	if album.Year > 0 {
+		return dirName(fmt.Sprintf("%s (%d)", album.Title, album.Year))
+	}
+	return dirName(album.Title)
+}
+
+// isVarious reports whether the album is a compilation credited to various artists
+func isVarious(album *api.Album) bool {
+	for _, artist := range album.Artists {
+		if artist.Various {
+			return true
+		}
+	}
+	return false
+}
+
+// dirName cleans a directory name like a file name. Trailing dots are dropped as well,
+// so a name can't refer to the parent directory and stays valid on Windows.
+func dirName(name string) string {
+	name = strings.TrimRight(utils.CleanFileName(name), ". ")
+	if name == "" {
+		return "Unknown"
+	}
+	return name
+}
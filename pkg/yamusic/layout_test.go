@@ -0,0 +1,119 @@
+package yamusic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// TestTrackDir checks the track directories of the artist/album layout
+func TestTrackDir(t *testing.T) {
+	artists := []api.Artist{{Name: "First"}, {Name: "Second"}}
+	various := []api.Artist{{Name: "сборник", Various: true}}
+
+	// Test cases
+	tests := []struct {
+		name     string
+		layout   DirLayout
+		artists  []api.Artist
+		albums   []api.Album
+		discs    int
+		expected string
+	}{
+		{"Flat", LayoutFlat, artists, []api.Album{{ID: "1", Title: "Album", Year: 2001}}, 0, "out"},
+		{"First artist", LayoutArtistAlbum, artists,
+			[]api.Album{{ID: "1", Title: "Album", Year: 2001}}, 0, "out/First/Album (2001)"},
+		{"No year", LayoutArtistAlbum, artists, []api.Album{{ID: "1", Title: "Album"}}, 0, "out/First/Album"},
+		{"Various artists", LayoutArtistAlbum, artists,
+			[]api.Album{{ID: "1", Title: "Hits", Year: 2010, Artists: various}}, 0, "out/Various Artists/Hits (2010)"},
+		{"Second disc", LayoutArtistAlbum, artists,
+			[]api.Album{{ID: "1", Title: "Album", TrackPosition: api.TrackPosition{Volume: 2}}}, 0, "out/First/Album/CD2"},
+		{"First disc of a known multi-disc album", LayoutArtistAlbum, artists,
+			[]api.Album{{ID: "1", Title: "Album", TrackPosition: api.TrackPosition{Volume: 1}}}, 2, "out/First/Album/CD1"},
+		{"Single disc", LayoutArtistAlbum, artists,
+			[]api.Album{{ID: "1", Title: "Album", TrackPosition: api.TrackPosition{Volume: 1}}}, 1, "out/First/Album"},
+		{"Invalid characters", LayoutArtistAlbum, []api.Artist{{Name: "AC/DC"}},
+			[]api.Album{{ID: "1", Title: "What?.."}}, 0, "out/AC_DC/What_"},
+		{"No album", LayoutArtistAlbum, artists, nil, 0, "out/First"},
+		{"No artist", LayoutArtistAlbum, nil, []api.Album{{ID: "1", Title: "Album"}}, 0, "out/Unknown/Album"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("token", "", nil)
+			client.SetDirLayout(tt.layout)
+			if tt.discs > 0 {
+				client.albumLayouts.Store("1", &albumLayout{volumes: make([]int, tt.discs)})
+			}
+
+			trackInfo := &api.TrackInfo{Artists: tt.artists, Albums: tt.albums}
+			got := client.trackDir(trackInfo, "", "out")
+			if got != filepath.FromSlash(tt.expected) {
+				t.Errorf("trackDir() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestParseDirLayout checks parsing of layout names
+func TestParseDirLayout(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		value    string
+		expected DirLayout
+		wantErr  bool
+	}{
+		{"", LayoutFlat, false},
+		{"flat", LayoutFlat, false},
+		{"artist-album", LayoutArtistAlbum, false},
+		{"tree", "", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDirLayout(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDirLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseDirLayout() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDownloadArtistAlbumLayout checks that downloads land in the album directory and
+// that existing files are found there
+func TestDownloadArtistAlbumLayout(t *testing.T) {
+	client := newDownloadServer(t, "64551568", []byte("fLaC layout test audio"))
+	client.SetDirLayout(LayoutArtistAlbum)
+	client.SetAlbumPolicy(AlbumFirst)
+	client.SetOverwritePolicy(OverwriteSkip)
+	outputDir := t.TempDir()
+
+	path, err := client.DownloadTrack("64551568", QualityHigh, outputDir)
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+
+	expectedDir := filepath.Join(outputDir, "Король и Шут", "Акустический альбом (1999)")
+	if filepath.Dir(path) != expectedDir {
+		t.Errorf("Directory = %q, want %q", filepath.Dir(path), expectedDir)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Downloaded file is missing: %v", err)
+	}
+
+	result, err := client.DownloadTrackResult("64551568", QualityHigh, outputDir)
+	if err != nil {
+		t.Fatalf("DownloadTrackResult() error = %v", err)
+	}
+	if !result.Skipped || result.Path != path {
+		t.Errorf("Second download = {Path: %s, Skipped: %v}, want {Path: %s, Skipped: true}",
+			result.Path, result.Skipped, path)
+	}
+}
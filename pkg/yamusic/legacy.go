@@ -0,0 +1,122 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// legacyTransport marks download infos of the legacy API, whose files are not encrypted
+const legacyTransport = "raw"
+
+// maxLegacyFileInfoSize limits the size of the XML file info of the legacy API
+const maxLegacyFileInfoSize = 64 << 10
+
+// SetLegacyFallback enables or disables falling back to the legacy download API when
+// get-file-info fails. The legacy API serves unencrypted MP3 of up to 320 kbps, so it is
+// not used for lossless downloads when quality fallback is disabled. Enabled by default.
+func (c *Client) SetLegacyFallback(enabled bool) {
+	c.noLegacyFallback = !enabled
+}
+
+// requestLegacyDownloadInfo retrieves information for downloading a track with the legacy
+// download API: the MP3 stream of the highest bitrate, or of the lowest one for the low
+// quality. AAC streams of the legacy API are raw ADTS, which can't be tagged, so they are
+// not used. The returned info has no decryption key.
+func (c *Client) requestLegacyDownloadInfo(ctx context.Context, trackID string, quality ApiTrackQuality,
+	log *logger.Logger) (*api.DownloadInfo, error) {
+	infos, err := c.getLegacyDownloadInfos(ctx, trackID, log)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *api.LegacyDownloadInfo
+	for i, info := range infos {
+		if info.Codec != "mp3" || info.Preview || info.DownloadInfoURL == "" {
+			continue
+		}
+		switch {
+		case best == nil,
+			quality == api.QualityLow && info.Bitrate < best.Bitrate,
+			quality != api.QualityLow && info.Bitrate > best.Bitrate:
+			best = &infos[i]
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("invalid response format: no MP3 stream in legacy download info")
+	}
+
+	fileInfo, err := c.getLegacyFileInfo(ctx, best.DownloadInfoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	sign := crypto.GenerateLegacySignature(fileInfo.Path, fileInfo.S)
+	fileURL := fmt.Sprintf("https://%s/get-mp3/%s/%s%s", fileInfo.Host, sign, fileInfo.TS, fileInfo.Path)
+
+	log.Debug("Legacy download info: codec %s, bitrate %d, host %s", best.Codec, best.Bitrate, fileInfo.Host)
+
+	// MP3 is never lossless
+	delivered := api.QualityNormal
+	if quality == api.QualityLow {
+		delivered = api.QualityLow
+	}
+
+	return &api.DownloadInfo{
+		TrackID:   trackID,
+		Quality:   string(delivered),
+		Codec:     best.Codec,
+		Bitrate:   best.Bitrate,
+		Transport: legacyTransport,
+		Gain:      best.Gain,
+		Urls:      []string{fileURL},
+		Url:       fileURL,
+	}, nil
+}
+
+// getLegacyDownloadInfos retrieves the streams of a track listed by the legacy download API
+func (c *Client) getLegacyDownloadInfos(ctx context.Context, trackID string, log *logger.Logger) ([]api.LegacyDownloadInfo, error) {
+	log.Debug("Getting legacy download info")
+
+	path := fmt.Sprintf("/tracks/%s/download-info", url.PathEscape(trackID))
+	responseData, err := c.apiGet(ctx, path, "/tracks/download-info", log)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.LegacyDownloadInfoResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+	return response.Result, nil
+}
+
+// getLegacyFileInfo downloads and parses the XML file location of the legacy download API
+func (c *Client) getLegacyFileInfo(ctx context.Context, infoURL string) (*api.LegacyFileInfo, error) {
+	resp, err := c.getMedia(ctx, infoURL)
+	if err != nil {
+		return nil, fmt.Errorf("error getting legacy file info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLegacyFileInfoSize))
+	if err != nil {
+		return nil, fmt.Errorf("error getting legacy file info: %w", err)
+	}
+
+	var fileInfo api.LegacyFileInfo
+	if err := xml.Unmarshal(data, &fileInfo); err != nil {
+		return nil, fmt.Errorf("legacy file info parsing error: %w", err)
+	}
+	if fileInfo.Host == "" || fileInfo.Path == "" || fileInfo.S == "" {
+		return nil, fmt.Errorf("invalid legacy file info: host, path or signature missing")
+	}
+	return &fileInfo, nil
+}
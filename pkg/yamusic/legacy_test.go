@@ -0,0 +1,70 @@
+package yamusic
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// TestLegacyFallback checks that a track is downloaded unencrypted through the legacy API
+// when get-file-info rejects the requests, picking the MP3 stream of the highest bitrate
+func TestLegacyFallback(t *testing.T) {
+	audio := append([]byte("ID3"), bytes.Repeat([]byte{0x5a}, 1021)...)
+
+	var serverURL string
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568": serveFixture(t, "track.json"),
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"name":"bad-request","message":"Invalid sign"}}`))
+		},
+		"/tracks/64551568/download-info": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":[` +
+				`{"codec":"mp3","bitrateInKbps":192,"downloadInfoUrl":"` + serverURL + `/info/192"},` +
+				`{"codec":"mp3","bitrateInKbps":320,"downloadInfoUrl":"` + serverURL + `/info/320"},` +
+				`{"codec":"mp3","bitrateInKbps":320,"preview":true,"downloadInfoUrl":"` + serverURL + `/info/preview"},` +
+				`{"codec":"aac","bitrateInKbps":192,"downloadInfoUrl":"` + serverURL + `/info/aac"}]}`))
+		},
+		"/info/320": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><download-info>` +
+				`<host>s1.storage.example</host><path>/abc/64551568.mp3</path>` +
+				`<ts>0005f1</ts><region>-1</region><s>0123abcd</s></download-info>`))
+		},
+		"/get-mp3/710320662c090ac66e1156cdc47aceb1/0005f1/abc/64551568.mp3": func(w http.ResponseWriter, r *http.Request) {
+			w.Write(audio)
+		},
+	})
+	serverURL = server.URL
+	if err := client.SetMediaBaseURL(server.URL); err != nil {
+		t.Fatalf("SetMediaBaseURL() error = %v", err)
+	}
+	client.SetRetry(1, 0)
+	client.SetStandardTags(false)
+	client.SetExtraTags(false)
+
+	result, err := client.DownloadTrackResult("64551568", api.QualityHigh, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadTrackResult() error = %v", err)
+	}
+	if result.Codec != "mp3" || result.Bitrate != 320 || filepath.Ext(result.Path) != ".mp3" {
+		t.Errorf("Result = {%s %d %s}, want {mp3 320 .mp3}", result.Codec, result.Bitrate, filepath.Ext(result.Path))
+	}
+
+	data, err := os.ReadFile(result.Path)
+	if err != nil {
+		t.Fatalf("Failed to read the track: %v", err)
+	}
+	if !bytes.Equal(data, audio) {
+		t.Errorf("Track content differs from the served audio")
+	}
+
+	// Without the fallback the failure of get-file-info is final
+	client.SetLegacyFallback(false)
+	if _, err := client.DownloadTrackResult("64551568", api.QualityHigh, t.TempDir()); err == nil {
+		t.Error("DownloadTrackResult() without legacy fallback succeeded, want an error")
+	}
+}
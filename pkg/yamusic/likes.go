@@ -0,0 +1,194 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+var (
+	// ErrAlreadyLiked is returned when liking a track that is already liked
+	ErrAlreadyLiked = errors.New("track is already liked")
+
+	// ErrNotLiked is returned when unliking a track that is not liked
+	ErrNotLiked = errors.New("track is not liked")
+)
+
+// LikedTrack is a reference to a liked track
+type LikedTrack = api.LikedTrack
+
+// LikedTrackInfo is a liked track with its resolved metadata
+type LikedTrackInfo struct {
+	// ID is the liked track ID
+	ID string `json:"id"`
+
+	// LikedAt is the time the track was liked, as reported by the API
+	LikedAt string `json:"likedAt"`
+
+	// AlbumID is the album the track was liked from
+	AlbumID string `json:"albumId,omitempty"`
+
+	// Track is the track metadata; nil when the API no longer returns the track
+	Track *api.TrackInfo `json:"track"`
+}
+
+// GetLikedTracks retrieves the complete list of tracks liked by the user.
+// An empty userID means the account the token belongs to.
+func (c *Client) GetLikedTracks(userID string) ([]LikedTrack, error) {
+	return c.getLikedTracks(context.Background(), userID)
+}
+
+// getLikedTracks retrieves the liked tracks of the user. IDs in the "trackId:albumId"
+// form are split, so ID always holds just the track ID.
+func (c *Client) getLikedTracks(ctx context.Context, userID string) ([]LikedTrack, error) {
+	userID, err := c.resolveUID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("Getting liked tracks of user %s", userID)
+	path := fmt.Sprintf("/users/%s/likes/tracks", url.PathEscape(userID))
+	data, err := c.apiGet(ctx, path, "/users/likes/tracks", c.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.LikesResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	likes := response.Result.Library.Tracks
+	for i := range likes {
+		likes[i].ID, likes[i].AlbumID = splitTrackRef(likes[i].ID, likes[i].AlbumID)
+	}
+
+	c.logger.Debug("Liked tracks: %d (revision %d)", len(likes), response.Result.Library.Revision)
+	return likes, nil
+}
+
+// resolveUID returns userID, or the UID of the account the token belongs to when it is empty.
+// The account UID is requested once.
+func (c *Client) resolveUID(ctx context.Context, userID string) (string, error) {
+	if userID != "" {
+		return userID, nil
+	}
+
+	c.uidMu.Lock()
+	defer c.uidMu.Unlock()
+	if c.uid != "" {
+		return c.uid, nil
+	}
+
+	status, err := c.getAccountStatus(ctx, c.logger)
+	if err != nil {
+		return "", fmt.Errorf("error getting account UID: %w", err)
+	}
+	c.uid = status.Account.UID.String()
+	return c.uid, nil
+}
+
+// LikeTrack adds a track to the likes of the account the token belongs to. Liking a track
+// that is already liked fails with ErrAlreadyLiked; a token without the music:write scope
+// fails with ErrUnauthorized.
+func (c *Client) LikeTrack(trackID string) error {
+	return c.setLiked(context.Background(), trackID, true)
+}
+
+// UnlikeTrack removes a track from the likes of the account the token belongs to. Tracks
+// that are not liked fail with ErrNotLiked.
+func (c *Client) UnlikeTrack(trackID string) error {
+	return c.setLiked(context.Background(), trackID, false)
+}
+
+// setLiked adds the track to the likes or removes it. The API accepts both silently,
+// so the likes list is checked first to report no-op changes.
+func (c *Client) setLiked(ctx context.Context, trackID string, liked bool) error {
+	trackID, _ = splitTrackRef(trackID, "")
+	uid, err := c.resolveUID(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	likes, err := c.getLikedTracks(ctx, uid)
+	if err != nil {
+		return fmt.Errorf("error getting liked tracks: %w", err)
+	}
+	present := slices.ContainsFunc(likes, func(like LikedTrack) bool { return like.ID == trackID })
+	switch {
+	case liked && present:
+		return fmt.Errorf("track %s: %w", trackID, ErrAlreadyLiked)
+	case !liked && !present:
+		return fmt.Errorf("track %s: %w", trackID, ErrNotLiked)
+	}
+
+	action, endpoint := "add-multiple", "/users/likes/tracks/add-multiple"
+	if !liked {
+		action, endpoint = "remove", "/users/likes/tracks/remove"
+	}
+
+	form := url.Values{}
+	form.Set("track-ids", trackID)
+	path := fmt.Sprintf("/users/%s/likes/tracks/%s", url.PathEscape(uid), action)
+	log := c.logger.WithField("track_id", trackID)
+	if _, err := c.apiPostForm(ctx, path, endpoint, form, log); err != nil {
+		return err
+	}
+
+	log.Debug("Liked: %v", liked)
+	return nil
+}
+
+// DownloadLikedTracks downloads all tracks liked by the user with a pool of concurrency
+// workers (see DownloadTracks). An empty userID means the account the token belongs to.
+// Tracks are named after the album they were liked from.
+func (c *Client) DownloadLikedTracks(userID string, quality AudioQuality, outputDir string, concurrency int) ([]TrackResult, error) {
+	return c.DownloadLikedTracksContext(context.Background(), userID, quality, outputDir, concurrency)
+}
+
+// DownloadLikedTracksContext is DownloadLikedTracks with cancellation through ctx
+func (c *Client) DownloadLikedTracksContext(ctx context.Context, userID string, quality AudioQuality,
+	outputDir string, concurrency int) ([]TrackResult, error) {
+	likes, err := c.getLikedTracks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]TrackRef, len(likes))
+	for i, like := range likes {
+		tracks[i] = TrackRef{ID: like.ID, AlbumID: like.AlbumID}
+	}
+
+	c.logger.Info("Downloading %d liked tracks", len(tracks))
+	return c.DownloadTracksContext(ctx, tracks, quality, outputDir, concurrency), nil
+}
+
+// ResolveLikedTracks fetches the metadata of liked tracks in batches, keeping the likes order
+func (c *Client) ResolveLikedTracks(likes []LikedTrack) ([]LikedTrackInfo, error) {
+	ids := make([]string, len(likes))
+	for i, like := range likes {
+		ids[i] = like.ID
+	}
+
+	tracks, err := c.getTracks(context.Background(), ids)
+	if err != nil {
+		return nil, fmt.Errorf("error getting track metadata: %w", err)
+	}
+
+	result := make([]LikedTrackInfo, len(likes))
+	for i, like := range likes {
+		result[i] = LikedTrackInfo{
+			ID:      like.ID,
+			LikedAt: like.Timestamp,
+			AlbumID: like.AlbumID,
+			Track:   tracks[like.ID],
+		}
+	}
+
+	return result, nil
+}
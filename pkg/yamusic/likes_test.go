@@ -0,0 +1,180 @@
+package yamusic
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// newLikesServer starts a fake API serving the account status, likes list and track metadata
+func newLikesServer(t *testing.T) *Client {
+	t.Helper()
+
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/account/status": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"account":{"uid":42,"login":"test"}}}`))
+		},
+		"/users/42/likes/tracks": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"library":{"uid":42,"revision":7,"tracks":[` +
+				`{"id":"2","albumId":"20","timestamp":"2024-02-01T10:00:00+00:00"},` +
+				`{"id":"1","albumId":"10","timestamp":"2024-01-01T10:00:00+00:00"},` +
+				`{"id":"3:30","timestamp":"2023-12-01T10:00:00+00:00"}]}}}`))
+		},
+		"/tracks": func(w http.ResponseWriter, r *http.Request) {
+			if got := r.FormValue("trackIds"); got != "2,1,3" {
+				t.Errorf("trackIds = %q, want %q", got, "2,1,3")
+			}
+			w.Write([]byte(`{"result":[` +
+				`{"id":"1","title":"Hello, \"World\"","durationMs":180000,"available":true,` +
+				`"artists":[{"id":1,"name":"A"},{"id":2,"name":"B"}],` +
+				`"albums":[{"id":11,"title":"Best of","year":2020},{"id":10,"title":"Debut","year":2001}]},` +
+				`{"id":"2","title":"Plain","durationMs":200000,"available":false,"contentWarning":"explicit",` +
+				`"artists":[{"id":1,"name":"A"}],"albums":[{"id":20,"title":"Second"}]}]}`))
+		},
+	})
+
+	return client
+}
+
+// TestGetLikedTracks checks that own likes are resolved through the account UID and
+// that "trackId:albumId" IDs are split
+func TestGetLikedTracks(t *testing.T) {
+	client := newLikesServer(t)
+
+	likes, err := client.GetLikedTracks("")
+	if err != nil {
+		t.Fatalf("GetLikedTracks() error = %v", err)
+	}
+
+	expected := []LikedTrack{
+		{ID: "2", AlbumID: "20", Timestamp: "2024-02-01T10:00:00+00:00"},
+		{ID: "1", AlbumID: "10", Timestamp: "2024-01-01T10:00:00+00:00"},
+		{ID: "3", AlbumID: "30", Timestamp: "2023-12-01T10:00:00+00:00"},
+	}
+	if !reflect.DeepEqual(likes, expected) {
+		t.Errorf("GetLikedTracks() = %+v, want %+v", likes, expected)
+	}
+}
+
+// TestExportLikes checks likes resolution and both export formats
+func TestExportLikes(t *testing.T) {
+	client := newLikesServer(t)
+
+	likes, err := client.GetLikedTracks("")
+	if err != nil {
+		t.Fatalf("GetLikedTracks() error = %v", err)
+	}
+	if len(likes) != 3 {
+		t.Fatalf("GetLikedTracks() returned %d tracks, want 3", len(likes))
+	}
+
+	tracks, err := client.ResolveLikedTracks(likes)
+	if err != nil {
+		t.Fatalf("ResolveLikedTracks() error = %v", err)
+	}
+
+	// CSV must round-trip titles with commas and quotes
+	var buf bytes.Buffer
+	if err := WriteLikesCSV(&buf, tracks); err != nil {
+		t.Fatalf("WriteLikesCSV() error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Exported CSV is invalid: %v", err)
+	}
+
+	expected := [][]string{
+		likesCSVHeader,
+		{"2", "Plain", "A", "Second", "200000", "", "true", "false", "2024-02-01T10:00:00+00:00"},
+		{"1", `Hello, "World"`, "A & B", "Debut", "180000", "2001", "false", "true", "2024-01-01T10:00:00+00:00"},
+		{"3", "", "", "", "", "", "", "false", "2023-12-01T10:00:00+00:00"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("CSV records = %q, want %q", records, expected)
+	}
+
+	// JSON reuses the typed API structs
+	buf.Reset()
+	if err := WriteLikesJSON(&buf, tracks); err != nil {
+		t.Fatalf("WriteLikesJSON() error = %v", err)
+	}
+	var decoded []LikedTrackInfo
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Exported JSON is invalid: %v", err)
+	}
+	if len(decoded) != 3 || decoded[1].Track == nil || decoded[1].Track.Title != `Hello, "World"` || decoded[2].Track != nil {
+		t.Errorf("JSON export = %+v, want the resolved likes", decoded)
+	}
+}
+
+// TestSetLiked checks the like and unlike requests and their typed errors
+func TestSetLiked(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		trackID  string
+		like     bool
+		status   int
+		path     string
+		expected error
+	}{
+		{"Like", "5", true, http.StatusOK, "/users/42/likes/tracks/add-multiple", nil},
+		{"Like composite ID", "5:50", true, http.StatusOK, "/users/42/likes/tracks/add-multiple", nil},
+		{"Already liked", "1", true, http.StatusOK, "", ErrAlreadyLiked},
+		{"Unlike", "1", false, http.StatusOK, "/users/42/likes/tracks/remove", nil},
+		{"Not liked", "5", false, http.StatusOK, "", ErrNotLiked},
+		{"No write scope", "5", true, http.StatusForbidden, "/users/42/likes/tracks/add-multiple", ErrUnauthorized},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted string
+			modify := func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("Method = %s, want POST", r.Method)
+				}
+				if got := r.PostFormValue("track-ids"); got != "5" && got != "1" {
+					t.Errorf("track-ids = %q, want the plain track ID", got)
+				}
+				posted = r.URL.Path
+				if tt.status != http.StatusOK {
+					http.Error(w, `{"error":{"name":"session-expired"}}`, tt.status)
+					return
+				}
+				w.Write([]byte(`{"result":{"revision":8}}`))
+			}
+
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{
+				"/account/status": func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"result":{"account":{"uid":42,"login":"test"}}}`))
+				},
+				"/users/42/likes/tracks": func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"result":{"library":{"uid":42,"revision":7,"tracks":[` +
+						`{"id":"1","albumId":"10","timestamp":"2024-01-01T10:00:00+00:00"}]}}}`))
+				},
+				"/users/42/likes/tracks/add-multiple": modify,
+				"/users/42/likes/tracks/remove":       modify,
+			})
+			client.SetRetry(1, 0)
+
+			var err error
+			if tt.like {
+				err = client.LikeTrack(tt.trackID)
+			} else {
+				err = client.UnlikeTrack(tt.trackID)
+			}
+
+			if !errors.Is(err, tt.expected) || (tt.expected == nil && err != nil) {
+				t.Errorf("error = %v, want %v", err, tt.expected)
+			}
+			if posted != tt.path {
+				t.Errorf("Request path = %q, want %q", posted, tt.path)
+			}
+		})
+	}
+}
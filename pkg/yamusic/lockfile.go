@@ -0,0 +1,110 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// DefaultLockStaleness is the default age after which the lock of a track file is
+// considered left behind by a crashed run
+const DefaultLockStaleness = 2 * time.Minute
+
+// lockSuffix is appended to the names of track files to form their lock files
+const lockSuffix = ".lock"
+
+// errLocked reports a track file being downloaded by another download of the same track
+var errLocked = errors.New("file is being downloaded by another process")
+
+// SetLockStaleness sets the age after which the lock of a track file is removed as left
+// behind by a crashed run. Locks of running downloads are refreshed well within it. Zero
+// or less restores DefaultLockStaleness.
+func (c *Client) SetLockStaleness(staleness time.Duration) {
+	if staleness <= 0 {
+		staleness = DefaultLockStaleness
+	}
+	c.lockStaleness = staleness
+}
+
+// fileLock is a held lock of a track file
+type fileLock struct {
+	path string
+	stop chan struct{}
+}
+
+// lockFile creates the lock file of path for the track, which every download of this and
+// other processes takes before writing the file. A lock held by another download is
+// reported with its track ID and errLocked; a stale lock is removed and taken over.
+func (c *Client) lockFile(path, trackID string, log *logger.Logger) (*fileLock, string, error) {
+	lockPath := path + lockSuffix
+	owner := trackID + " " + strconv.Itoa(os.Getpid()) + "\n"
+
+	for attempt := 0; ; attempt++ {
+		file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = file.WriteString(owner)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return nil, "", fmt.Errorf("error creating lock file: %w", err)
+			}
+
+			lock := &fileLock{path: lockPath, stop: make(chan struct{})}
+			go lock.refresh(c.lockStaleness / 4)
+			return lock, "", nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, "", fmt.Errorf("error creating lock file: %w", err)
+		}
+
+		holder, stale := c.lockHolder(lockPath)
+		if !stale || attempt > 0 {
+			return nil, holder, errLocked
+		}
+		log.Warn("Removing a stale lock file: %s", lockPath)
+		if err := os.Remove(lockPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, "", fmt.Errorf("error removing stale lock file: %w", err)
+		}
+	}
+}
+
+// lockHolder returns the track ID written to a lock file and whether the lock is stale.
+// A lock that disappeared in the meantime is stale, so it is taken right away.
+func (c *Client) lockHolder(lockPath string) (string, bool) {
+	info, err := os.Stat(lockPath)
+	if err != nil {
+		return "", true
+	}
+	data, _ := os.ReadFile(lockPath)
+	holder, _, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	return holder, time.Since(info.ModTime()) > c.lockStaleness
+}
+
+// refresh touches the lock file every interval until it is unlocked, so a long download
+// doesn't look stale
+func (l *fileLock) refresh(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-ticker.C:
+			os.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+// unlock stops refreshing and removes the lock file
+func (l *fileLock) unlock() {
+	close(l.stop)
+	os.Remove(l.path)
+}
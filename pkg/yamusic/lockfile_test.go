@@ -0,0 +1,64 @@
+package yamusic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLockedFile checks downloads of files locked by another process
+func TestLockedFile(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		holder   string
+		age      time.Duration
+		expected string
+		skipped  bool
+	}{
+		{"Same track being downloaded", "64551568", 0, "Кукла колдуна.flac", true},
+		{"Other track being downloaded", "777", 0, "Кукла колдуна [64551568].flac", false},
+		{"Stale lock of a crashed run", "64551568", time.Hour, "Кукла колдуна.flac", false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newDownloadServerWith(t, "64551568", []byte("fLaC lock test audio"), nil)
+			if err := client.SetFileNameTemplate("{title}"); err != nil {
+				t.Fatalf("SetFileNameTemplate() error = %v", err)
+			}
+			dir := t.TempDir()
+
+			lockPath := filepath.Join(dir, "Кукла колдуна.flac"+lockSuffix)
+			if err := os.WriteFile(lockPath, []byte(tt.holder+" 1\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			modTime := time.Now().Add(-tt.age)
+			if err := os.Chtimes(lockPath, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := client.DownloadTrackResult("64551568", QualityHigh, dir)
+			if err != nil {
+				t.Fatalf("DownloadTrackResult() error = %v", err)
+			}
+			if result.Skipped != tt.skipped {
+				t.Errorf("Skipped = %v, want %v", result.Skipped, tt.skipped)
+			}
+			if name := filepath.Base(result.Path); name != tt.expected {
+				t.Errorf("Path = %q, want %q", name, tt.expected)
+			}
+
+			// The lock of another process stays, the own and the stale ones are removed
+			_, err = os.Stat(lockPath)
+			if held := tt.age == 0; held != (err == nil) {
+				t.Errorf("Lock file exists = %v, want %v", err == nil, held)
+			}
+			if _, err := os.Stat(filepath.Join(dir, tt.expected+lockSuffix)); tt.age == 0 && !tt.skipped && err == nil {
+				t.Errorf("Lock of the finished download was not removed")
+			}
+		})
+	}
+}
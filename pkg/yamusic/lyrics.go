@@ -0,0 +1,139 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// Lyrics formats
+const (
+	// LyricsLRC - synced lyrics with timestamps
+	LyricsLRC = "LRC"
+
+	// LyricsText - plain text lyrics
+	LyricsText = "TEXT"
+)
+
+// ErrNoLyrics is returned when a track has no lyrics
+var ErrNoLyrics = errors.New("no lyrics available")
+
+// SetLyrics makes track downloads save the lyrics next to the audio file: synced lyrics
+// as .lrc when available, plain lyrics as .txt otherwise. Disabled by default.
+func (c *Client) SetLyrics(enabled bool) {
+	c.saveLyrics = enabled
+}
+
+// SetEmbedLyrics makes track downloads embed the lyrics into the file tags (LYRICS
+// in FLAC, ©lyr in MP4, USLT in MP3): synced lyrics when available, plain lyrics
+// otherwise. They are written with the other tags, so the file is rewritten once.
+// Disabled by default.
+func (c *Client) SetEmbedLyrics(enabled bool) {
+	c.embedLyrics = enabled
+}
+
+// GetLyrics retrieves the lyrics of a track in LyricsLRC or LyricsText format
+func (c *Client) GetLyrics(trackID string, format string) (string, error) {
+	log := c.trackLogger(trackID, phaseMetadata)
+	return c.getLyrics(context.Background(), trackID, format, log)
+}
+
+// getLyrics retrieves the lyrics of a track logging to log. The lyrics endpoint returns
+// a signed link to the lyrics file, which is then downloaded.
+func (c *Client) getLyrics(ctx context.Context, trackID, format string, log *logger.Logger) (string, error) {
+	log.Debug("Getting %s lyrics", format)
+
+	var responseData []byte
+	err := c.withSignKey(ctx, log, func(signKey string) error {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		query := url.Values{}
+		query.Set("format", format)
+		query.Set("timeStamp", ts)
+		query.Set("sign", crypto.GenerateSignature(ts+trackID, signKey))
+
+		path := fmt.Sprintf("/tracks/%s/lyrics?%s", url.PathEscape(trackID), query.Encode())
+		var err error
+		responseData, err = c.apiGet(ctx, path, "/tracks/lyrics", log)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrTrackNotFound) {
+			return "", fmt.Errorf("%w: %v", ErrNoLyrics, err)
+		}
+		return "", err
+	}
+
+	var response api.LyricsResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return "", fmt.Errorf("response parsing error: %w", err)
+	}
+	if response.Result.DownloadURL == "" {
+		return "", fmt.Errorf("invalid response format: lyrics URL not found")
+	}
+
+	var data []byte
+	err = c.withRetry(ctx, log, func() error {
+		resp, err := c.getMedia(ctx, response.Result.DownloadURL)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		data, err = c.readBody(resp, log)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("error downloading lyrics: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// trackLyrics retrieves the synced lyrics of a track, or the plain ones when there are
+// no synced lyrics, and returns them with their format. Missing lyrics and errors don't
+// fail the download; they are only reported, and the lyrics are empty.
+func (c *Client) trackLyrics(ctx context.Context, trackID string, trackInfo *api.TrackInfo, log *logger.Logger) (string, string) {
+	format := LyricsLRC
+	switch {
+	case trackInfo.LyricsInfo.HasAvailableSyncLyrics:
+	case trackInfo.LyricsInfo.HasAvailableTextLyrics:
+		format = LyricsText
+	default:
+		log.Debug("Track has no lyrics")
+		return "", ""
+	}
+
+	lyrics, err := c.getLyrics(ctx, trackID, format, log)
+	if err != nil {
+		log.Warn("Lyrics not saved: %v", err)
+		return "", ""
+	}
+	return lyrics, format
+}
+
+// writeLyrics saves the lyrics next to the audio file, as .lrc for synced lyrics and as
+// .txt for plain ones. Errors don't fail the download; they are only reported.
+func (c *Client) writeLyrics(audioPath, lyrics, format string, log *logger.Logger) {
+	ext := ".lrc"
+	if format == LyricsText {
+		ext = ".txt"
+	}
+
+	path := strings.TrimSuffix(audioPath, filepath.Ext(audioPath)) + ext
+	if err := os.WriteFile(path, []byte(lyrics), 0644); err != nil {
+		log.Warn("Error saving lyrics: %v", err)
+		return
+	}
+	log.Debug("Lyrics saved: %s", path)
+}
@@ -0,0 +1,125 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+	"github.com/Kud1nov/yamusic-dl/internal/tags"
+)
+
+// TestGetLyrics checks the signed lyrics request and the download of the lyrics file
+func TestGetLyrics(t *testing.T) {
+	var serverURL string
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/1/lyrics": func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if want := crypto.GenerateSignature(query.Get("timeStamp")+"1", api.DefaultSignKey); query.Get("sign") != want {
+				t.Errorf("sign = %q, want %q", query.Get("sign"), want)
+			}
+			fmt.Fprintf(w, `{"result":{"downloadUrl":%q,"lyricId":1}}`, serverURL+"/lyrics/"+query.Get("format"))
+		},
+		"/lyrics/": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "lyrics in %s", strings.TrimPrefix(r.URL.Path, "/lyrics/"))
+		},
+	})
+	serverURL = server.URL
+
+	// Test cases
+	tests := []struct {
+		name     string
+		trackID  string
+		format   string
+		expected string
+		wantErr  error
+	}{
+		{"Synced", "1", LyricsLRC, "lyrics in LRC", nil},
+		{"Plain", "1", LyricsText, "lyrics in TEXT", nil},
+		{"No lyrics", "2", LyricsLRC, "", ErrNoLyrics},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.GetLyrics(tt.trackID, tt.format)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetLyrics() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetLyrics() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("GetLyrics() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDownloadTrackLyricsMissing checks that a lyrics failure doesn't fail the download
+func TestDownloadTrackLyricsMissing(t *testing.T) {
+	client := newDownloadServer(t, "64551568", []byte("fLaC audio with lyrics"))
+	client.SetLyrics(true)
+
+	outputDir := t.TempDir()
+	path, err := client.DownloadTrack("64551568", QualityHigh, outputDir)
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+
+	entries, _ := os.ReadDir(outputDir)
+	if len(entries) != 1 || entries[0].Name() != filepath.Base(path) {
+		t.Errorf("Output directory has %v, want only the track", entries)
+	}
+}
+
+// TestDownloadTrackEmbedLyrics checks that embedded lyrics are written with the other tags
+// and that the sidecar file is saved from the same lyrics
+func TestDownloadTrackEmbedLyrics(t *testing.T) {
+	// A FLAC stream with an empty STREAMINFO block
+	audio := append([]byte("fLaC\x80\x00\x00\x22"), make([]byte, 34+64)...)
+
+	var serverURL string
+	var lyricsRequests int
+	client := newDownloadServerWith(t, "64551568", audio, map[string]http.HandlerFunc{
+		"/tracks/64551568/lyrics": func(w http.ResponseWriter, r *http.Request) {
+			lyricsRequests++
+			fmt.Fprintf(w, `{"result":{"downloadUrl":%q,"lyricId":1}}`, serverURL+"/lyrics/"+r.URL.Query().Get("format"))
+		},
+		"/lyrics/LRC": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("[00:01.00]Кукла колдуна"))
+		},
+	})
+	serverURL = client.baseURL
+	client.SetLyrics(true)
+	client.SetEmbedLyrics(true)
+
+	path, err := client.DownloadTrack("64551568", QualityHigh, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+
+	got, err := tags.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if lyrics, _ := got.Get(tags.KeyLyrics); lyrics != "[00:01.00]Кукла колдуна" {
+		t.Errorf("LYRICS tag = %q, want the synced lyrics", lyrics)
+	}
+
+	lrc, err := os.ReadFile(strings.TrimSuffix(path, filepath.Ext(path)) + ".lrc")
+	if err != nil || string(lrc) != "[00:01.00]Кукла колдуна" {
+		t.Errorf("Sidecar lyrics = %q, %v", lrc, err)
+	}
+	if lyricsRequests != 1 {
+		t.Errorf("Lyrics requested %d times, want 1", lyricsRequests)
+	}
+}
@@ -0,0 +1,87 @@
+package yamusic
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/media"
+	"github.com/Kud1nov/yamusic-dl/internal/tags"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+// M3U8Name returns the file name of a playlist file named after a playlist or album title
+func M3U8Name(title string) string {
+	return utils.CleanFileName(title) + ".m3u8"
+}
+
+// WriteM3U8 writes an extended M3U playlist in UTF-8 listing the files of the results in
+// order, with paths relative to the playlist file. Failed tracks are left out. Tracks
+// skipped as already downloaded are described from the tags and the audio of their file.
+func WriteM3U8(path, title string, results []TrackResult) error {
+	dir := filepath.Dir(path)
+
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n")
+	if title != "" {
+		fmt.Fprintf(&buf, "#PLAYLIST:%s\n", oneLine(title))
+	}
+	for _, result := range results {
+		if result.Err != nil || result.Path == "" {
+			continue
+		}
+
+		rel, err := filepath.Rel(dir, result.Path)
+		if err != nil {
+			rel = result.Path
+		}
+		seconds, name := m3uEntry(result)
+		fmt.Fprintf(&buf, "#EXTINF:%d,%s\n%s\n", seconds, oneLine(name), filepath.ToSlash(rel))
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing playlist file: %w", err)
+	}
+	return nil
+}
+
+// m3uEntry returns the duration in seconds, -1 when unknown, and the "Artist - Title"
+// name of a track
+func m3uEntry(result TrackResult) (int, string) {
+	seconds, title, artist := -1, "", ""
+	if download := result.Download; download != nil {
+		if download.DurationMs > 0 {
+			seconds = (download.DurationMs + 500) / 1000
+		}
+		title, artist = download.Title, strings.Join(download.Artists, " & ")
+	}
+
+	// Skipped tracks are only known by their file
+	if seconds < 0 {
+		if duration, err := media.ProbeDuration(result.Path); err == nil && duration > 0 {
+			seconds = int(duration.Seconds() + 0.5)
+		}
+	}
+	if title == "" {
+		if fileTags, err := tags.ReadFile(result.Path); err == nil {
+			title, _ = fileTags.Get(tags.KeyTitle)
+			artist, _ = fileTags.Get(tags.KeyArtist)
+		}
+	}
+
+	switch {
+	case title == "":
+		return seconds, strings.TrimSuffix(filepath.Base(result.Path), filepath.Ext(result.Path))
+	case artist == "":
+		return seconds, title
+	default:
+		return seconds, artist + " - " + title
+	}
+}
+
+// oneLine replaces line breaks, which would end an M3U directive
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,35 @@
+package yamusic
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteM3U8 checks the playlist file written for a batch
+func TestWriteM3U8(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, M3U8Name("Mix: Rock/Pop"))
+	results := []TrackResult{
+		{Path: filepath.Join(dir, "Artist", "First.flac"), Download: &DownloadResult{
+			Title: "First", Artists: []string{"A", "B"}, DurationMs: 184600}},
+		{Path: filepath.Join(dir, "Failed.flac"), Err: errors.New("unavailable")},
+		{Path: filepath.Join(dir, "Second.mp3"), Download: &DownloadResult{Title: "Second\nLine"}},
+	}
+
+	if err := WriteM3U8(path, "Mix: Rock/Pop", results); err != nil {
+		t.Fatalf("WriteM3U8() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "#EXTM3U\n#PLAYLIST:Mix: Rock/Pop\n" +
+		"#EXTINF:185,A & B - First\nArtist/First.flac\n" +
+		"#EXTINF:-1,Second Line\nSecond.mp3\n"
+	if string(data) != expected {
+		t.Errorf("WriteM3U8() wrote\n%s\nwant\n%s", data, expected)
+	}
+}
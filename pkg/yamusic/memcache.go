@@ -0,0 +1,125 @@
+package yamusic
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/cache"
+)
+
+const (
+	// DefaultMemoryCacheTTL is how long metadata stays in the in-process cache
+	DefaultMemoryCacheTTL = 10 * time.Minute
+
+	// DefaultMemoryCacheSize is the maximum number of entries of the in-process cache
+	DefaultMemoryCacheSize = 1000
+)
+
+// memoryCache is a size-bounded in-process cache of API metadata with a TTL.
+// The least recently used entry is evicted when the cache is full.
+type memoryCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// memoryEntry is a cached value with its expiry time
+type memoryEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// newMemoryCache creates an in-process cache; nil is returned when ttl or size
+// disables caching
+func newMemoryCache(ttl time.Duration, size int) *memoryCache {
+	if ttl <= 0 || size <= 0 {
+		return nil
+	}
+	return &memoryCache{
+		ttl:     ttl,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the value cached under kind and ID unless it has expired
+func (m *memoryCache) get(kind, id string) (interface{}, bool) {
+	if m == nil {
+		return nil, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[kind+":"+id]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expires) {
+		m.order.Remove(element)
+		delete(m.entries, entry.key)
+		return nil, false
+	}
+
+	m.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// put caches value under kind and ID, evicting the least recently used entry when full
+func (m *memoryCache) put(kind, id string, value interface{}) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := kind + ":" + id
+	expires := time.Now().Add(m.ttl)
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value, entry.expires = value, expires
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, value: value, expires: expires})
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+// SetMemoryCache sets the TTL and the maximum number of entries of the in-process
+// metadata cache, which spares repeated track and album lookups within a session.
+// A zero TTL or size disables the cache. It is enabled with DefaultMemoryCacheTTL
+// and DefaultMemoryCacheSize by default.
+func (c *Client) SetMemoryCache(ttl time.Duration, size int) {
+	c.memCache = newMemoryCache(ttl, size)
+}
+
+// memoryTrack returns track metadata from the in-process cache
+func (c *Client) memoryTrack(trackID string) (*TrackInfo, bool) {
+	value, ok := c.memCache.get(cache.KindTrack, trackID)
+	if !ok {
+		return nil, false
+	}
+	return value.(*TrackInfo), true
+}
+
+// memoryAlbum returns album metadata from the in-process cache
+func (c *Client) memoryAlbum(albumID string) (*Album, bool) {
+	value, ok := c.memCache.get(cache.KindAlbum, albumID)
+	if !ok {
+		return nil, false
+	}
+	return value.(*Album), true
+}
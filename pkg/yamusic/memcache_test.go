@@ -0,0 +1,97 @@
+package yamusic
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestMemoryCache checks that repeated lookups within a session are served from memory
+func TestMemoryCache(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name         string
+		enabled      bool
+		wantRequests int32
+	}{
+		{name: "Enabled", enabled: true, wantRequests: 1},
+		{name: "Disabled", enabled: false, wantRequests: 8},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var trackRequests, albumRequests atomic.Int32
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{
+				"/tracks/64551568": func(w http.ResponseWriter, r *http.Request) {
+					trackRequests.Add(1)
+					serveFixture(t, "track.json")(w, r)
+				},
+				"/albums/10376938/with-tracks": func(w http.ResponseWriter, r *http.Request) {
+					albumRequests.Add(1)
+					serveFixture(t, "album.json")(w, r)
+				},
+			})
+			if err := WithCache(tt.enabled)(client); err != nil {
+				t.Fatalf("WithCache() error = %v", err)
+			}
+
+			// The worker pool looks tracks up from many goroutines at once
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := client.GetTrackInfo("64551568"); err != nil {
+						t.Errorf("GetTrackInfo() error = %v", err)
+					}
+					if _, err := client.GetAlbum("10376938"); err != nil {
+						t.Errorf("GetAlbum() error = %v", err)
+					}
+				}()
+				if tt.enabled && i == 0 {
+					wg.Wait()
+				}
+			}
+			wg.Wait()
+
+			if got := trackRequests.Load(); got != tt.wantRequests {
+				t.Errorf("Made %d track requests, want %d", got, tt.wantRequests)
+			}
+			if got := albumRequests.Load(); got != tt.wantRequests {
+				t.Errorf("Made %d album requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+// TestMemoryCacheBounds checks expiry and eviction of the least recently used entries
+func TestMemoryCacheBounds(t *testing.T) {
+	cache := newMemoryCache(time.Hour, 2)
+	cache.put("track", "1", 1)
+	cache.put("track", "2", 2)
+	cache.get("track", "1")
+	cache.put("track", "3", 3)
+
+	for id, want := range map[string]bool{"1": true, "2": false, "3": true} {
+		if _, ok := cache.get("track", id); ok != want {
+			t.Errorf("get(%s) found = %v, want %v", id, ok, want)
+		}
+	}
+
+	expiring := newMemoryCache(time.Millisecond, 10)
+	expiring.put("album", "1", 1)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := expiring.get("album", "1"); ok {
+		t.Error("get() found an expired entry")
+	}
+
+	if newMemoryCache(0, 10) != nil || newMemoryCache(time.Hour, 0) != nil {
+		t.Error("newMemoryCache() with a zero TTL or size is not disabled")
+	}
+	if _, ok := (*memoryCache)(nil).get("track", "1"); ok {
+		t.Error("get() on a disabled cache found an entry")
+	}
+}
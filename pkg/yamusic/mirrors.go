@@ -0,0 +1,71 @@
+package yamusic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// downloadMirrors returns the media URLs of the download info: the mirror list, so
+// concurrent transfers can be spread across hosts, followed by the single URL when
+// it isn't one of them
+func downloadMirrors(downloadInfo *api.DownloadInfo) ([]string, error) {
+	var mirrors []string
+	for _, u := range downloadInfo.Urls {
+		if u != "" && !slices.Contains(mirrors, u) {
+			mirrors = append(mirrors, u)
+		}
+	}
+	if downloadInfo.Url != "" && !slices.Contains(mirrors, downloadInfo.Url) {
+		mirrors = append(mirrors, downloadInfo.Url)
+	}
+
+	if len(mirrors) == 0 {
+		return nil, fmt.Errorf("download URL not found")
+	}
+	return mirrors, nil
+}
+
+// openMirror requests the media file from the mirrors, trying the next one when a mirror
+// answers with a non-200 status or can't be reached. Every mirror is tried once; the
+// error of the last one is returned along with the hosts attempted. On success the
+// returned function releases the transfer slot of the host.
+func (c *Client) openMirror(ctx context.Context, mirrors []string, log *logger.Logger) (*http.Response, func(), error) {
+	remaining := slices.Clone(mirrors)
+	var hosts []string
+	var lastErr error
+
+	for len(remaining) > 0 {
+		fileURL, release, err := c.hosts.acquire(ctx, remaining, log)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		resp, err := c.getMedia(ctx, fileURL)
+		if err == nil {
+			return resp, release, nil
+		}
+		release()
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+
+		host := urlHost(fileURL)
+		hosts = append(hosts, host)
+		lastErr = err
+		remaining = slices.DeleteFunc(remaining, func(u string) bool { return u == fileURL })
+		if len(remaining) > 0 {
+			log.Warn("Mirror %s failed, trying another one: %v", host, err)
+		}
+	}
+
+	if len(hosts) == 1 {
+		return nil, nil, lastErr
+	}
+	return nil, nil, fmt.Errorf("all mirrors failed (tried %s): %w", strings.Join(hosts, ", "), lastErr)
+}
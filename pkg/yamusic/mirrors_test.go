@@ -0,0 +1,106 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// TestDownloadMirrors checks the order and deduplication of media URLs
+func TestDownloadMirrors(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		info     api.DownloadInfo
+		expected []string
+		wantErr  bool
+	}{
+		{"URL only", api.DownloadInfo{Url: "https://a/1"}, []string{"https://a/1"}, false},
+		{"Mirrors", api.DownloadInfo{Urls: []string{"https://a/1", "", "https://b/1"}, Url: "https://a/1"},
+			[]string{"https://a/1", "https://b/1"}, false},
+		{"Extra URL", api.DownloadInfo{Urls: []string{"https://a/1"}, Url: "https://c/1"},
+			[]string{"https://a/1", "https://c/1"}, false},
+		{"None", api.DownloadInfo{}, nil, true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mirrors, err := downloadMirrors(&tt.info)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadMirrors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(mirrors, tt.expected) {
+				t.Errorf("downloadMirrors() = %q, want %q", mirrors, tt.expected)
+			}
+		})
+	}
+}
+
+// TestOpenMirror checks falling back between flaky mirrors
+func TestOpenMirror(t *testing.T) {
+	serve := func(status int) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte("audio"))
+		}))
+		t.Cleanup(server.Close)
+		return server.URL + "/track"
+	}
+	forbidden := serve(http.StatusForbidden)
+	ok := strings.Replace(serve(http.StatusOK), "127.0.0.1", "localhost", 1)
+	unreachable := "http://127.0.0.2:1/track"
+
+	// Test cases
+	tests := []struct {
+		name      string
+		mirrors   []string
+		wantErr   bool
+		wantHosts string
+	}{
+		{"First works", []string{ok, forbidden}, false, ""},
+		{"Forbidden primary", []string{forbidden, ok}, false, ""},
+		{"Unreachable primary", []string{unreachable, ok}, false, ""},
+		{"All fail", []string{forbidden, unreachable}, true, "tried 127.0.0.1, 127.0.0.2"},
+		{"Single mirror", []string{forbidden}, true, ""},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("test-token", "", logger.New(false))
+			// Make the unreachable mirror fail fast instead of waiting for a connection timeout
+			client.downloadClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				if r.URL.Hostname() == "127.0.0.2" {
+					return nil, errors.New("connection refused")
+				}
+				return http.DefaultTransport.RoundTrip(r)
+			})
+
+			resp, release, err := client.openMirror(context.Background(), tt.mirrors, client.logger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openMirror() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.wantHosts) {
+					t.Errorf("openMirror() error = %v, want %q in it", err, tt.wantHosts)
+				}
+				return
+			}
+			defer release()
+			defer resp.Body.Close()
+
+			if body, _ := io.ReadAll(resp.Body); string(body) != "audio" {
+				t.Errorf("openMirror() body = %q", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package yamusic
+
+import (
+	"os"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// SetReleaseDateTimestamps enables or disables setting the modification time of downloaded
+// files to the release date of their album, so old releases don't show up as recently added.
+// Files of albums without a valid release date keep the download time.
+func (c *Client) SetReleaseDateTimestamps(enabled bool) {
+	c.releaseMtime = enabled
+}
+
+// setReleaseTime sets the modification time of the file of a track to the release date of
+// the album used for naming, or of the first album when all albums are kept
+func (c *Client) setReleaseTime(path string, trackInfo *api.TrackInfo, albumID string, log *logger.Logger) {
+	album := selectAlbum(trackInfo, albumID, c.albumPolicy)
+	if album == nil && len(trackInfo.Albums) > 0 {
+		album = &trackInfo.Albums[0]
+	}
+	if album == nil || album.ReleaseDate == "" {
+		return
+	}
+
+	date, err := time.Parse(time.RFC3339, album.ReleaseDate)
+	if err != nil {
+		log.Debug("Invalid release date %q: %v", album.ReleaseDate, err)
+		return
+	}
+	if err := os.Chtimes(path, date, date); err != nil {
+		log.Warn("Modification time not set: %v", err)
+	}
+}
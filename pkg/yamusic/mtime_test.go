@@ -0,0 +1,75 @@
+package yamusic
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// TestSetReleaseTime checks that valid release dates become the modification time and
+// other dates leave it alone
+func TestSetReleaseTime(t *testing.T) {
+	client := NewClient("test-token", "", logger.New(false))
+	modified := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	// Test cases
+	tests := []struct {
+		name        string
+		releaseDate string
+		want        time.Time
+	}{
+		{"Release date", "2013-05-20T00:00:00+04:00", time.Date(2013, time.May, 19, 20, 0, 0, 0, time.UTC)},
+		{"Missing date", "", modified},
+		{"Invalid date", "2013-05-20", modified},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track.flac")
+			if err := os.WriteFile(path, []byte("fLaC"), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+			if err := os.Chtimes(path, modified, modified); err != nil {
+				t.Fatalf("Failed to set modification time: %v", err)
+			}
+
+			trackInfo := &api.TrackInfo{Albums: []api.Album{{ID: "1", ReleaseDate: tt.releaseDate}}}
+			client.setReleaseTime(path, trackInfo, "", client.logger)
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("Stat() error = %v", err)
+			}
+			if !info.ModTime().Equal(tt.want) {
+				t.Errorf("ModTime() = %v, want %v", info.ModTime().UTC(), tt.want)
+			}
+		})
+	}
+}
+
+// TestDownloadReleaseDateTimestamps checks that the release date of the album the track
+// is named after ends up as the modification time of the file
+func TestDownloadReleaseDateTimestamps(t *testing.T) {
+	audio := append([]byte("fLaC"), bytes.Repeat([]byte{0x5a}, 4092)...)
+	client := newDownloadServer(t, "64551568", audio)
+	client.SetReleaseDateTimestamps(true)
+
+	path, err := client.DownloadTrack("64551568:2843017", api.QualityHigh, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if want := time.Date(2013, time.May, 19, 20, 0, 0, 0, time.UTC); !info.ModTime().Equal(want) {
+		t.Errorf("ModTime() = %v, want %v", info.ModTime().UTC(), want)
+	}
+}
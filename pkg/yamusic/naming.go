@@ -0,0 +1,96 @@
+package yamusic
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+// Fields of file name templates
+const (
+	FieldTitle  = "title"
+	FieldArtist = "artist"
+	FieldAlbum  = "album"
+	FieldID     = "id"
+	FieldYear   = "year"
+	FieldGenre  = "genre"
+	FieldLabel  = "label"
+	FieldTrack  = "track"
+	FieldDisc   = "disc"
+)
+
+// templateFields lists the fields a file name template may use
+var templateFields = []string{FieldTitle, FieldArtist, FieldAlbum, FieldID, FieldYear, FieldGenre, FieldLabel,
+	FieldTrack, FieldDisc}
+
+var (
+	// templateFieldPattern matches a field of a file name template, e.g. "{year}"
+	templateFieldPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+	// emptyBracketsPattern matches brackets left empty by fields without a value
+	emptyBracketsPattern = regexp.MustCompile(`\(\s*\)|\[\s*\]`)
+
+	// spacesPattern matches runs of whitespace
+	spacesPattern = regexp.MustCompile(`\s+`)
+)
+
+// ValidateFileNameTemplate checks that a file name template only uses known fields
+func ValidateFileNameTemplate(template string) error {
+	for _, match := range templateFieldPattern.FindAllStringSubmatch(template, -1) {
+		known := false
+		for _, field := range templateFields {
+			known = known || match[1] == field
+		}
+		if !known {
+			return fmt.Errorf("unknown field %s in file name template (valid fields: {%s})",
+				match[0], strings.Join(templateFields, "}, {"))
+		}
+	}
+	return nil
+}
+
+// SetFileNameTemplate sets the template of track file names, without the extension, e.g.
+// "{artist} - {album} ({year}) - {title}". Fields without a value are left out along with
+// the brackets around them. An empty template restores the default
+// "Title - Artist (Album) [ID]" naming. A template without {id} is accepted with a warning:
+// skipping existing files then relies on the computed name only, so a track renamed since
+// its download is downloaded again.
+func (c *Client) SetFileNameTemplate(template string) error {
+	if err := ValidateFileNameTemplate(template); err != nil {
+		return err
+	}
+	if template != "" && !strings.Contains(template, "{"+FieldID+"}") {
+		c.logger.Warn("File name template %q has no {%s}: existing files are only recognized by their exact name",
+			template, FieldID)
+	}
+	c.nameTemplate = template
+	return nil
+}
+
+// templateFileName forms a file name from the template and the field values
+func templateFileName(template string, fields map[string]string, ext string) string {
+	name := templateFieldPattern.ReplaceAllStringFunc(template, func(field string) string {
+		if value := fields[strings.Trim(field, "{}")]; value != "" {
+			return utils.CleanFileName(value)
+		}
+		return ""
+	})
+	name = emptyBracketsPattern.ReplaceAllString(name, "")
+	name = strings.Trim(spacesPattern.ReplaceAllString(name, " "), " -")
+	return utils.CleanFileName(name) + ext
+}
+
+// albumFields returns the year, genre and label fields of the album; empty without an album
+func albumFields(album *api.Album) (year, genre, label string) {
+	if album == nil {
+		return "", "", ""
+	}
+	if y := albumYear(album); y > 0 {
+		year = strconv.Itoa(y)
+	}
+	return year, album.Genre, albumLabel(album)
+}
@@ -0,0 +1,86 @@
+package yamusic
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// TestTemplateFileName checks the fields of file name templates and the cleanup of empty ones
+func TestTemplateFileName(t *testing.T) {
+	trackInfo := &api.TrackInfo{
+		ID:      "1",
+		Title:   "Лесник",
+		Artists: []api.Artist{{Name: "Король и Шут"}},
+		Albums: []api.Album{{
+			ID:          "10",
+			Title:       "Король и Шут",
+			ReleaseDate: "1997-03-01T00:00:00+03:00",
+			Genre:       "rusrock",
+			Labels:      []api.Label{{Name: "Melodiya"}, {Name: "Moroz"}},
+		}},
+	}
+	bare := &api.TrackInfo{ID: "2", Title: "Demo", Artists: []api.Artist{{Name: "Band"}}}
+
+	// Test cases
+	tests := []struct {
+		name      string
+		template  string
+		trackInfo *api.TrackInfo
+		want      string
+	}{
+		{"Year derived from the release date", "{artist} - {album} ({year})", trackInfo, "Король и Шут - Король и Шут (1997).flac"},
+		{"Genre and label", "{title} [{genre}] {label} {id}", trackInfo, "Лесник [rusrock] Melodiya, Moroz 1.flac"},
+		{"Empty fields dropped", "{artist} - {title} ({year}) [{label}]", bare, "Band - Demo.flac"},
+		{"Trailing separator dropped", "{title} - {genre}", bare, "Demo.flac"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("token", "", nil)
+			if err := client.SetFileNameTemplate(tt.template); err != nil {
+				t.Fatalf("SetFileNameTemplate() error = %v", err)
+			}
+			got := client.trackFileName(tt.trackInfo, tt.trackInfo.ID, "", ".flac", logger.New(false))
+			if got != tt.want {
+				t.Errorf("trackFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if err := ValidateFileNameTemplate("{artist} - {year} {bitrate}"); err == nil {
+		t.Error("ValidateFileNameTemplate() accepted an unknown field")
+	}
+}
+
+// TestFileNameTemplateWithoutID checks that templates without {id} are accepted with a warning
+func TestFileNameTemplateWithoutID(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		template string
+		wantWarn bool
+	}{
+		{"Default naming", "", false},
+		{"Template with the ID", "{artist} - {title} [{id}]", false},
+		{"Template without the ID", "{artist} - {title}", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			client := NewClient("token", "", logger.NewWithOptions(logger.Options{Output: &buf}))
+			if err := client.SetFileNameTemplate(tt.template); err != nil {
+				t.Fatalf("SetFileNameTemplate() error = %v", err)
+			}
+			if warned := strings.Contains(buf.String(), "{id}"); warned != tt.wantWarn {
+				t.Errorf("Warning logged = %v, want %v: %q", warned, tt.wantWarn, buf.String())
+			}
+		})
+	}
+}
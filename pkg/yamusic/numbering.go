@@ -0,0 +1,101 @@
+package yamusic
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// albumLayout is the track order of an album fetched with GetAlbum
+type albumLayout struct {
+	// volumes holds the number of tracks of every volume
+	volumes []int
+
+	// positions maps track IDs to their place in the album response
+	positions map[string]api.TrackPosition
+}
+
+// newAlbumLayout records the volumes and track order of an album
+func newAlbumLayout(album *api.Album) *albumLayout {
+	layout := &albumLayout{
+		volumes:   make([]int, len(album.Volumes)),
+		positions: make(map[string]api.TrackPosition),
+	}
+	for v, volume := range album.Volumes {
+		layout.volumes[v] = len(volume)
+		for i, track := range volume {
+			layout.positions[track.ID] = api.TrackPosition{Volume: v + 1, Index: i + 1}
+		}
+	}
+	return layout
+}
+
+// trackNumbering is the position of a track in an album with the album totals;
+// totals are zero when the album was not fetched with GetAlbum
+type trackNumbering struct {
+	api.TrackPosition
+
+	// Tracks is the number of tracks of the volume, Discs the number of volumes
+	Tracks int
+	Discs  int
+
+	// Width is the number of digits of zero-padded track numbers
+	Width int
+}
+
+// numbering returns the position of a track in the album. The position comes from the
+// track metadata; tracks without one take their place in the album response when the
+// album was fetched with GetAlbum.
+func (c *Client) numbering(trackInfo *api.TrackInfo, album *api.Album) (trackNumbering, bool) {
+	numbering := trackNumbering{TrackPosition: album.TrackPosition}
+
+	value, known := c.albumLayouts.Load(album.ID.String())
+	if known {
+		layout := value.(*albumLayout)
+		if position, ok := layout.positions[trackInfo.ID]; ok && numbering.Index == 0 {
+			numbering.TrackPosition = position
+		}
+		numbering.Discs = len(layout.volumes)
+		if v := numbering.Volume; v > 0 && v <= len(layout.volumes) {
+			numbering.Tracks = layout.volumes[v-1]
+		}
+	}
+
+	numbering.Width = max(2, len(strconv.Itoa(max(numbering.Tracks, album.TrackCount))))
+	return numbering, known
+}
+
+// prefix returns the "01 - " file name prefix of the track; tracks of a multi-disc album
+// saved into a single directory get the disc number as well ("2-01 - ")
+func (n trackNumbering) prefix(discFolders bool) string {
+	if n.Index == 0 {
+		return ""
+	}
+	if n.Discs > 1 && !discFolders {
+		return fmt.Sprintf("%d-%0*d - ", n.Volume, n.Width, n.Index)
+	}
+	return fmt.Sprintf("%0*d - ", n.Width, n.Index)
+}
+
+// trackTag returns the track number tag value, with the total when known ("3/12")
+func (n trackNumbering) trackTag() string {
+	return numberTag(n.Index, n.Tracks)
+}
+
+// discTag returns the disc number tag value, with the total when known ("1/2")
+func (n trackNumbering) discTag() string {
+	return numberTag(n.Volume, n.Discs)
+}
+
+// numberTag formats a number tag value; empty when the number is unknown
+func numberTag(number, total int) string {
+	switch {
+	case number <= 0:
+		return ""
+	case total > 0:
+		return fmt.Sprintf("%d/%d", number, total)
+	default:
+		return strconv.Itoa(number)
+	}
+}
@@ -0,0 +1,75 @@
+package yamusic
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/tags"
+)
+
+// TestTrackNumbering checks track numbers in file names and tags of tracks of a fetched album
+func TestTrackNumbering(t *testing.T) {
+	// A two-disc album with 12 tracks on the first disc and 105 on the second
+	album := &api.Album{ID: "7", Title: "Live", TrackCount: 117, Volumes: make([][]api.TrackInfo, 2)}
+	for v, count := range []int{12, 105} {
+		for i := 0; i < count; i++ {
+			album.Volumes[v] = append(album.Volumes[v], api.TrackInfo{ID: fmt.Sprintf("%d%03d", v+1, i+1)})
+		}
+	}
+
+	// track returns the metadata of a track of the album with the position given by the API
+	track := func(id string, position api.TrackPosition) *api.TrackInfo {
+		return &api.TrackInfo{ID: id, Title: "Song", Artists: []api.Artist{{Name: "Band"}},
+			Albums: []api.Album{{ID: "7", Title: "Live", TrackCount: 117, TrackPosition: position}}}
+	}
+
+	// Test cases
+	tests := []struct {
+		name      string
+		trackInfo *api.TrackInfo
+		layout    DirLayout
+		fileName  string
+		number    string
+		disc      string
+	}{
+		{"Position from the metadata", track("1003", api.TrackPosition{Volume: 1, Index: 3}), LayoutArtistAlbum,
+			"003 - Song - Band (Live) [1003].flac", "3/12", "1/2"},
+		{"Position from the album order", track("2004", api.TrackPosition{}), LayoutArtistAlbum,
+			"004 - Song - Band (Live) [2004].flac", "4/105", "2/2"},
+		{"Disc in the flat layout", track("2004", api.TrackPosition{}), LayoutFlat,
+			"2-004 - Song - Band (Live) [2004].flac", "4/105", "2/2"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("token", "", nil)
+			client.SetDirLayout(tt.layout)
+			client.albumLayouts.Store("7", newAlbumLayout(album))
+
+			if got := client.trackFileName(tt.trackInfo, tt.trackInfo.ID, "7", ".flac", logger.New(false)); got != tt.fileName {
+				t.Errorf("trackFileName() = %q, want %q", got, tt.fileName)
+			}
+
+			trackTags := client.trackTags(tt.trackInfo, "7")
+			if got, _ := trackTags.Get(tags.KeyTrackNumber); got != tt.number {
+				t.Errorf("Track number = %q, want %q", got, tt.number)
+			}
+			if got, _ := trackTags.Get(tags.KeyDiscNumber); got != tt.disc {
+				t.Errorf("Disc number = %q, want %q", got, tt.disc)
+			}
+		})
+	}
+
+	// Without the album fetched, names are not numbered and tags have no totals
+	client := NewClient("token", "", nil)
+	trackInfo := track("1003", api.TrackPosition{Volume: 1, Index: 3})
+	if got := client.trackFileName(trackInfo, "1003", "7", ".flac", logger.New(false)); got != "Song - Band (Live) [1003].flac" {
+		t.Errorf("trackFileName() of a single track = %q", got)
+	}
+	if got, _ := client.trackTags(trackInfo, "7").Get(tags.KeyTrackNumber); got != "3" {
+		t.Errorf("Track number of a single track = %q, want %q", got, "3")
+	}
+}
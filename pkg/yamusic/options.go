@@ -0,0 +1,260 @@
+package yamusic
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// Option configures a client created with NewClientWithOptions
+type Option func(*Client) error
+
+// NewClientWithOptions creates a new client for working with the Yandex Music API,
+// applying the options in order on top of the defaults of NewClient. The first invalid
+// option fails the construction.
+func NewClientWithOptions(accessToken string, opts ...Option) (*Client, error) {
+	client := NewClient(accessToken, "", nil)
+	for _, opt := range opts {
+		if err := opt(client); err != nil {
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// WithHTTPClient makes the client send every request, API calls and media downloads alike,
+// through httpClient. Its transport, cookie jar and redirect policy are used as is. Its
+// timeout applies to API calls only: media downloads use a copy without the timeout, as
+// large files may take longer to transfer. SetMaxConnsPerHost then only limits transfers,
+// leaving the connection settings of the transport alone. httpClient itself is copied, so
+// later settings such as SetTimeouts and WithTransport leave it unchanged.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) error {
+		if httpClient == nil {
+			return fmt.Errorf("HTTP client is nil")
+		}
+		apiClient := *httpClient
+		downloadClient := *httpClient
+		downloadClient.Timeout = 0
+
+		c.httpClient = &apiClient
+		c.downloadClient = &downloadClient
+		c.transport = nil
+		return nil
+	}
+}
+
+// WithTransport makes the client send every request through transport, e.g. one with
+// a proxy, custom TLS configuration or instrumentation. The client timeouts are kept.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) error {
+		c.httpClient.Transport = transport
+		c.downloadClient.Transport = transport
+		c.transport = nil
+		return nil
+	}
+}
+
+// WithLogger sets the logger of the client
+func WithLogger(log *logger.Logger) Option {
+	return func(c *Client) error {
+		if log != nil {
+			c.logger = log
+		}
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header of API and media requests, overriding the
+// client preset (see SetHeader)
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithClientHeader sets the x-yandex-music-client header of API and media requests,
+// e.g. "YandexMusicAndroid/24023621", overriding the client preset
+func WithClientHeader(client string) Option {
+	return WithHeader("x-yandex-music-client", client)
+}
+
+// WithHeader overrides a request header on top of the client preset (see SetHeader)
+func WithHeader(key, value string) Option {
+	return func(c *Client) error {
+		if key == "" {
+			return fmt.Errorf("header name is empty")
+		}
+		c.SetHeader(key, value)
+		return nil
+	}
+}
+
+// WithTimeouts sets the API call timeout and the media transfer stall timeout (see SetTimeouts)
+func WithTimeouts(apiTimeout, stallTimeout time.Duration) Option {
+	return func(c *Client) error {
+		c.SetTimeouts(apiTimeout, stallTimeout)
+		return nil
+	}
+}
+
+// WithSignKey sets the key used to sign download info requests
+func WithSignKey(signKey string) Option {
+	return func(c *Client) error {
+		if signKey != "" {
+			c.signKeys = []string{signKey}
+		}
+		return nil
+	}
+}
+
+// WithSignKeys sets candidate sign keys tried in order when a signature is rejected
+// (see SetSignKeys)
+func WithSignKeys(keys ...string) Option {
+	return func(c *Client) error {
+		return c.SetSignKeys(keys...)
+	}
+}
+
+// WithBaseURL points the client at a different API server (see SetBaseURL)
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		c.SetBaseURL(baseURL)
+		return nil
+	}
+}
+
+// WithMediaBaseURL sends media requests to a different server (see SetMediaBaseURL)
+func WithMediaBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		return c.SetMediaBaseURL(baseURL)
+	}
+}
+
+// WithProxy routes API and media requests through a proxy (see SetProxy)
+func WithProxy(proxyURL string) Option {
+	return func(c *Client) error {
+		return c.SetProxy(proxyURL)
+	}
+}
+
+// WithRateLimit limits the rate of API requests (see SetRateLimit)
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *Client) error {
+		c.SetRateLimit(requestsPerSecond, burst)
+		return nil
+	}
+}
+
+// WithMaxMediaDownloads limits the number of simultaneous media transfers (see SetMaxMediaDownloads)
+func WithMaxMediaDownloads(limit int) Option {
+	return func(c *Client) error {
+		c.SetMaxMediaDownloads(limit)
+		return nil
+	}
+}
+
+// WithChunkedDownload downloads media files over n connections at once (see SetChunkedDownload)
+func WithChunkedDownload(n int) Option {
+	return func(c *Client) error {
+		c.SetChunkedDownload(n)
+		return nil
+	}
+}
+
+// WithSpeedLimit limits the media download speed of all downloads together (see SetSpeedLimit)
+func WithSpeedLimit(bytesPerSecond int64) Option {
+	return func(c *Client) error {
+		c.SetSpeedLimit(bytesPerSecond)
+		return nil
+	}
+}
+
+// WithReleaseDateTimestamps sets the modification time of downloaded files to the album
+// release date (see SetReleaseDateTimestamps)
+func WithReleaseDateTimestamps(enabled bool) Option {
+	return func(c *Client) error {
+		c.SetReleaseDateTimestamps(enabled)
+		return nil
+	}
+}
+
+// WithCache enables or disables the in-process metadata cache (see SetMemoryCache).
+// Long-running processes that need fresh availability information disable it.
+func WithCache(enabled bool) Option {
+	return func(c *Client) error {
+		if enabled {
+			c.SetMemoryCache(DefaultMemoryCacheTTL, DefaultMemoryCacheSize)
+		} else {
+			c.SetMemoryCache(0, 0)
+		}
+		return nil
+	}
+}
+
+// WithSkipExplicit skips tracks marked explicit (see SetSkipExplicit)
+func WithSkipExplicit(skip bool) Option {
+	return func(c *Client) error {
+		c.SetSkipExplicit(skip)
+		return nil
+	}
+}
+
+// WithSidecarFormat writes a metadata file next to every downloaded track and album in
+// format: "nfo", "json" or "none" (see SetSidecarFormat)
+func WithSidecarFormat(format string) Option {
+	return func(c *Client) error {
+		sidecar, err := ParseSidecarFormat(format)
+		if err != nil {
+			return err
+		}
+		c.SetSidecarFormat(sidecar)
+		return nil
+	}
+}
+
+// WithPreDownloadHook sets a hook called before every track file is downloaded; an error
+// skips the track (see SetPreDownloadHook)
+func WithPreDownloadHook(hook func(TrackInfo) error) Option {
+	return func(c *Client) error {
+		c.SetPreDownloadHook(hook)
+		return nil
+	}
+}
+
+// WithPostDownloadHook sets a hook called after every track file is saved (see SetPostDownloadHook)
+func WithPostDownloadHook(hook func(DownloadResult) error) Option {
+	return func(c *Client) error {
+		c.SetPostDownloadHook(hook)
+		return nil
+	}
+}
+
+// WithArchive skips tracks recorded in the download archive and records new downloads (see SetArchive)
+func WithArchive(archive DownloadArchive) Option {
+	return func(c *Client) error {
+		c.archive = archive
+		return nil
+	}
+}
+
+// WithCodecs restricts the codecs the server may choose from, in order of preference;
+// unsupported codecs are an error (see SetCodecs)
+func WithCodecs(codecs ...string) Option {
+	return func(c *Client) error {
+		return c.SetCodecs(codecs...)
+	}
+}
+
+// WithOverwritePolicy sets what happens when the file of a track already exists
+// (see SetOverwritePolicy)
+func WithOverwritePolicy(policy OverwritePolicy) Option {
+	return func(c *Client) error {
+		policy, err := ParseOverwritePolicy(string(policy))
+		if err != nil {
+			return err
+		}
+		c.SetOverwritePolicy(policy)
+		return nil
+	}
+}
@@ -0,0 +1,223 @@
+package yamusic
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// TestWithHTTPClient checks that API calls and media downloads both go through the
+// injected client and that only API calls keep its timeout
+func TestWithHTTPClient(t *testing.T) {
+	const trackID = "12345"
+	audio := append([]byte("fLaC"), bytes.Repeat([]byte{0x42}, 1024)...)
+	server := newDownloadServer(t, trackID, audio)
+
+	var mu sync.Mutex
+	var paths []string
+	httpClient := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			mu.Lock()
+			paths = append(paths, r.URL.Path)
+			mu.Unlock()
+			return http.DefaultTransport.RoundTrip(r)
+		}),
+	}
+
+	client, err := NewClientWithOptions("test-token",
+		WithHTTPClient(httpClient),
+		WithBaseURL(server.baseURL),
+		WithLogger(logger.New(false)),
+	)
+	if err != nil {
+		t.Fatalf("NewClientWithOptions() error = %v", err)
+	}
+	if client.httpClient == httpClient || client.httpClient.Timeout != httpClient.Timeout ||
+		client.downloadClient.Timeout != 0 {
+		t.Errorf("Clients = %+v, %+v, want copies of the injected client, only the first with its timeout",
+			client.httpClient, client.downloadClient)
+	}
+
+	// Client settings must not change the injected client
+	client.SetTimeouts(time.Second, 0)
+	if httpClient.Timeout != 10*time.Second {
+		t.Errorf("Injected client timeout = %s after SetTimeouts, want it unchanged", httpClient.Timeout)
+	}
+
+	// The injected transport must survive connection limits
+	client.SetMaxConnsPerHost(2)
+
+	outputDir := t.TempDir()
+	path, err := client.DownloadTrack(trackID, QualityHigh, outputDir)
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, audio) {
+		t.Errorf("Downloaded file %s doesn't match the source audio (error %v)", filepath.Base(path), err)
+	}
+
+	got := strings.Join(paths, " ")
+	for _, want := range []string{"/tracks/" + trackID, "/get-file-info", "/media/" + trackID} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Requests through the injected client = %q, want %s among them", got, want)
+		}
+	}
+}
+
+// TestWithHeaders checks that identity headers set with options reach API and media
+// requests and that the token is only sent to the API
+func TestWithHeaders(t *testing.T) {
+	const trackID = "12345"
+	server := newDownloadServer(t, trackID, append([]byte("fLaC"), bytes.Repeat([]byte{0x42}, 1024)...))
+
+	var mu sync.Mutex
+	requests := make(map[string]http.Header)
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			mu.Lock()
+			requests[r.URL.Path] = r.Header.Clone()
+			mu.Unlock()
+			return http.DefaultTransport.RoundTrip(r)
+		}),
+	}
+
+	client, err := NewClientWithOptions("test-token",
+		WithHTTPClient(httpClient),
+		WithBaseURL(server.baseURL),
+		WithUserAgent("Test/1.0"),
+		WithClientHeader("TestClient/1"),
+		WithHeader("X-Test", "yes"),
+		WithLogger(logger.New(false)),
+	)
+	if err != nil {
+		t.Fatalf("NewClientWithOptions() error = %v", err)
+	}
+	if _, err := client.DownloadTrack(trackID, QualityHigh, t.TempDir()); err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		path          string
+		authorization string
+	}{
+		{"/get-file-info", "OAuth test-token"},
+		{"/media/" + trackID, ""},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			header, ok := requests[tt.path]
+			if !ok {
+				t.Fatalf("No request to %s", tt.path)
+			}
+			if got := header.Get("User-Agent"); got != "Test/1.0" {
+				t.Errorf("User-Agent = %q, want %q", got, "Test/1.0")
+			}
+			if got := header.Get("x-yandex-music-client"); got != "TestClient/1" {
+				t.Errorf("x-yandex-music-client = %q, want %q", got, "TestClient/1")
+			}
+			if got := header.Get("X-Test"); got != "yes" {
+				t.Errorf("X-Test = %q, want %q", got, "yes")
+			}
+			if got := header.Get("Authorization"); got != tt.authorization {
+				t.Errorf("Authorization = %q, want %q", got, tt.authorization)
+			}
+		})
+	}
+}
+
+// TestWithBaseURLs runs metadata, download info and the download end to end against
+// a fake API and CDN serving captured responses
+func TestWithBaseURLs(t *testing.T) {
+	const trackID = "64551568"
+	audio := append([]byte("fLaC"), bytes.Repeat([]byte{0x17}, 4092)...)
+	encrypted, err := crypto.DecryptAesCtr(audio, testDecryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to encrypt test audio: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tracks/"+trackID, serveFixture(t, "track.json"))
+	mux.HandleFunc("/api/get-file-info", serveFixture(t, "download_info.json"))
+	mux.HandleFunc("/cdn/music-v2/crypt/"+trackID+"/flac/0c1f7a3e.raw", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ts") != "685183a4" {
+			t.Errorf("Media query = %q, want the original query", r.URL.RawQuery)
+		}
+		w.Write(encrypted)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := NewClientWithOptions("test-token",
+		WithBaseURL(server.URL+"/api/"),
+		WithMediaBaseURL(server.URL+"/cdn"),
+		WithLogger(logger.New(false)),
+	)
+	if err != nil {
+		t.Fatalf("NewClientWithOptions() error = %v", err)
+	}
+
+	trackInfo, err := client.GetTrackInfo(trackID)
+	if err != nil {
+		t.Fatalf("GetTrackInfo() error = %v", err)
+	}
+	if trackInfo.Title != "Кукла колдуна" {
+		t.Errorf("GetTrackInfo() title = %q", trackInfo.Title)
+	}
+
+	downloadInfo, err := client.GetDownloadInfo(trackID, api.QualityLossless)
+	if err != nil {
+		t.Fatalf("GetDownloadInfo() error = %v", err)
+	}
+	if downloadInfo.Codec != "flac" || downloadInfo.Size != len(audio) || len(downloadInfo.Urls) != 2 {
+		t.Errorf("GetDownloadInfo() = %+v", downloadInfo)
+	}
+
+	path, err := client.DownloadTrack(trackID, QualityHigh, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, audio) {
+		t.Errorf("Downloaded file %s doesn't match the source audio (error %v)", filepath.Base(path), err)
+	}
+}
+
+// TestMediaURL checks rewriting of media URLs to the media base URL
+func TestMediaURL(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		base     string
+		fileURL  string
+		expected string
+		wantErr  bool
+	}{
+		{"", "https://strm.yandex.net/a/b?s=1", "https://strm.yandex.net/a/b?s=1", false},
+		{"http://127.0.0.1:8080", "https://strm.yandex.net/a/b?s=1", "http://127.0.0.1:8080/a/b?s=1", false},
+		{"http://proxy/cdn/", "https://avatars.yandex.net/get-music-content/1/1000x1000", "http://proxy/cdn/get-music-content/1/1000x1000", false},
+		{"proxy", "https://strm.yandex.net/a", "https://strm.yandex.net/a", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		client := NewClient("test-token", "", logger.New(false))
+		if err := client.SetMediaBaseURL(tt.base); (err != nil) != tt.wantErr {
+			t.Errorf("SetMediaBaseURL(%q) error = %v, wantErr %v", tt.base, err, tt.wantErr)
+		}
+		if got := client.mediaURL(tt.fileURL); got != tt.expected {
+			t.Errorf("mediaURL(%q) with base %q = %q, want %q", tt.fileURL, tt.base, got, tt.expected)
+		}
+	}
+}
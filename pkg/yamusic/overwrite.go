@@ -0,0 +1,150 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// OverwritePolicy decides what happens when the file of a track already exists
+type OverwritePolicy string
+
+const (
+	// OverwriteAlways - download the track again and replace the file
+	OverwriteAlways OverwritePolicy = ""
+
+	// OverwriteSkip - skip tracks already present in the output directory. A track is
+	// present when a non-empty audio file with its ID in brackets exists, so files
+	// keep matching after title changes, or one with the name it would be saved under.
+	OverwriteSkip OverwritePolicy = "skip"
+
+	// OverwriteRename - keep the existing file and save the new one as "Name (1).ext"
+	OverwriteRename OverwritePolicy = "rename"
+)
+
+// errExists reports a track skipped because it is already downloaded
+var errExists = errors.New("already exists")
+
+// audioExtensions are the extensions of downloaded tracks
+var audioExtensions = []string{".flac", ".m4a", ".mp3"}
+
+// ParseOverwritePolicy parses an overwrite policy name
+func ParseOverwritePolicy(value string) (OverwritePolicy, error) {
+	switch policy := OverwritePolicy(value); policy {
+	case "overwrite":
+		return OverwriteAlways, nil
+	case OverwriteAlways, OverwriteSkip, OverwriteRename:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid overwrite policy %q (valid values: overwrite, skip, rename)", value)
+	}
+}
+
+// SetOverwritePolicy sets what happens when the file of a track already exists.
+// By default existing files are overwritten.
+func (c *Client) SetOverwritePolicy(policy OverwritePolicy) {
+	c.overwrite = policy
+}
+
+// DownloadArchive records downloaded tracks so later runs skip them regardless of the
+// file names (see internal/archive for the file-based archive)
+type DownloadArchive interface {
+	// Contains reports whether the track was downloaded before
+	Contains(trackID string) bool
+
+	// Add records a downloaded track
+	Add(trackID string) error
+}
+
+// SetArchive enables a download archive: tracks in the archive are skipped and every
+// successfully downloaded track is added to it
+func (c *Client) SetArchive(archive DownloadArchive) {
+	c.archive = archive
+}
+
+// skipArchived reports whether the track of result is to be skipped because it is in the archive
+func (c *Client) skipArchived(result *DownloadResult, log *logger.Logger) bool {
+	if c.archive == nil || !c.archive.Contains(result.TrackID) {
+		return false
+	}
+
+	log.Info("Already in the download archive")
+	result.skip(SkipArchived)
+	return true
+}
+
+// skipExisting reports whether the track of result is to be skipped because it is already
+// in dir, filling in the path of the existing file
+func (c *Client) skipExisting(dir string, result *DownloadResult, log *logger.Logger) bool {
+	if c.overwrite != OverwriteSkip {
+		return false
+	}
+
+	existing := findExisting(dir, result.TrackID, c.preview)
+	if existing == "" {
+		return false
+	}
+
+	log.Info("Already exists: %s", existing)
+	result.Path = existing
+	result.skip(SkipExisting)
+	return true
+}
+
+// skipExistingName reports whether the track of result is to be skipped because a file
+// with the name it would be saved under is already in dir, in any of the audio formats,
+// filling in the path of the existing file. Unlike skipExisting it needs the metadata, but
+// also finds names without the ID (see SetFileNameTemplate).
+func (c *Client) skipExistingName(dir string, trackInfo *api.TrackInfo, albumID string, result *DownloadResult,
+	log *logger.Logger) bool {
+	if c.overwrite != OverwriteSkip {
+		return false
+	}
+
+	for _, ext := range audioExtensions {
+		name := c.trackFileName(trackInfo, result.TrackID, albumID, ext, log)
+		if c.preview {
+			name = previewName(name)
+		}
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Size() > 0 {
+			log.Info("Already exists: %s", path)
+			result.Path = path
+			result.skip(SkipExisting)
+			return true
+		}
+	}
+	return false
+}
+
+// findExisting returns a non-empty audio file of the track in dir, or an empty string.
+// Previews only match previews and full tracks only full tracks.
+func findExisting(dir, trackID string, preview bool) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	marker := "[" + trackID + "]"
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.Contains(name, marker) || !isAudioFile(name) || isPreviewFile(name) != preview {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.Size() > 0 {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
+
+// isAudioFile reports whether the file name has the extension of a downloaded track
+func isAudioFile(name string) bool {
+	return slices.Contains(audioExtensions, strings.ToLower(filepath.Ext(name)))
+}
@@ -0,0 +1,139 @@
+package yamusic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestOverwritePolicy checks downloads into a directory already holding the track
+func TestOverwritePolicy(t *testing.T) {
+	audio := []byte("fLaC new audio")
+
+	// Test cases
+	tests := []struct {
+		name     string
+		policy   OverwritePolicy
+		template string
+		existing string // file left by an earlier run; empty for the regular name
+		content  string
+		skipped  bool
+		files    int
+	}{
+		{"Overwrite", OverwriteAlways, "", "", "old", false, 1},
+		{"Skip by file name", OverwriteSkip, "", "", "old", true, 1},
+		{"Skip by a file name without the ID", OverwriteSkip, "{artist} - {title}", "", "old", true, 1},
+		{"Skip after a title change", OverwriteSkip, "", "Old Title - Artist (Album) [64551568].flac", "old", true, 1},
+		{"Empty file is not skipped", OverwriteSkip, "", "Old [64551568].flac", "", false, 2},
+		{"Lyrics are not a track", OverwriteSkip, "", "Old [64551568].lrc", "old", false, 2},
+		{"Rename", OverwriteRename, "", "", "old", false, 2},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newDownloadServer(t, "64551568", audio)
+			outputDir := t.TempDir()
+			if err := client.SetFileNameTemplate(tt.template); err != nil {
+				t.Fatalf("SetFileNameTemplate() error = %v", err)
+			}
+
+			// The first download finds out the regular name
+			regular, err := client.DownloadTrack("64551568", QualityHigh, outputDir)
+			if err != nil {
+				t.Fatalf("DownloadTrack() error = %v", err)
+			}
+			os.Remove(regular)
+			existing := regular
+			if tt.existing != "" {
+				existing = filepath.Join(outputDir, tt.existing)
+			}
+			os.WriteFile(existing, []byte(tt.content), 0644)
+
+			client.SetOverwritePolicy(tt.policy)
+			result := client.DownloadTracks([]string{"64551568"}, QualityHigh, outputDir, 1)[0]
+			if result.Err != nil {
+				t.Fatalf("DownloadTracks() error = %v", result.Err)
+			}
+			if result.Skipped != tt.skipped {
+				t.Errorf("Skipped = %v, want %v", result.Skipped, tt.skipped)
+			}
+
+			want := string(audio)
+			if tt.skipped {
+				want = tt.content
+			}
+			if data, _ := os.ReadFile(result.Path); string(data) != want {
+				t.Errorf("File content = %q, want %q", data, want)
+			}
+			if tt.policy == OverwriteRename && result.Path == regular {
+				t.Errorf("Path = %q, want a renamed file", result.Path)
+			}
+
+			if entries, _ := os.ReadDir(outputDir); len(entries) != tt.files {
+				t.Errorf("Output directory has %d files, want %d", len(entries), tt.files)
+			}
+		})
+	}
+}
+
+// memoryArchive is a download archive kept in memory
+type memoryArchive map[string]bool
+
+func (a memoryArchive) Contains(trackID string) bool { return a[trackID] }
+
+func (a memoryArchive) Add(trackID string) error {
+	a[trackID] = true
+	return nil
+}
+
+// TestDownloadArchive checks that archived tracks are skipped and downloaded ones recorded
+func TestDownloadArchive(t *testing.T) {
+	client := newDownloadServer(t, "64551568", []byte("fLaC archive test audio"))
+	archive := memoryArchive{}
+	client.SetArchive(archive)
+	outputDir := t.TempDir()
+
+	result := client.DownloadTracks([]string{"64551568"}, QualityHigh, outputDir, 1)[0]
+	if result.Err != nil || result.Skipped {
+		t.Fatalf("First download = {Err: %v, Skipped: %v}, want a download", result.Err, result.Skipped)
+	}
+	if !archive["64551568"] {
+		t.Error("Downloaded track is not in the archive")
+	}
+
+	// The file name no longer matters once the track is archived
+	os.Rename(result.Path, filepath.Join(outputDir, "renamed.flac"))
+	result = client.DownloadTracks([]string{"64551568"}, QualityHigh, outputDir, 1)[0]
+	if result.Err != nil || !result.Skipped {
+		t.Errorf("Second download = {Err: %v, Skipped: %v}, want skipped", result.Err, result.Skipped)
+	}
+}
+
+// TestWithOverwritePolicy checks that the option sets valid policies and rejects others
+func TestWithOverwritePolicy(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		policy  OverwritePolicy
+		want    OverwritePolicy
+		wantErr bool
+	}{
+		{OverwriteSkip, OverwriteSkip, false},
+		{OverwriteRename, OverwriteRename, false},
+		{"overwrite", OverwriteAlways, false},
+		{"replace", "", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			client, err := NewClientWithOptions("test-token", WithOverwritePolicy(tt.policy))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClientWithOptions() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && client.overwrite != tt.want {
+				t.Errorf("Overwrite policy = %q, want %q", client.overwrite, tt.want)
+			}
+		})
+	}
+}
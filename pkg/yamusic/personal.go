@@ -0,0 +1,62 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// PersonalPlaylist is a playlist generated for the user: Playlist of the Day, Premiere,
+// Déjà Vu and others. Data.UID and Data.Kind identify the playlist.
+type PersonalPlaylist = api.GeneratedPlaylist
+
+// Landing block and entity types of the personal playlists
+const (
+	personalPlaylistsBlock = "personal-playlists"
+	personalPlaylistEntity = "personal-playlist"
+)
+
+// GetPersonalPlaylists retrieves the personal playlists generated for the account the
+// token belongs to. Playlists not ready yet (Ready unset) are included.
+func (c *Client) GetPersonalPlaylists() ([]PersonalPlaylist, error) {
+	return c.GetPersonalPlaylistsContext(context.Background())
+}
+
+// GetPersonalPlaylistsContext retrieves the personal playlists; the request is cancelled with ctx
+func (c *Client) GetPersonalPlaylistsContext(ctx context.Context) ([]PersonalPlaylist, error) {
+	c.logger.Debug("Getting personal playlists")
+
+	responseData, err := c.apiGet(ctx, "/landing3?blocks=personalplaylists", "/landing3", c.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.LandingResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	var playlists []PersonalPlaylist
+	for _, block := range response.Result.Blocks {
+		if block.Type != personalPlaylistsBlock {
+			continue
+		}
+		for _, entity := range block.Entities {
+			if entity.Type != personalPlaylistEntity {
+				continue
+			}
+			var playlist PersonalPlaylist
+			if err := json.Unmarshal(entity.Data, &playlist); err != nil {
+				return nil, fmt.Errorf("response parsing error: %w", err)
+			}
+			if playlist.Data.Kind != "" {
+				playlists = append(playlists, playlist)
+			}
+		}
+	}
+
+	c.logger.Debug("Personal playlists: %d", len(playlists))
+	return playlists, nil
+}
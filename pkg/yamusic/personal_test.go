@@ -0,0 +1,50 @@
+package yamusic
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestGetPersonalPlaylists checks that personal playlists are picked from the landing blocks
+func TestGetPersonalPlaylists(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/landing3": func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("blocks"); got != "personalplaylists" {
+				t.Errorf("blocks = %q, want personalplaylists", got)
+			}
+			serveFixture(t, "landing.json")(w, r)
+		},
+	})
+
+	playlists, err := client.GetPersonalPlaylists()
+	if err != nil {
+		t.Fatalf("GetPersonalPlaylists() error = %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		kind  string
+		uid   string
+		title string
+		ready bool
+	}{
+		{"playlistOfTheDay", "503646255", "Плейлист дня", true},
+		{"neverHeard", "460141773", "Премьера", true},
+		{"recentTracks", "460141773", "Дежавю", false},
+	}
+
+	if len(playlists) != len(tests) {
+		t.Fatalf("GetPersonalPlaylists() returned %d playlists, want %d", len(playlists), len(tests))
+	}
+
+	// Run tests
+	for i, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			got := playlists[i]
+			if got.Type != tt.kind || got.Data.UID.String() != tt.uid || got.Data.Title != tt.title || got.Ready != tt.ready {
+				t.Errorf("Playlist = {%s %s %s ready=%v}, want {%s %s %s ready=%v}",
+					got.Type, got.Data.UID, got.Data.Title, got.Ready, tt.kind, tt.uid, tt.title, tt.ready)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package yamusic
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// playAudioFrom is the "from" field of play reports, naming the source of the play
+const playAudioFrom = "yamusic-dl"
+
+// SetScrobble enables or disables reporting every successfully downloaded track as played,
+// so the listening history follows the downloads. Reporting is best effort: failures are
+// only logged. It is disabled by default.
+func (c *Client) SetScrobble(enabled bool) {
+	c.scrobble = enabled
+}
+
+// SendPlayAudio reports a track as played for durationPlayed seconds from the start,
+// adding it to the listening history of the account. albumID may be empty.
+func (c *Client) SendPlayAudio(trackID, albumID string, durationPlayed float64) error {
+	return c.sendPlayAudio(context.Background(), trackID, albumID, durationPlayed, c.logger)
+}
+
+// sendPlayAudio sends a /play-audio report logging to log
+func (c *Client) sendPlayAudio(ctx context.Context, trackID, albumID string, durationPlayed float64,
+	log *logger.Logger) error {
+	trackID, albumID = splitTrackRef(trackID, albumID)
+	uid, err := c.resolveUID(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	playID, err := newPlayID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	seconds := strconv.FormatFloat(durationPlayed, 'f', 3, 64)
+
+	form := url.Values{}
+	form.Set("track-id", trackID)
+	form.Set("album-id", albumID)
+	form.Set("from-cache", "false")
+	form.Set("from", playAudioFrom)
+	form.Set("play-id", playID)
+	form.Set("uid", uid)
+	form.Set("timestamp", now)
+	form.Set("client-now", now)
+	form.Set("track-length-seconds", seconds)
+	form.Set("total-played-seconds", seconds)
+	form.Set("end-position-seconds", seconds)
+
+	log.Debug("Reporting play %s of %s seconds", playID, seconds)
+	_, err = c.apiPostForm(ctx, "/play-audio", "/play-audio", form, log)
+	return err
+}
+
+// scrobbleTrack reports a downloaded track as played in full; failures are only logged
+func (c *Client) scrobbleTrack(ctx context.Context, trackID string, trackInfo *api.TrackInfo, albumID string,
+	log *logger.Logger) {
+	if album := selectAlbum(trackInfo, albumID, AlbumFirst); album != nil {
+		albumID = album.ID.String()
+	}
+
+	duration := float64(trackInfo.DurationMs) / 1000
+	if err := c.sendPlayAudio(ctx, trackID, albumID, duration, log); err != nil {
+		log.Warn("Play not reported: %v", err)
+	}
+}
+
+// newPlayID returns a random UUID (version 4) identifying a play
+func newPlayID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("error generating play ID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
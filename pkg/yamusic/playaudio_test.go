@@ -0,0 +1,68 @@
+package yamusic
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+// TestScrobble checks the play report sent after a download and that failures don't fail it
+func TestScrobble(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	// Test cases
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"Reported", http.StatusOK},
+		{"Report failure", http.StatusInternalServerError},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var form map[string]string
+			client := newDownloadServerWith(t, "64551568", []byte("fLaC scrobble test audio"), map[string]http.HandlerFunc{
+				"/account/status": func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"result":{"account":{"uid":42,"login":"test"}}}`))
+				},
+				"/play-audio": func(w http.ResponseWriter, r *http.Request) {
+					r.ParseForm()
+					form = map[string]string{}
+					for key := range r.PostForm {
+						form[key] = r.PostForm.Get(key)
+					}
+					if tt.status != http.StatusOK {
+						http.Error(w, "{}", tt.status)
+						return
+					}
+					w.Write([]byte(`{"result":"ok"}`))
+				},
+			})
+			client.SetScrobble(true)
+			client.SetRetry(1, 0)
+
+			if _, err := client.DownloadTrack("64551568:2843017", QualityHigh, t.TempDir()); err != nil {
+				t.Fatalf("DownloadTrack() error = %v", err)
+			}
+
+			if form == nil {
+				t.Fatal("No play report sent")
+			}
+			expected := map[string]string{"track-id": "64551568", "album-id": "2843017", "uid": "42",
+				"track-length-seconds": "203.460", "total-played-seconds": "203.460"}
+			for key, value := range expected {
+				if form[key] != value {
+					t.Errorf("%s = %q, want %q", key, form[key], value)
+				}
+			}
+			if !uuidPattern.MatchString(form["play-id"]) {
+				t.Errorf("play-id = %q, want a UUID", form["play-id"])
+			}
+			if form["timestamp"] == "" || form["client-now"] == "" {
+				t.Error("Timestamps are missing")
+			}
+		})
+	}
+}
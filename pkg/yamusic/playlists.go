@@ -0,0 +1,124 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// PlaylistTrack is an entry of a playlist
+type PlaylistTrack = api.PlaylistTrack
+
+// GetUserPlaylists retrieves the playlists owned by the user, without tracks.
+// An empty userID means the account the token belongs to.
+func (c *Client) GetUserPlaylists(userID string) ([]Playlist, error) {
+	return c.GetUserPlaylistsContext(context.Background(), userID)
+}
+
+// GetUserPlaylistsContext retrieves the playlists of the user; the request is cancelled with ctx
+func (c *Client) GetUserPlaylistsContext(ctx context.Context, userID string) ([]Playlist, error) {
+	userID, err := c.resolveUID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	log := c.logger.WithField("user_id", userID)
+	log.Debug("Getting playlists")
+
+	path := fmt.Sprintf("/users/%s/playlists/list", url.PathEscape(userID))
+	responseData, err := c.apiGet(ctx, path, "/users/playlists/list", log)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.PlaylistsResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	log.Debug("Playlists: %d", len(response.Result))
+	return response.Result, nil
+}
+
+// GetPlaylist retrieves a playlist with its tracks in playlist order. userID is the UID or
+// login of the owner; an empty userID means the account the token belongs to.
+// A fresh copy in the metadata cache (see SetCache) is served without a request.
+func (c *Client) GetPlaylist(userID, kind string) (*Playlist, error) {
+	return c.GetPlaylistContext(context.Background(), userID, kind)
+}
+
+// GetPlaylistContext retrieves a playlist; the request is cancelled with ctx
+func (c *Client) GetPlaylistContext(ctx context.Context, userID, kind string) (*Playlist, error) {
+	if playlist, ok := c.cachedPlaylist(userID, kind); ok {
+		c.logger.Debug("Playlist %s:%s served from cache", userID, kind)
+		return playlist, nil
+	}
+	return c.fetchPlaylist(ctx, userID, kind)
+}
+
+// fetchPlaylist requests a playlist from the API, bypassing the metadata cache, and
+// stores it there
+func (c *Client) fetchPlaylist(ctx context.Context, owner, kind string) (*Playlist, error) {
+	userID, err := c.resolveUID(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	log := c.logger.With(map[string]interface{}{"user_id": userID, "playlist_kind": kind})
+	log.Debug("Getting playlist")
+
+	path := fmt.Sprintf("/users/%s/playlists/%s", url.PathEscape(userID), url.PathEscape(kind))
+	responseData, err := c.apiGet(ctx, path, "/users/playlists", log)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.PlaylistResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	playlist := &response.Result
+	if playlist.Kind == "" {
+		return nil, fmt.Errorf("no playlist information found in API response")
+	}
+
+	c.storePlaylist(owner, kind, playlist)
+	log.Debug("Playlist %q: %d tracks, revision %d", playlist.Title, len(playlist.Tracks), playlist.Revision)
+	return playlist, nil
+}
+
+// PlaylistTrackRefs returns the tracks of a playlist in playlist order, each named after
+// the album it was added from
+func PlaylistTrackRefs(playlist *Playlist) []TrackRef {
+	refs := make([]TrackRef, 0, len(playlist.Tracks))
+	for _, entry := range playlist.Tracks {
+		trackID, albumID := splitTrackRef(entry.ID.String(), entry.AlbumID.String())
+		if albumID == "" && entry.Track != nil && len(entry.Track.Albums) > 0 {
+			albumID = entry.Track.Albums[0].ID.String()
+		}
+		refs = append(refs, TrackRef{ID: trackID, AlbumID: albumID})
+	}
+	return refs
+}
+
+// DownloadPlaylist downloads all tracks of a playlist with a pool of concurrency workers
+// (see DownloadTracks). The results are in playlist order.
+func (c *Client) DownloadPlaylist(userID, kind string, quality AudioQuality, outputDir string,
+	concurrency int) ([]TrackResult, error) {
+	return c.DownloadPlaylistContext(context.Background(), userID, kind, quality, outputDir, concurrency)
+}
+
+// DownloadPlaylistContext is DownloadPlaylist with cancellation through ctx
+func (c *Client) DownloadPlaylistContext(ctx context.Context, userID, kind string, quality AudioQuality,
+	outputDir string, concurrency int) ([]TrackResult, error) {
+	playlist, err := c.GetPlaylistContext(ctx, userID, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.DownloadTracksContext(ctx, PlaylistTrackRefs(playlist), quality, outputDir, concurrency), nil
+}
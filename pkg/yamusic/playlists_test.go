@@ -0,0 +1,89 @@
+package yamusic
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestGetUserPlaylists checks that the playlists of the account are listed when no user is given
+func TestGetUserPlaylists(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/account/status": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"account":{"uid":42,"login":"test"}}}`))
+		},
+		"/users/42/playlists/list": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":[` +
+				`{"uid":42,"kind":3,"title":"Road","trackCount":12,"revision":5},` +
+				`{"uid":42,"kind":1003,"title":"Evening","trackCount":0,"revision":1}]}`))
+		},
+	})
+
+	playlists, err := client.GetUserPlaylists("")
+	if err != nil {
+		t.Fatalf("GetUserPlaylists() error = %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		kind       string
+		title      string
+		trackCount int
+		revision   int
+	}{
+		{"3", "Road", 12, 5},
+		{"1003", "Evening", 0, 1},
+	}
+
+	if len(playlists) != len(tests) {
+		t.Fatalf("GetUserPlaylists() returned %d playlists, want %d", len(playlists), len(tests))
+	}
+
+	// Run tests
+	for i, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got := playlists[i]
+			if got.Kind.String() != tt.kind || got.Title != tt.title || got.TrackCount != tt.trackCount || got.Revision != tt.revision {
+				t.Errorf("Playlist = {%s %s %d %d}, want {%s %s %d %d}", got.Kind, got.Title, got.TrackCount, got.Revision,
+					tt.kind, tt.title, tt.trackCount, tt.revision)
+			}
+		})
+	}
+}
+
+// TestPlaylistTrackRefs checks that playlist entries keep their order and the album they were added from
+func TestPlaylistTrackRefs(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/users/test/playlists/3": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"uid":42,"kind":3,"title":"Road","trackCount":3,"revision":5,"tracks":[` +
+				`{"id":2,"albumId":20},` +
+				`{"id":"1","albumId":"10"},` +
+				`{"id":3,"track":{"id":"3","albums":[{"id":30}]}}]}}`))
+		},
+	})
+
+	playlist, err := client.GetPlaylist("test", "3")
+	if err != nil {
+		t.Fatalf("GetPlaylist() error = %v", err)
+	}
+	refs := PlaylistTrackRefs(playlist)
+
+	// Test cases
+	tests := []TrackRef{
+		{ID: "2", AlbumID: "20"},
+		{ID: "1", AlbumID: "10"},
+		{ID: "3", AlbumID: "30"},
+	}
+
+	if len(refs) != len(tests) {
+		t.Fatalf("PlaylistTrackRefs() returned %d tracks, want %d", len(refs), len(tests))
+	}
+
+	// Run tests
+	for i, tt := range tests {
+		t.Run(tt.ID, func(t *testing.T) {
+			if refs[i] != tt {
+				t.Errorf("TrackRef = %+v, want %+v", refs[i], tt)
+			}
+		})
+	}
+}
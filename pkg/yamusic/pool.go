@@ -0,0 +1,166 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is the default number of parallel downloads in DownloadTracks
+const DefaultConcurrency = 3
+
+// ErrSkipped is reported for tracks not attempted after a failure in fail-fast mode
+var ErrSkipped = errors.New("skipped after an earlier failure")
+
+// TrackRef identifies a track to download, optionally in the context of an album
+type TrackRef struct {
+	ID string
+
+	// AlbumID selects the album used for naming (see DownloadAlbumTrack); may be empty
+	AlbumID string
+}
+
+// TrackResult is the outcome of downloading one track of a batch
+type TrackResult struct {
+	TrackRef
+	Path     string
+	Err      error
+	Started  time.Time
+	Finished time.Time
+
+	// Skipped is set when the track was already downloaded (see OverwriteSkip) or
+	// rejected by the pre-download hook, which Err reports then (see SetPreDownloadHook).
+	// SkipReason tells why the track was skipped.
+	Skipped    bool
+	SkipReason SkipReason
+
+	// Quality, Codec and Bitrate describe the stream actually downloaded, which may be
+	// of a lower quality than requested (see SetQualityFallback)
+	Quality ApiTrackQuality
+	Codec   string
+	Bitrate int
+
+	// Download describes the track with the details known when the download ended;
+	// nil for tracks that were not started
+	Download *DownloadResult
+}
+
+// SetFailFast makes DownloadTracks abort the remaining downloads after the first failure.
+// By default a failed track doesn't affect the others.
+func (c *Client) SetFailFast(failFast bool) {
+	c.failFast = failFast
+}
+
+// DownloadTracks downloads tracks with a pool of concurrency workers (DefaultConcurrency
+// when zero) and returns the results in input order
+func (c *Client) DownloadTracks(trackIDs []string, quality AudioQuality, outputDir string, concurrency int) []TrackResult {
+	tracks := make([]TrackRef, len(trackIDs))
+	for i, id := range trackIDs {
+		tracks[i] = TrackRef{ID: id}
+	}
+	return c.DownloadTracksContext(context.Background(), tracks, quality, outputDir, concurrency)
+}
+
+// DownloadTracksContext downloads tracks with a pool of concurrency workers and returns
+// the results in input order. The metadata of all tracks is fetched with batched requests
+// up front. Cancelling ctx aborts the downloads in progress; tracks that were not started
+// report the context error (or ErrSkipped in fail-fast mode).
+func (c *Client) DownloadTracksContext(ctx context.Context, tracks []TrackRef, quality AudioQuality,
+	outputDir string, concurrency int) []TrackResult {
+	return c.downloadTracks(ctx, tracks, quality, outputDir, concurrency, c.batch)
+}
+
+// downloadTracks downloads tracks with a pool of workers, recording the outcome of every
+// track in batch unless it is nil
+func (c *Client) downloadTracks(ctx context.Context, tracks []TrackRef, quality AudioQuality,
+	outputDir string, concurrency int, batch *BatchState) []TrackResult {
+	results := make([]TrackResult, len(tracks))
+	for i, track := range tracks {
+		track.ID, track.AlbumID = splitTrackRef(track.ID, track.AlbumID)
+		results[i].TrackRef = track
+	}
+	if len(tracks) == 0 {
+		return results
+	}
+
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	concurrency = min(concurrency, len(tracks))
+
+	// Share the metadata requests; tracks missing from the response are fetched one by one
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	infos, err := c.getTracks(ctx, ids)
+	if err != nil {
+		c.logger.Warn("Error getting metadata for %d tracks, falling back to per-track requests: %v", len(ids), err)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				track := results[i].TrackRef
+				if ctx.Err() != nil {
+					results[i].Err = context.Cause(ctx)
+					continue
+				}
+
+				// Prefix messages with the track ID so interleaved output stays readable
+				log := c.logger
+				if concurrency > 1 {
+					log = log.WithPrefix(track.ID)
+				}
+
+				result := &results[i]
+				result.Started = time.Now()
+				download, err := c.downloadTrack(ctx, track.ID, track.AlbumID, infos[track.ID],
+					quality, outputDir, log)
+				result.Path, result.Err, result.Download = download.Path, err, download
+				if errors.Is(err, errExists) || errors.Is(err, ErrRejected) {
+					result.Skipped, result.SkipReason = true, download.SkipReason
+				}
+				if errors.Is(err, errExists) {
+					result.Err = nil
+				}
+				result.Finished = time.Now()
+				result.Quality, result.Codec, result.Bitrate = download.Quality, download.Codec, download.Bitrate
+				if batch != nil {
+					if err := batch.record(result); err != nil {
+						log.Warn("Batch progress not saved: %v", err)
+					}
+				}
+
+				// Tracks rejected by the pre-download hook are skipped, not failed
+				if result.Err != nil && !result.Skipped && c.failFast {
+					cancel(ErrSkipped)
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range tracks {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for j := i; j < len(tracks); j++ {
+				results[j].Err = context.Cause(ctx)
+			}
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,61 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// ErrMissingScope is returned by Preflight when the token lacks the music:content scope
+var ErrMissingScope = errors.New("the access token lacks the music:content scope required for downloads; " +
+	"obtain a new token with yamusic-auth")
+
+// Preflight verifies once per client that the token can request download info,
+// returning ErrMissingScope when it was issued without the music:content scope
+// and an error when the token is rejected altogether.
+// The result is cached, so calling it before every download costs a single request.
+func (c *Client) Preflight() error {
+	c.preflightOnce.Do(func() {
+		c.preflightErr = c.preflight()
+	})
+	return c.preflightErr
+}
+
+// preflight performs the signed download info request used by Preflight
+func (c *Client) preflight() error {
+	c.logger.Debug("Pre-flight check: requesting download info for track %s", DoctorTrackID)
+
+	_, err := c.GetDownloadInfo(DoctorTrackID, api.QualityLow)
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		c.logger.Debug("Pre-flight check failed: status %d, error name %q", apiErr.Status, apiErr.Name)
+		if isScopeError(apiErr) {
+			return ErrMissingScope
+		}
+		// Other forbidden responses, e.g. region blocks, don't mean the token lacks the scope
+		if apiErr.Status == http.StatusForbidden {
+			return fmt.Errorf("the pre-flight download info request was forbidden: %w", err)
+		}
+		if apiErr.Status == http.StatusUnauthorized {
+			return fmt.Errorf("the access token is invalid or expired; obtain a new token with yamusic-auth: %w", err)
+		}
+	}
+
+	// Other failures (network, removed test track) don't prove anything about the token,
+	// so they must not block the run
+	c.logger.Warn("Pre-flight check inconclusive: %v", err)
+	return nil
+}
+
+// isScopeError reports whether an API error names the missing scope of the token
+func isScopeError(apiErr *APIError) bool {
+	return strings.Contains(strings.ToLower(apiErr.Name), "scope") ||
+		strings.Contains(strings.ToLower(apiErr.Message), "scope")
+}
@@ -0,0 +1,56 @@
+package yamusic
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// previewSuffix is added before the extension of preview files, so they are never taken
+// for full tracks
+const previewSuffix = ".preview"
+
+// defaultPreviewMs is the preview duration used when the metadata has none
+const defaultPreviewMs = 30000
+
+// SetPreview enables or disables preview mode. In preview mode tracks are downloaded in the
+// lowest quality and cut after the preview duration of the metadata, and files are saved
+// as "Name.preview.ext". Previews are neither added to the download archive nor scrobbled.
+func (c *Client) SetPreview(enabled bool) {
+	c.preview = enabled
+}
+
+// previewName adds the preview suffix to a file name
+func previewName(fileName string) string {
+	ext := filepath.Ext(fileName)
+	return strings.TrimSuffix(fileName, ext) + previewSuffix + ext
+}
+
+// isPreviewFile reports whether the file name is that of a preview
+func isPreviewFile(name string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(name, filepath.Ext(name)), previewSuffix)
+}
+
+// previewSize estimates the number of audio bytes covering the preview of a track: from
+// the bitrate when the server reports one, from the share of the duration in the file size
+// otherwise. Zero means the whole file, when neither is known or the track is shorter.
+func previewSize(trackInfo *api.TrackInfo, downloadInfo *api.DownloadInfo) int64 {
+	previewMs := int64(trackInfo.PreviewDurationMs)
+	if previewMs <= 0 {
+		previewMs = defaultPreviewMs
+	}
+
+	var size int64
+	switch {
+	case downloadInfo.Bitrate > 0:
+		size = int64(downloadInfo.Bitrate) * 1000 / 8 * previewMs / 1000
+	case downloadInfo.Size > 0 && trackInfo.DurationMs > 0:
+		size = int64(downloadInfo.Size) * previewMs / int64(trackInfo.DurationMs)
+	}
+
+	if downloadInfo.Size > 0 && size >= int64(downloadInfo.Size) {
+		return 0
+	}
+	return size
+}
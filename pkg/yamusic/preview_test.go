@@ -0,0 +1,92 @@
+package yamusic
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// TestPreviewSize checks the preview size estimates
+func TestPreviewSize(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name      string
+		previewMs int
+		duration  int
+		bitrate   int
+		size      int
+		want      int64
+	}{
+		{"From bitrate", 30000, 200000, 128, 3200000, 480000},
+		{"From file size", 30000, 200000, 0, 4000000, 600000},
+		{"Default preview duration", 0, 200000, 192, 4800000, 720000},
+		{"Track shorter than preview", 30000, 20000, 128, 320000, 0},
+		{"Nothing known", 30000, 0, 0, 0, 0},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trackInfo := &api.TrackInfo{PreviewDurationMs: tt.previewMs, DurationMs: tt.duration}
+			downloadInfo := &api.DownloadInfo{Bitrate: tt.bitrate, Size: tt.size}
+			if got := previewSize(trackInfo, downloadInfo); got != tt.want {
+				t.Errorf("previewSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDownloadPreview checks that previews are cut at the preview duration, saved under
+// their own name and kept apart from full tracks when skipping existing files
+func TestDownloadPreview(t *testing.T) {
+	audio := append([]byte("fLaC"), bytes.Repeat([]byte{0x5a}, 99996)...)
+	client := newDownloadServer(t, "64551568", audio)
+	client.SetPreview(true)
+	client.SetOverwritePolicy(OverwriteSkip)
+
+	// The fixture has no bitrate: 30 s of the 203.46 s track
+	want := int64(len(audio)) * 30000 / 203460
+
+	var buf bytes.Buffer
+	meta, err := client.DownloadTrackTo(context.Background(), "64551568", api.QualityHigh, &buf)
+	if err != nil {
+		t.Fatalf("DownloadTrackTo() error = %v", err)
+	}
+	if int64(buf.Len()) != want {
+		t.Errorf("Streamed %d bytes, want %d", buf.Len(), want)
+	}
+	if !strings.HasSuffix(meta.FileName, "[64551568].preview.flac") {
+		t.Errorf("FileName = %q, want a .preview.flac name", meta.FileName)
+	}
+
+	// A full track in the directory is not an existing preview
+	dir := t.TempDir()
+	full := filepath.Join(dir, "Кукла колдуна [64551568].flac")
+	if err := os.WriteFile(full, audio, 0644); err != nil {
+		t.Fatalf("Failed to write full track: %v", err)
+	}
+
+	path, err := client.DownloadTrack("64551568", api.QualityHigh, dir)
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+	if path == full || !isPreviewFile(filepath.Base(path)) {
+		t.Errorf("DownloadTrack() = %q, want a preview file", path)
+	}
+
+	// Without preview mode the preview is not an existing full track
+	os.Remove(full)
+	client.SetPreview(false)
+	path, err = client.DownloadTrack("64551568", api.QualityHigh, dir)
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+	if isPreviewFile(filepath.Base(path)) {
+		t.Errorf("DownloadTrack() = %q, want a full track", path)
+	}
+}
@@ -0,0 +1,102 @@
+package yamusic
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// progressInterval is the minimum interval between intermediate progress reports of a track
+const progressInterval = 100 * time.Millisecond
+
+// Progress describes the state of a track download
+type Progress struct {
+	TrackID    string `json:"trackId"`
+	Downloaded int64  `json:"downloaded"`
+
+	// Total is the expected size in bytes, or 0 when it is unknown
+	Total int64 `json:"total,omitempty"`
+
+	// Done is set in the final report of a download, successful or not
+	Done bool `json:"done"`
+
+	// Err is the error of a failed download; it is only set when Done is set
+	Err error `json:"-"`
+}
+
+// ProgressFunc receives download progress reports. Reports for different tracks may
+// come from different goroutines when tracks are downloaded concurrently.
+type ProgressFunc func(Progress)
+
+// SetProgressFunc sets a function called as the audio of a track is downloaded, at most
+// every 100ms, and once more with Done set when the download completes or fails.
+// A nil function disables progress reporting.
+func (c *Client) SetProgressFunc(fn ProgressFunc) {
+	c.progress = fn
+}
+
+// progressTracker reports the progress of a single track download.
+// A nil tracker ignores all calls.
+type progressTracker struct {
+	// mu guards add, which the chunks of a chunked download call concurrently
+	mu sync.Mutex
+
+	fn       ProgressFunc
+	state    Progress
+	reported time.Time
+}
+
+// newProgress returns a tracker for the track, or nil when no progress function is set
+func (c *Client) newProgress(trackID string) *progressTracker {
+	if c.progress == nil {
+		return nil
+	}
+	return &progressTracker{fn: c.progress, state: Progress{TrackID: trackID}}
+}
+
+// setTotal sets the expected size unless it is already known
+func (t *progressTracker) setTotal(total int64) {
+	if t == nil || t.state.Total > 0 || total <= 0 {
+		return
+	}
+	t.state.Total = total
+}
+
+// add counts downloaded bytes and reports them when the report interval has passed
+func (t *progressTracker) add(n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.state.Downloaded += int64(n)
+	if now := time.Now(); now.Sub(t.reported) >= progressInterval {
+		t.reported = now
+		t.fn(t.state)
+	}
+}
+
+// finish sends the final report
+func (t *progressTracker) finish(err error) {
+	if t == nil {
+		return
+	}
+
+	t.state.Done = true
+	t.state.Err = err
+	t.fn(t.state)
+}
+
+// progressReader counts the bytes read through it in a progress tracker
+type progressReader struct {
+	r       io.Reader
+	tracker *progressTracker
+}
+
+// Read implements io.Reader
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.tracker.add(n)
+	return n, err
+}
@@ -0,0 +1,88 @@
+package yamusic
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+)
+
+// TestProgress checks the final progress report of successful and failed downloads
+func TestProgress(t *testing.T) {
+	audio := bytes.Repeat([]byte("fLaC progress "), 8192)
+
+	// Test cases
+	tests := []struct {
+		name    string
+		trackID string
+		wantErr bool
+	}{
+		{"Completed download", "64551568", false},
+		{"Failed download", "1", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newDownloadServer(t, "64551568", audio)
+
+			var reports []Progress
+			client.SetProgressFunc(func(p Progress) {
+				reports = append(reports, p)
+			})
+
+			_, err := client.DownloadTrack(tt.trackID, QualityHigh, t.TempDir())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DownloadTrack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(reports) == 0 {
+				t.Fatal("No progress reports")
+			}
+			last := reports[len(reports)-1]
+			if !last.Done || last.TrackID != tt.trackID || (last.Err != nil) != tt.wantErr {
+				t.Errorf("Final report = %+v, want Done for track %s with wantErr %v", last, tt.trackID, tt.wantErr)
+			}
+			for _, report := range reports[:len(reports)-1] {
+				if report.Done {
+					t.Errorf("Intermediate report %+v has Done set", report)
+				}
+			}
+
+			if !tt.wantErr && (last.Downloaded != int64(len(audio)) || last.Total != int64(len(audio))) {
+				t.Errorf("Final report = %d of %d bytes, want %d of %d", last.Downloaded, last.Total, len(audio), len(audio))
+			}
+		})
+	}
+}
+
+// TestProgressContentLength checks that Content-Length is used when the size is unknown
+func TestProgressContentLength(t *testing.T) {
+	audio := []byte("fLaC audio without size")
+	encrypted, _ := crypto.DecryptAesCtr(audio, testDecryptionKey)
+
+	var serverURL string
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568": serveFixture(t, "track.json"),
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"result":{"downloadInfo":{"codec":"flac","key":%q,"url":%q}}}`,
+				testDecryptionKey, serverURL+"/media")
+		},
+		"/media": func(w http.ResponseWriter, r *http.Request) {
+			w.Write(encrypted)
+		},
+	})
+	serverURL = server.URL
+
+	var last Progress
+	client.SetProgressFunc(func(p Progress) { last = p })
+
+	if _, err := client.DownloadTrack("64551568", QualityHigh, t.TempDir()); err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+	if last.Total != int64(len(audio)) {
+		t.Errorf("Total = %d, want Content-Length %d", last.Total, len(audio))
+	}
+}
@@ -0,0 +1,51 @@
+package yamusic
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// SetProxy routes API requests and media downloads through a proxy. Supported schemes
+// are http, https, socks5 and socks5h (host names resolved by the proxy); credentials
+// may be given in the URL. An empty proxyURL restores the proxy settings from the
+// environment (HTTP_PROXY, HTTPS_PROXY and NO_PROXY). A transport injected with
+// WithHTTPClient or WithTransport must be configured by the caller.
+func (c *Client) SetProxy(proxyURL string) error {
+	if c.transport == nil {
+		return fmt.Errorf("proxy can't be set on an injected transport")
+	}
+
+	if proxyURL == "" {
+		c.transport.Proxy = http.ProxyFromEnvironment
+		return nil
+	}
+
+	u, err := parseProxyURL(proxyURL)
+	if err != nil {
+		return err
+	}
+	c.transport.Proxy = http.ProxyURL(u)
+	c.logger.Debug("Using proxy %s://%s", u.Scheme, u.Host)
+	return nil
+}
+
+// parseProxyURL parses and validates a proxy URL
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: unsupported scheme %q (supported schemes: http, https, socks5, socks5h)",
+			u.Redacted(), u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: no host", u.Redacted())
+	}
+
+	return u, nil
+}
@@ -0,0 +1,166 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// SetQualityFallback enables or disables falling back to lower qualities when the requested
+// quality is not available for a track. Fallback is enabled by default; without it such
+// tracks fail.
+func (c *Client) SetQualityFallback(enabled bool) {
+	c.noFallback = !enabled
+}
+
+// SetCodecs restricts the codecs the server may choose from, in order of preference,
+// e.g. "aac", "aac-mp4" to get AAC even at the maximum quality. The codecs must be from
+// api.Codecs; no codecs restore the full list.
+func (c *Client) SetCodecs(codecs ...string) error {
+	if len(codecs) == 0 {
+		c.codecs = api.Codecs
+		return nil
+	}
+
+	supported := strings.Split(api.Codecs, ",")
+	for _, codec := range codecs {
+		if !slices.Contains(supported, codec) {
+			return fmt.Errorf("unsupported codec %q (supported codecs: %s)", codec, api.Codecs)
+		}
+	}
+
+	c.codecs = strings.Join(codecs, ",")
+	return nil
+}
+
+// qualityChain returns the qualities to try for a requested quality, best first
+func qualityChain(quality ApiTrackQuality) []ApiTrackQuality {
+	switch quality {
+	case api.QualityLossless:
+		return []ApiTrackQuality{api.QualityLossless, api.QualityNormal, api.QualityLow}
+	case api.QualityNormal:
+		return []ApiTrackQuality{api.QualityNormal, api.QualityLow}
+	default:
+		return []ApiTrackQuality{quality}
+	}
+}
+
+// getDownloadInfo retrieves information for downloading a track logging to log. When the
+// quality is not available, lower qualities are tried in turn unless fallback is disabled;
+// the quality of the returned info is the one actually selected. Failures of accounts
+// without a subscription are reported as ErrNoSubscription.
+func (c *Client) getDownloadInfo(ctx context.Context, trackID string, quality ApiTrackQuality,
+	log *logger.Logger) (*api.DownloadInfo, error) {
+	chain := qualityChain(quality)
+	if c.noFallback {
+		chain = chain[:1]
+	}
+
+	var err error
+	for i, q := range chain {
+		var downloadInfo *api.DownloadInfo
+		downloadInfo, err = c.requestDownloadInfo(ctx, trackID, q, log)
+		if err == nil {
+			if downloadInfo.Quality == "" {
+				downloadInfo.Quality = string(q)
+			}
+			if i > 0 {
+				log.Info("Quality %s is not available, using %s (%s, %d kbps)",
+					quality, downloadInfo.Quality, downloadInfo.Codec, downloadInfo.Bitrate)
+				if !c.hasSubscription(ctx, log) {
+					log.Warn("%v", ErrNoSubscription)
+				}
+			}
+			return downloadInfo, nil
+		}
+
+		// A lower quality won't help when the request itself didn't get through
+		if ctx.Err() != nil || ErrorCategory(err) == CategoryNetwork {
+			break
+		}
+		if i+1 < len(chain) {
+			log.Info("Quality %s is not available (%v), trying %s", q, err, chain[i+1])
+		}
+	}
+
+	// The legacy API may still work when get-file-info rejects the request; it never
+	// delivers lossless, which is a quality fallback of its own
+	if !c.noLegacyFallback && ctx.Err() == nil && ErrorCategory(err) != CategoryNetwork &&
+		(quality != api.QualityLossless || !c.noFallback) {
+		downloadInfo, legacyErr := c.requestLegacyDownloadInfo(ctx, trackID, quality, log)
+		if legacyErr == nil {
+			log.Warn("get-file-info failed (%v), using the legacy download API: %s, %d kbps, unencrypted",
+				err, downloadInfo.Codec, downloadInfo.Bitrate)
+			return downloadInfo, nil
+		}
+		log.Debug("Legacy download API failed as well: %v", legacyErr)
+	}
+
+	// A generic API error is confusing when the real reason is a missing subscription
+	if ctx.Err() == nil && ErrorCategory(err) != CategoryNetwork && !c.hasSubscription(ctx, log) {
+		return nil, fmt.Errorf("%w: %w", ErrNoSubscription, err)
+	}
+	return nil, err
+}
+
+// ErrQualityDowngrade is returned in strict quality mode for tracks delivered in a lower
+// quality than requested
+var ErrQualityDowngrade = errors.New("delivered quality is lower than requested")
+
+// SetStrictQuality fails tracks with ErrQualityDowngrade when the delivered quality is lower
+// than requested, e.g. AAC instead of FLAC on a subscription without lossless, instead of
+// downloading them with a warning
+func (c *Client) SetStrictQuality(strict bool) {
+	c.strictQuality = strict
+}
+
+// checkDelivered compares the stream the API delivered with the requested quality, warning
+// about a downgrade or, in strict mode, returning ErrQualityDowngrade
+func (c *Client) checkDelivered(requested ApiTrackQuality, downloadInfo *api.DownloadInfo, log *logger.Logger) error {
+	if !downgraded(requested, downloadInfo) {
+		return nil
+	}
+
+	if c.strictQuality {
+		return fmt.Errorf("%w: requested %s, delivered %s (%s, %d kbps)", ErrQualityDowngrade,
+			requested, downloadInfo.Quality, downloadInfo.Codec, downloadInfo.Bitrate)
+	}
+	log.Warn("⚠️ Requested quality %s, but the API delivered %s (%s, %d kbps)",
+		requested, downloadInfo.Quality, downloadInfo.Codec, downloadInfo.Bitrate)
+	return nil
+}
+
+// downgraded reports whether the delivered stream is of a lower quality than requested
+func downgraded(requested ApiTrackQuality, downloadInfo *api.DownloadInfo) bool {
+	return deliveredRank(downloadInfo) < qualityRank(requested)
+}
+
+// qualityRank orders API qualities, higher is better
+func qualityRank(quality ApiTrackQuality) int {
+	switch quality {
+	case api.QualityLossless:
+		return 2
+	case api.QualityLow:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// deliveredRank ranks a delivered stream like qualityRank; only lossless codecs count
+// as lossless whatever quality the API reports
+func deliveredRank(downloadInfo *api.DownloadInfo) int {
+	switch {
+	case strings.HasPrefix(downloadInfo.Codec, "flac"):
+		return 2
+	case ApiTrackQuality(downloadInfo.Quality) == api.QualityLow:
+		return 0
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,199 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+)
+
+// TestQualityFallback checks that unavailable qualities fall back to lower ones
+func TestQualityFallback(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name      string
+		available map[string]bool
+		fallback  bool
+		expected  string
+		requested []string
+		wantErr   bool
+	}{
+		{"Requested quality available", map[string]bool{"lossless": true}, true, "lossless", []string{"lossless"}, false},
+		{"Falls back to nq", map[string]bool{"nq": true}, true, "nq", []string{"lossless", "nq"}, false},
+		{"Falls back to lq", map[string]bool{"lq": true}, true, "lq", []string{"lossless", "nq", "lq"}, false},
+		{"Nothing available", map[string]bool{}, true, "", []string{"lossless", "nq", "lq"}, true},
+		{"Fallback disabled", map[string]bool{"nq": true}, false, "", []string{"lossless"}, true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested []string
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{
+				"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+					quality := r.URL.Query().Get("quality")
+					requested = append(requested, quality)
+					if !tt.available[quality] {
+						// An empty download info, as returned for qualities the track lacks
+						w.Write([]byte(`{"result":{"downloadInfo":{}}}`))
+						return
+					}
+					fmt.Fprintf(w, `{"result":{"downloadInfo":{"codec":"aac","bitrate":256,"key":%q,"url":"http://cdn/1"}}}`,
+						testDecryptionKey)
+				},
+			})
+			client.SetQualityFallback(tt.fallback)
+
+			downloadInfo, err := client.GetDownloadInfo("1", api.QualityLossless)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetDownloadInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && downloadInfo.Quality != tt.expected {
+				t.Errorf("Quality = %q, want %q", downloadInfo.Quality, tt.expected)
+			}
+			if fmt.Sprint(requested) != fmt.Sprint(tt.requested) {
+				t.Errorf("Requested qualities %v, want %v", requested, tt.requested)
+			}
+		})
+	}
+}
+
+// TestSetCodecs checks codec validation and that the codec list is sent and signed
+func TestSetCodecs(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		codecs   []string
+		expected string
+		wantErr  bool
+	}{
+		{"Default", nil, api.Codecs, false},
+		{"AAC only", []string{"aac", "aac-mp4"}, "aac,aac-mp4", false},
+		{"Unsupported codec", []string{"aac", "opus"}, "", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent string
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{
+				"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+					query := r.URL.Query()
+					sent = query.Get("codecs")
+					data := query.Get("ts") + query.Get("trackId") + query.Get("quality") + sent + query.Get("transports")
+					if query.Get("sign") != crypto.GenerateSignature(data, api.DefaultSignKey) {
+						t.Error("Signature doesn't match the codec list")
+					}
+					fmt.Fprintf(w, `{"result":{"downloadInfo":{"codec":"aac","key":%q,"url":"http://cdn/1"}}}`,
+						testDecryptionKey)
+				},
+			})
+
+			err := client.SetCodecs(tt.codecs...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetCodecs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if _, err := client.GetDownloadInfo("1", api.QualityLossless); err != nil {
+				t.Fatalf("GetDownloadInfo() error = %v", err)
+			}
+			if sent != tt.expected {
+				t.Errorf("codecs = %q, want %q", sent, tt.expected)
+			}
+		})
+	}
+}
+
+// TestWithCodecs checks that the option restricts the codec list and rejects unsupported codecs
+func TestWithCodecs(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		codecs   []string
+		expected string
+		wantErr  bool
+	}{
+		{"AAC only", []string{"aac", "aac-mp4"}, "aac,aac-mp4", false},
+		{"Unsupported codec", []string{"opus"}, "", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClientWithOptions("test-token", WithCodecs(tt.codecs...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClientWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && client.codecs != tt.expected {
+				t.Errorf("codecs = %q, want %q", client.codecs, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDowngraded checks the comparison of the delivered stream with the requested quality
+func TestDowngraded(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name      string
+		requested ApiTrackQuality
+		quality   string
+		codec     string
+		expected  bool
+	}{
+		{"Lossless delivered", api.QualityLossless, "lossless", "flac", false},
+		{"Lossless in MP4", api.QualityLossless, "lossless", "flac-mp4", false},
+		{"AAC instead of lossless", api.QualityLossless, "nq", "aac", true},
+		{"AAC labelled lossless", api.QualityLossless, "lossless", "aac-mp4", true},
+		{"Standard delivered", api.QualityNormal, "nq", "mp3", false},
+		{"Low instead of standard", api.QualityNormal, "lq", "aac", true},
+		{"Better than requested", api.QualityLow, "nq", "aac", false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &api.DownloadInfo{Quality: tt.quality, Codec: tt.codec}
+			if got := downgraded(tt.requested, info); got != tt.expected {
+				t.Errorf("downgraded() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestStrictQuality checks that strict mode fails a downgraded track before downloading it
+func TestStrictQuality(t *testing.T) {
+	var mediaRequests int
+	var serverURL string
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568": serveFixture(t, "track.json"),
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"result":{"downloadInfo":{"quality":"nq","codec":"aac","bitrate":256,`+
+				`"transport":"encraw","key":%q,"url":%q}}}`, testDecryptionKey, serverURL+"/media")
+		},
+		"/media": func(w http.ResponseWriter, r *http.Request) {
+			mediaRequests++
+		},
+	})
+	serverURL = server.URL
+	client.SetStrictQuality(true)
+
+	result, err := client.downloadTrack(context.Background(), "64551568", "", nil, QualityHigh, t.TempDir(), client.logger)
+	if !errors.Is(err, ErrQualityDowngrade) {
+		t.Fatalf("downloadTrack() error = %v, want ErrQualityDowngrade", err)
+	}
+	if result.RequestedQuality != api.QualityLossless || result.Quality != api.QualityNormal || !result.Downgraded {
+		t.Errorf("Result quality = %s of %s (downgraded %v), want nq of lossless",
+			result.Quality, result.RequestedQuality, result.Downgraded)
+	}
+	if mediaRequests != 0 {
+		t.Errorf("Media requested %d times, want none", mediaRequests)
+	}
+}
@@ -0,0 +1,167 @@
+package yamusic
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRequestsPerSecond is the default rate limit of API requests
+	DefaultRequestsPerSecond = 5
+
+	// DefaultRequestBurst is the default number of API requests allowed at once above the rate
+	DefaultRequestBurst = 5
+
+	// DefaultMaxMediaDownloads is the default number of simultaneous media transfers
+	DefaultMaxMediaDownloads = 1
+)
+
+// rateLimiter is a token bucket shared by all requests (or transferred bytes) of a client.
+// After a 429 response it is paused, so every worker waits instead of hitting the limit again.
+type rateLimiter struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// newRateLimiter creates a limiter allowing rate tokens per second with bursts of burst
+// tokens; a non-positive rate disables limiting and returns nil
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	burst = max(burst, 1)
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve takes n tokens and returns how long to wait before using them
+func (l *rateLimiter) reserve(now time.Time, n float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= n
+
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	return max(delay, l.pausedUntil.Sub(now))
+}
+
+// wait blocks until a request may be sent or ctx is done; a nil limiter never blocks
+func (l *rateLimiter) wait(ctx context.Context) error {
+	return l.waitN(ctx, 1)
+}
+
+// waitN blocks until n tokens are available or ctx is done; a nil limiter never blocks
+func (l *rateLimiter) waitN(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+
+	delay := l.reserve(time.Now(), float64(n))
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pause holds back all requests for d
+func (l *rateLimiter) pause(d time.Duration) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// SetRateLimit limits API requests to requestsPerSecond with bursts of burst requests,
+// shared by all concurrent downloads. A zero rate disables the limit. Rate-limited (429)
+// responses pause all requests for the delay the server asked for.
+func (c *Client) SetRateLimit(requestsPerSecond float64, burst int) {
+	c.limiter = newRateLimiter(requestsPerSecond, burst)
+}
+
+// SetMaxMediaDownloads limits the number of simultaneous media transfers across all hosts,
+// on top of the per-host limit of SetMaxConnsPerHost. The default is DefaultMaxMediaDownloads;
+// zero removes the limit.
+func (c *Client) SetMaxMediaDownloads(limit int) {
+	if limit <= 0 {
+		c.mediaSlots = nil
+		return
+	}
+	c.mediaSlots = make(chan struct{}, limit)
+}
+
+// acquireMediaSlot blocks until a media transfer may start or ctx is done. The returned
+// function releases the slot.
+func (c *Client) acquireMediaSlot(ctx context.Context) (func(), error) {
+	slots := c.mediaSlots
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SetSpeedLimit limits the media download speed to bytesPerSecond, shared by all concurrent
+// downloads of the client. Zero removes the limit (the default).
+func (c *Client) SetSpeedLimit(bytesPerSecond int64) {
+	// A quarter of a second worth of data smooths the transfer without long stalls
+	c.speedLimiter = newRateLimiter(float64(bytesPerSecond), int(max(bytesPerSecond/4, 1)))
+}
+
+// throttledReader limits the rate of reading from r with a limiter counting bytes
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+// Read implements io.Reader
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	// Read no more than a burst at once so the wait for it is bounded
+	if limit := int(tr.limiter.burst); len(p) > limit {
+		p = p[:limit]
+	}
+
+	n, err := tr.r.Read(p)
+	if n > 0 {
+		if waitErr := tr.limiter.waitN(tr.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// throttle wraps r with the speed limit of the client, if any
+func (c *Client) throttle(ctx context.Context, r io.Reader) io.Reader {
+	if c.speedLimiter == nil {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, limiter: c.speedLimiter}
+}
@@ -0,0 +1,154 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRateLimiter checks the token bucket delays, pausing and cancellation
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter(10, 2)
+	l.last = now
+
+	// Test cases
+	tests := []struct {
+		at       time.Duration
+		expected time.Duration
+	}{
+		{0, 0},
+		{0, 0},
+		{0, 100 * time.Millisecond},
+		{0, 200 * time.Millisecond},
+		{time.Second, 0},
+	}
+
+	// Run tests
+	for i, tt := range tests {
+		if got := l.reserve(now.Add(tt.at), 1); (got - tt.expected).Abs() > time.Millisecond {
+			t.Errorf("Reservation %d delay = %v, want %v", i, got, tt.expected)
+		}
+	}
+
+	l.pause(time.Hour)
+	if got := l.reserve(time.Now(), 1); got < 59*time.Minute {
+		t.Errorf("Delay while paused = %v, want about an hour", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wait() while paused error = %v, want the context error", err)
+	}
+
+	if newRateLimiter(0, 5) != nil {
+		t.Error("newRateLimiter() with zero rate must disable limiting")
+	}
+}
+
+// TestRateLimited checks that 429 responses are waited out without caller involvement
+func TestRateLimited(t *testing.T) {
+	var calls atomic.Int32
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/1": func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) <= 2 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{"result":[{"id":"1","title":"Track"}]}`))
+		},
+	})
+
+	started := time.Now()
+	trackInfo, err := client.GetTrackInfo("1")
+	if err != nil {
+		t.Fatalf("GetTrackInfo() error = %v", err)
+	}
+	if trackInfo.Title != "Track" || calls.Load() != 3 {
+		t.Errorf("GetTrackInfo() = %q after %d requests, want success after 3", trackInfo.Title, calls.Load())
+	}
+	if elapsed := time.Since(started); elapsed < 2*time.Second {
+		t.Errorf("GetTrackInfo() took %v, want the Retry-After delays respected", elapsed)
+	}
+}
+
+// TestMaxMediaDownloads checks that media slots limit the transfers and are released
+func TestMaxMediaDownloads(t *testing.T) {
+	client, _ := newTestServer(t, nil)
+	client.SetMaxMediaDownloads(1)
+
+	release, err := client.acquireMediaSlot(context.Background())
+	if err != nil {
+		t.Fatalf("acquireMediaSlot() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.acquireMediaSlot(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquireMediaSlot() with no free slot error = %v, want the context error", err)
+	}
+
+	release()
+	if _, err := client.acquireMediaSlot(context.Background()); err != nil {
+		t.Errorf("acquireMediaSlot() after release error = %v", err)
+	}
+}
+
+// TestDefaultMaxMediaDownloads checks the default media transfer limit and that zero removes it
+func TestDefaultMaxMediaDownloads(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		options  []Option
+		expected int
+	}{
+		{"Default", nil, DefaultMaxMediaDownloads},
+		{"Custom limit", []Option{WithMaxMediaDownloads(3)}, 3},
+		{"No limit", []Option{WithMaxMediaDownloads(0)}, 0},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClientWithOptions("test-token", tt.options...)
+			if err != nil {
+				t.Fatalf("NewClientWithOptions() error = %v", err)
+			}
+			if got := cap(client.mediaSlots); got != tt.expected {
+				t.Errorf("Media slots = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSpeedLimit checks that the speed limit is shared by concurrent downloads
+func TestSpeedLimit(t *testing.T) {
+	const limit = 64 << 10
+	audio := append([]byte("fLaC"), make([]byte, 32<<10-4)...)
+	client := newDownloadServer(t, "64551568", audio)
+	client.SetSpeedLimit(limit)
+
+	// Two downloads of half the limit each start with a quarter second burst, so together
+	// they need at least three quarters of a second
+	started := time.Now()
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := client.DownloadTrack("64551568", QualityHigh, t.TempDir())
+			errs <- err
+		}()
+	}
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Fatalf("DownloadTrack() error = %v", err)
+		}
+	}
+
+	if elapsed := time.Since(started); elapsed < 700*time.Millisecond {
+		t.Errorf("Downloads of %d KiB at %d KiB/s took %v, want the limit shared", 2*len(audio)>>10, limit>>10, elapsed)
+	}
+}
@@ -0,0 +1,185 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+// maxShortLinkRedirects is the maximum number of redirects followed to resolve a short link
+const maxShortLinkRedirects = 10
+
+// ErrUnknownInput is returned by ResolveInput for inputs that are not a track, album,
+// playlist or artist
+var ErrUnknownInput = errors.New("not a track, album, playlist or artist")
+
+// EntityKind is the kind of entity an input refers to
+type EntityKind string
+
+const (
+	// EntityTrack - a track
+	EntityTrack EntityKind = "track"
+
+	// EntityAlbum - an album
+	EntityAlbum EntityKind = "album"
+
+	// EntityPlaylist - a user playlist
+	EntityPlaylist EntityKind = "playlist"
+
+	// EntityArtist - an artist
+	EntityArtist EntityKind = "artist"
+)
+
+// EntityRef is an input resolved with ResolveInput
+type EntityRef struct {
+	Kind EntityKind
+
+	// ID is the ID of the track, album or artist, or the kind of the playlist
+	ID string
+
+	// AlbumID is the album of a track given with one, as in /album/{albumId}/track/{trackId}
+	// links and "trackId:albumId" IDs
+	AlbumID string
+
+	// Owner is the UID or login of the owner of a playlist
+	Owner string
+
+	// URL is the link a short link redirected to; empty for other inputs
+	URL string
+}
+
+// ResolveInput classifies an input as a track, album, playlist or artist. Links of
+// music.yandex.ru, .com and other domains are recognized as is; other links, such as
+// ya.cc short links, are resolved by following their redirects up to the first
+// recognized link. Plain numeric IDs and "trackId:albumId" IDs are tracks, "owner:kind"
+// IDs playlists; other inputs without a link are taken as IDs of uploaded tracks.
+func (c *Client) ResolveInput(input string) (EntityRef, error) {
+	return c.ResolveInputContext(context.Background(), input)
+}
+
+// ResolveInputContext is ResolveInput with a context for resolving short links
+func (c *Client) ResolveInputContext(ctx context.Context, input string) (EntityRef, error) {
+	input = strings.TrimSpace(input)
+	if !isLink(input) {
+		return classifyID(input), nil
+	}
+	if ref, ok := classifyLink(input); ok {
+		return ref, nil
+	}
+
+	link := input
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+	if parsed, err := url.Parse(link); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return EntityRef{}, fmt.Errorf("%w: %s", ErrUnknownInput, input)
+	}
+
+	target, err := c.followShortLink(ctx, link)
+	if err != nil {
+		return EntityRef{}, fmt.Errorf("error resolving %s: %w", input, err)
+	}
+	ref, ok := classifyLink(target)
+	if !ok {
+		return EntityRef{}, fmt.Errorf("%w: %s leads to %s", ErrUnknownInput, input, target)
+	}
+	ref.URL = target
+	return ref, nil
+}
+
+// isLink reports whether an input is a link rather than an ID
+func isLink(input string) bool {
+	return strings.Contains(input, "/")
+}
+
+// classifyID classifies an input that is not a link
+func classifyID(input string) EntityRef {
+	if trackID, albumID := utils.SplitTrackID(input); albumID != "" {
+		return EntityRef{Kind: EntityTrack, ID: trackID, AlbumID: albumID}
+	}
+	if owner, kind, ok := utils.ExtractPlaylistRef(input); ok {
+		return EntityRef{Kind: EntityPlaylist, ID: kind, Owner: owner}
+	}
+	return EntityRef{Kind: EntityTrack, ID: input}
+}
+
+// classifyLink classifies a Yandex Music link; other links are not recognized
+func classifyLink(link string) (EntityRef, bool) {
+	if !strings.Contains(link, "music.yandex") {
+		return EntityRef{}, false
+	}
+	if albumID, ok := utils.ExtractAlbumID(link); ok {
+		return EntityRef{Kind: EntityAlbum, ID: albumID}, true
+	}
+	if owner, kind, ok := utils.ExtractPlaylistRef(link); ok {
+		return EntityRef{Kind: EntityPlaylist, ID: kind, Owner: owner}, true
+	}
+	if artistID, ok := utils.ExtractArtistID(link); ok {
+		return EntityRef{Kind: EntityArtist, ID: artistID}, true
+	}
+	if trackID, albumID := utils.ExtractTrackRef(link); catalogIDPattern.MatchString(trackID) {
+		return EntityRef{Kind: EntityTrack, ID: trackID, AlbumID: albumID}, true
+	}
+	return EntityRef{}, false
+}
+
+// followShortLink follows the redirects of a link until a recognized link or the last
+// redirect and returns where it led. HEAD is tried first, as the page itself is not needed;
+// servers rejecting it get a GET. The token is never sent, as the link may be anywhere.
+func (c *Client) followShortLink(ctx context.Context, link string) (string, error) {
+	log := c.logger.WithField("input", link)
+
+	var target string
+	client := *c.httpClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// The music page is not requested: the link is all that is needed
+		if _, ok := classifyLink(req.URL.String()); ok {
+			target = req.URL.String()
+			return http.ErrUseLastResponse
+		}
+		// Endless redirects end at the last link, which is then not recognized
+		if len(via) >= maxShortLinkRedirects {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+
+	err := c.withRetry(ctx, log, func() error {
+		for _, method := range []string{http.MethodHead, http.MethodGet} {
+			req, err := http.NewRequestWithContext(ctx, method, link, nil)
+			if err != nil {
+				return fmt.Errorf("request creation error: %w", err)
+			}
+			for key, value := range c.mediaHeaders {
+				req.Header.Set(key, value)
+			}
+
+			target = ""
+			resp, err := client.Do(req)
+			if err != nil {
+				return fmt.Errorf("request execution error: %w", err)
+			}
+			resp.Body.Close()
+
+			if target == "" {
+				target = resp.Request.URL.String()
+			}
+			if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+				continue
+			}
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	log.Debug("Short link leads to %s", target)
+	return target, nil
+}
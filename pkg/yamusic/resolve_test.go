@@ -0,0 +1,105 @@
+package yamusic
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// TestResolveInput checks the classification of links and IDs that need no requests
+func TestResolveInput(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name  string
+		input string
+		want  EntityRef
+	}{
+		{"Track ID", "64551568", EntityRef{Kind: EntityTrack, ID: "64551568"}},
+		{"Composite ID", "64551568:10376938", EntityRef{Kind: EntityTrack, ID: "64551568", AlbumID: "10376938"}},
+		{"Track URL", "https://music.yandex.ru/track/64551568", EntityRef{Kind: EntityTrack, ID: "64551568"}},
+		{"Track URL on .com", "https://music.yandex.com/track/64551568?utm_medium=copy_link",
+			EntityRef{Kind: EntityTrack, ID: "64551568"}},
+		{"Album track URL", "https://music.yandex.ru/album/10376938/track/64551568",
+			EntityRef{Kind: EntityTrack, ID: "64551568", AlbumID: "10376938"}},
+		{"Album URL without scheme", "music.yandex.ru/album/10376938", EntityRef{Kind: EntityAlbum, ID: "10376938"}},
+		{"Playlist URL", "https://music.yandex.ru/users/test/playlists/3",
+			EntityRef{Kind: EntityPlaylist, ID: "3", Owner: "test"}},
+		{"Playlist ID", "test:3", EntityRef{Kind: EntityPlaylist, ID: "3", Owner: "test"}},
+		{"Artist URL", "https://music.yandex.com/artist/41191/tracks", EntityRef{Kind: EntityArtist, ID: "41191"}},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("test-token", "", nil)
+			got, err := client.ResolveInput(tt.input)
+			if err != nil {
+				t.Fatalf("ResolveInput(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveInput(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestResolveShortLink checks that short links are resolved by following their redirects
+func TestResolveShortLink(t *testing.T) {
+	const target = "https://music.yandex.ru/album/10376938/track/64551568"
+
+	redirect := func(location string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if auth := r.Header.Get("Authorization"); auth != "" {
+				t.Errorf("Authorization = %q sent to a short link", auth)
+			}
+			http.Redirect(w, r, location, http.StatusFound)
+		}
+	}
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/s/direct": redirect(target),
+		"/s/chain":  redirect("/s/direct"),
+		"/s/loop":   redirect("/s/loop"),
+		"/s/page":   redirect("/somewhere"),
+		"/s/no-head": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		},
+		"/somewhere": func(w http.ResponseWriter, r *http.Request) {},
+	})
+
+	// Test cases
+	tests := []struct {
+		name    string
+		path    string
+		wantErr error
+	}{
+		{name: "Direct redirect", path: "/s/direct"},
+		{name: "Redirect chain", path: "/s/chain"},
+		{name: "HEAD not allowed", path: "/s/no-head"},
+		{name: "Endless redirects", path: "/s/loop", wantErr: ErrUnknownInput},
+		{name: "Not a music link", path: "/s/page", wantErr: ErrUnknownInput},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.ResolveInput(server.URL + tt.path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ResolveInput() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveInput() error = %v", err)
+			}
+			want := EntityRef{Kind: EntityTrack, ID: "64551568", AlbumID: "10376938", URL: target}
+			if got != want {
+				t.Errorf("ResolveInput() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// DownloadResult describes a downloaded track
+type DownloadResult struct {
+	// Path is the file the track was saved to
+	Path string
+
+	TrackID string
+	Title   string
+	Artists []string
+
+	// Albums are the album titles used for naming: the selected album, or all albums of
+	// the track when none is selected (see SetAlbumPolicy)
+	Albums []string
+
+	// Codec, Bitrate and Quality describe the stream actually downloaded, which may be
+	// of a lower quality than requested (see SetQualityFallback and SetStrictQuality)
+	Codec   string
+	Bitrate int
+	Quality ApiTrackQuality
+
+	// RequestedQuality is the quality requested from the API; Downgraded is set when the
+	// delivered stream is of a lower quality
+	RequestedQuality ApiTrackQuality
+	Downgraded       bool
+
+	// Size is the size of the saved file in bytes, including tags
+	Size int64
+
+	DurationMs int
+
+	// Fade holds the fade points of the track, e.g. for crossfading tracks of a continuous
+	// mix; zero when the API has none
+	Fade Fade
+
+	// Year, Genre and Label describe the album used for tags: the selected album, or the
+	// first album of the track when none is selected. Year is derived from the release date
+	// when the API has no year; Label lists the labels separated by commas.
+	Year  int
+	Genre string
+	Label string
+
+	// Explicit is set when the track is marked explicit; Disclaimers are its legal
+	// disclaimers, e.g. "modal"
+	Explicit    bool
+	Disclaimers []string
+
+	// Skipped is set when the track was already downloaded (see OverwriteSkip); only
+	// Path and TrackID are filled in then. It is also set for tracks rejected by the
+	// pre-download hook (see SetPreDownloadHook). SkipReason tells why the track was skipped.
+	Skipped    bool
+	SkipReason SkipReason
+}
+
+// SkipReason tells why a track was skipped instead of downloaded
+type SkipReason string
+
+// Reasons for skipping tracks
+const (
+	// SkipExisting - a file of the track is already in the output directory (see OverwriteSkip)
+	SkipExisting SkipReason = "existing"
+
+	// SkipArchived - the track is in the download archive (see SetArchive)
+	SkipArchived SkipReason = "archived"
+
+	// SkipDuplicate - another release of the same recording was already downloaded
+	SkipDuplicate SkipReason = "duplicate"
+
+	// SkipExplicit - the track is marked explicit (see SetSkipExplicit)
+	SkipExplicit SkipReason = "explicit"
+
+	// SkipRejected - the pre-download hook rejected the track (see SetPreDownloadHook)
+	SkipRejected SkipReason = "rejected"
+
+	// SkipLocked - another process is downloading the same file
+	SkipLocked SkipReason = "locked"
+)
+
+// skip marks the track of the result as skipped for the reason
+func (r *DownloadResult) skip(reason SkipReason) {
+	r.Skipped, r.SkipReason = true, reason
+}
+
+// TrackMeta describes a track streamed with DownloadTrackTo
+type TrackMeta struct {
+	TrackID string
+	Title   string
+	Artists []string
+	Albums  []string
+
+	Codec   string
+	Bitrate int
+	Quality ApiTrackQuality
+
+	// Size is the size of the audio in bytes as reported by the server; zero when unknown
+	Size int64
+
+	DurationMs int
+	Fade       Fade
+
+	Year  int
+	Genre string
+	Label string
+
+	Explicit    bool
+	Disclaimers []string
+
+	// ContentType is the MIME type of the audio, e.g. "audio/flac"
+	ContentType string
+
+	// FileName is the name DownloadTrack would save the track under
+	FileName string
+}
+
+// newTrackMeta describes a track about to be streamed
+func newTrackMeta(trackInfo *api.TrackInfo, downloadInfo *api.DownloadInfo, albumID string,
+	policy AlbumPolicy) *TrackMeta {
+	var result DownloadResult
+	result.describeTrack(trackInfo, albumID, policy)
+	result.describeDownload(downloadInfo)
+
+	return &TrackMeta{
+		TrackID:     trackInfo.ID,
+		Title:       result.Title,
+		Artists:     result.Artists,
+		Albums:      result.Albums,
+		Codec:       result.Codec,
+		Bitrate:     result.Bitrate,
+		Quality:     result.Quality,
+		Size:        int64(downloadInfo.Size),
+		DurationMs:  result.DurationMs,
+		Fade:        result.Fade,
+		Year:        result.Year,
+		Genre:       result.Genre,
+		Label:       result.Label,
+		Explicit:    result.Explicit,
+		Disclaimers: result.Disclaimers,
+		ContentType: downloadInfo.ContentType(),
+	}
+}
+
+// DownloadTrackResult downloads a track like DownloadTrack and describes the result
+func (c *Client) DownloadTrackResult(trackID string, quality AudioQuality, outputDir string) (*DownloadResult, error) {
+	return c.DownloadTrackResultContext(context.Background(), TrackRef{ID: trackID}, quality, outputDir)
+}
+
+// DownloadTrackResultContext downloads a track, named after the album of track.AlbumID
+// when given, and describes the result. The download is cancelled with ctx.
+func (c *Client) DownloadTrackResultContext(ctx context.Context, track TrackRef, quality AudioQuality,
+	outputDir string) (*DownloadResult, error) {
+	result, err := c.downloadTrack(ctx, track.ID, track.AlbumID, nil, quality, outputDir, c.logger)
+	if errors.Is(err, errExists) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// describeTrack fills in the metadata of a result from the track info
+func (r *DownloadResult) describeTrack(trackInfo *api.TrackInfo, albumID string, policy AlbumPolicy) {
+	r.Title = trackInfo.Title
+	r.DurationMs = trackInfo.DurationMs
+	r.Fade = trackInfo.Fade
+	r.Explicit = trackInfo.ContentWarning == contentExplicit
+	r.Disclaimers = trackInfo.Disclaimers
+
+	r.Artists = make([]string, 0, len(trackInfo.Artists))
+	for _, artist := range trackInfo.Artists {
+		r.Artists = append(r.Artists, artist.Name)
+	}
+
+	if album := singleAlbum(trackInfo, albumID, policy); album != nil {
+		r.Year, r.Genre, r.Label = albumYear(album), album.Genre, albumLabel(album)
+	}
+
+	if album := selectAlbum(trackInfo, albumID, policy); album != nil {
+		r.Albums = []string{album.Title}
+		return
+	}
+	r.Albums = make([]string, 0, len(trackInfo.Albums))
+	for _, album := range trackInfo.Albums {
+		if album.Title != "" {
+			r.Albums = append(r.Albums, album.Title)
+		}
+	}
+}
+
+// describeDownload fills in the stream details of a result from the download info
+func (r *DownloadResult) describeDownload(downloadInfo *api.DownloadInfo) {
+	r.Codec = downloadInfo.Codec
+	r.Bitrate = downloadInfo.Bitrate
+	r.Quality = ApiTrackQuality(downloadInfo.Quality)
+}
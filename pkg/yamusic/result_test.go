@@ -0,0 +1,67 @@
+package yamusic
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestDownloadTrackResult(t *testing.T) {
+	audio := []byte("fLaC result test audio")
+	client := newDownloadServer(t, "64551568", audio)
+	outputDir := t.TempDir()
+
+	result, err := client.DownloadTrackResult("64551568", QualityHigh, outputDir)
+	if err != nil {
+		t.Fatalf("DownloadTrackResult() error = %v", err)
+	}
+
+	info, err := os.Stat(result.Path)
+	if err != nil {
+		t.Fatalf("Downloaded file is missing: %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"TrackID", result.TrackID, "64551568"},
+		{"Title", result.Title, "Кукла колдуна"},
+		{"Artists", result.Artists[0], "Король и Шут"},
+		{"Codec", result.Codec, "flac"},
+		{"Quality", result.Quality, ApiTrackQuality("lossless")},
+		{"Size", result.Size, info.Size()},
+		{"DurationMs", result.DurationMs, 203460},
+		{"Fade", result.Fade, Fade{InStart: 0.4, InStop: 2.1, OutStart: 198.6, OutStop: 202.9}},
+		{"Year", result.Year, 1999},
+		{"Genre", result.Genre, "rusrock"},
+		{"Label", result.Label, "Никитин"},
+		{"Skipped", result.Skipped, false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+
+	if !slices.Contains(result.Albums, "Акустический альбом") {
+		t.Errorf("Albums = %v, want to contain %q", result.Albums, "Акустический альбом")
+	}
+
+	// The second download finds the file in place
+	client.SetOverwritePolicy(OverwriteSkip)
+	again, err := client.DownloadTrackResult("64551568", QualityHigh, outputDir)
+	if err != nil {
+		t.Fatalf("DownloadTrackResult() error = %v", err)
+	}
+	if !again.Skipped || again.Path != result.Path {
+		t.Errorf("Second download = {Path: %s, Skipped: %v}, want {Path: %s, Skipped: true}",
+			again.Path, again.Skipped, result.Path)
+	}
+}
@@ -0,0 +1,156 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// Retry defaults
+const (
+	// DefaultRetryAttempts is the default number of attempts for API and media requests
+	DefaultRetryAttempts = 3
+
+	// DefaultRetryDelay is the default delay before the first retry; it doubles with every attempt
+	DefaultRetryDelay = 500 * time.Millisecond
+
+	// maxRetryDelay caps both the backoff and the server-requested Retry-After delay
+	maxRetryDelay = time.Minute
+)
+
+// mediaStatusError is returned for CDN responses with a non-200 status
+type mediaStatusError struct {
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration
+}
+
+// newMediaStatusError returns the error of an unexpected media response status
+func newMediaStatusError(resp *http.Response) *mediaStatusError {
+	return &mediaStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// Error implements the error interface
+func (e *mediaStatusError) Error() string {
+	return fmt.Sprintf("error downloading file, status: %s", e.Status)
+}
+
+// Is reports CDN rate limiting as ErrRateLimited
+func (e *mediaStatusError) Is(target error) bool {
+	return target == ErrRateLimited && e.StatusCode == http.StatusTooManyRequests
+}
+
+// SetRetry configures retries of transient failures: attempts is the total number of
+// tries per request (1 disables retries), baseDelay the delay before the first retry.
+// Zero values restore the defaults.
+func (c *Client) SetRetry(attempts int, baseDelay time.Duration) {
+	if attempts <= 0 {
+		attempts = DefaultRetryAttempts
+	}
+	if baseDelay <= 0 {
+		baseDelay = DefaultRetryDelay
+	}
+	c.retryAttempts = attempts
+	c.retryDelay = baseDelay
+}
+
+// withRetry runs op until it succeeds, fails with a non-transient error or runs out of attempts.
+// Retries back off exponentially with jitter unless the server asked for a specific delay.
+func (c *Client) withRetry(ctx context.Context, log *logger.Logger, op func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= c.retryAttempts || ctx.Err() != nil || !isTransient(err) {
+			return err
+		}
+
+		delay := retryAfter(err)
+		if delay == 0 {
+			delay = backoff(c.retryDelay, attempt)
+		}
+		// Hitting the rate limit affects every request, not only this one
+		if errors.Is(err, ErrRateLimited) {
+			c.limiter.pause(delay)
+		}
+		log.Warn("Attempt %d of %d failed, retrying in %s: %v", attempt, c.retryAttempts, delay.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// isTransient reports whether a failed request may succeed when repeated:
+// 5xx and 429 responses and network-level errors, including timeouts.
+// Cancellation of the caller's context is checked separately by withRetry.
+func isTransient(err error) bool {
+	var apiErr *APIError
+	var mediaErr *mediaStatusError
+	switch {
+	case errors.As(err, &apiErr):
+		return retryableStatus(apiErr.Status)
+	case errors.As(err, &mediaErr):
+		return retryableStatus(mediaErr.StatusCode)
+	default:
+		return ErrorCategory(err) == CategoryNetwork
+	}
+}
+
+// retryableStatus reports whether a response status indicates a transient failure
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryAfter returns the delay requested by the server for a failed response, if any
+func retryAfter(err error) time.Duration {
+	var apiErr *APIError
+	var mediaErr *mediaStatusError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.RetryAfter
+	case errors.As(err, &mediaErr):
+		return mediaErr.RetryAfter
+	default:
+		return 0
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		delay = date.Sub(now)
+	}
+
+	return min(max(delay, 0), maxRetryDelay)
+}
+
+// backoff returns the delay before the retry following the given attempt:
+// base * 2^(attempt-1) plus up to 50% of jitter
+func backoff(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryDelay)
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
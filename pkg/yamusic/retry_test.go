@@ -0,0 +1,123 @@
+package yamusic
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestRetry checks which failures are retried and how many attempts are made
+func TestRetry(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		statuses []int
+		calls    int
+		wantErr  bool
+	}{
+		{"Success", []int{200}, 1, false},
+		{"Transient 503", []int{503, 503, 200}, 3, false},
+		{"Rate limited", []int{429, 200}, 2, false},
+		{"Attempts exhausted", []int{502, 502, 502, 200}, 3, true},
+		{"Not found is not retried", []int{404, 200}, 1, true},
+		{"Unauthorized is not retried", []int{401, 200}, 1, true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{
+				"/tracks/1": func(w http.ResponseWriter, r *http.Request) {
+					status := tt.statuses[min(calls, len(tt.statuses)-1)]
+					calls++
+					w.WriteHeader(status)
+					w.Write([]byte(`{"result":[{"id":"1"}]}`))
+				},
+			})
+			client.SetRetry(3, time.Millisecond)
+
+			_, err := client.GetTrackInfo("1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetTrackInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.calls {
+				t.Errorf("GetTrackInfo() made %d requests, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+// TestRetryMedia checks that a failed media request is retried before any audio is written
+func TestRetryMedia(t *testing.T) {
+	audio := []byte("fLaC retried audio")
+	client := newDownloadServer(t, "64551568", audio)
+	client.SetRetry(2, time.Millisecond)
+
+	failed := false
+	media := client.downloadClient.Transport
+	client.downloadClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if !failed {
+			failed = true
+			return &http.Response{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway",
+				Header: http.Header{}, Body: http.NoBody, Request: r}, nil
+		}
+		return media.RoundTrip(r)
+	})
+
+	if _, err := client.DownloadTrack("64551568", QualityHigh, t.TempDir()); err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+	if !failed {
+		t.Error("The media request was not intercepted")
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// TestParseRetryAfter checks both Retry-After formats
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Test cases
+	tests := []struct {
+		header   string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-3", 0},
+		{"3600", maxRetryDelay},
+		{"Mon, 01 Jan 2024 12:00:10 GMT", 10 * time.Second},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.expected {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.header, got, tt.expected)
+		}
+	}
+}
+
+// TestBackoff checks the exponential growth, the jitter bounds and the cap
+func TestBackoff(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt := 1; attempt <= 3; attempt++ {
+		low := base << (attempt - 1)
+		if got := backoff(base, attempt); got < low || got > low+low/2 {
+			t.Errorf("backoff(%v, %d) = %v, want between %v and %v", base, attempt, got, low, low+low/2)
+		}
+	}
+
+	if got := backoff(base, 100); got > maxRetryDelay+maxRetryDelay/2 {
+		t.Errorf("backoff(%v, 100) = %v, want capped at %v plus jitter", base, got, maxRetryDelay)
+	}
+}
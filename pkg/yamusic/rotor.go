@@ -0,0 +1,127 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// MyWaveStation is the rotor station of My Wave, the personalized stream of the account
+const MyWaveStation = "user:onyourwave"
+
+// maxStaleBatches is the number of batches in a row without new tracks after which the
+// station is considered exhausted
+const maxStaleBatches = 3
+
+// Rotor station feedback types
+const (
+	feedbackRadioStarted  = "radioStarted"
+	feedbackTrackFinished = "trackFinished"
+)
+
+// GetStationTracks retrieves up to count unique tracks of a rotor station, e.g.
+// MyWaveStation or "genre:rock", for downloading them as a playlist. Batches are requested
+// until count tracks are collected or the station stops serving new ones; the feedback
+// the rotor expects from a listening session is sent along. Unavailable tracks are kept
+// with Available unset, so callers can filter them.
+func (c *Client) GetStationTracks(stationID string, count int) ([]api.TrackInfo, error) {
+	return c.GetStationTracksContext(context.Background(), stationID, count)
+}
+
+// GetStationTracksContext retrieves station tracks; the requests are cancelled with ctx
+func (c *Client) GetStationTracksContext(ctx context.Context, stationID string, count int) ([]api.TrackInfo, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("invalid track count %d", count)
+	}
+
+	log := c.logger.WithField("station", stationID)
+	log.Debug("Getting station tracks")
+	station := "/rotor/station/" + url.PathEscape(stationID)
+
+	c.stationFeedback(ctx, station, "", api.StationFeedback{Type: feedbackRadioStarted, From: playAudioFrom}, log)
+
+	var tracks []api.TrackInfo
+	seen := make(map[string]bool)
+	lastID := ""
+	// Every batch but the stale ones adds a track, so the number of requests is bounded
+	for stale := 0; len(tracks) < count && stale < maxStaleBatches; {
+		query := url.Values{"settings2": {"true"}}
+		if lastID != "" {
+			query.Set("queue", lastID)
+		}
+		responseData, err := c.apiGet(ctx, station+"/tracks?"+query.Encode(), "/rotor/station/tracks", log)
+		if err != nil {
+			return nil, err
+		}
+
+		var response api.StationTracksResponse
+		if err := json.Unmarshal(responseData, &response); err != nil {
+			return nil, fmt.Errorf("response parsing error: %w", err)
+		}
+
+		added := 0
+		var last *api.TrackInfo
+		for _, item := range response.Result.Sequence {
+			if item.Type != "track" || item.Track.ID == "" {
+				continue
+			}
+			track := item.Track
+			last = &track
+			if seen[track.ID] || len(tracks) == count {
+				continue
+			}
+			seen[track.ID] = true
+			tracks = append(tracks, track)
+			added++
+		}
+		if last == nil {
+			break
+		}
+
+		// The rotor moves on once the last track of the batch was listened to
+		lastID = stationTrackID(last)
+		c.stationFeedback(ctx, station, response.Result.BatchID, api.StationFeedback{
+			Type:               feedbackTrackFinished,
+			TrackID:            lastID,
+			TotalPlayedSeconds: float64(last.DurationMs) / 1000,
+		}, log)
+
+		if added == 0 {
+			stale++
+		} else {
+			stale = 0
+		}
+		log.Debug("Station batch %s: %d new tracks, %d in total", response.Result.BatchID, added, len(tracks))
+	}
+
+	log.Debug("Station tracks: %d", len(tracks))
+	return tracks, nil
+}
+
+// stationFeedback sends a feedback event of a station session; failures are only logged,
+// as the rotor serves tracks without it too, just less varied
+func (c *Client) stationFeedback(ctx context.Context, station, batchID string, feedback api.StationFeedback,
+	log *logger.Logger) {
+	path := station + "/feedback"
+	if batchID != "" {
+		path += "?" + url.Values{"batch-id": {batchID}}.Encode()
+	}
+	feedback.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+
+	if _, err := c.apiPostJSON(ctx, path, "/rotor/station/feedback", feedback, log); err != nil {
+		log.Warn("Station feedback %s not sent: %v", feedback.Type, err)
+	}
+}
+
+// stationTrackID returns the "trackId:albumId" ID the rotor uses for a track
+func stationTrackID(track *api.TrackInfo) string {
+	if len(track.Albums) > 0 {
+		return track.ID + ":" + track.Albums[0].ID.String()
+	}
+	return track.ID
+}
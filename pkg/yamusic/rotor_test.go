@@ -0,0 +1,101 @@
+package yamusic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// newStationServer starts a fake rotor serving the given batches of track IDs in turn,
+// the last one repeatedly, and records the queue parameters and feedback types
+func newStationServer(t *testing.T, batches [][]string) (*Client, *[]string, *[]string) {
+	t.Helper()
+
+	var queues, feedback []string
+	requests := 0
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/rotor/station/user:onyourwave/tracks": func(w http.ResponseWriter, r *http.Request) {
+			queues = append(queues, r.URL.Query().Get("queue"))
+			batch := batches[min(requests, len(batches)-1)]
+			requests++
+
+			var items []string
+			for _, id := range batch {
+				items = append(items, fmt.Sprintf(`{"type":"track","track":{"id":%q,"available":true,`+
+					`"durationMs":1000,"albums":[{"id":7}]}}`, id))
+			}
+			fmt.Fprintf(w, `{"result":{"batchId":"b%d","sequence":[%s,{"type":"ad"}]}}`,
+				requests, strings.Join(items, ","))
+		},
+		"/rotor/station/user:onyourwave/feedback": func(w http.ResponseWriter, r *http.Request) {
+			var event api.StationFeedback
+			if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+				t.Errorf("Feedback body: %v", err)
+			}
+			feedback = append(feedback, event.Type+" "+event.TrackID+" "+r.URL.Query().Get("batch-id"))
+			w.Write([]byte(`{"result":"ok"}`))
+		},
+	})
+	return client, &queues, &feedback
+}
+
+// TestGetStationTracks checks that station batches are collected into unique tracks
+func TestGetStationTracks(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name     string
+		batches  [][]string
+		count    int
+		expected []string
+		queues   []string
+	}{
+		{
+			name:     "Count reached",
+			batches:  [][]string{{"1", "2"}, {"2", "3", "4"}},
+			count:    3,
+			expected: []string{"1", "2", "3"},
+			queues:   []string{"", "2:7"},
+		},
+		{
+			name:     "Station exhausted",
+			batches:  [][]string{{"1", "2"}, {"1"}},
+			count:    10,
+			expected: []string{"1", "2"},
+			queues:   []string{"", "2:7", "1:7", "1:7"},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, queues, feedback := newStationServer(t, tt.batches)
+
+			tracks, err := client.GetStationTracks(MyWaveStation, tt.count)
+			if err != nil {
+				t.Fatalf("GetStationTracks() error = %v", err)
+			}
+
+			var ids []string
+			for _, track := range tracks {
+				ids = append(ids, track.ID)
+			}
+			if !slices.Equal(ids, tt.expected) {
+				t.Errorf("Tracks = %v, want %v", ids, tt.expected)
+			}
+			if !slices.Equal(*queues, tt.queues) {
+				t.Errorf("Queue parameters = %q, want %q", *queues, tt.queues)
+			}
+
+			// The session starts once, then every batch is finished
+			if len(*feedback) != len(tt.queues)+1 || (*feedback)[0] != "radioStarted  " ||
+				!strings.HasPrefix((*feedback)[1], "trackFinished ") || !strings.HasSuffix((*feedback)[1], " b1") {
+				t.Errorf("Feedback = %q", *feedback)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// Search types
+const (
+	SearchAll      = "all"
+	SearchTrack    = "track"
+	SearchAlbum    = "album"
+	SearchArtist   = "artist"
+	SearchPlaylist = "playlist"
+)
+
+type (
+	// SearchResult is a page of search results; only the requested types are set
+	SearchResult = api.SearchResult
+
+	// Playlist represents playlist information
+	Playlist = api.Playlist
+)
+
+// Search searches the catalogue. searchType is one of the Search* constants; page is
+// zero-based. Every result type carries the total number of matches for pagination.
+func (c *Client) Search(query string, searchType string, page int) (*SearchResult, error) {
+	switch searchType {
+	case SearchAll, SearchTrack, SearchAlbum, SearchArtist, SearchPlaylist:
+	default:
+		return nil, fmt.Errorf("invalid search type %q (valid values: all, track, album, artist, playlist)", searchType)
+	}
+
+	log := c.logger.WithField("search_type", searchType)
+	log.Debug("Searching for %q, page %d", query, page)
+
+	params := url.Values{}
+	params.Set("text", query)
+	params.Set("type", searchType)
+	params.Set("page", strconv.Itoa(page))
+	params.Set("nocorrect", "false")
+
+	responseData, err := c.apiGet(context.Background(), "/search?"+params.Encode(), "/search", log)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.SearchResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	result := &response.Result
+	if result.MisspellCorrected {
+		log.Debug("Query corrected to %q", result.MisspellResult)
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package yamusic
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestSearch checks the query parameters and parsing of typed results
+func TestSearch(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/search": func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if query.Get("text") != "кукла колдуна" || query.Get("page") != "2" {
+				t.Errorf("Query = %v", query)
+			}
+			switch query.Get("type") {
+			case SearchTrack:
+				w.Write([]byte(`{"result":{"text":"кукла колдуна","type":"track","page":2,"perPage":10,` +
+					`"tracks":{"total":31,"perPage":10,"results":[{"id":"64551568","title":"Кукла колдуна",` +
+					`"artists":[{"id":41191,"name":"Король и Шут"}],"albums":[{"id":10376938,"title":"Акустический альбом"}]}]}}}`))
+			case SearchPlaylist:
+				w.Write([]byte(`{"result":{"type":"playlist","page":2,` +
+					`"playlists":{"total":1,"results":[{"uid":7,"kind":1003,"title":"Панк","trackCount":50,` +
+					`"owner":{"uid":7,"login":"user"}}]}}}`))
+			}
+		},
+	})
+
+	// Test cases
+	tests := []struct {
+		name       string
+		searchType string
+		total      int
+		first      string
+		wantErr    bool
+	}{
+		{"Tracks", SearchTrack, 31, "Кукла колдуна", false},
+		{"Playlists", SearchPlaylist, 1, "Панк", false},
+		{"Invalid type", "video", 0, "", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := client.Search("кукла колдуна", tt.searchType, 2)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Search() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var total int
+			var first string
+			switch tt.searchType {
+			case SearchTrack:
+				if result.Tracks == nil || result.Albums != nil {
+					t.Fatalf("Search() = %+v, want only tracks", result)
+				}
+				total, first = result.Tracks.Total, result.Tracks.Results[0].Title
+			case SearchPlaylist:
+				if result.Playlists == nil {
+					t.Fatalf("Search() = %+v, want playlists", result)
+				}
+				total, first = result.Playlists.Total, result.Playlists.Results[0].Title
+			}
+			if total != tt.total || first != tt.first {
+				t.Errorf("Search() = total %d, first %q, want %d, %q", total, first, tt.total, tt.first)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package yamusic
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+// SidecarFormat selects the format of the metadata files written next to downloads
+type SidecarFormat string
+
+const (
+	// SidecarNone - no metadata files (the default)
+	SidecarNone SidecarFormat = ""
+
+	// SidecarNFO - XML .nfo files as read by Kodi and Jellyfin
+	SidecarNFO SidecarFormat = "nfo"
+
+	// SidecarJSON - .json files
+	SidecarJSON SidecarFormat = "json"
+)
+
+// albumSidecarName is the base name of album metadata files
+const albumSidecarName = "album"
+
+// ParseSidecarFormat parses a metadata file format name: nfo, json or none
+func ParseSidecarFormat(value string) (SidecarFormat, error) {
+	switch format := SidecarFormat(value); format {
+	case SidecarNFO, SidecarJSON:
+		return format, nil
+	case "none", SidecarNone:
+		return SidecarNone, nil
+	default:
+		return "", fmt.Errorf("invalid metadata file format %q (valid values: nfo, json, none)", value)
+	}
+}
+
+// SetSidecarFormat makes downloads write a metadata file in format next to every track
+// (the audio file name with the .nfo or .json extension) and album downloads one per
+// album ("album.nfo" or "album.json"). SidecarNone disables them (the default).
+func (c *Client) SetSidecarFormat(format SidecarFormat) {
+	c.sidecar = format
+}
+
+// SidecarArtist is an artist in a metadata file
+type SidecarArtist struct {
+	ID   string `xml:"yandexId,attr,omitempty" json:"id,omitempty"`
+	Name string `xml:",chardata" json:"name"`
+}
+
+// TrackSidecar is the metadata file of a track. Durations are in seconds.
+type TrackSidecar struct {
+	XMLName xml.Name `xml:"song" json:"-"`
+
+	Title       string          `xml:"title" json:"title"`
+	Version     string          `xml:"version,omitempty" json:"version,omitempty"`
+	Artists     []SidecarArtist `xml:"artist" json:"artists"`
+	Album       string          `xml:"album,omitempty" json:"album,omitempty"`
+	AlbumArtist string          `xml:"albumartist,omitempty" json:"albumArtist,omitempty"`
+	Year        int             `xml:"year,omitempty" json:"year,omitempty"`
+	Genre       string          `xml:"genre,omitempty" json:"genre,omitempty"`
+	Duration    int             `xml:"duration" json:"duration"`
+	Track       int             `xml:"track,omitempty" json:"track,omitempty"`
+	Disc        int             `xml:"disc,omitempty" json:"disc,omitempty"`
+	Lyrics      bool            `xml:"lyrics" json:"lyrics"`
+	Explicit    bool            `xml:"explicit,omitempty" json:"explicit,omitempty"`
+
+	// TrackID is under "id" in JSON, where library verification looks for it (see VerifyLibrary)
+	TrackID string `xml:"yandexTrackId" json:"id"`
+	AlbumID string `xml:"yandexAlbumId,omitempty" json:"yandexAlbumId,omitempty"`
+	Cover   string `xml:"thumb,omitempty" json:"cover,omitempty"`
+}
+
+// AlbumSidecar is the metadata file of an album
+type AlbumSidecar struct {
+	XMLName xml.Name `xml:"album" json:"-"`
+
+	Title       string          `xml:"title" json:"title"`
+	Version     string          `xml:"version,omitempty" json:"version,omitempty"`
+	Artists     []SidecarArtist `xml:"artist" json:"artists"`
+	Year        int             `xml:"year,omitempty" json:"year,omitempty"`
+	ReleaseDate string          `xml:"releasedate,omitempty" json:"releaseDate,omitempty"`
+	Genre       string          `xml:"genre,omitempty" json:"genre,omitempty"`
+	Label       string          `xml:"label,omitempty" json:"label,omitempty"`
+
+	AlbumID string `xml:"yandexAlbumId" json:"yandexAlbumId"`
+	Cover   string `xml:"thumb,omitempty" json:"cover,omitempty"`
+
+	Tracks []AlbumSidecarTrack `xml:"track" json:"tracks"`
+}
+
+// AlbumSidecarTrack is a track in the metadata file of an album
+type AlbumSidecarTrack struct {
+	Disc     int    `xml:"disc" json:"disc"`
+	Position int    `xml:"position" json:"position"`
+	Title    string `xml:"title" json:"title"`
+	Duration int    `xml:"duration" json:"duration"`
+	TrackID  string `xml:"yandexTrackId" json:"yandexTrackId"`
+}
+
+// newTrackSidecar describes a track for its metadata file; the album fields come from the
+// album used for tags
+func (c *Client) newTrackSidecar(trackInfo *api.TrackInfo, albumID string) *TrackSidecar {
+	sidecar := &TrackSidecar{
+		Title:    trackInfo.Title,
+		Version:  trackInfo.Version,
+		Artists:  sidecarArtists(trackInfo.Artists),
+		Duration: trackInfo.DurationMs / 1000,
+		Lyrics: trackInfo.LyricsAvailable || trackInfo.LyricsInfo.HasAvailableSyncLyrics ||
+			trackInfo.LyricsInfo.HasAvailableTextLyrics,
+		Explicit: trackInfo.ContentWarning == contentExplicit,
+		TrackID:  trackInfo.ID,
+	}
+
+	uri := trackInfo.CoverUri
+	if album := singleAlbum(trackInfo, albumID, c.albumPolicy); album != nil {
+		numbering, _ := c.numbering(trackInfo, album)
+		sidecar.Album = album.Title
+		sidecar.AlbumArtist = joinArtists(album.Artists)
+		sidecar.Year = albumYear(album)
+		sidecar.Genre = album.Genre
+		sidecar.Track, sidecar.Disc = numbering.Index, numbering.Volume
+		sidecar.AlbumID = album.ID.String()
+		if albumCoverURI(album) != "" {
+			uri = albumCoverURI(album)
+		}
+	}
+	if uri == "" {
+		uri = trackInfo.OgImage
+	}
+	if uri != "" {
+		sidecar.Cover = coverURL(uri, c.sidecarCoverSize())
+	}
+	return sidecar
+}
+
+// newAlbumSidecar describes an album fetched with GetAlbum for its metadata file
+func (c *Client) newAlbumSidecar(album *api.Album) *AlbumSidecar {
+	sidecar := &AlbumSidecar{
+		Title:   album.Title,
+		Version: album.Version,
+		Artists: sidecarArtists(album.Artists),
+		Year:    albumYear(album),
+		Genre:   album.Genre,
+		Label:   albumLabel(album),
+		AlbumID: album.ID.String(),
+		Tracks:  []AlbumSidecarTrack{},
+	}
+	// The release date comes as a timestamp; keep the date part
+	sidecar.ReleaseDate, _, _ = strings.Cut(album.ReleaseDate, "T")
+	if uri := albumCoverURI(album); uri != "" {
+		sidecar.Cover = coverURL(uri, c.sidecarCoverSize())
+	}
+
+	for v, volume := range album.Volumes {
+		for i, track := range volume {
+			sidecar.Tracks = append(sidecar.Tracks, AlbumSidecarTrack{
+				Disc:     v + 1,
+				Position: i + 1,
+				Title:    track.Title,
+				Duration: track.DurationMs / 1000,
+				TrackID:  track.ID,
+			})
+		}
+	}
+	return sidecar
+}
+
+// sidecarArtists lists artists with their IDs
+func sidecarArtists(artists []api.Artist) []SidecarArtist {
+	result := make([]SidecarArtist, 0, len(artists))
+	for _, artist := range artists {
+		if artist.Name != "" {
+			result = append(result, SidecarArtist{ID: artist.ID.String(), Name: artist.Name})
+		}
+	}
+	return result
+}
+
+// sidecarCoverSize returns the cover size linked from metadata files: the configured size
+// of saved covers or DefaultCoverSize
+func (c *Client) sidecarCoverSize() string {
+	if c.coverSize != "" {
+		return c.coverSize
+	}
+	return DefaultCoverSize
+}
+
+// WriteAlbumSidecar writes the metadata file of an album fetched with GetAlbum into
+// outputDir in the configured format (see SetSidecarFormat) and returns its path; nothing
+// is written, and the path is empty, when metadata files are disabled
+func (c *Client) WriteAlbumSidecar(album *Album, outputDir string) (string, error) {
+	if c.sidecar == SidecarNone {
+		return "", nil
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating directory: %w", err)
+	}
+	path := filepath.Join(outputDir, albumSidecarName+"."+string(c.sidecar))
+	if err := c.saveSidecar(path, c.newAlbumSidecar(album)); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// writeTrackSidecar writes the metadata file of a downloaded track next to it. Problems
+// don't fail the download: the audio is already saved, so they are only reported.
+func (c *Client) writeTrackSidecar(audioPath string, trackInfo *api.TrackInfo, albumID string, log *logger.Logger) {
+	path := strings.TrimSuffix(audioPath, filepath.Ext(audioPath)) + "." + string(c.sidecar)
+	if err := c.saveSidecar(path, c.newTrackSidecar(trackInfo, albumID)); err != nil {
+		log.Warn("%v", err)
+		return
+	}
+	log.Debug("Metadata file saved: %s", path)
+}
+
+// saveSidecar encodes a metadata file in the configured format and writes it atomically:
+// the data is written to a temporary file that replaces the target only when complete
+func (c *Client) saveSidecar(path string, sidecar interface{}) error {
+	var data []byte
+	var err error
+	switch c.sidecar {
+	case SidecarNFO:
+		data, err = xml.MarshalIndent(sidecar, "", "  ")
+		data = append([]byte(xml.Header), append(data, '\n')...)
+	default:
+		data, err = json.MarshalIndent(sidecar, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("error encoding metadata file: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("error saving metadata file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package yamusic
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseSidecarFormat checks parsing of metadata file format names
+func TestParseSidecarFormat(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		value   string
+		want    SidecarFormat
+		wantErr bool
+	}{
+		{"nfo", SidecarNFO, false},
+		{"json", SidecarJSON, false},
+		{"none", SidecarNone, false},
+		{"", SidecarNone, false},
+		{"xml", "", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSidecarFormat(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSidecarFormat(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSidecarFormat(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTrackSidecar checks the metadata file written next to a downloaded track
+func TestTrackSidecar(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name   string
+		format SidecarFormat
+		decode func([]byte, interface{}) error
+	}{
+		{"NFO", SidecarNFO, xml.Unmarshal},
+		{"JSON", SidecarJSON, json.Unmarshal},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newDownloadServerWith(t, "64551568", testFLAC(1), nil)
+			client.SetSidecarFormat(tt.format)
+
+			result, err := client.DownloadTrackResult("64551568", QualityHigh, t.TempDir())
+			if err != nil {
+				t.Fatalf("DownloadTrackResult() error = %v", err)
+			}
+
+			path := strings.TrimSuffix(result.Path, filepath.Ext(result.Path)) + "." + string(tt.format)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Metadata file not written: %v", err)
+			}
+			var sidecar TrackSidecar
+			if err := tt.decode(data, &sidecar); err != nil {
+				t.Fatalf("Metadata file not decoded: %v", err)
+			}
+
+			if sidecar.Title != "Кукла колдуна" || sidecar.TrackID != "64551568" {
+				t.Errorf("Title = %q, TrackID = %q", sidecar.Title, sidecar.TrackID)
+			}
+			if len(sidecar.Artists) != 2 || sidecar.Artists[0] != (SidecarArtist{ID: "41191", Name: "Король и Шут"}) {
+				t.Errorf("Artists = %+v", sidecar.Artists)
+			}
+			if sidecar.Album != "Акустический альбом" || sidecar.AlbumID != "10376938" || sidecar.Year != 1999 {
+				t.Errorf("Album = %q, AlbumID = %q, Year = %d", sidecar.Album, sidecar.AlbumID, sidecar.Year)
+			}
+			if sidecar.Track != 3 || sidecar.Disc != 1 || sidecar.Duration != 203 || !sidecar.Lyrics {
+				t.Errorf("Track = %d, Disc = %d, Duration = %d, Lyrics = %v",
+					sidecar.Track, sidecar.Disc, sidecar.Duration, sidecar.Lyrics)
+			}
+			if !strings.HasPrefix(sidecar.Cover, "https://avatars.yandex.net/") || !strings.HasSuffix(sidecar.Cover, "/"+DefaultCoverSize) {
+				t.Errorf("Cover = %q", sidecar.Cover)
+			}
+
+			// No temporary files are left behind
+			entries, _ := os.ReadDir(filepath.Dir(result.Path))
+			if len(entries) != 2 {
+				t.Errorf("Output directory has %d files, want the track and its metadata file", len(entries))
+			}
+		})
+	}
+}
+
+// TestWriteAlbumSidecar checks the album metadata file and that it is off by default
+func TestWriteAlbumSidecar(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/albums/10376938/with-tracks": serveFixture(t, "album.json"),
+	})
+	album, err := client.GetAlbum("10376938")
+	if err != nil {
+		t.Fatalf("GetAlbum() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if path, err := client.WriteAlbumSidecar(album, dir); err != nil || path != "" {
+		t.Fatalf("WriteAlbumSidecar() = %q, %v, want nothing written by default", path, err)
+	}
+
+	client.SetSidecarFormat(SidecarNFO)
+	path, err := client.WriteAlbumSidecar(album, dir)
+	if err != nil {
+		t.Fatalf("WriteAlbumSidecar() error = %v", err)
+	}
+	if filepath.Base(path) != "album.nfo" {
+		t.Errorf("Path = %q, want album.nfo", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Metadata file not written: %v", err)
+	}
+	var sidecar AlbumSidecar
+	if err := xml.Unmarshal(data, &sidecar); err != nil {
+		t.Fatalf("Metadata file not decoded: %v", err)
+	}
+	if sidecar.Title != "Акустический альбом" || sidecar.AlbumID != "10376938" || sidecar.ReleaseDate != "1999-01-01" {
+		t.Errorf("Title = %q, AlbumID = %q, ReleaseDate = %q", sidecar.Title, sidecar.AlbumID, sidecar.ReleaseDate)
+	}
+	if len(sidecar.Tracks) != 4 {
+		t.Fatalf("Tracks = %d, want 4", len(sidecar.Tracks))
+	}
+	if first := sidecar.Tracks[0]; first.Disc != 1 || first.Position != 1 || first.TrackID != "64551564" {
+		t.Errorf("First track = %+v", first)
+	}
+	if last := sidecar.Tracks[3]; last.Disc != 2 || last.Position != 2 {
+		t.Errorf("Last track = %+v", last)
+	}
+}
+
+// TestTrackSidecarVerifyID checks that library verification finds the track ID in a JSON
+// metadata file of a track named without the ID
+func TestTrackSidecarVerifyID(t *testing.T) {
+	client := NewClient("test-token", "", nil)
+	client.SetSidecarFormat(SidecarJSON)
+
+	audioPath := filepath.Join(t.TempDir(), "Король и Шут - Кукла колдуна.flac")
+	client.writeTrackSidecar(audioPath, &TrackInfo{ID: "64551568", Title: "Кукла колдуна"}, "", client.logger)
+
+	if got := fileTrackID(audioPath); got != "64551568" {
+		t.Errorf("fileTrackID() = %q, want %q", got, "64551568")
+	}
+}
@@ -0,0 +1,74 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// ErrInvalidSign is matched by errors of signed requests rejected because of the signature,
+// typically after the sign key was rotated
+var ErrInvalidSign = errors.New("invalid signature")
+
+// SetSignKeys sets the candidate keys used to sign requests, in order of preference.
+// A request rejected with ErrInvalidSign is repeated with the next candidates; the first
+// key that works is used for the rest of the session.
+func (c *Client) SetSignKeys(keys ...string) error {
+	var candidates []string
+	for _, key := range keys {
+		if key != "" {
+			candidates = append(candidates, key)
+		}
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("no sign keys given")
+	}
+
+	c.signMu.Lock()
+	c.signKeys = candidates
+	c.signMu.Unlock()
+	return nil
+}
+
+// currentSignKeys returns the candidate sign keys, the one that worked last first
+func (c *Client) currentSignKeys() []string {
+	c.signMu.Lock()
+	defer c.signMu.Unlock()
+	return c.signKeys
+}
+
+// withSignKey performs a signed request, trying the candidate sign keys in turn while the
+// signature is rejected, and remembers the key that worked
+func (c *Client) withSignKey(ctx context.Context, log *logger.Logger, request func(signKey string) error) error {
+	keys := c.currentSignKeys()
+
+	var err error
+	for i, key := range keys {
+		err = request(key)
+		if !errors.Is(err, ErrInvalidSign) || ctx.Err() != nil {
+			if err == nil && i > 0 {
+				log.Warn("Sign key rejected, switched to candidate key %d of %d", i+1, len(keys))
+				c.preferSignKey(key)
+			}
+			return err
+		}
+		log.Debug("Sign key %d of %d rejected: %v", i+1, len(keys), err)
+	}
+	return err
+}
+
+// preferSignKey moves a candidate sign key to the front
+func (c *Client) preferSignKey(key string) {
+	c.signMu.Lock()
+	defer c.signMu.Unlock()
+
+	keys := []string{key}
+	for _, candidate := range c.signKeys {
+		if candidate != key {
+			keys = append(keys, candidate)
+		}
+	}
+	c.signKeys = keys
+}
@@ -0,0 +1,76 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+)
+
+// TestSignKeys checks that rejected sign keys are replaced by the first candidate that works
+// and that unrelated errors don't make the client try other keys
+func TestSignKeys(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name         string
+		keys         []string
+		serverKey    string
+		wantErr      error
+		wantRequests int
+	}{
+		{"Current key", []string{"new", "old"}, "new", nil, 1},
+		{"Rotated key", []string{"old", "older", "new"}, "new", nil, 3},
+		{"No valid key", []string{"old", "older"}, "new", ErrInvalidSign, 2},
+		{"Unrelated error", []string{"old", "new"}, "", ErrTrackNotFound, 1},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			client, _ := newTestServer(t, map[string]http.HandlerFunc{
+				"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+					requests++
+					if tt.serverKey == "" {
+						w.WriteHeader(http.StatusNotFound)
+						w.Write([]byte(`{"error":{"name":"not-found","message":"Track not found"}}`))
+						return
+					}
+
+					query := r.URL.Query()
+					expected := crypto.GenerateSignatureFromParams(query.Get("ts"), query.Get("trackId"),
+						query.Get("quality"), query.Get("codecs"), query.Get("transports"), tt.serverKey)
+					if query.Get("sign") != expected {
+						w.WriteHeader(http.StatusBadRequest)
+						w.Write([]byte(`{"error":{"name":"bad-request","message":"Invalid sign"}}`))
+						return
+					}
+					fmt.Fprintf(w, `{"result":{"downloadInfo":{"codec":"flac","key":%q,"url":"http://media/1"}}}`, testDecryptionKey)
+				},
+			})
+			client.SetRetry(1, 0)
+			client.SetQualityFallback(false)
+			if err := client.SetSignKeys(tt.keys...); err != nil {
+				t.Fatalf("SetSignKeys() error = %v", err)
+			}
+
+			_, err := client.GetDownloadInfo("1", api.QualityLossless)
+			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("GetDownloadInfo() error = %v, want %v", err, tt.wantErr)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Requests = %d, want %d", requests, tt.wantRequests)
+			}
+
+			// The working key is remembered for the next requests
+			if tt.wantErr == nil {
+				if got := client.currentSignKeys()[0]; got != tt.serverKey {
+					t.Errorf("Current sign key = %q, want %q", got, tt.serverKey)
+				}
+			}
+		})
+	}
+}
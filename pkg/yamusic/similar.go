@@ -0,0 +1,37 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// GetSimilarTracks retrieves the tracks similar to a track, most similar first.
+// Unavailable suggestions are kept with Available unset, so callers can filter them.
+func (c *Client) GetSimilarTracks(trackID string) ([]api.TrackInfo, error) {
+	return c.GetSimilarTracksContext(context.Background(), trackID)
+}
+
+// GetSimilarTracksContext retrieves similar tracks; the request is cancelled with ctx
+func (c *Client) GetSimilarTracksContext(ctx context.Context, trackID string) ([]api.TrackInfo, error) {
+	trackID, _ = splitTrackRef(trackID, "")
+	log := c.logger.WithField("track_id", trackID)
+	log.Debug("Getting similar tracks")
+
+	path := fmt.Sprintf("/tracks/%s/similar", url.PathEscape(trackID))
+	responseData, err := c.apiGet(ctx, path, "/tracks/similar", log)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.SimilarTracksResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, fmt.Errorf("response parsing error: %w", err)
+	}
+
+	log.Debug("Similar tracks: %d", len(response.Result.SimilarTracks))
+	return response.Result.SimilarTracks, nil
+}
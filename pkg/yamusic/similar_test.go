@@ -0,0 +1,46 @@
+package yamusic
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestGetSimilarTracks checks that similar tracks are unwrapped with their availability
+func TestGetSimilarTracks(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568/similar": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":{"track":{"id":"64551568","title":"Кукла колдуна"},"similarTracks":[` +
+				`{"id":"1","title":"First","available":true,"albums":[{"id":10,"title":"A"}]},` +
+				`{"id":"2","title":"Second","available":false}]}}`))
+		},
+	})
+
+	// The album part of a composite ID is not sent
+	tracks, err := client.GetSimilarTracks("64551568:10376938")
+	if err != nil {
+		t.Fatalf("GetSimilarTracks() error = %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		id        string
+		title     string
+		available bool
+	}{
+		{"1", "First", true},
+		{"2", "Second", false},
+	}
+
+	if len(tracks) != len(tests) {
+		t.Fatalf("GetSimilarTracks() returned %d tracks, want %d", len(tracks), len(tests))
+	}
+
+	// Run tests
+	for i, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := tracks[i]; got.ID != tt.id || got.Title != tt.title || got.Available != tt.available {
+				t.Errorf("Track = {%s %s %v}, want {%s %s %v}", got.ID, got.Title, got.Available, tt.id, tt.title, tt.available)
+			}
+		})
+	}
+}
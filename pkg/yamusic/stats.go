@@ -0,0 +1,124 @@
+package yamusic
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+)
+
+// DefaultSlowCallThreshold is the duration above which an API call is reported as slow
+const DefaultSlowCallThreshold = 3 * time.Second
+
+// EndpointStats aggregates timings of API calls to a single endpoint
+type EndpointStats struct {
+	Endpoint string
+	Calls    int
+	Slow     int
+
+	// Wall-clock time measured by the client, including network transfer
+	TotalWall time.Duration
+	MaxWall   time.Duration
+
+	// Execution time reported by the API in InvocationInfo
+	TotalServer time.Duration
+	MaxServer   time.Duration
+}
+
+// Network returns the average time spent outside the API server (network, queueing)
+func (s EndpointStats) Network() time.Duration {
+	if s.Calls == 0 || s.TotalWall < s.TotalServer {
+		return 0
+	}
+	return (s.TotalWall - s.TotalServer) / time.Duration(s.Calls)
+}
+
+// apiStats collects per-endpoint call timings
+type apiStats struct {
+	mu        sync.Mutex
+	endpoints map[string]*EndpointStats
+}
+
+// invocationEnvelope is used to decode only the invocation info from any API response
+type invocationEnvelope struct {
+	InvocationInfo api.InvocationInfo `json:"invocationInfo"`
+}
+
+// withReqID adds the request ID of an API response to the log fields, so that problems
+// can be reported with it
+func withReqID(log *logger.Logger, reqID string) *logger.Logger {
+	if reqID == "" {
+		return log
+	}
+	return log.WithField("req_id", reqID)
+}
+
+// recordCall decodes the invocation info from the response body, records the timings
+// and warns when either the wall-clock or the server-reported duration is too long.
+// The messages carry the request ID of the response.
+func (c *Client) recordCall(endpoint string, wall time.Duration, body []byte, log *logger.Logger) api.InvocationInfo {
+	var envelope invocationEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		log.Debug("Could not decode invocation info: %v", err)
+	}
+	info := envelope.InvocationInfo
+	server := time.Duration(info.ExecDurationMillis) * time.Millisecond
+	log = withReqID(log, info.ReqID)
+
+	slow := wall > c.slowThreshold || server > c.slowThreshold
+	if slow {
+		log.Warn("Slow API call %s: total %s, server %s, network %s",
+			endpoint, wall.Round(time.Millisecond), server, (wall - server).Round(time.Millisecond))
+	} else {
+		log.Debug("API call %s: total %s, server %s", endpoint, wall.Round(time.Millisecond), server)
+	}
+
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+
+	if c.stats.endpoints == nil {
+		c.stats.endpoints = make(map[string]*EndpointStats)
+	}
+	s, ok := c.stats.endpoints[endpoint]
+	if !ok {
+		s = &EndpointStats{Endpoint: endpoint}
+		c.stats.endpoints[endpoint] = s
+	}
+
+	s.Calls++
+	if slow {
+		s.Slow++
+	}
+	s.TotalWall += wall
+	s.TotalServer += server
+	if wall > s.MaxWall {
+		s.MaxWall = wall
+	}
+	if server > s.MaxServer {
+		s.MaxServer = server
+	}
+
+	return info
+}
+
+// SetSlowCallThreshold sets the duration above which API calls are reported as slow
+func (c *Client) SetSlowCallThreshold(threshold time.Duration) {
+	c.slowThreshold = threshold
+}
+
+// Stats returns a snapshot of API call timings, sorted by endpoint
+func (c *Client) Stats() []EndpointStats {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+
+	result := make([]EndpointStats, 0, len(c.stats.endpoints))
+	for _, s := range c.stats.endpoints {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Endpoint < result[j].Endpoint })
+
+	return result
+}
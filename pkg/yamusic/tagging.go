@@ -0,0 +1,166 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/tags"
+)
+
+// SetStandardTags enables or disables the standard tags (title, artists, album, track
+// and disc numbers, date, genre, content advisory, ReplayGain) written into downloaded
+// files. They are enabled by default; disable them when the files are tagged by another tool.
+func (c *Client) SetStandardTags(enabled bool) {
+	c.noStandardTags = !enabled
+}
+
+// SetExtraTags enables or disables the Yandex-specific tags (fade points) and the gapless
+// info of AAC files (iTunSMPB) written into downloaded files. They are enabled by default.
+func (c *Client) SetExtraTags(enabled bool) {
+	c.noExtraTags = !enabled
+}
+
+// fadeTags returns the fade-in/fade-out points of a track as custom tags (seconds)
+func fadeTags(fade api.Fade) tags.Tags {
+	if fade == (api.Fade{}) {
+		return nil
+	}
+
+	format := func(seconds float64) string {
+		return strconv.FormatFloat(seconds, 'f', 3, 64)
+	}
+
+	return tags.Tags{
+		{Key: "YANDEX_FADE_IN_START", Value: format(fade.InStart)},
+		{Key: "YANDEX_FADE_IN_STOP", Value: format(fade.InStop)},
+		{Key: "YANDEX_FADE_OUT_START", Value: format(fade.OutStart)},
+		{Key: "YANDEX_FADE_OUT_STOP", Value: format(fade.OutStop)},
+	}
+}
+
+// gaplessTags returns the iTunSMPB tag of AAC files, derived from the edit list the file
+// already has, so that players trim the encoder priming and padding between tracks
+func gaplessTags(path string, log *logger.Logger) tags.Tags {
+	gapless, ok, err := tags.ReadGapless(path)
+	if err != nil || !ok {
+		return nil
+	}
+
+	log.Debug("Gapless info: %d priming, %d padding, %d samples", gapless.Delay, gapless.Padding, gapless.Samples)
+	return tags.Tags{{Key: tags.KeyITunSMPB, Value: gapless.ITunSMPB()}}
+}
+
+// replayGainReference is the loudness ReplayGain 2.0 normalizes to, in LUFS
+const replayGainReference = -18.0
+
+// loudnessTags returns the ReplayGain track gain and peak computed from the EBU R128
+// loudness of a track: the integrated loudness in LUFS and the true peak in dBTP
+func loudnessTags(r128 api.R128) tags.Tags {
+	if r128 == (api.R128{}) {
+		return nil
+	}
+
+	gain := replayGainReference - r128.I
+	peak := math.Pow(10, r128.Tp/20)
+
+	return tags.Tags{
+		{Key: "REPLAYGAIN_TRACK_GAIN", Value: fmt.Sprintf("%.2f dB", gain)},
+		{Key: "REPLAYGAIN_TRACK_PEAK", Value: strconv.FormatFloat(peak, 'f', 6, 64)},
+	}
+}
+
+// joinArtists joins artist names with " & ", skipping empty names
+func joinArtists(artists []api.Artist) string {
+	names := make([]string, 0, len(artists))
+	for _, a := range artists {
+		if a.Name != "" {
+			names = append(names, a.Name)
+		}
+	}
+	return strings.Join(names, " & ")
+}
+
+// standardTags returns the title, artist and album tags of a track. The album fields
+// come from the album selected for naming; the track and disc numbers from numbering,
+// with the totals when known.
+func standardTags(trackInfo *api.TrackInfo, album *api.Album, numbering trackNumbering) tags.Tags {
+	var result tags.Tags
+	add := func(key, value string) {
+		if value != "" {
+			result = append(result, tags.Tag{Key: key, Value: value})
+		}
+	}
+
+	add(tags.KeyTitle, trackInfo.Title)
+	add(tags.KeyArtist, joinArtists(trackInfo.Artists))
+	add(tags.KeyAdvisory, advisory(trackInfo))
+	if album == nil {
+		return result
+	}
+
+	add(tags.KeyAlbum, album.Title)
+	add(tags.KeyAlbumArtist, joinArtists(album.Artists))
+	add(tags.KeyTrackNumber, numbering.trackTag())
+	add(tags.KeyDiscNumber, numbering.discTag())
+
+	// The release date comes as a timestamp; keep the date part
+	if date, _, _ := strings.Cut(album.ReleaseDate, "T"); date != "" {
+		add(tags.KeyDate, date)
+	} else if album.Year > 0 {
+		add(tags.KeyDate, strconv.Itoa(album.Year))
+	}
+	add(tags.KeyGenre, album.Genre)
+	add(tags.KeyLabel, albumLabel(album))
+
+	return result
+}
+
+// trackTags collects the tags to write for a track
+func (c *Client) trackTags(trackInfo *api.TrackInfo, albumID string) tags.Tags {
+	var result tags.Tags
+	if !c.noStandardTags {
+		// A tag holds a single album; without a policy take the first one
+		album := singleAlbum(trackInfo, albumID, c.albumPolicy)
+		var numbering trackNumbering
+		if album != nil {
+			numbering, _ = c.numbering(trackInfo, album)
+		}
+		result = append(result, standardTags(trackInfo, album, numbering)...)
+		result = append(result, loudnessTags(trackInfo.R128)...)
+	}
+	if !c.noExtraTags {
+		result = append(result, fadeTags(trackInfo.Fade)...)
+	}
+	return result
+}
+
+// writeTags writes the track tags, and the lyrics when embedding is enabled, into the
+// downloaded file. Tagging problems don't fail the download: the audio is already saved,
+// so they are only reported.
+// The container is rewritten box by box, so edit lists and gapless info are kept as is.
+func (c *Client) writeTags(path string, trackInfo *api.TrackInfo, albumID, lyrics string, log *logger.Logger) {
+	trackTags := c.trackTags(trackInfo, albumID)
+	if c.embedLyrics && lyrics != "" {
+		trackTags = append(trackTags, tags.Tag{Key: tags.KeyLyrics, Value: lyrics})
+	}
+	if !c.noExtraTags {
+		trackTags = append(trackTags, gaplessTags(path, log)...)
+	}
+	if len(trackTags) == 0 {
+		return
+	}
+
+	log.Debug("Writing %d tags", len(trackTags))
+	err := tags.WriteFile(path, trackTags)
+	switch {
+	case errors.Is(err, tags.ErrUnsupported):
+		log.Warn("Tags not written: %v", err)
+	case err != nil:
+		log.Warn("Error writing tags: %v", err)
+	}
+}
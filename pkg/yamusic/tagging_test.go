@@ -0,0 +1,88 @@
+package yamusic
+
+import (
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/tags"
+)
+
+// TestTrackTags checks the standard tags taken from the track and the selected album
+func TestTrackTags(t *testing.T) {
+	trackInfo := &api.TrackInfo{
+		Title:   "Лесник",
+		Artists: []api.Artist{{Name: "Король и Шут"}, {Name: ""}},
+		Albums: []api.Album{
+			{ID: "1", Title: "Камнем по голове", Year: 1996, Genre: "rusrock",
+				Artists: []api.Artist{{Name: "Король и Шут"}}, TrackPosition: api.TrackPosition{Volume: 1, Index: 3}},
+			{ID: "2", Title: "Лучшее", ReleaseDate: "2013-05-20T00:00:00+04:00",
+				TrackPosition: api.TrackPosition{Volume: 2, Index: 7}},
+		},
+	}
+
+	// Test cases
+	tests := []struct {
+		name     string
+		albumID  string
+		disabled bool
+		expected map[string]string
+	}{
+		{"First album without a policy", "", false, map[string]string{
+			tags.KeyTitle: "Лесник", tags.KeyArtist: "Король и Шут", tags.KeyAlbum: "Камнем по голове",
+			tags.KeyAlbumArtist: "Король и Шут", tags.KeyTrackNumber: "3", tags.KeyDiscNumber: "1",
+			tags.KeyDate: "1996", tags.KeyGenre: "rusrock",
+		}},
+		{"Album from the input", "2", false, map[string]string{
+			tags.KeyTitle: "Лесник", tags.KeyArtist: "Король и Шут", tags.KeyAlbum: "Лучшее",
+			tags.KeyTrackNumber: "7", tags.KeyDiscNumber: "2", tags.KeyDate: "2013-05-20",
+		}},
+		{"Disabled", "", true, map[string]string{}},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("token", "", nil)
+			client.SetExtraTags(false)
+			client.SetStandardTags(!tt.disabled)
+
+			got := client.trackTags(trackInfo, tt.albumID)
+			if len(got) != len(tt.expected) {
+				t.Errorf("trackTags() = %v, want %d tags", got, len(tt.expected))
+			}
+			for key, value := range tt.expected {
+				if v, _ := got.Get(key); v != value {
+					t.Errorf("Tag %s = %q, want %q", key, v, value)
+				}
+			}
+		})
+	}
+}
+
+// TestLoudnessTags checks the ReplayGain tags computed from the R128 loudness
+func TestLoudnessTags(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name string
+		r128 api.R128
+		gain string
+		peak string
+	}{
+		{"Loud track", api.R128{I: -9.05, Tp: 0.21}, "-8.95 dB", "1.024472"},
+		{"Quiet track", api.R128{I: -23, Tp: -6.02}, "5.00 dB", "0.500035"},
+		{"Missing", api.R128{}, "", ""},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := loudnessTags(tt.r128)
+			if gain, _ := got.Get("REPLAYGAIN_TRACK_GAIN"); gain != tt.gain {
+				t.Errorf("REPLAYGAIN_TRACK_GAIN = %q, want %q", gain, tt.gain)
+			}
+			if peak, _ := got.Get("REPLAYGAIN_TRACK_PEAK"); peak != tt.peak {
+				t.Errorf("REPLAYGAIN_TRACK_PEAK = %q, want %q", peak, tt.peak)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package yamusic
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	// DefaultAPITimeout is the default time limit of an API call, including reading the response
+	DefaultAPITimeout = 15 * time.Second
+
+	// DefaultStallTimeout is the default time a media transfer may go without receiving data
+	DefaultStallTimeout = 60 * time.Second
+)
+
+// ErrStalled is returned when a media transfer receives no data for the stall timeout
+var ErrStalled = errors.New("transfer stalled")
+
+// SetTimeouts sets the time limit of API calls and the time a media transfer may go
+// without receiving data before it is aborted with ErrStalled. Media transfers have no
+// overall time limit, so large files on slow connections complete as long as data keeps
+// coming. Zero disables the respective timeout.
+func (c *Client) SetTimeouts(apiTimeout, stallTimeout time.Duration) {
+	c.httpClient.Timeout = apiTimeout
+	c.stallTimeout = stallTimeout
+}
+
+// stallReader calls a function when no data was read from r for the timeout
+type stallReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+// newStallReader starts the timer calling stalled unless data is read from r in time
+func newStallReader(r io.Reader, timeout time.Duration, stalled func()) *stallReader {
+	return &stallReader{r: r, timer: time.AfterFunc(timeout, stalled), timeout: timeout}
+}
+
+// Read implements io.Reader
+func (sr *stallReader) Read(p []byte) (int, error) {
+	n, err := sr.r.Read(p)
+	if n > 0 {
+		sr.timer.Reset(sr.timeout)
+	}
+	return n, err
+}
+
+// stop stops the timer
+func (sr *stallReader) stop() {
+	sr.timer.Stop()
+}
@@ -0,0 +1,65 @@
+package yamusic
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+)
+
+// TestStallTimeout checks that transfers slower than the stall timeout in total complete
+// as long as data keeps coming and that a transfer without data is aborted
+func TestStallTimeout(t *testing.T) {
+	const trackID = "64551568"
+	audio := append([]byte("fLaC"), bytes.Repeat([]byte{0x5a}, 1020)...)
+	encrypted, err := crypto.DecryptAesCtr(audio, testDecryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to encrypt test audio: %v", err)
+	}
+
+	// Test cases
+	tests := []struct {
+		name    string
+		pause   time.Duration
+		wantErr error
+	}{
+		{"Slow transfer", 20 * time.Millisecond, nil},
+		{"Stalled transfer", time.Second, ErrStalled},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newDownloadServerWith(t, trackID, audio, map[string]http.HandlerFunc{
+				// Eight chunks with a pause before each but the first
+				"/media/" + trackID: func(w http.ResponseWriter, r *http.Request) {
+					chunk := len(encrypted) / 8
+					for i := 0; i < len(encrypted); i += chunk {
+						if i > 0 {
+							select {
+							case <-time.After(tt.pause):
+							case <-r.Context().Done():
+								return
+							}
+						}
+						w.Write(encrypted[i:min(i+chunk, len(encrypted))])
+						w.(http.Flusher).Flush()
+					}
+				},
+			})
+			client.SetRetry(1, 0)
+			client.SetTimeouts(DefaultAPITimeout, 100*time.Millisecond)
+
+			_, err := client.DownloadTrack(trackID, QualityHigh, t.TempDir())
+			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("DownloadTrack() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && ErrorCategory(err) != CategoryNetwork {
+				t.Errorf("ErrorCategory() = %s, want %s", ErrorCategory(err), CategoryNetwork)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package yamusic
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrFiltered is reported for album tracks excluded by the track filter (see SetTrackFilter)
+var ErrFiltered = errors.New("excluded by the track filter")
+
+// TrackFilter selects the tracks of album downloads. The zero value keeps all tracks.
+type TrackFilter struct {
+	// Exclude drops tracks whose title or version, or whose album version or meta type,
+	// matches, e.g. (?i)(live|remix|karaoke)
+	Exclude *regexp.Regexp
+
+	// Volumes keeps the tracks of these 1-based volumes (discs) only; empty keeps all
+	Volumes []int
+}
+
+// SetTrackFilter sets the filter applied to the tracks of album downloads. Excluded tracks
+// are reported with ErrFiltered in DownloadAlbum results.
+func (c *Client) SetTrackFilter(filter TrackFilter) {
+	c.trackFilter = filter
+}
+
+// FilterAlbumTracks splits album tracks into those kept by the track filter and those it
+// excludes, keeping their order
+func (c *Client) FilterAlbumTracks(album *Album, tracks []AlbumTrack) (kept, excluded []AlbumTrack) {
+	for _, track := range tracks {
+		if c.trackFilter.exclusion(album, track) != "" {
+			excluded = append(excluded, track)
+		} else {
+			kept = append(kept, track)
+		}
+	}
+	return kept, excluded
+}
+
+// exclusion returns why the filter excludes an album track, or "" when it is kept
+func (f TrackFilter) exclusion(album *Album, track AlbumTrack) string {
+	if len(f.Volumes) > 0 && !slices.Contains(f.Volumes, track.Volume) {
+		return fmt.Sprintf("volume %d", track.Volume)
+	}
+	if f.Exclude == nil {
+		return ""
+	}
+
+	for _, field := range []string{track.Track.Title, track.Track.Version, album.Version, album.MetaType} {
+		if field != "" && f.Exclude.MatchString(field) {
+			return fmt.Sprintf("%q matches %s", field, f.Exclude)
+		}
+	}
+	return ""
+}
+
+// ParseVolumes parses a comma-separated list of 1-based volume numbers, e.g. "1,2"
+func ParseVolumes(s string) ([]int, error) {
+	var volumes []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		volume, err := strconv.Atoi(part)
+		if err != nil || volume < 1 {
+			return nil, fmt.Errorf("invalid volume %q: expected a number starting from 1", part)
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
@@ -0,0 +1,123 @@
+package yamusic
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// TestFilterAlbumTracks checks the exclusion of album tracks by title, version and volume
+func TestFilterAlbumTracks(t *testing.T) {
+	album := &Album{Title: "Album", Volumes: [][]TrackInfo{
+		{
+			{ID: "1", Title: "Song", Available: true},
+			{ID: "2", Title: "Song (Live)", Available: true},
+			{ID: "3", Title: "Song", Version: "Remix", Available: true},
+		},
+		{{ID: "4", Title: "Bonus", Available: true}},
+	}}
+	tracks, _ := AlbumTracks(album)
+	exclude := regexp.MustCompile(`(?i)(live|remix|karaoke)`)
+
+	// Test cases
+	tests := []struct {
+		name     string
+		filter   TrackFilter
+		album    Album
+		expected []string
+	}{
+		{"No filter", TrackFilter{}, Album{}, []string{"1", "2", "3", "4"}},
+		{"Title and version", TrackFilter{Exclude: exclude}, Album{}, []string{"1", "4"}},
+		{"Album version", TrackFilter{Exclude: exclude}, Album{Version: "Live at Wembley"}, nil},
+		{"First volume", TrackFilter{Volumes: []int{1}}, Album{}, []string{"1", "2", "3"}},
+		{"Both", TrackFilter{Exclude: exclude, Volumes: []int{2}}, Album{}, []string{"4"}},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("test-token", "", nil)
+			client.SetTrackFilter(tt.filter)
+			withVersion := *album
+			withVersion.Version = tt.album.Version
+
+			kept, excluded := client.FilterAlbumTracks(&withVersion, tracks)
+			var ids []string
+			for _, track := range kept {
+				ids = append(ids, track.Track.ID)
+			}
+			if !slices.Equal(ids, tt.expected) {
+				t.Errorf("Kept = %v, want %v", ids, tt.expected)
+			}
+			if len(kept)+len(excluded) != len(tracks) {
+				t.Errorf("Kept %d and excluded %d of %d tracks", len(kept), len(excluded), len(tracks))
+			}
+		})
+	}
+}
+
+// TestCheckAlbumAvailabilityFiltered checks that excluded tracks don't make an album incomplete
+func TestCheckAlbumAvailabilityFiltered(t *testing.T) {
+	album := &Album{Title: "Album", Volumes: [][]TrackInfo{
+		{{ID: "1", Title: "Song", Available: true}},
+		{{ID: "2", Title: "Bonus (Live)", Available: false}},
+	}}
+
+	// Test cases
+	tests := []struct {
+		name    string
+		filter  TrackFilter
+		wantErr bool
+	}{
+		{"No filter", TrackFilter{}, true},
+		{"Volume excluded", TrackFilter{Volumes: []int{1}}, false},
+		{"Title excluded", TrackFilter{Exclude: regexp.MustCompile(`(?i)live`)}, false},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("test-token", "", nil)
+			client.SetRequireComplete(true)
+			client.SetTrackFilter(tt.filter)
+
+			err := client.CheckAlbumAvailability(album, client.logger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckAlbumAvailability() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrIncompleteAlbum) {
+				t.Errorf("CheckAlbumAvailability() error = %v, want ErrIncompleteAlbum", err)
+			}
+		})
+	}
+}
+
+// TestParseVolumes checks the parsing of volume lists
+func TestParseVolumes(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		input    string
+		expected []int
+		wantErr  bool
+	}{
+		{"1", []int{1}, false},
+		{"1, 3", []int{1, 3}, false},
+		{"", nil, false},
+		{"0", nil, true},
+		{"cd1", nil, true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseVolumes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVolumes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("ParseVolumes() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
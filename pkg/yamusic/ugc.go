@@ -0,0 +1,66 @@
+package yamusic
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+// trackSourceUGC is the track source of tracks uploaded by users to their library
+const trackSourceUGC = "UGC"
+
+// catalogIDPattern matches the numeric IDs of catalog tracks
+var catalogIDPattern = regexp.MustCompile(`^\d+$`)
+
+// isUGCID reports whether the track ID is outside the numeric namespace of the catalog,
+// as the IDs of uploaded tracks are
+func isUGCID(trackID string) bool {
+	return !catalogIDPattern.MatchString(trackID)
+}
+
+// isUGC reports whether a track was uploaded by a user. get-file-info rejects uploaded
+// tracks; they are served unencrypted by the legacy download API.
+func isUGC(trackInfo *api.TrackInfo, trackID string) bool {
+	return strings.EqualFold(trackInfo.TrackSource, trackSourceUGC) || isUGCID(trackID)
+}
+
+// trackDownloadInfo retrieves information for downloading a track whose metadata is known:
+// uploaded tracks go to the legacy download API, others through getDownloadInfo
+func (c *Client) trackDownloadInfo(ctx context.Context, trackInfo *api.TrackInfo, trackID string,
+	quality ApiTrackQuality, log *logger.Logger) (*api.DownloadInfo, error) {
+	if isUGC(trackInfo, trackID) {
+		return c.ugcDownloadInfo(ctx, trackID, quality, log)
+	}
+	return c.getDownloadInfo(ctx, trackID, quality, log)
+}
+
+// ugcDownloadInfo retrieves information for downloading an uploaded track
+func (c *Client) ugcDownloadInfo(ctx context.Context, trackID string, quality ApiTrackQuality,
+	log *logger.Logger) (*api.DownloadInfo, error) {
+	log.Debug("Uploaded track, using the legacy download API")
+
+	downloadInfo, err := c.requestLegacyDownloadInfo(ctx, trackID, quality, log)
+	if err != nil {
+		return nil, fmt.Errorf("uploaded track: %w", err)
+	}
+	return downloadInfo, nil
+}
+
+// ugcFileName forms the file name of an uploaded track from its title, which is the name
+// of the uploaded file: "Title [ID].mp3". An extension kept in the title is dropped.
+func ugcFileName(trackInfo *api.TrackInfo, trackID, ext string) string {
+	title := trackInfo.Title
+	if titleExt := filepath.Ext(title); strings.EqualFold(titleExt, ext) {
+		title = strings.TrimSuffix(title, titleExt)
+	}
+	if title == "" {
+		title = "Unknown"
+	}
+	return fmt.Sprintf("%s [%s]%s", utils.CleanFileName(title), trackID, ext)
+}
@@ -0,0 +1,87 @@
+package yamusic
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+)
+
+// TestIsUGC checks the detection of uploaded tracks
+func TestIsUGC(t *testing.T) {
+	// Test cases
+	tests := []struct {
+		name    string
+		trackID string
+		source  string
+		want    bool
+	}{
+		{"Catalog track", "64551568", "OWN", false},
+		{"UGC source", "64551568", "UGC", true},
+		{"Non-numeric ID", "0b5d8e2c-3f6a-4c1e-9a8b-7d6c5e4f3a2b", "", true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUGC(&api.TrackInfo{TrackSource: tt.source}, tt.trackID); got != tt.want {
+				t.Errorf("isUGC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDownloadUGCTrack checks that uploaded tracks skip get-file-info, are downloaded
+// unencrypted through the legacy API and keep the name of the uploaded file
+func TestDownloadUGCTrack(t *testing.T) {
+	const trackID = "0b5d8e2c-3f6a-4c1e-9a8b-7d6c5e4f3a2b"
+	audio := append([]byte("ID3"), bytes.Repeat([]byte{0x5a}, 1021)...)
+
+	var serverURL string
+	client, server := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/" + trackID: func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":[{"id":"` + trackID + `","title":"demo take 2.mp3",` +
+				`"available":true,"trackSource":"UGC","artists":[],"albums":[]}]}`))
+		},
+		"/get-file-info": func(w http.ResponseWriter, r *http.Request) {
+			t.Error("get-file-info requested for an uploaded track")
+			w.WriteHeader(http.StatusBadRequest)
+		},
+		"/tracks/" + trackID + "/download-info": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":[{"codec":"mp3","bitrateInKbps":256,"downloadInfoUrl":"` + serverURL + `/info"}]}`))
+		},
+		"/info": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><download-info>` +
+				`<host>s1.storage.example</host><path>/ugc/demo.mp3</path>` +
+				`<ts>0005f1</ts><region>-1</region><s>0123abcd</s></download-info>`))
+		},
+		"/get-mp3/": func(w http.ResponseWriter, r *http.Request) {
+			w.Write(audio)
+		},
+	})
+	serverURL = server.URL
+	if err := client.SetMediaBaseURL(server.URL); err != nil {
+		t.Fatalf("SetMediaBaseURL() error = %v", err)
+	}
+	client.SetStandardTags(false)
+	client.SetExtraTags(false)
+
+	result, err := client.DownloadTrackResult(trackID, api.QualityHigh, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadTrackResult() error = %v", err)
+	}
+	if want := "demo take 2 [" + trackID + "].mp3"; filepath.Base(result.Path) != want {
+		t.Errorf("File name = %q, want %q", filepath.Base(result.Path), want)
+	}
+
+	data, err := os.ReadFile(result.Path)
+	if err != nil {
+		t.Fatalf("Failed to read the track: %v", err)
+	}
+	if !bytes.Equal(data, audio) {
+		t.Errorf("Track content differs from the served audio")
+	}
+}
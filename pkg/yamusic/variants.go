@@ -0,0 +1,49 @@
+package yamusic
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// DownloadVariant is a codec and bitrate combination a track is offered in
+type DownloadVariant struct {
+	Codec   string `json:"codec"`
+	Bitrate int    `json:"bitrate"`
+
+	// Gain is set for streams with loudness normalization applied
+	Gain bool `json:"gain,omitempty"`
+
+	// Preview is set for streams with only the preview of the track
+	Preview bool `json:"preview,omitempty"`
+}
+
+// ListDownloadVariants lists every codec and bitrate combination the track is offered in,
+// highest bitrate first. Unlike get-file-info, which picks a single stream, the list
+// comes from the legacy download info endpoint, so lossless streams are not included.
+func (c *Client) ListDownloadVariants(trackID string) ([]DownloadVariant, error) {
+	return c.ListDownloadVariantsContext(context.Background(), trackID)
+}
+
+// ListDownloadVariantsContext lists the download variants of a track; the request is cancelled with ctx
+func (c *Client) ListDownloadVariantsContext(ctx context.Context, trackID string) ([]DownloadVariant, error) {
+	trackID, _ = splitTrackRef(trackID, "")
+	infos, err := c.getLegacyDownloadInfos(ctx, trackID, c.trackLogger(trackID, phaseMetadata))
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make([]DownloadVariant, 0, len(infos))
+	for _, info := range infos {
+		variants = append(variants, DownloadVariant{
+			Codec:   info.Codec,
+			Bitrate: info.Bitrate,
+			Gain:    info.Gain,
+			Preview: info.Preview,
+		})
+	}
+	slices.SortStableFunc(variants, func(a, b DownloadVariant) int {
+		return cmp.Compare(b.Bitrate, a.Bitrate)
+	})
+	return variants, nil
+}
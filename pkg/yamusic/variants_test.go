@@ -0,0 +1,46 @@
+package yamusic
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestListDownloadVariants checks that every offered variant is listed, highest bitrate first
+func TestListDownloadVariants(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks/64551568/download-info": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result":[` +
+				`{"codec":"mp3","bitrateInKbps":192,"gain":false,"downloadInfoUrl":"https://storage/1"},` +
+				`{"codec":"aac","bitrateInKbps":64,"gain":true,"downloadInfoUrl":"https://storage/2"},` +
+				`{"codec":"mp3","bitrateInKbps":320,"downloadInfoUrl":"https://storage/3"},` +
+				`{"codec":"mp3","bitrateInKbps":128,"preview":true,"downloadInfoUrl":"https://storage/4"}]}`))
+		},
+	})
+
+	// The album part of a composite ID is not sent
+	variants, err := client.ListDownloadVariants("64551568:10376938")
+	if err != nil {
+		t.Fatalf("ListDownloadVariants() error = %v", err)
+	}
+
+	// Test cases
+	tests := []DownloadVariant{
+		{Codec: "mp3", Bitrate: 320},
+		{Codec: "mp3", Bitrate: 192},
+		{Codec: "mp3", Bitrate: 128, Preview: true},
+		{Codec: "aac", Bitrate: 64, Gain: true},
+	}
+
+	if len(variants) != len(tests) {
+		t.Fatalf("ListDownloadVariants() returned %d variants, want %d", len(variants), len(tests))
+	}
+
+	// Run tests
+	for i, tt := range tests {
+		t.Run(tt.Codec, func(t *testing.T) {
+			if variants[i] != tt {
+				t.Errorf("Variant = %+v, want %+v", variants[i], tt)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package yamusic
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/media"
+)
+
+// DefaultDurationTolerance is the allowed difference between file and API durations
+const DefaultDurationTolerance = 2 * time.Second
+
+// checksumFile is the name of the checksum list validated in each directory
+const checksumFile = "SHA256SUMS"
+
+// trackIDSuffix matches the "[ID]" suffix of downloaded file names
+var trackIDSuffix = regexp.MustCompile(`\[(\d+)\]$`)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// VerifyOptions configures library verification
+type VerifyOptions struct {
+	// Metadata enables comparing files against the API metadata (requires network)
+	Metadata bool
+
+	// DurationTolerance is the allowed duration difference (defaults to DefaultDurationTolerance)
+	DurationTolerance time.Duration
+}
+
+// VerifyIssue describes a problem found in a file
+type VerifyIssue struct {
+	Path     string
+	TrackID  string
+	Severity string
+	Problem  string
+}
+
+// VerifyReport is the result of library verification
+type VerifyReport struct {
+	Files  int
+	Issues []VerifyIssue
+}
+
+// HasErrors reports whether any error-level issue was found
+func (r *VerifyReport) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// verifiedFile holds the per-file data collected during the offline pass
+type verifiedFile struct {
+	path     string
+	trackID  string
+	duration time.Duration
+}
+
+// VerifyLibrary checks previously downloaded files in dir: container magic bytes,
+// SHA256SUMS entries and, when opts.Metadata is set, durations against the API.
+// The offline checks never touch the network.
+func (c *Client) VerifyLibrary(dir string, opts VerifyOptions) (*VerifyReport, error) {
+	if opts.DurationTolerance <= 0 {
+		opts.DurationTolerance = DefaultDurationTolerance
+	}
+
+	report := &VerifyReport{}
+	var files []verifiedFile
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if _, err := os.Stat(filepath.Join(path, checksumFile)); err == nil {
+				report.Issues = append(report.Issues, verifyChecksums(path)...)
+			}
+			return nil
+		}
+
+		ext := filepath.Ext(path)
+		if media.ContainerForExtension(ext) == media.ContainerUnknown {
+			return nil
+		}
+
+		report.Files++
+		file, issues := verifyFile(path)
+		report.Issues = append(report.Issues, issues...)
+		if file != nil {
+			files = append(files, *file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking library: %w", err)
+	}
+
+	if opts.Metadata {
+		issues, err := c.verifyMetadata(files, opts.DurationTolerance)
+		if err != nil {
+			return nil, err
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool { return report.Issues[i].Path < report.Issues[j].Path })
+
+	return report, nil
+}
+
+// verifyFile checks the container of a single file and collects data for the metadata pass
+func verifyFile(path string) (*verifiedFile, []VerifyIssue) {
+	trackID := fileTrackID(path)
+
+	container, err := media.DetectFile(path)
+	if err != nil {
+		return nil, []VerifyIssue{{Path: path, TrackID: trackID, Severity: SeverityError, Problem: err.Error()}}
+	}
+
+	if container == media.ContainerUnknown {
+		return nil, []VerifyIssue{{Path: path, TrackID: trackID, Severity: SeverityError,
+			Problem: "unrecognized container (corrupt or wrongly decrypted file)"}}
+	}
+
+	var issues []VerifyIssue
+	if expected := media.ContainerForExtension(filepath.Ext(path)); expected != container {
+		issues = append(issues, VerifyIssue{Path: path, TrackID: trackID, Severity: SeverityWarning,
+			Problem: fmt.Sprintf("extension %s doesn't match %s content", filepath.Ext(path), container)})
+	}
+
+	file := &verifiedFile{path: path, trackID: trackID}
+	duration, err := media.ProbeDuration(path)
+	switch {
+	case err == nil:
+		file.duration = duration
+	case err != media.ErrUnsupported:
+		issues = append(issues, VerifyIssue{Path: path, TrackID: trackID, Severity: SeverityError,
+			Problem: fmt.Sprintf("can't read duration: %v", err)})
+	}
+
+	return file, issues
+}
+
+// fileTrackID extracts the track ID from the "[ID]" file name suffix or a JSON sidecar
+func fileTrackID(path string) string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	if matches := trackIDSuffix.FindStringSubmatch(filepath.Base(base)); len(matches) > 1 {
+		return matches[1]
+	}
+
+	data, err := os.ReadFile(base + ".json")
+	if err != nil {
+		return ""
+	}
+
+	var sidecar struct {
+		ID json.Number `json:"id"`
+	}
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return ""
+	}
+
+	return sidecar.ID.String()
+}
+
+// verifyChecksums validates the SHA256SUMS entries of a directory
+func verifyChecksums(dir string) []VerifyIssue {
+	sumsPath := filepath.Join(dir, checksumFile)
+	file, err := os.Open(sumsPath)
+	if err != nil {
+		return []VerifyIssue{{Path: sumsPath, Severity: SeverityError, Problem: err.Error()}}
+	}
+	defer file.Close()
+
+	var issues []VerifyIssue
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Format: "<hex>  <name>" or "<hex> *<name>" (binary mode)
+		expected, name, ok := strings.Cut(line, " ")
+		if !ok {
+			issues = append(issues, VerifyIssue{Path: sumsPath, Severity: SeverityWarning,
+				Problem: fmt.Sprintf("malformed line %q", line)})
+			continue
+		}
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+		path := filepath.Join(dir, name)
+
+		actual, err := fileSHA256(path)
+		if err != nil {
+			issues = append(issues, VerifyIssue{Path: path, TrackID: fileTrackID(path), Severity: SeverityError,
+				Problem: fmt.Sprintf("checksum entry can't be verified: %v", err)})
+			continue
+		}
+
+		if !strings.EqualFold(actual, expected) {
+			issues = append(issues, VerifyIssue{Path: path, TrackID: fileTrackID(path), Severity: SeverityError,
+				Problem: "SHA256 checksum mismatch"})
+		}
+	}
+
+	return issues
+}
+
+// fileSHA256 returns the hex-encoded SHA256 of a file
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyMetadata compares file durations with the API metadata using batched requests
+func (c *Client) verifyMetadata(files []verifiedFile, tolerance time.Duration) ([]VerifyIssue, error) {
+	var ids []string
+	for _, file := range files {
+		if file.trackID != "" {
+			ids = append(ids, file.trackID)
+		}
+	}
+
+	tracks, err := c.getTracks(context.Background(), ids)
+	if err != nil {
+		return nil, fmt.Errorf("error getting track metadata: %w", err)
+	}
+
+	var issues []VerifyIssue
+	for _, file := range files {
+		if file.trackID == "" {
+			issues = append(issues, VerifyIssue{Path: file.path, Severity: SeverityWarning,
+				Problem: "track ID unknown, metadata not checked"})
+			continue
+		}
+
+		trackInfo, ok := tracks[file.trackID]
+		if !ok {
+			issues = append(issues, VerifyIssue{Path: file.path, TrackID: file.trackID, Severity: SeverityWarning,
+				Problem: "track not found in the API"})
+			continue
+		}
+
+		if file.duration == 0 || trackInfo.DurationMs == 0 {
+			continue
+		}
+
+		expected := time.Duration(trackInfo.DurationMs) * time.Millisecond
+		diff := file.duration - expected
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > tolerance {
+			issues = append(issues, VerifyIssue{Path: file.path, TrackID: file.trackID, Severity: SeverityError,
+				Problem: fmt.Sprintf("duration %s differs from expected %s (truncated file?)",
+					file.duration.Round(time.Second), expected.Round(time.Second))})
+		}
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,103 @@
+package yamusic
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testFLAC builds a minimal FLAC file of the given length in seconds
+func testFLAC(seconds uint64) []byte {
+	const sampleRate = 48000
+	totalSamples := seconds * sampleRate
+
+	info := make([]byte, 34)
+	rate := uint32(sampleRate)
+	info[10] = byte(rate >> 12)
+	info[11] = byte(rate >> 4)
+	info[12] = byte(rate<<4) | 0x02
+	info[13] = 0xF0 | byte(totalSamples>>32&0x0F)
+	binary.BigEndian.PutUint32(info[14:18], uint32(totalSamples))
+
+	return append([]byte("fLaC\x80\x00\x00\x22"), info...)
+}
+
+// TestVerifyLibrary checks the offline and metadata verification passes
+func TestVerifyLibrary(t *testing.T) {
+	dir := t.TempDir()
+	good := testFLAC(200)
+	files := map[string][]byte{
+		"Good - Artist (Album) [1].flac":      good,
+		"Corrupt - Artist (Album) [2].flac":   []byte("\x13\x37 garbage garbage"),
+		"Truncated - Artist (Album) [3].flac": testFLAC(60),
+		"notes.txt":                           []byte("not audio"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goodSum := sha256.Sum256(good)
+	sums := fmt.Sprintf("%s  Good - Artist (Album) [1].flac\n%s *Truncated - Artist (Album) [3].flac\n",
+		hex.EncodeToString(goodSum[:]), hex.EncodeToString(make([]byte, 32)))
+	if err := os.WriteFile(filepath.Join(dir, "SHA256SUMS"), []byte(sums), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/tracks": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				t.Errorf("Method = %s, want POST", r.Method)
+			}
+			if got := r.FormValue("trackIds"); got != "1,3" {
+				t.Errorf("trackIds = %q, want %q", got, "1,3")
+			}
+			w.Write([]byte(`{"result":[{"id":"1","durationMs":200500},{"id":"3","durationMs":200000}]}`))
+		},
+	})
+
+	// Offline pass: corrupt container and checksum mismatch
+	report, err := client.VerifyLibrary(dir, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("VerifyLibrary() error = %v", err)
+	}
+	if report.Files != 3 {
+		t.Errorf("Files = %d, want 3", report.Files)
+	}
+	assertIssues(t, report, map[string]int{"2": 1, "3": 1})
+
+	// Metadata pass additionally detects the truncated file
+	report, err = client.VerifyLibrary(dir, VerifyOptions{Metadata: true})
+	if err != nil {
+		t.Fatalf("VerifyLibrary() error = %v", err)
+	}
+	assertIssues(t, report, map[string]int{"2": 1, "3": 2})
+}
+
+// assertIssues checks the number of error-level issues per track ID
+func assertIssues(t *testing.T, report *VerifyReport, expected map[string]int) {
+	t.Helper()
+
+	got := map[string]int{}
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityError {
+			got[issue.TrackID]++
+		}
+	}
+
+	if len(got) != len(expected) {
+		t.Errorf("Issues = %+v, want errors %v", report.Issues, expected)
+		return
+	}
+	for id, n := range expected {
+		if got[id] != n {
+			t.Errorf("Track %s has %d errors, want %d (issues: %+v)", id, got[id], n, report.Issues)
+		}
+	}
+}
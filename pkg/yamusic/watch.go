@@ -0,0 +1,185 @@
+package yamusic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/utils"
+)
+
+// maxWatchBackoff caps the delay between polls after repeated API errors
+const maxWatchBackoff = time.Hour
+
+// watchedPlaylist is the last seen state of a watched playlist
+type watchedPlaylist struct {
+	Revision int      `json:"revision"`
+	TrackIDs []string `json:"trackIds"`
+}
+
+// SetWatchState sets the JSON file where WatchPlaylist keeps the last seen revision and
+// tracks of every watched playlist, so a restarted watch only reports tracks added since.
+// Without it the state is kept in memory and the first poll reports every track.
+func (c *Client) SetWatchState(path string) {
+	c.watchState = path
+}
+
+// WatchPlaylist polls the playlist every interval and calls onNew for every track added
+// since the previous poll, in playlist order; onNew is expected to download the track.
+// Tracks for which onNew returns an error are not recorded as seen and are reported again
+// on the next poll; apart from them, the playlist is only diffed when its revision changed.
+// API errors are logged and the next poll is delayed up to maxWatchBackoff, doubling with
+// every failure. WatchPlaylist returns when ctx is cancelled, or when the token is rejected
+// as retrying can't help then.
+func (c *Client) WatchPlaylist(ctx context.Context, userID string, kind int, interval time.Duration,
+	onNew func(TrackInfo) error) error {
+	userID, err := c.resolveUID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	key := userID + ":" + strconv.Itoa(kind)
+	log := c.logger.WithField("playlist", key)
+
+	states, err := readWatchState(c.watchState)
+	if err != nil {
+		return err
+	}
+	state := states[key]
+
+	delay := interval
+	for {
+		next, err := c.pollPlaylist(ctx, userID, strconv.Itoa(kind), state, onNew)
+		if next.Revision != state.Revision || (state.TrackIDs == nil) != (next.TrackIDs == nil) ||
+			!slices.Equal(next.TrackIDs, state.TrackIDs) {
+			state = next
+			states[key] = state
+			if err := writeWatchState(c.watchState, states); err != nil {
+				log.Warn("Error saving watch state: %v", err)
+			}
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, ErrUnauthorized):
+			return err
+		case err != nil:
+			delay = min(2*delay, max(maxWatchBackoff, interval))
+			log.Warn("Error polling playlist, next attempt in %s: %v", delay, err)
+		default:
+			delay = interval
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// pollPlaylist fetches the playlist and reports the tracks missing from the previous state,
+// returning the new state. When ctx is cancelled while reporting, the returned state keeps
+// the previous revision and adds just the tracks reported, so the rest is reported again.
+// Tracks onNew fails for are left out of the state, which then keeps the previous revision
+// too, so the next poll diffs the playlist again even if it is unchanged.
+func (c *Client) pollPlaylist(ctx context.Context, userID, kind string, previous watchedPlaylist,
+	onNew func(TrackInfo) error) (watchedPlaylist, error) {
+	// A cached playlist would hide the tracks added since it was stored
+	playlist, err := c.fetchPlaylist(ctx, userID, kind)
+	if err != nil {
+		return previous, err
+	}
+	if playlist.Revision == previous.Revision && previous.TrackIDs != nil {
+		return previous, nil
+	}
+
+	seen := make(map[string]bool, len(previous.TrackIDs))
+	for _, id := range previous.TrackIDs {
+		seen[id] = true
+	}
+
+	refs := PlaylistTrackRefs(playlist)
+	next := watchedPlaylist{Revision: playlist.Revision, TrackIDs: make([]string, 0, len(refs))}
+	var added []string
+	for _, ref := range refs {
+		next.TrackIDs = append(next.TrackIDs, ref.ID)
+		if !seen[ref.ID] {
+			added = append(added, ref.ID)
+		}
+	}
+	if len(added) == 0 {
+		return next, nil
+	}
+
+	c.logger.Info("Playlist %q: %d new tracks (revision %d)", playlist.Title, len(added), playlist.Revision)
+	tracks, err := c.getTracks(ctx, added)
+	if err != nil {
+		return previous, err
+	}
+	var reported []string
+	failed := make(map[string]bool)
+	for _, id := range added {
+		if ctx.Err() != nil {
+			return watchedPlaylist{
+				Revision: previous.Revision,
+				TrackIDs: append(slices.Clone(previous.TrackIDs), reported...),
+			}, ctx.Err()
+		}
+		trackInfo, ok := tracks[id]
+		if !ok {
+			c.logger.Warn("Track %s added to the playlist was not found", id)
+		} else if err := onNew(*trackInfo); err != nil {
+			c.logger.Warn("Track %s will be retried on the next poll: %v", id, err)
+			failed[id] = true
+			continue
+		}
+		reported = append(reported, id)
+	}
+
+	if len(failed) > 0 {
+		next.Revision = previous.Revision
+		next.TrackIDs = slices.DeleteFunc(next.TrackIDs, func(id string) bool { return failed[id] })
+	}
+	return next, nil
+}
+
+// readWatchState reads the watch state file; a missing file or an empty path is an empty state
+func readWatchState(path string) (map[string]watchedPlaylist, error) {
+	states := make(map[string]watchedPlaylist)
+	if path == "" {
+		return states, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading watch state: %w", err)
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("error parsing watch state %s: %w", path, err)
+	}
+	return states, nil
+}
+
+// writeWatchState replaces the watch state file atomically; nothing is written for an empty path
+func writeWatchState(path string, states map[string]watchedPlaylist) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileAtomic(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,108 @@
+package yamusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWatchPlaylist checks that only added tracks are reported, across polls and restarts
+func TestWatchPlaylist(t *testing.T) {
+	// Playlist revisions served by successive polls; nil is an API error
+	var revisions [][]string
+	poll := 0
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/users/42/playlists/3": func(w http.ResponseWriter, r *http.Request) {
+			ids := revisions[min(poll, len(revisions)-1)]
+			poll++
+			if ids == nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			entries := make([]string, len(ids))
+			for i, id := range ids {
+				entries[i] = fmt.Sprintf(`{"id":%s,"albumId":7}`, id)
+			}
+			fmt.Fprintf(w, `{"result":{"uid":42,"kind":3,"title":"Shared","revision":%d,"tracks":[%s]}}`,
+				len(ids), strings.Join(entries, ","))
+		},
+		"/tracks": func(w http.ResponseWriter, r *http.Request) {
+			var tracks []string
+			for _, id := range strings.Split(r.FormValue("trackIds"), ",") {
+				tracks = append(tracks, fmt.Sprintf(`{"id":"%s","title":"Track %s"}`, id, id))
+			}
+			fmt.Fprintf(w, `{"result":[%s]}`, strings.Join(tracks, ","))
+		},
+	})
+	client.SetRetry(1, 0)
+	client.SetWatchState(filepath.Join(t.TempDir(), "watch.json"))
+
+	// watch runs until n tracks are reported and returns the reported IDs; the first
+	// report of the track with the failing ID fails
+	watch := func(n int, failing string) []string {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var got []string
+		err := client.WatchPlaylist(ctx, "42", 3, time.Millisecond, func(track TrackInfo) error {
+			got = append(got, track.ID)
+			if len(got) == n {
+				cancel()
+			}
+			if track.ID == failing {
+				failing = ""
+				return errors.New("download failed")
+			}
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("WatchPlaylist() error = %v, want context.Canceled", err)
+		}
+		return got
+	}
+
+	// Test cases
+	tests := []struct {
+		name      string
+		revisions [][]string
+		failing   string
+		want      []string
+	}{
+		{"First run", [][]string{{"1", "2"}, nil, {"1", "2"}, {"2", "3", "1"}}, "", []string{"1", "2", "3"}},
+		{"Restart", [][]string{{"2", "3", "1", "4"}}, "", []string{"4"}},
+		{"Failed download retried", [][]string{{"2", "3", "1", "4", "5", "6"}}, "5", []string{"5", "6", "5"}},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revisions, poll = tt.revisions, 0
+			got := watch(len(tt.want), tt.failing)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Reported tracks %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWatchPlaylistUnauthorized checks that watching stops when the token is rejected
+func TestWatchPlaylistUnauthorized(t *testing.T) {
+	client, _ := newTestServer(t, map[string]http.HandlerFunc{
+		"/users/42/playlists/3": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		},
+	})
+
+	err := client.WatchPlaylist(context.Background(), "42", 3, time.Millisecond, func(TrackInfo) error {
+		t.Error("onNew() called for an inaccessible playlist")
+		return nil
+	})
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("WatchPlaylist() error = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,368 @@
+// Package yamusictest provides a fake Yandex Music API server for end-to-end tests
+// of code built on top of the yamusic client.
+package yamusictest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/internal/crypto"
+	"github.com/Kud1nov/yamusic-dl/internal/logger"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+)
+
+// Track is a track served by the fake server
+type Track struct {
+	// Info is returned by the /tracks endpoints; Info.ID is required
+	Info yamusic.TrackInfo
+
+	// Audio is the decrypted content served (encrypted) by the fake CDN
+	Audio []byte
+
+	// Codec reported by get-file-info (defaults to "flac")
+	Codec string
+
+	// Bitrate reported by get-file-info
+	Bitrate int
+
+	// Key is the hex-encoded AES key (generated when empty)
+	Key string
+
+	encrypted []byte
+}
+
+// Server is a fake Yandex Music API and CDN
+type Server struct {
+	*httptest.Server
+
+	// SignKey is the key get-file-info signatures are verified against
+	SignKey string
+
+	// Token, when set, is required in the Authorization header of API requests
+	Token string
+
+	// Gzip compresses API responses to requests accepting gzip, like the real API
+	Gzip bool
+
+	mu       sync.Mutex
+	tracks   map[string]*Track
+	albums   map[string]*yamusic.Album
+	failures map[string][]int
+	requests map[string]int
+}
+
+// NewServer starts a fake server verifying signatures against signKey
+// (api.DefaultSignKey when empty). The caller must Close it.
+func NewServer(signKey string) *Server {
+	if signKey == "" {
+		signKey = api.DefaultSignKey
+	}
+
+	s := &Server{
+		SignKey:  signKey,
+		tracks:   make(map[string]*Track),
+		albums:   make(map[string]*yamusic.Album),
+		failures: make(map[string][]int),
+		requests: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tracks", s.handleTracks)
+	mux.HandleFunc("/tracks/", s.handleTracks)
+	mux.HandleFunc("/albums/", s.handleAlbum)
+	mux.HandleFunc("/get-file-info", s.handleFileInfo)
+	mux.HandleFunc("/media/", s.handleMedia)
+	s.Server = httptest.NewServer(s.intercept(mux))
+
+	return s
+}
+
+// FailNext answers the next requests to path with the given statuses, one request per
+// status, before serving it normally. 429 responses ask to retry right away.
+func (s *Server) FailNext(path string, statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], statuses...)
+}
+
+// Requests returns the number of requests to path served so far, failed ones included
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// intercept counts requests, injects the failures queued with FailNext and compresses
+// API responses when Gzip is set
+func (s *Server) intercept(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		status := 0
+		if queued := s.failures[r.URL.Path]; len(queued) > 0 {
+			status, s.failures[r.URL.Path] = queued[0], queued[1:]
+		}
+		s.mu.Unlock()
+
+		if status != 0 {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			writeError(w, status, "injected", http.StatusText(status))
+			return
+		}
+
+		if !s.Gzip || strings.HasPrefix(r.URL.Path, "/media/") ||
+			!strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		next.ServeHTTP(gzipResponseWriter{ResponseWriter: w, w: gz}, r)
+	})
+}
+
+// gzipResponseWriter writes the body through a gzip writer
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	w *gzip.Writer
+}
+
+// Write implements io.Writer
+func (g gzipResponseWriter) Write(p []byte) (int, error) {
+	return g.w.Write(p)
+}
+
+// Client returns a yamusic client pointed at the fake server
+func (s *Server) Client(token string) *yamusic.Client {
+	client := yamusic.NewClient(token, s.SignKey, logger.New(false))
+	client.SetBaseURL(s.URL)
+	return client
+}
+
+// AddTrack registers a track. The audio is encrypted with the track key.
+func (s *Server) AddTrack(track Track) {
+	if track.Codec == "" {
+		track.Codec = "flac"
+	}
+	if track.Key == "" {
+		key := make([]byte, 32)
+		rand.Read(key)
+		track.Key = hex.EncodeToString(key)
+	}
+
+	// Encryption and decryption are the same operation in CTR mode
+	encrypted, err := crypto.DecryptAesCtr(track.Audio, track.Key)
+	if err != nil {
+		panic(fmt.Sprintf("yamusictest: invalid key for track %s: %v", track.Info.ID, err))
+	}
+	track.encrypted = encrypted
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tracks[track.Info.ID] = &track
+}
+
+// AddSimpleTrack registers a track with a single artist and album
+func (s *Server) AddSimpleTrack(id, title, artist, album string, audio []byte) {
+	s.AddTrack(Track{
+		Info: yamusic.TrackInfo{
+			ID:                       id,
+			RealID:                   id,
+			Title:                    title,
+			Available:                true,
+			AvailableForPremiumUsers: true,
+			Artists:                  []yamusic.Artist{{ID: "1", Name: artist}},
+			Albums:                   []yamusic.Album{{ID: "1", Title: album}},
+		},
+		Audio: audio,
+	})
+}
+
+// AddAlbum registers an album whose volumes list the IDs of registered tracks.
+// Tracks that are not registered are served as unavailable.
+func (s *Server) AddAlbum(id, title string, volumes ...[]string) {
+	album := &yamusic.Album{
+		ID:        json.Number(id),
+		Title:     title,
+		Available: true,
+	}
+
+	for _, ids := range volumes {
+		var volume []yamusic.TrackInfo
+		for i, trackID := range ids {
+			info := yamusic.TrackInfo{ID: trackID, RealID: trackID, Title: "Unavailable " + trackID}
+			if track, ok := s.track(trackID); ok {
+				info = track.Info
+			} else {
+				album.AvailablePartially = true
+			}
+			info.Albums = []yamusic.Album{{
+				ID:            json.Number(id),
+				Title:         title,
+				TrackPosition: api.TrackPosition{Volume: len(album.Volumes) + 1, Index: i + 1},
+			}}
+			volume = append(volume, info)
+		}
+		album.Volumes = append(album.Volumes, volume)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.albums[id] = album
+}
+
+// track returns a registered track
+func (s *Server) track(id string) (*Track, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	track, ok := s.tracks[id]
+	return track, ok
+}
+
+// authorized checks the Authorization header and writes an error when it's wrong
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if s.Token == "" || r.Header.Get("Authorization") == "OAuth "+s.Token {
+		return true
+	}
+	writeError(w, http.StatusUnauthorized, "session-expired", "Token is invalid")
+	return false
+}
+
+// handleTracks serves GET /tracks/{id} and POST /tracks with a trackIds form field
+func (s *Server) handleTracks(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+
+	var ids []string
+	if id := strings.TrimPrefix(r.URL.Path, "/tracks/"); id != r.URL.Path && id != "" {
+		ids = []string{id}
+	} else {
+		if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+			writeError(w, http.StatusBadRequest, "validate", err.Error())
+			return
+		}
+		ids = strings.Split(r.FormValue("trackIds"), ",")
+	}
+
+	result := []yamusic.TrackInfo{}
+	for _, id := range ids {
+		// Composite IDs (trackId:albumId) refer to the track itself
+		id, _, _ = strings.Cut(strings.TrimSpace(id), ":")
+		if track, ok := s.track(id); ok {
+			result = append(result, track.Info)
+		}
+	}
+
+	if len(result) == 0 && r.Method == http.MethodGet {
+		writeError(w, http.StatusNotFound, "not-found", "Track not found")
+		return
+	}
+
+	writeResult(w, result)
+}
+
+// handleAlbum serves GET /albums/{id}/with-tracks
+func (s *Server) handleAlbum(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/albums/"), "/with-tracks")
+	s.mu.Lock()
+	album, ok := s.albums[id]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "not-found", "Album not found")
+		return
+	}
+
+	writeResult(w, album)
+}
+
+// handleFileInfo serves the signed get-file-info endpoint
+func (s *Server) handleFileInfo(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	trackID := query.Get("trackId")
+	expected := crypto.GenerateSignatureFromParams(query.Get("ts"), trackID, query.Get("quality"),
+		query.Get("codecs"), query.Get("transports"), s.SignKey)
+	if query.Get("sign") != expected {
+		writeError(w, http.StatusBadRequest, "bad-request", "Invalid sign")
+		return
+	}
+
+	track, ok := s.track(trackID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not-found", "Track not found")
+		return
+	}
+
+	mediaURL := s.URL + "/media/" + trackID
+	writeResult(w, api.DownloadInfoResult{
+		DownloadInfo: api.DownloadInfo{
+			TrackID:   trackID,
+			Quality:   query.Get("quality"),
+			Codec:     track.Codec,
+			Bitrate:   track.Bitrate,
+			Transport: query.Get("transports"),
+			Key:       track.Key,
+			Size:      len(track.encrypted),
+			Urls:      []string{mediaURL},
+			Url:       mediaURL,
+			RealID:    track.Info.RealID,
+		},
+	})
+}
+
+// handleMedia serves encrypted audio like the storage CDN
+func (s *Server) handleMedia(w http.ResponseWriter, r *http.Request) {
+	track, ok := s.track(strings.TrimPrefix(r.URL.Path, "/media/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(track.encrypted))
+}
+
+// writeResult writes a successful API response
+func writeResult(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invocationInfo": api.InvocationInfo{ReqID: "yamusictest", Hostname: "yamusictest"},
+		"result":         result,
+	})
+}
+
+// writeError writes an API error response
+func writeError(w http.ResponseWriter, status int, name, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invocationInfo": api.InvocationInfo{ReqID: "yamusictest", Hostname: "yamusictest"},
+		"error": map[string]string{
+			"name":    name,
+			"message": message,
+		},
+	})
+}
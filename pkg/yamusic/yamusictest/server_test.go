@@ -0,0 +1,312 @@
+package yamusictest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kud1nov/yamusic-dl/internal/api"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic"
+	"github.com/Kud1nov/yamusic-dl/pkg/yamusic/yamusictest"
+)
+
+// TestDownloadTrack runs a full download against the fake server
+func TestDownloadTrack(t *testing.T) {
+	server := yamusictest.NewServer("")
+	defer server.Close()
+
+	audio := bytes.Repeat([]byte("fLaC fake audio "), 4096)
+	server.AddSimpleTrack("100500", "Песня", "Исполнитель", "Альбом", audio)
+
+	client := server.Client("token")
+	path, err := client.DownloadTrack("100500", yamusic.QualityHigh, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadTrack() error = %v", err)
+	}
+
+	if !strings.HasPrefix(filepath.Base(path), "Песня - Исполнитель (Альбом) [100500]") {
+		t.Errorf("Unexpected file name %q", filepath.Base(path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if !bytes.Equal(data, audio) {
+		t.Error("Downloaded file content doesn't match the registered audio")
+	}
+}
+
+// TestDownloadTrackScenarios runs downloads against a misbehaving server
+func TestDownloadTrackScenarios(t *testing.T) {
+	audio := bytes.Repeat([]byte("fLaC scenario audio "), 4096)
+
+	// Test cases
+	tests := []struct {
+		name     string
+		trackID  string
+		signKey  string
+		gzip     bool
+		failures map[string][]int
+		wantErr  error
+		requests map[string]int
+	}{
+		{
+			name:     "Happy path",
+			trackID:  "100500",
+			requests: map[string]int{"/tracks/100500": 1, "/get-file-info": 1, "/media/100500": 1},
+		},
+		{
+			name:     "Wrong sign key",
+			trackID:  "100500",
+			signKey:  "wrong-key",
+			wantErr:  yamusic.ErrInvalidSign,
+			requests: map[string]int{"/media/100500": 0},
+		},
+		{
+			name:    "Track not found",
+			trackID: "404",
+			wantErr: yamusic.ErrTrackNotFound,
+		},
+		{
+			name:    "Rate limited, then served",
+			trackID: "100500",
+			failures: map[string][]int{
+				"/tracks/100500": {http.StatusTooManyRequests},
+				"/get-file-info": {http.StatusTooManyRequests, http.StatusTooManyRequests},
+			},
+			requests: map[string]int{"/tracks/100500": 2, "/get-file-info": 3, "/media/100500": 1},
+		},
+		{
+			name:     "Rate limited beyond the retries",
+			trackID:  "100500",
+			failures: map[string][]int{"/tracks/100500": {429, 429, 429}},
+			wantErr:  yamusic.ErrRateLimited,
+			requests: map[string]int{"/tracks/100500": 3, "/get-file-info": 0},
+		},
+		{
+			name:    "Gzip-encoded responses",
+			trackID: "100500",
+			gzip:    true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := yamusictest.NewServer("")
+			defer server.Close()
+
+			server.Gzip = tt.gzip
+			server.AddSimpleTrack("100500", "Песня", "Исполнитель", "Альбом", audio)
+			for path, statuses := range tt.failures {
+				server.FailNext(path, statuses...)
+			}
+
+			client := server.Client("token")
+			if tt.signKey != "" {
+				client = yamusic.NewClient("token", tt.signKey, nil)
+				client.SetBaseURL(server.URL)
+			}
+			client.SetRetry(3, time.Millisecond)
+			client.SetRateLimit(0, 0)
+			client.SetLegacyFallback(false)
+
+			path, err := client.DownloadTrack(tt.trackID, yamusic.QualityHigh, t.TempDir())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("DownloadTrack() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("DownloadTrack() error = %v", err)
+			} else if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, audio) {
+				t.Errorf("Downloaded file doesn't match the registered audio (%v)", err)
+			}
+
+			for path, want := range tt.requests {
+				if got := server.Requests(path); got != want {
+					t.Errorf("Requests to %s = %d, want %d", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestDownloadTrackTo checks streaming a track into a writer without creating files
+func TestDownloadTrackTo(t *testing.T) {
+	server := yamusictest.NewServer("")
+	defer server.Close()
+
+	audio := bytes.Repeat([]byte("fLaC streamed audio "), 4096)
+	server.AddSimpleTrack("100500", "Песня", "Исполнитель", "Альбом", audio)
+
+	// Run in an empty directory to detect temporary files
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var buf bytes.Buffer
+	meta, err := server.Client("token").DownloadTrackTo(context.Background(), "100500", yamusic.QualityHigh, &buf)
+	if err != nil {
+		t.Fatalf("DownloadTrackTo() error = %v", err)
+	}
+
+	if meta.FileName != "Песня - Исполнитель (Альбом) [100500].flac" {
+		t.Errorf("Unexpected file name %q", meta.FileName)
+	}
+	if meta.ContentType != "audio/flac" || meta.Title != "Песня" {
+		t.Errorf("Unexpected metadata %+v", meta)
+	}
+	if !bytes.Equal(buf.Bytes(), audio) {
+		t.Error("Streamed content doesn't match the registered audio")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("DownloadTrackTo() created %d files, want none", len(entries))
+	}
+}
+
+// TestDownloadPartialAlbum checks that partially available albums keep the original numbering
+func TestDownloadPartialAlbum(t *testing.T) {
+	server := yamusictest.NewServer("")
+	defer server.Close()
+
+	server.AddSimpleTrack("1", "One", "Artist", "Album", []byte("fLaC audio one"))
+	server.AddSimpleTrack("3", "Three", "Artist", "Album", []byte("fLaC audio three"))
+	server.AddAlbum("10", "Album", []string{"1", "2"}, []string{"3"})
+
+	client := server.Client("token")
+
+	client.SetRequireComplete(true)
+	if _, err := client.DownloadAlbum("10", yamusic.QualityHigh, t.TempDir()); !errors.Is(err, yamusic.ErrIncompleteAlbum) {
+		t.Fatalf("DownloadAlbum() error = %v, want ErrIncompleteAlbum", err)
+	}
+
+	client.SetRequireComplete(false)
+	results, err := client.DownloadAlbum("10", yamusic.QualityHigh, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadAlbum() error = %v", err)
+	}
+
+	// Test cases
+	expected := []struct {
+		id       string
+		volume   int
+		position int
+		ok       bool
+	}{
+		{"1", 1, 1, true},
+		{"2", 1, 2, false},
+		{"3", 2, 1, true},
+	}
+
+	if len(results) != len(expected) {
+		t.Fatalf("DownloadAlbum() returned %d results, want %d", len(results), len(expected))
+	}
+
+	// Run tests
+	for i, want := range expected {
+		got := results[i]
+		if got.Track.ID != want.id || got.Volume != want.volume || got.Position != want.position || (got.Err == nil) != want.ok {
+			t.Errorf("Result %d = %s %d-%d (err %v), want %s %d-%d (ok %v)",
+				i, got.Track.ID, got.Volume, got.Position, got.Err, want.id, want.volume, want.position, want.ok)
+		}
+	}
+}
+
+// TestDownloadTracks checks that the worker pool keeps the input order and isolates failures
+func TestDownloadTracks(t *testing.T) {
+	server := yamusictest.NewServer("")
+	defer server.Close()
+
+	ids := []string{"1", "2", "missing", "3", "4"}
+	for _, id := range ids {
+		if id != "missing" {
+			server.AddSimpleTrack(id, "Title "+id, "Artist", "Album", []byte("fLaC audio "+id))
+		}
+	}
+
+	results := server.Client("token").DownloadTracks(ids, yamusic.QualityHigh, t.TempDir(), 3)
+	if len(results) != len(ids) {
+		t.Fatalf("DownloadTracks() returned %d results, want %d", len(results), len(ids))
+	}
+
+	for i, result := range results {
+		if result.ID != ids[i] {
+			t.Errorf("Result %d is for track %s, want %s", i, result.ID, ids[i])
+		}
+		if wantErr := ids[i] == "missing"; (result.Err != nil) != wantErr {
+			t.Errorf("Result %d error = %v, wantErr %v", i, result.Err, wantErr)
+		}
+		if result.Err == nil {
+			if data, err := os.ReadFile(result.Path); err != nil || string(data) != "fLaC audio "+ids[i] {
+				t.Errorf("Result %d file content = %q (%v), want %q", i, data, err, "fLaC audio "+ids[i])
+			}
+		}
+	}
+}
+
+// TestDownloadTracksFailFast checks that fail-fast mode skips the tracks after a failure
+func TestDownloadTracksFailFast(t *testing.T) {
+	server := yamusictest.NewServer("")
+	defer server.Close()
+
+	server.AddSimpleTrack("2", "Title", "Artist", "Album", []byte("fLaC audio"))
+
+	client := server.Client("token")
+	client.SetFailFast(true)
+
+	results := client.DownloadTracks([]string{"missing", "2"}, yamusic.QualityHigh, t.TempDir(), 1)
+	if results[0].Err == nil {
+		t.Error("Result 0 error = nil, want error")
+	}
+	if !errors.Is(results[1].Err, yamusic.ErrSkipped) {
+		t.Errorf("Result 1 error = %v, want ErrSkipped", results[1].Err)
+	}
+}
+
+// TestSignatureVerification checks that requests signed with another key are rejected
+func TestSignatureVerification(t *testing.T) {
+	server := yamusictest.NewServer("server-key")
+	defer server.Close()
+
+	server.AddSimpleTrack("1", "Title", "Artist", "Album", []byte("fLaC audio"))
+
+	client := server.Client("token")
+	if _, err := client.GetDownloadInfo("1", api.QualityLossless); err != nil {
+		t.Errorf("GetDownloadInfo() with the server key error = %v", err)
+	}
+
+	other := yamusictest.NewServer("other-key")
+	defer other.Close()
+
+	client = other.Client("token")
+	client.SetBaseURL(server.URL)
+	if _, err := client.GetDownloadInfo("1", api.QualityLossless); err == nil {
+		t.Error("GetDownloadInfo() with a wrong key error = nil, want error")
+	}
+}
+
+// TestToken checks the Authorization header requirement
+func TestToken(t *testing.T) {
+	server := yamusictest.NewServer("")
+	defer server.Close()
+
+	server.Token = "secret"
+	server.AddSimpleTrack("1", "Title", "Artist", "Album", []byte("fLaC audio"))
+
+	if _, err := server.Client("wrong").GetTrackInfo("1"); err == nil {
+		t.Error("GetTrackInfo() with a wrong token error = nil, want error")
+	}
+	if _, err := server.Client("secret").GetTrackInfo("1"); err != nil {
+		t.Errorf("GetTrackInfo() with the right token error = %v", err)
+	}
+}